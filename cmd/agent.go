@@ -0,0 +1,147 @@
+// cmd/agent.go
+package cmd
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/manifest"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentListen  string // Address the agent listens on
+	agentTargets int    // Exit once this many targets finished (0 = never)
+)
+
+// agentCmd fans a directory out to many pulling targets from one scan.
+var agentCmd = &cobra.Command{
+	Use:   "agent <dir>",
+	Short: "Scan a directory once and serve it to many targets pulling at the same time.",
+	Long: `Runs on the source host: scans and hashes <dir> once (one file per CPU at a
+time), then serves the result to any number of targets at once, each running
+"sync-dir sync http://<host>:<port>/ <target>". The targets plan against the
+agent's file list and checksums instead of scanning the source themselves,
+and download only what they lack.
+
+Targets report when they finish, and the agent prints what each was sent.
+With --targets N, it exits once N different hosts have finished, e.g. at the
+end of fanning a build output out to N machines. It accepts the same
+--sign-key, --tls-cert and --tls-key as "sync-dir serve".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid directory '%s': %w", args[0], err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", root)
+		}
+		if (serveCert == "") != (serveKey == "") {
+			return fmt.Errorf("--tls-cert and --tls-key must be given together")
+		}
+		if agentTargets < 0 {
+			return fmt.Errorf("--targets cannot be negative")
+		}
+		var key ed25519.PrivateKey
+		if serveSignKey != "" {
+			if key, err = loadSigningKey(serveSignKey); err != nil {
+				return err
+			}
+		}
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		matcher, err := ignore.NewMatcher(root, excludes, consoleLogger())
+		if err != nil {
+			return fmt.Errorf("failed to load ignore rules: %w", err)
+		}
+
+		start := time.Now()
+		fmt.Fprintf(os.Stderr, "Scanning %s...\n", root)
+		server, err := manifest.NewServer(root, matcher, key)
+		if err != nil {
+			return err
+		}
+		m := server.Manifest()
+		var size int64
+		for _, entry := range m.Entries {
+			size += entry.Size
+		}
+		fmt.Fprintf(os.Stderr, "Scanned %d entries (%s) in %s.\n", len(m.Entries), summary.FormatBytes(size), time.Since(start).Round(time.Millisecond))
+
+		httpServer := &http.Server{Addr: agentListen, Handler: server, ReadHeaderTimeout: 30 * time.Second}
+		server.Done = func(c manifest.Client) {
+			// A host reporting again isn't counted twice
+			finished := countFinished(server.Clients())
+			fmt.Printf("%s finished: %d files, %s sent (%d finished)\n", c.Addr, c.Files, summary.FormatBytes(c.Bytes), finished)
+			if agentTargets > 0 && finished >= agentTargets {
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+					defer cancel()
+					_ = httpServer.Shutdown(ctx)
+				}()
+			}
+		}
+
+		scheme := "http"
+		if serveCert != "" {
+			scheme = "https"
+		}
+		fmt.Fprintf(os.Stderr, "Serving at %s://%s/\n", scheme, agentListen)
+		if serveCert != "" {
+			err = httpServer.ListenAndServeTLS(serveCert, serveKey)
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		printAgentClients(server.Clients())
+		return nil
+	},
+}
+
+// countFinished counts the clients that reported they finished.
+func countFinished(clients []manifest.Client) int {
+	n := 0
+	for _, c := range clients {
+		if c.Finished {
+			n++
+		}
+	}
+	return n
+}
+
+// printAgentClients prints what the agent sent to each target.
+func printAgentClients(clients []manifest.Client) {
+	fmt.Println("Targets:")
+	var total int64
+	for _, c := range clients {
+		state := "finished"
+		if !c.Finished {
+			state = "unfinished"
+		}
+		fmt.Printf("  %-40s %6d files  %10s  %s\n", c.Addr, c.Files, summary.FormatBytes(c.Bytes), state)
+		total += c.Bytes
+	}
+	fmt.Printf("Sent %s to %d targets.\n", summary.FormatBytes(total), len(clients))
+}
+
+func init() {
+	agentCmd.Flags().StringVar(&agentListen, "listen", ":8444", "Address to listen on")
+	agentCmd.Flags().IntVar(&agentTargets, "targets", 0, "Exit once this many targets have finished (0 = serve until stopped)")
+	agentCmd.Flags().StringVar(&serveCert, "tls-cert", "", "Certificate file for serving over HTTPS (with --tls-key)")
+	agentCmd.Flags().StringVar(&serveKey, "tls-key", "", "Private key file for serving over HTTPS (with --tls-cert)")
+	agentCmd.Flags().StringVar(&serveSignKey, "sign-key", "", "Sign the file list with the private key in this file, generating it (and FILE.pub) if it doesn't exist")
+	rootCmd.AddCommand(agentCmd)
+}
@@ -0,0 +1,124 @@
+// cmd/bench.go
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jeepinbird/sync-dir/pkg/bench"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchScenarios  []string
+	benchScale      float64
+	benchDir        string
+	benchWorkers    int
+	benchBufferSize = byteSize(syncer.DefaultBufferSize)
+	benchSave       string
+	benchBaseline   string
+	benchTolerance  float64
+)
+
+// benchCmd measures sync throughput on synthetic trees.
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure sync throughput on generated trees.",
+	Long: `Generates synthetic source trees (many small files, a few huge files, a deep
+tree), syncs each into an empty target and then again when nothing changed,
+and reports the time and throughput of every pass. The trees are the same on
+every run, so results of two builds can be compared: save them with --save and
+pass them to a later run as --baseline, which fails if a pass got slower by
+more than --tolerance.
+
+The trees are generated in --dir (the temp directory by default), which should
+be on the kind of disk you want to measure. Freshly written files are usually
+still in the page cache, so reads are faster than from a cold disk.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if benchWorkers < 0 || benchWorkers > syncer.MaxWorkers {
+			return fmt.Errorf("--workers must be between 0 and %d", syncer.MaxWorkers)
+		}
+		if benchTolerance < 0 || benchTolerance >= 1 {
+			return fmt.Errorf("--tolerance must be at least 0 and less than 1")
+		}
+		var scenarios []bench.Scenario
+		for _, name := range benchScenarios {
+			scenario, err := bench.Find(name)
+			if err != nil {
+				return err
+			}
+			scenarios = append(scenarios, scenario)
+		}
+		var baseline []bench.Result
+		if benchBaseline != "" {
+			var err error
+			if baseline, err = bench.Load(benchBaseline); err != nil {
+				return err
+			}
+		}
+
+		dir, err := os.MkdirTemp(benchDir, "sync-dir-bench-")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer os.RemoveAll(dir)
+
+		// The measured syncs print their plans, stats and narration; only the
+		// results are shown. Their state is kept in the work directory, out of
+		// the user's, so that every first pass is cold.
+		var results []bench.Result
+		err = bench.Run(bench.Options{
+			Dir:       dir,
+			Scale:     benchScale,
+			Scenarios: scenarios,
+			NewSyncer: func(source, target string) *syncer.Syncer {
+				s := syncer.NewSyncer(source, target, nil, false)
+				s.AssumeYes = true
+				s.Workers = benchWorkers
+				s.BufferSize = int(benchBufferSize)
+				s.Stdout, s.Stderr = io.Discard, io.Discard
+				s.StateDir = filepath.Join(dir, "state")
+				return s
+			},
+		}, func(result bench.Result) {
+			results = append(results, result)
+			fmt.Println(result)
+		})
+		if err != nil {
+			return fmt.Errorf("benchmark failed: %w", err)
+		}
+
+		if benchSave != "" {
+			if err := bench.Save(benchSave, results); err != nil {
+				return err
+			}
+		}
+		if baseline != nil {
+			regressions := bench.Regressions(baseline, results, benchTolerance)
+			for _, regression := range regressions {
+				fmt.Fprintf(os.Stderr, "Error: Slower than the baseline: %s\n", regression)
+			}
+			if len(regressions) > 0 {
+				return fmt.Errorf("%d pass(es) slower than the baseline by more than %.0f%%", len(regressions), benchTolerance*100)
+			}
+			fmt.Println("No pass is slower than the baseline.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	benchCmd.Flags().StringSliceVar(&benchScenarios, "scenario", nil, "Scenarios to run: small-files, huge-files, deep-tree (default: all)")
+	benchCmd.Flags().Float64Var(&benchScale, "scale", 1, "Multiply the file counts and sizes of the scenarios, e.g. 0.1 for a quick run")
+	benchCmd.Flags().StringVar(&benchDir, "dir", "", "Directory to generate the trees in (default: the temp directory)")
+	benchCmd.Flags().IntVar(&benchWorkers, "workers", 0, "Parallel file operations (0 = automatic)")
+	benchCmd.Flags().Var(&benchBufferSize, "buffer-size", "Copy buffer size per file operation (e.g. 256K, 4M)")
+	benchCmd.Flags().StringVar(&benchSave, "save", "", "Write the results to this JSON file, for use as a later --baseline")
+	benchCmd.Flags().StringVar(&benchBaseline, "baseline", "", "Compare with results saved by --save and fail on regressions")
+	benchCmd.Flags().Float64Var(&benchTolerance, "tolerance", 0.2, "Slowdown against the baseline tolerated before failing (0.2 = 20%)")
+	rootCmd.AddCommand(benchCmd)
+}
@@ -0,0 +1,74 @@
+// cmd/clean.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanMinAge time.Duration // Temp files younger than this are left alone
+	cleanDryRun bool
+)
+
+// cleanCmd removes temp files orphaned in a target by crashed runs.
+var cleanCmd = &cobra.Command{
+	Use:   "clean <target>",
+	Short: "Remove temp files left in a target by interrupted syncs.",
+	Long: `Files are copied to a temp file named ` + syncer.TempPrefix + `<pid>.<random> next to
+their destination and then renamed into place. A sync that crashes or is killed
+can leave such temp files behind; this command finds them (by name and through
+the journals of pairs syncing into the target) and removes those whose process
+is no longer running on this host and that are older than --min-age.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid target path '%s': %w", args[0], err)
+		}
+		info, err := os.Stat(targetPath)
+		if err != nil {
+			return fmt.Errorf("could not stat target path '%s': %w", targetPath, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("target path '%s' is not a directory", targetPath)
+		}
+
+		fmt.Fprintln(os.Stderr, i18n.T("sync.target", targetPath))
+		result, err := syncer.CleanTemps(targetPath, cleanMinAge, cleanDryRun)
+		if err != nil {
+			return fmt.Errorf("clean failed: %w", err)
+		}
+
+		removed, total := "clean.removed", "clean.total"
+		if cleanDryRun {
+			removed, total = "clean.would_remove", "clean.total_dry_run"
+		}
+		for _, path := range result.Removed {
+			fmt.Println(i18n.T(removed, path))
+		}
+		for _, path := range result.Kept {
+			fmt.Println(i18n.T("clean.kept", path))
+		}
+		for _, msg := range result.Errors {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+		}
+		fmt.Println("\n" + i18n.T(total, len(result.Removed), len(result.Kept)))
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("%d temp file(s) could not be removed", len(result.Errors))
+		}
+		return nil
+	},
+}
+
+func init() {
+	cleanCmd.Flags().DurationVar(&cleanMinAge, "min-age", time.Hour, "Only remove temp files older than this (protects runs on other hosts sharing the target)")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List orphaned temp files without removing them")
+	rootCmd.AddCommand(cleanCmd)
+}
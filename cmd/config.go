@@ -0,0 +1,301 @@
+// cmd/config.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/config"
+	"github.com/jeepinbird/sync-dir/pkg/notify"
+	"github.com/jeepinbird/sync-dir/pkg/rules"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/jeepinbird/sync-dir/pkg/transform"
+	"github.com/spf13/cobra"
+)
+
+var (
+	configPath    string          // --config: path of the config file
+	profileName   string          // --profile: profile to load from the config file
+	activeProfile *config.Profile // Loaded profile, nil when --profile isn't used
+)
+
+// loadProfile loads the profile named by --profile (if any) and applies its
+// flag values to every flag of cmd that wasn't set on the command line.
+func loadProfile(cmd *cobra.Command) error {
+	if profileName == "" {
+		return nil
+	}
+
+	path := configPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return fmt.Errorf("failed to locate config file: %w", err)
+		}
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	profile, err := cfg.Profile(profileName)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range profile.Flags {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			if !anyCommandHasFlag(cmd.Root(), name) {
+				return fmt.Errorf("profile %q sets unknown flag %q", profileName, name)
+			}
+			continue // Valid flag, just not one of this command's
+		}
+		if flag.Changed {
+			continue // The command line wins
+		}
+		values, err := config.FlagValues(value)
+		if err != nil {
+			return fmt.Errorf("profile %q, flag %q: %w", profileName, name, err)
+		}
+		for _, v := range values {
+			if err := flag.Value.Set(v); err != nil {
+				return fmt.Errorf("profile %q, flag %q: %w", profileName, name, err)
+			}
+		}
+	}
+
+	activeProfile = profile
+	fmt.Fprintf(os.Stderr, "Using profile %q from %s\n", profileName, path)
+	return nil
+}
+
+// loadColors sets up the color theme from the "colors" settings of the config
+// file, if there is one. A broken config only costs the custom colors here;
+// commands that need the config report it themselves.
+func loadColors() {
+	path := configPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			path = ""
+		} else if _, err := os.Stat(path); err != nil {
+			path = "" // No config file yet
+		}
+	}
+	var settings map[string]string
+	if path != "" {
+		cfg, err := config.Load(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Using the default colors: %v\n", err)
+		} else {
+			settings = cfg.Colors
+		}
+	}
+	if err := theme.Configure(settings); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Using the default colors: %v\n", err)
+		_ = theme.Configure(nil)
+	}
+}
+
+// anyCommandHasFlag reports whether cmd or one of its subcommands defines the flag.
+func anyCommandHasFlag(cmd *cobra.Command, name string) bool {
+	if cmd.Flags().Lookup(name) != nil {
+		return true
+	}
+	for _, sub := range cmd.Commands() {
+		if anyCommandHasFlag(sub, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadLimits re-reads the profile of a running sync and applies its
+// "workers" and "bwlimit" flags, if it sets them. Other changes to the
+// profile take effect on the next run.
+func reloadLimits(sync *syncer.Syncer) error {
+	if profileName == "" {
+		return fmt.Errorf("no --profile to reload")
+	}
+	path := configPath
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return fmt.Errorf("failed to locate config file: %w", err)
+		}
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	profile, err := cfg.Profile(profileName)
+	if err != nil {
+		return err
+	}
+
+	limits := map[string]string{}
+	for _, name := range []string{"workers", "bwlimit"} {
+		value, ok := profile.Flags[name]
+		if !ok {
+			continue
+		}
+		values, err := config.FlagValues(value)
+		if err != nil || len(values) != 1 {
+			return fmt.Errorf("profile %q, flag %q: expected a single value", profileName, name)
+		}
+		limits[name] = values[0]
+	}
+	if len(limits) == 0 {
+		fmt.Fprintf(os.Stderr, "\nReloaded profile %q: it sets no workers or bwlimit\n", profileName)
+		return nil
+	}
+	return applyLimits(sync, limits)
+}
+
+// profileArgs splits the arguments into sources and targets: the last one is
+// the target, unless --target gives the targets. When the command line gives
+// no arguments, the source and targets come from the active profile.
+func profileArgs(args []string) ([]string, []string, error) {
+	if len(args) == 0 {
+		if activeProfile == nil || activeProfile.Source == "" {
+			return nil, nil, fmt.Errorf("requires <source> and <target> arguments (or a --profile that defines them)")
+		}
+		args = []string{activeProfile.Source}
+		if len(targetFlags) == 0 {
+			var targets []string
+			if activeProfile.Target != "" {
+				targets = append(targets, activeProfile.Target)
+			}
+			targets = append(targets, activeProfile.Targets...)
+			if len(targets) == 0 {
+				return nil, nil, fmt.Errorf("requires <source> and <target> arguments (or a --profile that defines them)")
+			}
+			return args, targets, nil
+		}
+	}
+	if len(targetFlags) > 0 {
+		return args, targetFlags, nil
+	}
+	return args[:len(args)-1], args[len(args)-1:], nil
+}
+
+// configureTransforms sets up the profile's transform pipeline and action
+// rules, if it has them. Compress rules become gzip transforms, after the
+// profile's own transforms.
+func configureTransforms(sync *syncer.Syncer) error {
+	if activeProfile == nil {
+		return nil
+	}
+	actionRules, err := rules.New(activeProfile.Rules)
+	if err != nil {
+		return fmt.Errorf("invalid rules in profile %q: %w", profileName, err)
+	}
+	sync.Rules = actionRules
+	transforms := append(append([]config.TransformRule(nil), activeProfile.Transforms...), actionRules.Transforms()...)
+	if len(transforms) == 0 {
+		return nil
+	}
+	cacheDir, err := transform.DefaultCacheDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate transform cache: %w", err)
+	}
+	pipeline, err := transform.NewPipeline(transforms, cacheDir)
+	if err != nil {
+		return fmt.Errorf("invalid transforms in profile %q: %w", profileName, err)
+	}
+	sync.Transforms = pipeline
+	return nil
+}
+
+// configureTiers routes the source files of the profile's tiers to their
+// targets, if it has tiers.
+func configureTiers(sync *syncer.Syncer, source string) error {
+	if activeProfile == nil || len(activeProfile.Tiers) == 0 {
+		return nil
+	}
+	if len(sync.MergeSources) > 0 || len(sync.Mappings) > 0 || sync.CAS || len(sync.Targets) > 0 {
+		return fmt.Errorf("the tiers of profile %q cannot be combined with merged sources, --map, --cas or several targets", profileName)
+	}
+	for _, rule := range activeProfile.Tiers {
+		var tier syncer.Tier
+		var err error
+		if rule.OlderThan == "" && rule.LargerThan == "" {
+			return fmt.Errorf("tier %s in profile %q: set older_than, larger_than or both", rule.Target, profileName)
+		}
+		if rule.OlderThan != "" {
+			if tier.OlderThan, err = syncer.ParseTierAge(rule.OlderThan); err != nil {
+				return fmt.Errorf("tier %s in profile %q: %w", rule.Target, profileName, err)
+			}
+		}
+		if rule.LargerThan != "" {
+			if tier.LargerThan, err = parseByteSize(rule.LargerThan); err != nil {
+				return fmt.Errorf("tier %s in profile %q: %w", rule.Target, profileName, err)
+			}
+		}
+		if _, tier.Target, err = resolvePair(source, rule.Target); err != nil {
+			return fmt.Errorf("tier %s in profile %q: %w", rule.Target, profileName, err)
+		}
+		for _, other := range append([]string{sync.TargetRoot}, tierTargets(sync.Tiers)...) {
+			if nested(tier.Target, other) || nested(other, tier.Target) {
+				return fmt.Errorf("tier %s in profile %q: the targets of tiers can't be, contain or lie inside one another or the main target", rule.Target, profileName)
+			}
+		}
+		sync.Tiers = append(sync.Tiers, tier)
+	}
+	return nil
+}
+
+func mappedTargets(mappings []syncer.SubtreeMapping) []string {
+	targets := make([]string, len(mappings))
+	for i, mapping := range mappings {
+		targets[i] = mapping.Target
+	}
+	return targets
+}
+
+func tierTargets(tiers []syncer.Tier) []string {
+	targets := make([]string, len(tiers))
+	for i, tier := range tiers {
+		targets[i] = tier.Target
+	}
+	return targets
+}
+
+// nested reports whether path is dir or lies below it.
+func nested(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// profileNotifiers returns the chat notifiers configured in the active profile.
+func profileNotifiers() ([]*notify.Notifier, error) {
+	if activeProfile == nil {
+		return nil, nil
+	}
+	var notifiers []*notify.Notifier
+	for _, cfg := range activeProfile.Notify {
+		n, err := notify.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notifier in profile %q: %w", profileName, err)
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// sendNotifications posts the run summary to every notifier that wants it.
+// Failures only produce warnings: the sync itself is already over.
+func sendNotifications(notifiers []*notify.Notifier, result *summary.Summary) {
+	for _, n := range notifiers {
+		if !n.Wants(result) {
+			continue
+		}
+		if err := n.Send(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s notification failed: %v\n", n.Type(), err)
+		}
+	}
+}
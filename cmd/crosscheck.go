@@ -0,0 +1,119 @@
+// cmd/crosscheck.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+// crossCheckCmd compares two replicas of the same source with each other.
+var crossCheckCmd = &cobra.Command{
+	Use:   "cross-check <targetA> <targetB>",
+	Short: "Compare two mirror targets with each other and report divergence.",
+	Long: `Compares two targets synced from the same source with each other instead of
+with the source, using the same ignore rules and comparisons as a sync, and
+lists the items only in one of them and the files that differ, split by the
+replica holding the newer copy. From that it tells which replica looks stale:
+the one holding only the older copies of the files that differ or, when only
+items missing on one side differ, the one whose newest file is older.
+
+Nothing is changed. Exits with an error if the replicas differ, so it can
+gate a scheduled check.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true, // Divergence isn't a usage error
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pathA, pathB, err := resolveReplicas(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("crosscheck.replica_a", pathA))
+		fmt.Fprintln(os.Stderr, i18n.T("crosscheck.replica_b", pathB))
+
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		sync := syncer.NewSyncer(pathA, pathB, excludes, true)
+		sync.Gitignore = gitignore
+		sync.Logger = consoleLogger()
+		sync.Observer = newProgressObserver()
+		report, err := sync.CrossCheck()
+		if err != nil {
+			return fmt.Errorf("cross-check failed: %w", err)
+		}
+
+		printCrossCheck(i18n.T("crosscheck.only_a"), theme.Paint(theme.Add, "+"), report.OnlyA)
+		printCrossCheck(i18n.T("crosscheck.only_b"), theme.Paint(theme.Delete, "-"), report.OnlyB)
+		printCrossCheck(i18n.T("crosscheck.newer_a"), theme.Paint(theme.Update, ">"), report.NewerA)
+		printCrossCheck(i18n.T("crosscheck.newer_b"), theme.Paint(theme.Update, "<"), report.NewerB)
+		fmt.Println("\n" + i18n.N("crosscheck.identical", report.Identical, report.Identical))
+		fmt.Println(i18n.T("crosscheck.latest", formatLatest(report.LatestA), formatLatest(report.LatestB)))
+
+		switch report.Verdict() {
+		case syncer.CrossCheckInSync:
+			fmt.Println("\n" + theme.Paint(theme.Success, i18n.T("crosscheck.in_sync")))
+			return nil
+		case syncer.CrossCheckStaleA:
+			fmt.Println("\n" + theme.Paint(theme.Failure, i18n.T("crosscheck.stale_a")))
+		case syncer.CrossCheckStaleB:
+			fmt.Println("\n" + theme.Paint(theme.Failure, i18n.T("crosscheck.stale_b")))
+		case syncer.CrossCheckDiverged:
+			fmt.Println("\n" + theme.Paint(theme.Failure, i18n.T("crosscheck.diverged")))
+		}
+		return i18n.Errorf("crosscheck.differ", report.Differences())
+	},
+}
+
+// resolveReplicas returns the absolute paths of two replicas, which must be
+// existing directories apart from each other.
+func resolveReplicas(a, b string) (string, string, error) {
+	var paths [2]string
+	for i, path := range []string{a, b} {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid target path '%s': %w", path, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return "", "", fmt.Errorf("could not stat target path '%s': %w", abs, err)
+		}
+		if !info.IsDir() {
+			return "", "", fmt.Errorf("target path '%s' is not a directory", abs)
+		}
+		paths[i] = abs
+	}
+	if nested(paths[0], paths[1]) || nested(paths[1], paths[0]) {
+		return "", "", fmt.Errorf("targets '%s' and '%s' must not lie inside one another", paths[0], paths[1])
+	}
+	return paths[0], paths[1], nil
+}
+
+// printCrossCheck prints the count and a sample of one kind of difference,
+// unless there is none.
+func printCrossCheck(title, marker string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	fmt.Printf("\n%s (%d):\n", title, len(paths))
+	printSample(marker, paths)
+}
+
+// formatLatest formats the newest mtime of a replica, which is zero if it
+// holds no files.
+func formatLatest(t time.Time) string {
+	if t.IsZero() {
+		return i18n.T("crosscheck.none")
+	}
+	return t.Format(time.RFC1123)
+}
+
+func init() {
+	rootCmd.AddCommand(crossCheckCmd)
+}
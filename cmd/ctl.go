@@ -0,0 +1,350 @@
+// cmd/ctl.go
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/control"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	controlEnabled bool   // --control: serve the control socket during the run
+	ctlSocketPath  string // --socket: socket ctl talks to
+	ctlWorkers     string // ctl set --workers
+	ctlBwLimit     string // ctl set --bwlimit
+)
+
+// jobStatus is what a running sync reports over its control socket.
+type jobStatus struct {
+	PID     int               `json:"pid"`
+	Job     string            `json:"job"`
+	Sources []string          `json:"sources"`
+	Target  string            `json:"target"`
+	Started time.Time         `json:"started"`
+	Live    syncer.LiveStatus `json:"live"`
+}
+
+// startControl serves the control socket of this run, if --control is set.
+func startControl(sync *syncer.Syncer, sources []string, target string) *control.Server {
+	if !controlEnabled {
+		return nil
+	}
+	path, err := control.DefaultSocketPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Control socket unavailable: %v\n", err)
+		return nil
+	}
+	started := time.Now()
+	server, err := control.Listen(path, func(req control.Request) (any, bool, error) {
+		live := sync.LiveStatus()
+		done := live.Phase == syncer.PhaseDone
+		switch method := req.Method; method {
+		case "status":
+			return jobStatus{
+				PID:     os.Getpid(),
+				Job:     jobName(target),
+				Sources: sources,
+				Target:  target,
+				Started: started,
+				Live:    live,
+			}, done, nil
+		case "pause", "resume":
+			if method == "pause" {
+				sync.Pause()
+			} else {
+				sync.Resume()
+			}
+			return sync.LiveStatus(), true, nil
+		case "set":
+			if err := applyLimits(sync, req.Params); err != nil {
+				return nil, true, err
+			}
+			return sync.LiveStatus(), true, nil
+		default:
+			return nil, true, fmt.Errorf("unknown method %q", method)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Control socket unavailable: %v\n", err)
+		return nil
+	}
+	fmt.Fprintln(os.Stderr, i18n.T("ctl.socket", server.Path()))
+	return server
+}
+
+// applyLimits changes the limits of a running sync given as "workers" (a
+// count) and "bwlimit" (a size per second, 0 = unlimited). Missing limits
+// are left as they are.
+func applyLimits(sync *syncer.Syncer, limits map[string]string) error {
+	for name := range limits {
+		if name != "workers" && name != "bwlimit" {
+			return fmt.Errorf("unknown limit %q", name)
+		}
+	}
+	if value, ok := limits["workers"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid worker count %q", value)
+		}
+		if err := sync.SetWorkers(n); err != nil {
+			return err
+		}
+	}
+	if value, ok := limits["bwlimit"]; ok {
+		rate, err := parseByteSize(value)
+		if err != nil {
+			return err
+		}
+		if err := sync.SetBandwidthLimit(rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ctlCmd groups the commands talking to running syncs.
+var ctlCmd = &cobra.Command{
+	Use:   "ctl",
+	Short: "Inspect syncs running with --control.",
+	Long: `Talks to the control socket of syncs started with --control. The sockets live
+in the control directory under the state directory, one per process. With a
+single running sync no --socket is needed.`,
+}
+
+var ctlListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List running syncs that have a control socket.",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sockets, err := control.Sockets()
+		if err != nil {
+			return err
+		}
+		for _, path := range sockets {
+			var status jobStatus
+			if err := callControl(path, "status", &status); err != nil {
+				fmt.Printf("%s: %s\n", path, i18n.T("ctl.unreachable", err))
+				continue
+			}
+			fmt.Printf("%s: %s\n", path, i18n.T("ctl.job", status.PID, status.Job, status.Live.Phase))
+		}
+		if len(sockets) == 0 {
+			fmt.Println(i18n.T("ctl.none"))
+		}
+		return nil
+	},
+}
+
+var ctlStatusCmd = &cobra.Command{
+	Use:          "status",
+	Short:        "Show the status of a running sync.",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := ctlSocket()
+		if err != nil {
+			return err
+		}
+		var status jobStatus
+		if err := callControl(path, "status", &status); err != nil {
+			return err
+		}
+		printJobStatus(status)
+		return nil
+	},
+}
+
+var ctlWatchCmd = &cobra.Command{
+	Use:          "watch",
+	Short:        "Stream the progress of a running sync until it finishes.",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := ctlSocket()
+		if err != nil {
+			return err
+		}
+		client, err := control.Dial(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = client.Close()
+		}()
+		err = client.Stream("status", true, func(result json.RawMessage) error {
+			var status jobStatus
+			if err := json.Unmarshal(result, &status); err != nil {
+				return err
+			}
+			fmt.Println(progressLine(status.Live))
+			return nil
+		})
+		if errors.Is(err, control.ErrClosed) {
+			fmt.Println(i18n.T("ctl.done"))
+			return nil
+		}
+		return err
+	},
+}
+
+// ctlPauseCmd and ctlResumeCmd hold and release the workers of a running sync.
+var ctlPauseCmd = &cobra.Command{
+	Use:          "pause",
+	Short:        "Pause a running sync after the files in progress.",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return ctlSetPaused("pause")
+	},
+}
+
+var ctlResumeCmd = &cobra.Command{
+	Use:          "resume",
+	Short:        "Resume a paused sync.",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return ctlSetPaused("resume")
+	},
+}
+
+var ctlSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Change the worker count or bandwidth limit of a running sync.",
+	Long: `Changes the limits of a running sync without restarting it. A lower worker
+count lets the operations in progress finish; the bandwidth limit applies to
+the copies in progress right away.`,
+	Example:      "  sync-dir ctl set --workers 2 --bwlimit 20M",
+	Args:         cobra.NoArgs,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limits := map[string]string{}
+		if cmd.Flags().Changed("workers") {
+			limits["workers"] = ctlWorkers
+		}
+		if cmd.Flags().Changed("bwlimit") {
+			limits["bwlimit"] = ctlBwLimit
+		}
+		if len(limits) == 0 {
+			return fmt.Errorf("nothing to set: use --workers and/or --bwlimit")
+		}
+		path, err := ctlSocket()
+		if err != nil {
+			return err
+		}
+		client, err := control.Dial(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = client.Close()
+		}()
+		var live syncer.LiveStatus
+		if err := client.CallWith("set", limits, &live); err != nil {
+			return err
+		}
+		fmt.Println(limitsLine(live))
+		return nil
+	},
+}
+
+func ctlSetPaused(method string) error {
+	path, err := ctlSocket()
+	if err != nil {
+		return err
+	}
+	var live syncer.LiveStatus
+	if err := callControl(path, method, &live); err != nil {
+		return err
+	}
+	fmt.Println(progressLine(live))
+	return nil
+}
+
+// ctlSocket returns the socket to talk to: --socket, or the only one running.
+func ctlSocket() (string, error) {
+	if ctlSocketPath != "" {
+		return ctlSocketPath, nil
+	}
+	sockets, err := control.Sockets()
+	if err != nil {
+		return "", err
+	}
+	switch len(sockets) {
+	case 0:
+		return "", fmt.Errorf("no running syncs with a control socket (start them with --control)")
+	case 1:
+		return sockets[0], nil
+	default:
+		return "", fmt.Errorf("several syncs are running, choose one with --socket:\n- %s", strings.Join(sockets, "\n- "))
+	}
+}
+
+// callControl performs a single call on a control socket.
+func callControl(path, method string, v any) error {
+	client, err := control.Dial(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	return client.Call(method, v)
+}
+
+func printJobStatus(status jobStatus) {
+	labels := i18n.Pad("ctl.label_job", "ctl.label_source", "ctl.label_target", "ctl.label_started", "ctl.label_status", "ctl.label_limits")
+	fmt.Printf("%s %s (pid %d)\n", labels[0], status.Job, status.PID)
+	fmt.Printf("%s %s\n", labels[1], strings.Join(status.Sources, ", "))
+	fmt.Printf("%s %s\n", labels[2], status.Target)
+	fmt.Printf("%s %s\n", labels[3], status.Started.Format(time.RFC1123))
+	fmt.Printf("%s %s\n", labels[4], progressLine(status.Live))
+	if status.Live.Workers > 0 {
+		fmt.Printf("%s %s\n", labels[5], limitsLine(status.Live))
+	}
+}
+
+// limitsLine renders the worker count and bandwidth limit of a LiveStatus.
+func limitsLine(live syncer.LiveStatus) string {
+	bandwidth := i18n.T("ctl.unlimited")
+	if live.Bandwidth > 0 {
+		bandwidth = summary.FormatBytes(live.Bandwidth) + "/s"
+	}
+	workers := i18n.T("ctl.automatic")
+	if live.Workers > 0 {
+		workers = strconv.Itoa(live.Workers)
+	}
+	return i18n.T("ctl.limits", workers, bandwidth)
+}
+
+// progressLine renders a LiveStatus on one line.
+func progressLine(live syncer.LiveStatus) string {
+	phase := live.Phase
+	if live.Paused {
+		phase += " " + i18n.T("ctl.paused")
+	}
+	if live.ActionsTotal == 0 {
+		return phase
+	}
+	return i18n.T("ctl.progress", phase, live.ActionsDone, live.ActionsTotal,
+		summary.FormatBytes(live.BytesDone), summary.FormatBytes(live.BytesTotal))
+}
+
+func init() {
+	ctlCmd.PersistentFlags().StringVar(&ctlSocketPath, "socket", "", "Control socket of the sync to talk to (see ctl list)")
+	ctlSetCmd.Flags().StringVar(&ctlWorkers, "workers", "", fmt.Sprintf("Parallel file operations (1-%d)", syncer.MaxWorkers))
+	ctlSetCmd.Flags().StringVar(&ctlBwLimit, "bwlimit", "", "Copy bandwidth per second, e.g. 10M (0 = unlimited)")
+	ctlCmd.AddCommand(ctlListCmd, ctlStatusCmd, ctlWatchCmd, ctlPauseCmd, ctlResumeCmd, ctlSetCmd)
+	rootCmd.AddCommand(ctlCmd)
+}
@@ -0,0 +1,76 @@
+// cmd/gc.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jeepinbird/sync-dir/pkg/cas"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/spf13/cobra"
+)
+
+var (
+	gcRetention cas.Retention // --keep-* rules
+	gcDryRun    bool
+)
+
+// gcCmd prunes old snapshots of a --cas store and reclaims unreferenced content.
+var gcCmd = &cobra.Command{
+	Use:   "gc <target>",
+	Short: "Prune old snapshots of a --cas store and reclaim unreferenced content.",
+	Long: `Removes the snapshots of a content-addressed store (see --cas) that the
+--keep-* rules don't keep, then deletes the objects and chunk lists no
+remaining snapshot refers to, along with temp files left by interrupted
+backups. A snapshot is kept if any rule keeps it; without rules, no snapshot
+is removed and only unreferenced content is reclaimed.
+
+The periodic rules keep the newest snapshot of each of the last N days, weeks
+or months that have snapshots, e.g. --keep-last 10 --keep-daily 30 keeps the
+10 newest snapshots plus one per day for the last 30 days with backups.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid target path '%s': %w", args[0], err)
+		}
+		store, err := cas.Open(targetPath)
+		if err != nil {
+			return err
+		}
+		for _, n := range []int{gcRetention.Last, gcRetention.Daily, gcRetention.Weekly, gcRetention.Monthly} {
+			if n < 0 {
+				return fmt.Errorf("--keep-* values cannot be negative")
+			}
+		}
+
+		fmt.Fprintln(os.Stderr, i18n.T("gc.store", targetPath))
+		result, err := store.CollectGarbage(&gcRetention, gcDryRun)
+		if err != nil {
+			return fmt.Errorf("gc failed: %w", err)
+		}
+
+		snapshot, kept, swept := "gc.removed", "gc.kept", "gc.swept"
+		if gcDryRun {
+			snapshot, kept, swept = "gc.would_remove", "gc.kept_dry_run", "gc.swept_dry_run"
+		}
+		for _, id := range result.Removed {
+			fmt.Println(i18n.T(snapshot, id))
+		}
+		fmt.Println("\n" + i18n.T(kept, len(result.Kept), len(result.Removed)))
+		fmt.Println(i18n.T(swept, result.Objects, result.Indexes, result.Temps, summary.FormatBytes(result.Bytes)))
+		return nil
+	},
+}
+
+func init() {
+	gcCmd.Flags().IntVar(&gcRetention.Last, "keep-last", 0, "Keep the N newest snapshots")
+	gcCmd.Flags().IntVar(&gcRetention.Daily, "keep-daily", 0, "Keep the newest snapshot of each of the last N days with snapshots")
+	gcCmd.Flags().IntVar(&gcRetention.Weekly, "keep-weekly", 0, "Keep the newest snapshot of each of the last N weeks with snapshots")
+	gcCmd.Flags().IntVar(&gcRetention.Monthly, "keep-monthly", 0, "Keep the newest snapshot of each of the last N months with snapshots")
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Report what would be removed without removing anything")
+	rootCmd.AddCommand(gcCmd)
+}
@@ -0,0 +1,80 @@
+// cmd/heartbeat.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/console"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/logsink"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+)
+
+var heartbeatInterval time.Duration // --heartbeat: how often long runs report they are alive
+
+// startHeartbeat reports every heartbeatInterval that the run is still going,
+// with its progress so far and its copy rate since the last heartbeat: on
+// stderr when it isn't a terminal (logs, cron mail, systemd units), where
+// nothing else shows for hours during a long copy, and to the log sink. The
+// returned function stops it.
+func startHeartbeat(sync *syncer.Syncer, sink logsink.Sink, target string) func() {
+	toStderr := !console.IsTerminal(os.Stderr)
+	if heartbeatInterval <= 0 || (!toStderr && sink == nil) {
+		return func() {}
+	}
+	started := time.Now()
+	ticker := time.NewTicker(heartbeatInterval)
+	done := make(chan struct{})
+	go func() {
+		var lastBytes int64
+		last := started
+		for {
+			select {
+			case now := <-ticker.C:
+				live := sync.LiveStatus()
+				copied := live.BytesDone - lastBytes
+				if copied < 0 { // A new (mapped) run reset the counters
+					copied = live.BytesDone
+				}
+				rate := int64(float64(copied) / now.Sub(last).Seconds())
+				lastBytes, last = live.BytesDone, now
+				elapsed := now.Sub(started).Round(time.Second)
+				if toStderr {
+					fmt.Fprintf(os.Stderr, "\n%s %s\n", now.Format(time.DateTime),
+						i18n.T("heartbeat.line", elapsed, progressLine(live), summary.FormatBytes(rate)))
+				}
+				logHeartbeat(sink, target, live, elapsed, rate)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// logHeartbeat reports a heartbeat to the log sink.
+func logHeartbeat(sink logsink.Sink, target string, live syncer.LiveStatus, elapsed time.Duration, rate int64) {
+	if sink == nil {
+		return
+	}
+	fields := map[string]string{
+		"job":           jobName(target),
+		"phase":         live.Phase,
+		"actions_done":  strconv.FormatInt(live.ActionsDone, 10),
+		"actions_total": strconv.FormatInt(live.ActionsTotal, 10),
+		"bytes_done":    strconv.FormatInt(live.BytesDone, 10),
+		"bytes_total":   strconv.FormatInt(live.BytesTotal, 10),
+		"rate":          strconv.FormatInt(rate, 10),
+		"elapsed_ms":    strconv.FormatInt(elapsed.Milliseconds(), 10),
+	}
+	if err := sink.Log(logsink.Info, "sync running", fields); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not write to %s: %v\n", logSink, err)
+	}
+}
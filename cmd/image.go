@@ -0,0 +1,42 @@
+// cmd/image.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/image"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+var (
+	imagePath string   // --image: disk image mounted on the target for the run
+	imageSize byteSize // --image-size: size of the image if it has to be created
+)
+
+// mountImage mounts the --image disk image on the target directory, creating
+// the image first if it doesn't exist. A dry run mounts it read-only.
+func mountImage(targetPath string, sourcePaths []string) (*image.Image, error) {
+	abs, err := filepath.Abs(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image path '%s': %w", imagePath, err)
+	}
+	for _, sourcePath := range append(sourcePaths, targetPath) {
+		if rel, err := filepath.Rel(sourcePath, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("image %s cannot be inside %s", abs, sourcePath)
+		}
+	}
+
+	img, err := image.Mount(abs, int64(imageSize), targetPath, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if img.Created {
+		fmt.Fprintln(os.Stderr, i18n.T("image.created", summary.FormatBytes(int64(imageSize)), img.Path))
+	}
+	fmt.Fprintln(os.Stderr, i18n.T("image.mounted", img.Path, img.MountPoint))
+	return img, nil
+}
@@ -0,0 +1,76 @@
+// cmd/log.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jeepinbird/sync-dir/pkg/logsink"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+var logSink string // --log-sink: system logging facility to report runs to
+
+// openLogSink connects to the --log-sink facility, if one was chosen.
+func openLogSink() (logsink.Sink, error) {
+	if logSink == "" {
+		return nil, nil
+	}
+	return logsink.Open(logSink, "sync-dir")
+}
+
+// jobName identifies the job in log entries: the profile, or the target.
+func jobName(target string) string {
+	if profileName != "" {
+		return profileName
+	}
+	return target
+}
+
+// logRunStart reports the start of a run to the log sink.
+func logRunStart(sink logsink.Sink, sources []string, target string) {
+	if sink == nil {
+		return
+	}
+	fields := map[string]string{"job": jobName(target), "target": target}
+	for i, source := range sources {
+		key := "source"
+		if i > 0 {
+			key = "source" + strconv.Itoa(i+1)
+		}
+		fields[key] = source
+	}
+	if err := sink.Log(logsink.Info, "sync started", fields); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not write to %s: %v\n", logSink, err)
+	}
+}
+
+// logRunEnd reports the outcome of a run to the log sink.
+func logRunEnd(sink logsink.Sink, result *summary.Summary) {
+	if sink == nil {
+		return
+	}
+	fields := map[string]string{
+		"job":         jobName(result.Target),
+		"result":      result.Result(),
+		"source":      result.Source,
+		"target":      result.Target,
+		"dry_run":     strconv.FormatBool(result.DryRun),
+		"adds":        strconv.Itoa(result.Adds),
+		"updates":     strconv.Itoa(result.Updates),
+		"deletes":     strconv.Itoa(result.Deletes),
+		"renames":     strconv.Itoa(result.Renames),
+		"bytes":       strconv.FormatInt(result.Bytes, 10),
+		"duration_ms": strconv.FormatInt(result.Duration().Milliseconds(), 10),
+	}
+	priority := logsink.Info
+	if len(result.Errors) > 0 {
+		priority = logsink.Error
+		fields["errors"] = strconv.Itoa(len(result.Errors))
+		fields["first_error"] = result.Errors[0]
+	}
+	if err := sink.Log(priority, "sync "+result.Result(), fields); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not write to %s: %v\n", logSink, err)
+	}
+}
@@ -0,0 +1,153 @@
+// cmd/logger.go
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/progress"
+)
+
+// consoleLogger returns the logger handed to syncers: it prints their records
+// on stderr the way the rest of sync-dir's output looks.
+// With --debug it prints debug records too.
+func consoleLogger() *slog.Logger {
+	level := slog.LevelInfo
+	if debugLog {
+		level = slog.LevelDebug
+	}
+	return slog.New(&consoleHandler{mu: &sync.Mutex{}, w: os.Stderr, level: level})
+}
+
+// withTraceFile returns logger extended to write every record, down to debug
+// records such as the planner's decision on each path, to a file at path as
+// one JSON object per line. The file is closed with the returned closer.
+func withTraceFile(logger *slog.Logger, path string) (*slog.Logger, io.Closer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create trace file: %w", err)
+	}
+	trace := slog.NewJSONHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(teeHandler{logger.Handler(), trace}), file, nil
+}
+
+// consoleHandler renders log records as plain lines: informational ones as
+// their message, problems prefixed with "Warning: " or "Error: ". A "path"
+// attribute follows the message, an "err" attribute comes last after a
+// colon, and any others are appended as key=value.
+type consoleHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	attrs  []slog.Attr
+	prefix string     // Group prefix of attribute keys
+	level  slog.Level // Records below this level are dropped
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	var line, errText strings.Builder
+	if progress.Active() {
+		line.WriteString("\n") // Don't continue the line of a bar
+	}
+	switch {
+	case r.Level >= slog.LevelError:
+		line.WriteString("Error: ")
+	case r.Level >= slog.LevelWarn:
+		line.WriteString("Warning: ")
+	}
+	line.WriteString(r.Message)
+	write := func(key string, value slog.Value) {
+		switch key {
+		case "path":
+			fmt.Fprintf(&line, " %s", value)
+		case "err":
+			fmt.Fprintf(&errText, ": %s", value)
+		default:
+			fmt.Fprintf(&line, " %s=%s", key, quoteValue(value.String()))
+		}
+	}
+	for _, a := range h.attrs {
+		write(a.Key, a.Value)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		write(h.prefix+a.Key, a.Value)
+		return true
+	})
+	line.WriteString(errText.String())
+	line.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line.String())
+	return err
+}
+
+func (h *consoleHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := *h
+	child.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		child.attrs = append(child.attrs, slog.Attr{Key: h.prefix + a.Key, Value: a.Value})
+	}
+	return &child
+}
+
+func (h *consoleHandler) WithGroup(name string) slog.Handler {
+	child := *h
+	child.prefix = h.prefix + name + "."
+	return &child
+}
+
+// teeHandler passes each record on to every handler that takes its level.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := make(teeHandler, len(t))
+	for i, h := range t {
+		child[i] = h.WithAttrs(attrs)
+	}
+	return child
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	child := make(teeHandler, len(t))
+	for i, h := range t {
+		child[i] = h.WithGroup(name)
+	}
+	return child
+}
+
+// quoteValue quotes values that would be ambiguous unquoted.
+func quoteValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\"=") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
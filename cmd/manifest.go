@@ -0,0 +1,114 @@
+// cmd/manifest.go
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestOutput  string // File the manifest is written to ("" = stdout)
+	manifestSignKey string // Private key the manifest is signed with ("" = unsigned)
+)
+
+// manifestCmd describes a directory for publishing over HTTP(S).
+var manifestCmd = &cobra.Command{
+	Use:   "manifest <dir>",
+	Short: "Describe a directory so it can be mirrored over HTTP(S).",
+	Long: `Writes the manifest of a directory: every file and directory with its size,
+mtime, permissions and SHA256 checksum, as JSON. Publish it as ` + manifest.FileName + ` in
+the root of the directory on any web server, and clients can mirror the tree
+with "sync-dir sync https://host/path/ <target>", downloading only what changed.
+The source's .sync-ignore and --exclude/--preset patterns leave paths out.
+
+With --sign-key, the signature is written next to the manifest (-o FILE, as
+FILE.sig) for clients checking it with --manifest-key.
+To serve the directory without a separate web server, see "sync-dir serve".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid directory '%s': %w", args[0], err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", root)
+		}
+		if manifestSignKey != "" && manifestOutput == "" {
+			return fmt.Errorf("--sign-key needs --output, to write the signature next to the manifest")
+		}
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		matcher, err := ignore.NewMatcher(root, excludes, consoleLogger())
+		if err != nil {
+			return fmt.Errorf("failed to load ignore rules: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Describing %s...\n", root)
+		m, err := manifest.Build(root, matcher)
+		if err != nil {
+			return err
+		}
+		if manifestOutput == "" {
+			return m.Write(os.Stdout)
+		}
+		file, err := os.Create(manifestOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", manifestOutput, err)
+		}
+		if err := m.Write(file); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %w", manifestOutput, err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", manifestOutput, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d entries).\n", manifestOutput, len(m.Entries))
+		if manifestSignKey != "" {
+			return signManifest(manifestOutput, manifestSignKey)
+		}
+		return nil
+	},
+}
+
+// signManifest writes the signature of the manifest in path next to it.
+func signManifest(path, keyPath string) error {
+	key, err := loadSigningKey(keyPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+manifest.SignatureSuffix, manifest.Sign(data, key), 0644); err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s.\n", path+manifest.SignatureSuffix)
+	return nil
+}
+
+// loadSigningKey loads the key in path, generating it if it doesn't exist.
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	key, created, err := manifest.LoadSigningKey(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load signing key: %w", err)
+	}
+	if created {
+		fmt.Fprintf(os.Stderr, "Note: Generated signing key %s; clients check signatures with --manifest-key %s.pub\n", path, path)
+	}
+	return key, nil
+}
+
+func init() {
+	manifestCmd.Flags().StringVarP(&manifestOutput, "output", "o", "", "Write the manifest to this file instead of stdout, e.g. <dir>/"+manifest.FileName)
+	manifestCmd.Flags().StringVar(&manifestSignKey, "sign-key", "", "Sign the manifest with the private key in this file, generating it (and FILE.pub) if it doesn't exist")
+	rootCmd.AddCommand(manifestCmd)
+}
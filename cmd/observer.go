@@ -0,0 +1,88 @@
+// cmd/observer.go
+package cmd
+
+import (
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/progress"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+)
+
+// progressObserver draws the progress of a run on stderr: a spinner for each
+// root being scanned, then a bar over the bytes copied while the plan is
+// executed, showing the file being worked on.
+type progressObserver struct {
+	syncer.NopObserver
+	mu        sync.Mutex
+	scans     map[string]*progress.Progress // Spinners by root description
+	plan      *syncer.SyncPlan
+	bar       *progress.Progress // Started with the first action of the plan
+	remaining int                // Actions of the plan not done yet
+}
+
+func newProgressObserver() *progressObserver {
+	return &progressObserver{scans: make(map[string]*progress.Progress)}
+}
+
+func (o *progressObserver) OnScanProgress(p syncer.ScanProgress) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	spinner := o.scans[p.Description]
+	if p.Done {
+		spinner.Finish()
+		delete(o.scans, p.Description)
+		return
+	}
+	if spinner == nil {
+		spinner = progress.NewSpinner(i18n.T("progress.scanning", syncer.RoleName(p.Description)))
+		o.scans[p.Description] = spinner
+	}
+	spinner.Add(1)
+}
+
+func (o *progressObserver) OnPlanReady(plan *syncer.SyncPlan) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.plan = plan
+	o.remaining = len(plan.Actions)
+}
+
+func (o *progressObserver) OnActionStart(action syncer.SyncAction) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.bar == nil && o.plan != nil {
+		o.bar = progress.NewBytes(i18n.T("progress.syncing"), o.plan.CopyBytes())
+	}
+	o.bar.Current(action.RelPath)
+}
+
+func (o *progressObserver) OnBytesCopied(n int64) {
+	o.mu.Lock()
+	bar := o.bar
+	o.mu.Unlock()
+	bar.Add64(n)
+}
+
+func (o *progressObserver) OnActionDone(syncer.SyncAction, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.remaining--
+	if o.remaining == 0 {
+		// Remove the bar before the executor reports the outcome
+		o.bar.Finish()
+		o.bar, o.plan = nil, nil
+	}
+}
+
+func (o *progressObserver) OnComplete(*summary.Summary, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.bar.Finish() // Left when actions were skipped after a failure
+	o.bar = nil
+	for description, spinner := range o.scans {
+		spinner.Finish()
+		delete(o.scans, description)
+	}
+}
@@ -0,0 +1,141 @@
+// cmd/oplog.go
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/state"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/spf13/cobra"
+)
+
+var (
+	oplogSince string   // Only records at or after this time or this long ago
+	oplogOps   []string // Only these operations (none = all)
+	oplogJSON  bool     // Print the records as JSON Lines
+)
+
+// oplogCmd browses the operations log syncs keep for a target.
+var oplogCmd = &cobra.Command{
+	Use:   "log <target> [path]",
+	Short: "Show the changes syncs made to a target, oldest first.",
+	Long: `Every sync appends the changes it makes to a target to that target's
+operations log, kept in the state directory: each add, update, delete and
+rename with its time, the start of the run that made it, the size and, when
+the sync knew it, the SHA256 of the content written or deleted. Nothing is
+ever removed from the log, so it answers questions like "when did this file
+disappear?" long after the fact, even if the target is gone.
+
+With a path, only the records of that path and, for a directory, of
+everything below it are shown.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid target path '%s': %w", args[0], err)
+		}
+		var prefix string
+		if len(args) == 2 {
+			prefix = strings.Trim(filepath.ToSlash(filepath.Clean(args[1])), "/")
+		}
+		since, err := parseSince(oplogSince)
+		if err != nil {
+			return err
+		}
+		ops := make(map[string]bool)
+		for _, op := range oplogOps {
+			switch op = strings.ToLower(op); op {
+			case "add", "update", "delete", "rename":
+				ops[op] = true
+			default:
+				return fmt.Errorf("unknown operation %q (valid: add, update, delete, rename)", op)
+			}
+		}
+
+		target, err := state.LookupTarget("", targetPath)
+		if err != nil {
+			return fmt.Errorf("failed to read sync state: %w", err)
+		}
+		if target == nil {
+			fmt.Fprintf(os.Stderr, "No operations recorded for %s.\n", targetPath)
+			return nil
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		shown := 0
+		err = target.Operations(func(record state.OpRecord) {
+			if record.Time.Before(since) || (len(ops) > 0 && !ops[record.Op]) {
+				return
+			}
+			if prefix != "" && !within(record.Path, prefix) && !within(record.From, prefix) {
+				return
+			}
+			shown++
+			if oplogJSON {
+				_ = enc.Encode(record)
+				return
+			}
+			fmt.Println(formatOpRecord(record))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read operations log: %w", err)
+		}
+		if shown == 0 {
+			fmt.Fprintf(os.Stderr, "No matching operations recorded for %s.\n", targetPath)
+		}
+		return nil
+	},
+}
+
+// parseSince parses --since: a duration ago (e.g. 36h), a date (2006-01-02,
+// local time) or an RFC 3339 time. "" is the zero time.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 36h, a date like 2006-01-02 or an RFC 3339 time", value)
+}
+
+// within reports whether the slash-separated path is dir or lies below it.
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// formatOpRecord renders a record as one line: local time, operation, path
+// and what is known about the content.
+func formatOpRecord(record state.OpRecord) string {
+	line := fmt.Sprintf("%s  %-6s  %s", record.Time.Local().Format("2006-01-02 15:04:05"), record.Op, record.Path)
+	switch {
+	case record.From != "":
+		line += "  (from " + record.From + ")"
+	case record.Dir:
+		line += "/"
+	case record.Op != "rename":
+		line += "  " + summary.FormatBytes(record.Size)
+		if record.SHA256 != "" {
+			line += "  sha256:" + record.SHA256
+		}
+	}
+	return line
+}
+
+func init() {
+	oplogCmd.Flags().StringVar(&oplogSince, "since", "", "Only show changes since this long ago (e.g. 36h), this date (2006-01-02) or this RFC 3339 time")
+	oplogCmd.Flags().StringSliceVar(&oplogOps, "op", nil, "Only show these operations: add, update, delete, rename (can be specified multiple times)")
+	oplogCmd.Flags().BoolVar(&oplogJSON, "json", false, "Print the records as JSON Lines, as stored")
+	rootCmd.AddCommand(oplogCmd)
+}
@@ -0,0 +1,242 @@
+// cmd/peer.go
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/peer"
+	"github.com/jeepinbird/sync-dir/pkg/progress"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/spf13/cobra"
+)
+
+// tokenEnv holds the peer token, so it doesn't show in the process list.
+const tokenEnv = "SYNC_DIR_PEER_TOKEN"
+
+var (
+	peerToken      string        // Token shared by receiver and pusher
+	peerListen     string        // Address the receiver listens on
+	peerName       string        // Name the receiver announces
+	peerNoAnnounce bool          // Don't announce the receiver on the network
+	peerAddr       string        // Receiver address, bypassing discovery
+	peerTimeout    time.Duration // How long to wait for receivers to answer
+)
+
+// receiveCmd accepts pushes from other machines.
+var receiveCmd = &cobra.Command{
+	Use:   "receive <dir>",
+	Short: "Accept pushes into a directory from other machines on the LAN.",
+	Long: `Listens for "sync-dir push" from other machines and syncs what they push into
+directories below <dir>. The receiver announces itself on the local network
+over multicast DNS (` + peer.ServiceType + `), so pushers find it by --name.
+
+Pushers must present the token given with --token or $` + tokenEnv + `; without
+one, a token is generated and printed. Transfers are not encrypted: use this
+on trusted networks only.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid directory '%s': %w", args[0], err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", root)
+		}
+		token := peerTokenValue()
+		if token == "" {
+			var random [12]byte
+			if _, err := rand.Read(random[:]); err != nil {
+				return err
+			}
+			token = hex.EncodeToString(random[:])
+			fmt.Fprintf(os.Stderr, "Note: Pushers need the token %s (--token or $%s).\n", token, tokenEnv)
+		}
+		listener, err := net.Listen("tcp", peerListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", peerListen, err)
+		}
+		defer listener.Close()
+
+		name := peerName
+		if !peerNoAnnounce {
+			port := listener.Addr().(*net.TCPAddr).Port
+			stop := make(chan struct{})
+			defer close(stop)
+			go func() {
+				if err := peer.Announce(name, port, stop); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: Could not announce on the local network (pushers need --addr): %v\n", err)
+				}
+			}()
+		}
+		fmt.Fprintf(os.Stderr, "Receiving into %s as %q on %s\n", root, name, listener.Addr())
+		receiver := &peer.Receiver{Root: root, Token: token, Log: func(remote net.Addr, format string, args ...any) {
+			fmt.Fprintf(os.Stderr, "%s %s: %s\n", time.Now().Format(time.DateTime), remote, fmt.Sprintf(format, args...))
+		}}
+		return receiver.Serve(listener)
+	},
+}
+
+// pushCmd syncs a directory to a receiver.
+var pushCmd = &cobra.Command{
+	Use:   "push <dir> <peer>:<path>",
+	Short: "Sync a directory to another machine running \"sync-dir receive\".",
+	Long: `Mirrors <dir> into <path> below the root of the receiver called <peer>, found
+on the local network over multicast DNS (see "sync-dir peers"), or at --addr.
+Like sync, files whose size, mtime or permissions differ are sent, and what
+the receiver has that <dir> doesn't is deleted; .sync-ignore and --exclude
+patterns leave paths out on both sides. A file of unchanged size is hashed on
+both machines first, and only its mode and mtime are set if the checksums
+match, so verifying it sends no content over the network.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid directory '%s': %w", args[0], err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", root)
+		}
+		name, dir, err := peer.ParseTarget(args[1])
+		if err != nil {
+			return err
+		}
+		addr := peerAddr
+		if addr == "" {
+			found, err := peer.Resolve(name, peerTimeout)
+			if err != nil {
+				return err
+			}
+			addr = found.Addr
+		}
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		matcher, err := ignore.NewMatcher(root, excludes, consoleLogger())
+		if err != nil {
+			return fmt.Errorf("failed to load ignore rules: %w", err)
+		}
+
+		local, err := peer.Scan(root, matcher)
+		if err != nil {
+			return err
+		}
+		session, err := peer.Dial(addr, peerTokenValue(), dir)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s (%s): %w", name, addr, err)
+		}
+		defer session.Close()
+		remote, err := session.List()
+		if err != nil {
+			return fmt.Errorf("failed to list %s: %w", args[1], err)
+		}
+		plan := peer.PlanPush(local, remote, matcher)
+		if err := session.CompareContent(plan, root, remote); err != nil {
+			return err
+		}
+		printPushPlan(plan, args[1])
+		if plan.Changes() == 0 || dryRun {
+			return nil
+		}
+		if !assumeYes {
+			fmt.Fprint(os.Stderr, i18n.T("prompt.proceed"))
+			answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return i18n.Errorf("prompt.read_failed", err)
+			}
+			if !i18n.Confirmed(answer) {
+				fmt.Fprintln(os.Stderr, i18n.T("prompt.aborted"))
+				return nil
+			}
+		}
+
+		var bar *progress.Progress
+		if plan.Bytes > 0 {
+			bar = progress.NewBytes("Pushing", plan.Bytes)
+		}
+		err = session.Apply(plan, root, func(entry peer.Entry, sent int64) {
+			if bar != nil && sent > 0 {
+				bar.Current(entry.Path)
+				bar.Add64(sent)
+			}
+		})
+		if bar != nil {
+			bar.Finish()
+		}
+		if err != nil {
+			return fmt.Errorf("push finished with errors: %w", err)
+		}
+		fmt.Println("Push completed successfully.")
+		return nil
+	},
+}
+
+// peersCmd lists the receivers on the network.
+var peersCmd = &cobra.Command{
+	Use:   "peers",
+	Short: "List the receivers announcing themselves on the local network.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		peers, err := peer.Browse(peerTimeout)
+		if err != nil {
+			return err
+		}
+		if len(peers) == 0 {
+			fmt.Fprintln(os.Stderr, "No receivers answered.")
+			return nil
+		}
+		for _, p := range peers {
+			fmt.Printf("%s\t%s\n", p.Name, p.Addr)
+		}
+		return nil
+	},
+}
+
+// peerTokenValue returns the token from --token or the environment.
+func peerTokenValue() string {
+	if peerToken != "" {
+		return peerToken
+	}
+	return os.Getenv(tokenEnv)
+}
+
+// printPushPlan shows what a push changes on the receiver.
+func printPushPlan(plan *peer.Plan, target string) {
+	fmt.Printf("Push plan for %s:\n", target)
+	fmt.Printf("  Directories to create: %d\n", len(plan.Mkdirs))
+	fmt.Printf("  Files to add:          %d\n", len(plan.Adds))
+	fmt.Printf("  Files to update:       %d\n", len(plan.Updates))
+	fmt.Printf("  Files to touch:        %d (same content, checked on the receiver)\n", len(plan.Touches))
+	fmt.Printf("  Paths to delete:       %d\n", len(plan.Deletes))
+	fmt.Printf("  Data to send:          %s\n", summary.FormatBytes(plan.Bytes))
+	if plan.Changes() == 0 {
+		fmt.Println("Nothing to do.")
+	}
+}
+
+func init() {
+	hostname, _ := os.Hostname()
+	receiveCmd.Flags().StringVar(&peerListen, "listen", ":"+strconv.Itoa(peer.DefaultPort), "Address to listen on")
+	receiveCmd.Flags().StringVar(&peerName, "name", hostname, "Name to announce on the local network")
+	receiveCmd.Flags().BoolVar(&peerNoAnnounce, "no-announce", false, "Don't announce on the local network; pushers connect with --addr")
+	for _, cmd := range []*cobra.Command{receiveCmd, pushCmd} {
+		cmd.Flags().StringVar(&peerToken, "token", "", "Token shared by receiver and pusher (default $"+tokenEnv+")")
+	}
+	pushCmd.Flags().StringVar(&peerAddr, "addr", "", "Connect to the receiver at this host:port instead of looking it up by name")
+	pushCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the plan without changing anything")
+	pushCmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Proceed without asking for confirmation")
+	for _, cmd := range []*cobra.Command{pushCmd, peersCmd} {
+		cmd.Flags().DurationVar(&peerTimeout, "discovery-timeout", 2*time.Second, "How long to wait for receivers to answer")
+	}
+	rootCmd.AddCommand(receiveCmd, pushCmd, peersCmd)
+}
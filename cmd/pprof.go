@@ -0,0 +1,35 @@
+// cmd/pprof.go
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"time"
+)
+
+// startPprof serves the runtime profiles of net/http/pprof on addr, e.g. to
+// watch the heap of a sync of a huge tree while it runs. The returned
+// function stops serving.
+func startPprof(addr string) (func(), error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s for --pprof: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		_ = server.Serve(listener) // Until closed
+	}()
+	fmt.Fprintf(os.Stderr, "Note: Serving profiles on http://%s/debug/pprof/\n", listener.Addr())
+	return func() {
+		_ = server.Close()
+	}, nil
+}
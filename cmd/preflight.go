@@ -0,0 +1,78 @@
+// cmd/preflight.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+// preflightCmd checks permissions along the plan without executing it.
+var preflightCmd = &cobra.Command{
+	Use:   "preflight <source> <target>",
+	Short: "Check that a sync could read and write everything it needs to.",
+	Long: `Plans the sync of source into target like a dry run, then checks that every
+source file the plan copies can be opened for reading and every target
+directory it adds to, replaces files in or deletes from is writable. Nothing
+is changed. Exits with an error if any problem was found, so it can gate a
+scheduled sync.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true, // Problems found aren't usage errors
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourcePath, targetPath, err := resolvePair(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("sync.source", sourcePath))
+		fmt.Fprintln(os.Stderr, i18n.T("sync.target", targetPath))
+
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		sync := syncer.NewSyncer(sourcePath, targetPath, excludes, true)
+		sync.Gitignore = gitignore
+		sync.Logger = consoleLogger()
+		sync.Observer = newProgressObserver()
+		report, err := sync.Preflight()
+		if err != nil {
+			return fmt.Errorf("preflight failed: %w", err)
+		}
+
+		fmt.Println("\n" + i18n.T("preflight.checked", report.SourceChecked, report.TargetDirsChecked))
+		if report.ScanSkipped > 0 {
+			fmt.Println("\n" + i18n.N("preflight.scan_skipped", int(report.ScanSkipped), report.ScanSkipped))
+		}
+		printProblems(i18n.T("preflight.unreadable"), report.Unreadable)
+		printProblems(i18n.T("preflight.not_writable"), report.NotWritable)
+
+		if report.OK() {
+			fmt.Println("\n" + theme.Paint(theme.Success, i18n.T("preflight.ok")))
+			return nil
+		}
+		return i18n.Errorf("preflight.problems", len(report.Unreadable)+len(report.NotWritable)+int(report.ScanSkipped))
+	},
+}
+
+// printProblems lists up to statusSampleLimit problems under a title.
+func printProblems(title string, problems []syncer.PreflightProblem) {
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Printf("\n%s: %d\n", title, len(problems))
+	for i, p := range problems {
+		if i == statusSampleLimit {
+			fmt.Printf("  ! %s\n", i18n.T("sample.more", len(problems)-statusSampleLimit))
+			break
+		}
+		fmt.Printf("  ! %s (%s)\n", p.Path, p.Reason)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(preflightCmd)
+}
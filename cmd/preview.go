@@ -0,0 +1,188 @@
+// cmd/preview.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var previewTree bool // Draw the previewed target as a tree
+
+// previewCmd shows the target as a sync would leave it, without syncing.
+var previewCmd = &cobra.Command{
+	Use:   "preview <source> <target>",
+	Short: "Show what the target would look like after a sync.",
+	Long: `Plans the sync of source into target like a dry run and lists the target as
+it would be once the plan is applied: every file, directory and symlink with
+its size and where it comes from (unchanged, new or updated from a source
+file, renamed from another target path, or only in the target and kept).
+Nothing is changed, so the result can be reviewed before syncing.
+
+With --tree the listing is drawn as a tree, directories showing the total
+size of what they would hold. Target items the ignore rules leave out are not
+listed; a sync leaves them alone.`,
+	Args:         cobra.ExactArgs(2),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourcePath, targetPath, err := resolvePair(args[0], args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("sync.source", sourcePath))
+		fmt.Fprintln(os.Stderr, i18n.T("sync.target", targetPath))
+
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		sync := syncer.NewSyncer(sourcePath, targetPath, excludes, true)
+		sync.Gitignore = gitignore
+		sync.Logger = consoleLogger()
+		sync.Observer = newProgressObserver()
+		entries, plan, err := sync.Preview()
+		if err != nil {
+			return fmt.Errorf("preview failed: %w", err)
+		}
+
+		fmt.Println()
+		if previewTree {
+			printPreviewTree(filepath.Base(targetPath), entries)
+		} else {
+			printPreviewList(entries)
+		}
+
+		var files, dirs int
+		var size int64
+		for _, entry := range entries {
+			if entry.IsDir {
+				dirs++
+				continue
+			}
+			files++
+			size += entry.Size
+		}
+		fmt.Println("\n" + i18n.T("preview.totals", files, dirs, summary.FormatBytes(size)))
+		fmt.Println(i18n.T("plan.counts", plan.Adds, plan.Updates, plan.Deletes, plan.Renames))
+		return nil
+	},
+}
+
+// previewLabels returns the label of each origin, padded to the same width
+// with pad, colored like the plan actions that lead to it.
+func previewLabels(pad bool) map[syncer.PreviewOrigin]string {
+	keys := []string{"preview.kept", "preview.extra", "preview.added", "preview.updated", "preview.renamed"}
+	labels := i18n.Pad(keys...)
+	if !pad {
+		for i, key := range keys {
+			labels[i] = i18n.T(key)
+		}
+	}
+	return map[syncer.PreviewOrigin]string{
+		syncer.PreviewKept:    labels[0],
+		syncer.PreviewExtra:   labels[1],
+		syncer.PreviewAdded:   theme.Paint(theme.Add, labels[2]),
+		syncer.PreviewUpdated: theme.Paint(theme.Update, labels[3]),
+		syncer.PreviewRenamed: theme.Paint(theme.Rename, labels[4]),
+	}
+}
+
+// previewName is how an entry is listed: directories end in a separator,
+// symlinks in an @.
+func previewName(name string, entry syncer.PreviewEntry) string {
+	switch {
+	case entry.IsDir:
+		return name + string(filepath.Separator)
+	case entry.Symlink:
+		return name + "@"
+	}
+	return name
+}
+
+// previewFrom describes where an entry comes from ("" for directories and
+// entries only in the target).
+func previewFrom(entry syncer.PreviewEntry) string {
+	if entry.From == "" || entry.IsDir && entry.Origin != syncer.PreviewRenamed {
+		return ""
+	}
+	return "  <- " + entry.From
+}
+
+// printPreviewList lists entries one per line, in path order.
+func printPreviewList(entries []syncer.PreviewEntry) {
+	labels := previewLabels(true)
+	for _, entry := range entries {
+		size := ""
+		if !entry.IsDir {
+			size = summary.FormatBytes(entry.Size)
+		}
+		fmt.Printf("  [%s] %10s  %s%s\n", labels[entry.Origin], size, previewName(entry.RelPath, entry), previewFrom(entry))
+	}
+}
+
+// previewNode is a directory level of the previewed tree.
+type previewNode struct {
+	entry    syncer.PreviewEntry
+	size     int64 // Of the entry, or of everything below a directory
+	children map[string]*previewNode
+}
+
+// printPreviewTree draws entries as a tree below a root named root.
+func printPreviewTree(root string, entries []syncer.PreviewEntry) {
+	top := &previewNode{entry: syncer.PreviewEntry{IsDir: true}, children: make(map[string]*previewNode)}
+	for _, entry := range entries {
+		node := top
+		for _, name := range strings.Split(entry.RelPath, string(filepath.Separator)) {
+			child, ok := node.children[name]
+			if !ok {
+				child = &previewNode{entry: syncer.PreviewEntry{IsDir: true}, children: make(map[string]*previewNode)}
+				node.children[name] = child
+			}
+			node = child
+		}
+		node.entry = entry
+	}
+	top.total()
+
+	fmt.Printf("%s  %s\n", previewName(root, top.entry), summary.FormatBytes(top.size))
+	top.print("", previewLabels(false))
+}
+
+// total sums the sizes below n into n.size.
+func (n *previewNode) total() int64 {
+	n.size = n.entry.Size
+	for _, child := range n.children {
+		n.size += child.total()
+	}
+	return n.size
+}
+
+func (n *previewNode) print(indent string, labels map[syncer.PreviewOrigin]string) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		child := n.children[name]
+		branch, next := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Printf("%s%s%s  %s  [%s]%s\n", indent, branch, previewName(name, child.entry), summary.FormatBytes(child.size), labels[child.entry.Origin], previewFrom(child.entry))
+		child.print(indent+next, labels)
+	}
+}
+
+func init() {
+	previewCmd.Flags().BoolVar(&previewTree, "tree", false, "Draw the target as a tree, with the total size of each directory")
+	rootCmd.AddCommand(previewCmd)
+}
@@ -0,0 +1,144 @@
+// cmd/report.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/report"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+)
+
+var (
+	planExport   string // File the plan is exported to as CSV/TSV ("" = none)
+	reportExport string // File the plan and its outcome are exported to ("" = none)
+)
+
+// actionRecorder observes a run whose plan or outcome is exported: it passes
+// the events on to the progress observer and records every planned action
+// with its result and duration. Mapped subtrees add their plans to the rows.
+type actionRecorder struct {
+	*progressObserver
+	recMu   sync.Mutex
+	rows    []report.Row
+	index   map[actionKey]int       // Row of each action of the current plans
+	started map[actionKey]time.Time // Start of the actions running
+}
+
+// actionKey identifies an action within a plan; a path replaced by an item
+// of another type has both a delete and an add.
+type actionKey struct {
+	actionType syncer.SyncActionType
+	relPath    string
+}
+
+func newActionRecorder(progress *progressObserver) *actionRecorder {
+	return &actionRecorder{
+		progressObserver: progress,
+		index:            make(map[actionKey]int),
+		started:          make(map[actionKey]time.Time),
+	}
+}
+
+func (r *actionRecorder) OnPlanReady(plan *syncer.SyncPlan) {
+	r.progressObserver.OnPlanReady(plan)
+	r.recMu.Lock()
+	for _, action := range plan.Actions {
+		r.index[actionKey{action.Type, action.RelPath}] = len(r.rows)
+		r.rows = append(r.rows, planRow(action))
+	}
+	rows := append([]report.Row(nil), r.rows...)
+	r.recMu.Unlock()
+	if planExport != "" {
+		// Written before the plan is confirmed, so it can be reviewed first
+		if err := report.Write(planExport, rows, false); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not export plan to %s: %v\n", planExport, err)
+		}
+	}
+}
+
+func (r *actionRecorder) OnActionStart(action syncer.SyncAction) {
+	r.recMu.Lock()
+	r.started[actionKey{action.Type, action.RelPath}] = time.Now()
+	r.recMu.Unlock()
+	r.progressObserver.OnActionStart(action)
+}
+
+func (r *actionRecorder) OnActionDone(action syncer.SyncAction, err error) {
+	r.progressObserver.OnActionDone(action, err)
+	key := actionKey{action.Type, action.RelPath}
+	r.recMu.Lock()
+	defer r.recMu.Unlock()
+	i, ok := r.index[key]
+	if !ok {
+		return
+	}
+	r.rows[i].Duration = time.Since(r.started[key])
+	delete(r.started, key)
+	r.rows[i].Result = report.Succeeded
+	if err != nil {
+		r.rows[i].Result = report.Failed
+		r.rows[i].Error = err.Error()
+	}
+}
+
+// writeReport exports the recorded actions with their outcome to
+// --report-export.
+func (r *actionRecorder) writeReport() {
+	r.recMu.Lock()
+	defer r.recMu.Unlock()
+	if err := report.Write(reportExport, r.rows, true); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not export report to %s: %v\n", reportExport, err)
+	}
+}
+
+// planRow describes an action not run yet.
+func planRow(action syncer.SyncAction) report.Row {
+	row := report.Row{Action: strings.ToLower(action.Type.String()), Path: filepath.ToSlash(action.RelPath), Result: report.NotRun}
+	info := action.SourceInfo
+	if action.Type == syncer.Delete || info == nil {
+		info = action.TargetInfo
+	}
+	if info != nil {
+		row.ModTime = info.ModTime
+		if info.IsDir {
+			row.Path += "/"
+		} else {
+			row.Size = info.Size
+		}
+	}
+	return row
+}
+
+// writeMetrics writes the run's metrics to --metrics-file, replacing the file
+// at once so collectors never read half of it.
+func writeMetrics(result *summary.Summary) {
+	if err := writeMetricsFile(metricsFile, result); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not write metrics to %s: %v\n", metricsFile, err)
+	}
+}
+
+func writeMetricsFile(path string, result *summary.Summary) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sync-dir-metrics-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once renamed
+	if err := result.WriteMetrics(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil { // Collectors often run as another user
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
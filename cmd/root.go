@@ -2,18 +2,104 @@
 package cmd
 
 import (
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
 
+	"github.com/jeepinbird/sync-dir/pkg/console"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/manifest"
+	"github.com/jeepinbird/sync-dir/pkg/progress"
+	"github.com/jeepinbird/sync-dir/pkg/seclabel"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
 	"github.com/jeepinbird/sync-dir/pkg/syncer" // Import the syncer package
+	"github.com/jeepinbird/sync-dir/pkg/theme"
 	"github.com/spf13/cobra"
 )
 
 var (
 	// Flags
 	excludePatterns []string // Stores values from --exclude flags
+	presetNames     []string // Exclusion presets from --preset
+	noDefaults      bool     // Don't exclude editor, office and file manager temp files
+	gitignore       bool     // Also honor the source's .gitignore files
 	dryRun          bool     // Flag for dry run
+	bufferSize      = byteSize(syncer.DefaultBufferSize)
+	tempDir         string   // Where temp files are written ("" = next to their destination)
+	partial         bool     // Keep the data of interrupted copies and resume from it
+	partialDir      string   // Name of the directories partial copies are kept in ("" = default)
+	appendResume    bool     // Complete truncated target files in place
+	stageAndSwap    bool     // Sync into a clone of the target and swap it into place
+	spotCheck       string   // Percentage of the copied files verified after the run ("" = none)
+	targetQuota     byteSize // Soft limit on the size of the target's files (0 = none)
+	seedDir         string   // Local copy files are taken from instead of the source ("" = none)
+	manifestPubKey  string   // Key a URL source's manifest must be signed with ("" = none)
+	proxyURL        string   // Proxy a URL source is fetched through ("" = from the environment)
+	caCert          string   // PEM CA certificates trusted for a URL source ("" = system's only)
+	clientCert      string   // PEM client certificate presented to a URL source ("" = none)
+	clientKey       string   // Private key of clientCert ("" = in the same file)
+	sameDiskWorkers int      // Override for concurrency on a shared device
+	workers         int      // Parallel file operations (0 = automatic)
+	serializeDirs   bool     // Run the actions in one directory one at a time
+	bwLimit         byteSize // Copy bandwidth per second (0 = unlimited)
+	ioPriority      string   // I/O priority from --io-priority ("" = inherited)
+	incremental     bool     // Reuse cached scans for unchanged directories
+	minDepth        int      // Entries above this depth don't take part (0 = none)
+	maxDepth        int      // Entries below this depth don't take part (0 = no limit)
+	follow          []string // Patterns of source symlinks synced as the directories they point to
+	subtreeMaps     []string // SUBTREE=TARGET rules redirecting source subtrees
+	fsQuirks        string   // Target filesystem workarounds profile
+	sanitizeNames   bool     // Sync paths the target's filesystem can't hold under valid names
+	hashWorkers     int      // Files hashed in parallel while planning
+	hashCPULimit    string   // Cap on parallel hashing and its nice level ("" = none)
+	targetFlags     []string // Targets given with --target, all synced from one scan of the source
+	assumeYes       bool     // Skip the confirmation prompt
+	assertInSync    bool     // Change nothing and fail if the target differs from the source
+	confirmDeletes  int      // Confirm only plans deleting more than this (-1 = unset)
+	confirmChanges  int      // Confirm only plans with more actions than this (-1 = unset)
+	churnWarning    string   // Share of the target's files a plan may change before it is flagged
+	massChangeGuard string   // What happens when the source looks encrypted by ransomware
+	summaryFormat   string   // Format of the final report ("" = none)
+	metricsFile     string   // File the run's Prometheus metrics are written to ("" = none)
+	deleteToTrash   bool     // Move deleted target items to the OS trash
+	alwaysHash      []string // Patterns compared by checksum even when size and mtime match
+	includeTypes    []string // Content types synced (none = all)
+	excludeTypes    []string // Content types left out
+	sniffTypes      bool     // Sniff the type of files whose extension doesn't tell it
+	skipHotDBs      bool     // Leave databases that look in use out of the sync
+	allowHotDBs     bool     // Copy databases that look in use without warning
+	checkOpenFiles  bool     // Warn about source files open for writing
+	skipOpenFiles   bool     // Leave source files open for writing out of the sync
+	xattrMarkers    bool     // Tag copied files with checksum/mtime xattrs
+	selinux         bool     // Replicate SELinux security contexts
+	fileCaps        bool     // Replicate file capabilities
+	fakeSuper       bool     // Record ownership and special files in xattrs of the target
+	fromFakeSuper   bool     // Restore ownership and special files recorded by --fake-super
+	pprofAddr       string   // Serve net/http/pprof here during the run ("" = don't)
+	memLimit        byteSize // Soft cap on memory (0 = none)
+	traceFile       string   // Write debug records to this file as JSON lines ("" = don't)
+	debugLog        bool     // Also print debug records
+	dedupeTarget    bool     // Hard link identical files in the target after syncing
+	timesDirs       bool     // Give target directories the source's mtimes after syncing
+	casMode         bool     // Back up into a content-addressed store instead of mirroring
+	language        string   // Language of messages ("" = from the locale)
+	noColor         bool     // Never color the output
+	plainProgress   bool     // Periodic text lines instead of bars and spinners
+	requireMounted  bool     // Refuse to run unless the target is a mount point
+	requireFile     string   // Refuse to run unless this marker file exists in the target
+	minSourceFiles  int      // Refuse deletions if the source holds fewer files than this
+	force           bool     // Delete from the target even if the source looks empty
+	explainPaths    []string // Paths whose planned actions are explained with the plan
+	planFilter      []string // Action types listed with the plan (none = all)
+	planSort        string   // Order of the actions listed with the plan
+	planLimit       int      // Actions listed with the plan (0 = all)
 
 	// rootCmd represents the base command when called without any subcommands
 	rootCmd = &cobra.Command{
@@ -26,75 +112,547 @@ The source is treated as the source of truth.
 - Files that differ based on modification time and size will be updated from the source.
 - A checksum is automatically used to verify differences when modification times or sizes alone are inconclusive (e.g., same size but different time).
 - Exclusions can be specified via --exclude flags or a .sync-ignore file in the source directory.`,
-		Args: cobra.ExactArgs(2), // Requires exactly two arguments: source and target
-		RunE: func(cmd *cobra.Command, args []string) error {
-			sourcePath, err := filepath.Abs(args[0])
-			if err != nil {
-				return fmt.Errorf("invalid source path '%s': %w", args[0], err)
+		Args: func(cmd *cobra.Command, args []string) error {
+			// Exactly two arguments (source and target), the source alone with
+			// --target, or none with a profile
+			if len(args) == 0 && profileName != "" {
+				return nil
+			}
+			if len(targetFlags) > 0 {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := loadProfile(cmd); err != nil {
+				return err
+			}
+			if noColor {
+				console.DisableColor()
 			}
-			targetPath, err := filepath.Abs(args[1])
+			progress.SetPlain(plainProgress || console.Dumb())
+			loadColors()
+			return i18n.SetLanguage(language)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources, targets, err := profileArgs(args)
 			if err != nil {
-				return fmt.Errorf("invalid target path '%s': %w", args[1], err)
+				return err
 			}
+			return notInSync(cmd, runSync(sources, targets))
+		},
+	}
+)
 
-			// Basic validation: source must exist and be a directory
-			sourceInfo, err := os.Stat(sourcePath)
+// runSync validates the arguments, builds a Syncer from the flags and runs it.
+// More than one source is only valid with --merge (see the sync command),
+// more than one target fans the source out to all of them.
+func runSync(sources []string, targets []string) (err error) {
+	var sourcePaths []string
+	targetPaths := make([]string, len(targets))
+	for _, source := range sources {
+		for i, target := range targets {
+			sourcePath, resolvedTarget, err := resolvePair(source, target)
 			if err != nil {
-				if os.IsNotExist(err) {
-					return fmt.Errorf("source path '%s' does not exist", sourcePath)
-				}
-				return fmt.Errorf("could not stat source path '%s': %w", sourcePath, err)
+				return err
 			}
-			if !sourceInfo.IsDir() {
-				return fmt.Errorf("source path '%s' is not a directory", sourcePath)
+			if i == 0 {
+				sourcePaths = append(sourcePaths, sourcePath)
+				fmt.Fprintln(os.Stderr, i18n.T("sync.source", sourcePath))
 			}
-
-			// Target validation: if it exists, must be a directory
-			targetInfo, err := os.Stat(targetPath)
-			if err != nil {
-				if !os.IsNotExist(err) {
-					return fmt.Errorf("could not stat target path '%s': %w", targetPath, err)
-				}
-				// Target doesn't exist, which is fine, it will be created
-			} else if !targetInfo.IsDir() {
-				return fmt.Errorf("target path '%s' exists but is not a directory", targetPath)
+			targetPaths[i] = resolvedTarget
+		}
+	}
+	for i, targetPath := range targetPaths {
+		for _, other := range targetPaths[:i] {
+			if nested(targetPath, other) || nested(other, targetPath) {
+				return fmt.Errorf("targets %s and %s can't be the same or lie inside one another", other, targetPath)
 			}
+		}
+		fmt.Fprintln(os.Stderr, i18n.T("sync.target", targetPath))
+	}
+	targetPath := targetPaths[0]
+	if len(targetPaths) > 1 && (len(subtreeMaps) > 0 || imagePath != "") {
+		return fmt.Errorf("several targets cannot be combined with --map or --image")
+	}
+	if len(excludePatterns) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("sync.excludes", excludePatterns))
+	}
+	if len(presetNames) > 0 {
+		fmt.Fprintln(os.Stderr, i18n.T("sync.presets", strings.Join(presetNames, ", ")))
+	}
+	if gitignore {
+		fmt.Fprintln(os.Stderr, i18n.T("sync.gitignore"))
+	}
+	excludes, err := cliExcludes()
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Fprintln(os.Stderr, theme.PaintErr(theme.Header, i18n.T("sync.dry_run_mode")))
+	}
 
-			// Prevent syncing a directory to itself or a subdirectory of itself
-			if sourcePath == targetPath {
-				return fmt.Errorf("source and target paths cannot be the same")
-			}
-			rel, err := filepath.Rel(sourcePath, targetPath)
-			if err == nil && !filepath.IsAbs(rel) && len(rel) > 0 && rel[0] != '.' {
-				return fmt.Errorf("target path '%s' cannot be inside the source path '%s'", targetPath, sourcePath)
+	if bufferSize <= 0 {
+		return fmt.Errorf("--buffer-size must be greater than zero")
+	}
+	if bufferSize > syncer.MaxBufferSize {
+		return fmt.Errorf("--buffer-size must be at most %s (each parallel copy holds a buffer of this size)", formatByteSize(syncer.MaxBufferSize))
+	}
+	if tempDir != "" {
+		for _, targetPath := range targetPaths {
+			if tempDir, err = checkTempDir(tempDir, targetPath); err != nil {
+				return err
 			}
+		}
+	}
+	if partialDir != "" {
+		partial = true
+		if partialDir != filepath.Base(partialDir) || partialDir == "." || partialDir == ".." {
+			return fmt.Errorf("--partial-dir must be a directory name, not a path: %s", partialDir)
+		}
+	} else if partial {
+		partialDir = syncer.DefaultPartialDir
+	}
+	if partial && tempDir != "" {
+		return fmt.Errorf("--partial and --temp-dir cannot be combined")
+	}
+	if stageAndSwap && (partial || appendResume) {
+		return fmt.Errorf("--stage-and-swap cannot be combined with --partial or --append-resume, which write into existing target files")
+	}
+	if stageAndSwap && (dedupeTarget || selinux || fileCaps || fakeSuper || fromFakeSuper) {
+		return fmt.Errorf("--stage-and-swap cannot be combined with --dedupe-target, --selinux, --caps, --fake-super or --from-fake-super, which change files of the target after the swap, through inodes the staged clone shares with the previous tree")
+	}
+	if stageAndSwap && requireMounted {
+		return fmt.Errorf("--stage-and-swap cannot be combined with --require-mounted: staging needs the target on the filesystem of its parent directory, so it can't be a mount point")
+	}
+	if err := checkSecurityFlags(); err != nil {
+		return err
+	}
+	if fakeSuper && fromFakeSuper {
+		return fmt.Errorf("--fake-super and --from-fake-super cannot be combined")
+	}
+	if (fakeSuper || fromFakeSuper) && runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		return fmt.Errorf("--fake-super and --from-fake-super are only supported on Linux and macOS")
+	}
+	if seedDir != "" {
+		if seedDir, err = filepath.Abs(seedDir); err != nil {
+			return fmt.Errorf("invalid seed directory: %w", err)
+		}
+		if info, err := os.Stat(seedDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("--seed-dir %s is not a directory", seedDir)
+		}
+	}
+	if skipHotDBs && allowHotDBs {
+		return fmt.Errorf("--skip-hot-databases and --allow-hot-databases cannot be combined")
+	}
+	for _, source := range sourcePaths {
+		if manifest.IsURL(source) && (len(sources) > 1 || len(subtreeMaps) > 0 || casMode || gitignore) {
+			return fmt.Errorf("a published source (URL) cannot be combined with merged sources, --map, --cas or --respect-gitignore")
+		}
+	}
+	var manifestKey ed25519.PublicKey
+	if manifestPubKey != "" {
+		if len(sourcePaths) != 1 || !manifest.IsURL(sourcePaths[0]) {
+			return fmt.Errorf("--manifest-key applies to a published source (URL) only")
+		}
+		if manifestKey, err = manifest.ParsePublicKey(manifestPubKey); err != nil {
+			return fmt.Errorf("invalid --manifest-key: %w", err)
+		}
+	}
+	transport := manifest.Transport{Proxy: proxyURL, CACert: caCert, ClientCert: clientCert, ClientKey: clientKey}
+	if transport != (manifest.Transport{}) && (len(sourcePaths) != 1 || !manifest.IsURL(sourcePaths[0])) {
+		return fmt.Errorf("--proxy, --ca-cert, --client-cert and --client-key apply to a published source (URL) only")
+	}
+	if clientKey != "" && clientCert == "" {
+		return fmt.Errorf("--client-key requires --client-cert")
+	}
+	if minDepth < 0 || maxDepth < 0 {
+		return fmt.Errorf("--min-depth and --max-depth must not be negative")
+	}
+	if maxDepth > 0 && minDepth > maxDepth {
+		return fmt.Errorf("--min-depth %d is deeper than --max-depth %d", minDepth, maxDepth)
+	}
+	typeFilter, err := ignore.NewTypeFilter(includeTypes, excludeTypes, sniffTypes)
+	if err != nil {
+		return err
+	}
+	if typeFilter != nil && casMode {
+		return fmt.Errorf("--include-type and --exclude-type cannot be combined with --cas")
+	}
+	if casMode && (len(sources) > 1 || len(mergeInto) > 0 || len(subtreeMaps) > 0 || dedupeTarget) {
+		return fmt.Errorf("--cas cannot be combined with merged sources, --map or --dedupe-target")
+	}
+	planView, err := syncer.ParsePlanView(planFilter, planSort, planLimit)
+	if err != nil {
+		return err
+	}
+	if workers < 0 || workers > syncer.MaxWorkers {
+		return fmt.Errorf("--workers must be between 0 and %d", syncer.MaxWorkers)
+	}
+	quirks, err := syncer.ParseFSQuirks(fsQuirks)
+	if err != nil {
+		return err
+	}
+	if summaryFormat != "" {
+		if err := summary.CheckFormat(summaryFormat); err != nil {
+			return err
+		}
+	}
+	hashCPU, err := parseHashCPULimit()
+	if err != nil {
+		return err
+	}
+	var spotCheckShare float64
+	if spotCheck != "" {
+		if spotCheckShare, err = syncer.ParseSpotCheck(spotCheck); err != nil {
+			return err
+		}
+	}
+	churnShare, err := syncer.ParseChurnWarning(churnWarning)
+	if err != nil {
+		return err
+	}
+	massChange, err := syncer.ParseMassChangeGuard(massChangeGuard)
+	if err != nil {
+		return err
+	}
+	if ioPriority != "" {
+		priority, err := syncer.ParseIOPriority(ioPriority)
+		if err != nil {
+			return err
+		}
+		if err := syncer.SetIOPriority(priority); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not set the I/O priority: %v\n", err)
+		}
+	}
 
-			fmt.Printf("Source: %s\n", sourcePath)
-			fmt.Printf("Target: %s\n", targetPath)
-			if len(excludePatterns) > 0 {
-				fmt.Println("CLI Exclusions:", excludePatterns)
+	if imagePath != "" {
+		img, mountErr := mountImage(targetPath, sourcePaths)
+		if mountErr != nil {
+			return mountErr
+		}
+		defer func() {
+			if unmountErr := img.Unmount(); unmountErr != nil {
+				if err == nil {
+					err = unmountErr
+				} else {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", unmountErr)
+				}
+				return
 			}
-			if dryRun {
-				fmt.Println("--- DRY RUN MODE ---")
+			fmt.Fprintln(os.Stderr, i18n.T("image.unmounted", img.Path))
+		}()
+	} else if imageSize > 0 {
+		return fmt.Errorf("--image-size requires --image")
+	}
+
+	// Create Syncer instance
+	sync := syncer.NewSyncer(sourcePaths[0], targetPath, excludes, dryRun || assertInSync)
+	sync.Targets = targetPaths[1:]
+	sync.Gitignore = gitignore
+	sync.Logger = consoleLogger()
+	if traceFile != "" {
+		logger, trace, err := withTraceFile(sync.Logger, traceFile)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = trace.Close()
+		}()
+		sync.Logger = logger
+	}
+	sync.Observer = newProgressObserver()
+	var recorder *actionRecorder
+	if planExport != "" || reportExport != "" {
+		recorder = newActionRecorder(newProgressObserver())
+		sync.Observer = recorder
+	}
+	sync.BufferSize = int(bufferSize)
+	sync.TempDir = tempDir
+	sync.PartialDir = partialDir
+	sync.AppendResume = appendResume
+	sync.StageAndSwap = stageAndSwap
+	sync.SpotCheck = spotCheckShare
+	sync.TargetQuota = int64(targetQuota)
+	sync.SeedDir = seedDir
+	sync.ManifestKey = manifestKey
+	sync.Transport = transport
+	sync.SameDiskWorkers = sameDiskWorkers
+	sync.Workers = workers
+	sync.SerializeDirs = serializeDirs
+	sync.BandwidthLimit = int64(bwLimit)
+	sync.Incremental = incremental
+	sync.MinDepth = minDepth
+	sync.MaxDepth = maxDepth
+	sync.Follow = follow
+	sync.Quirks = quirks
+	sync.SanitizeNames = sanitizeNames
+	sync.HashWorkers = hashWorkers
+	sync.HashCPU = hashCPU
+	sync.AssumeYes = assumeYes
+	sync.AssertInSync = assertInSync
+	sync.ConfirmDeletes = confirmDeletes
+	sync.ConfirmChanges = confirmChanges
+	sync.ChurnWarning = churnShare
+	sync.MassChange = massChange
+	sync.DeleteToTrash = deleteToTrash
+	sync.AlwaysHash = alwaysHash
+	sync.TypeFilter = typeFilter
+	sync.SkipHotDBs = skipHotDBs
+	sync.AllowHotDBs = allowHotDBs
+	sync.CheckOpenFiles = checkOpenFiles
+	sync.SkipOpenFiles = skipOpenFiles
+	sync.XattrMarkers = xattrMarkers
+	sync.SELinux = selinux
+	sync.Caps = fileCaps
+	sync.FakeSuper = fakeSuper
+	sync.FromFakeSuper = fromFakeSuper
+	sync.DedupeTarget = dedupeTarget
+	sync.TimesDirs = timesDirs
+	sync.CAS = casMode
+	sync.RequireMounted = requireMounted
+	sync.RequireFile = requireFile
+	sync.MinSourceFiles = minSourceFiles
+	sync.Force = force
+	sync.Explain = explainPaths
+	sync.MemLimit = int64(memLimit)
+	sync.PlanView = planView
+	if len(sources) > 1 || len(mergeInto) > 0 {
+		if err := configureMerge(sync, sourcePaths); err != nil {
+			return err
+		}
+	}
+	if err := configureTransforms(sync); err != nil {
+		return err
+	}
+	for _, rule := range subtreeMaps {
+		if len(sync.MergeSources) > 0 {
+			return fmt.Errorf("--map cannot be combined with merged sources")
+		}
+		mapping, err := syncer.ParseSubtreeMapping(rule)
+		if err != nil {
+			return err
+		}
+		_, mappedTarget, err := resolvePair(sourcePaths[0], mapping.Target)
+		if err != nil {
+			return fmt.Errorf("mapping %s: %w", mapping.Subtree, err)
+		}
+		// The main sync would delete a mapped target inside its own, and the
+		// mapping copy it back, on every run
+		for _, other := range append([]string{sync.TargetRoot}, mappedTargets(sync.Mappings)...) {
+			if nested(mappedTarget, other) || nested(other, mappedTarget) {
+				return fmt.Errorf("mapping %s: the targets of mappings can't be, contain or lie inside one another or the main target", mapping.Subtree)
 			}
+		}
+		mapping.Target = mappedTarget
+		sync.Mappings = append(sync.Mappings, mapping)
+	}
+	if err := configureTiers(sync, sourcePaths[0]); err != nil {
+		return err
+	}
 
-			// Create Syncer instance
-			sync := syncer.NewSyncer(sourcePath, targetPath, excludePatterns, dryRun)
+	notifiers, err := profileNotifiers()
+	if err != nil {
+		return err
+	}
+	sink, err := openLogSink()
+	if err != nil {
+		return err
+	}
+	if sink != nil {
+		defer func() {
+			_ = sink.Close()
+		}()
+	}
 
-			// Run the synchronization process
-			err = sync.Run()
-			if err != nil {
-				return fmt.Errorf("sync failed: %w", err) // Wrap error for context
-			}
+	if memLimit > 0 {
+		debug.SetMemoryLimit(int64(memLimit))
+	}
+	if pprofAddr != "" {
+		stop, err := startPprof(pprofAddr)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
 
-			fmt.Println("\nSync completed successfully.")
-			if dryRun {
-				fmt.Println("(Dry run - no changes were actually made)")
-			}
-			return nil // Return nil for successful execution
-		},
+	if server := startControl(sync, sourcePaths, targetPath); server != nil {
+		defer func() {
+			_ = server.Close()
+		}()
 	}
-)
+
+	defer handleSignals(sync)()
+	defer startHeartbeat(sync, sink, targetPath)()
+
+	// Run the synchronization process
+	logRunStart(sink, sourcePaths, targetPath)
+	err = sync.Run()
+	result := sync.Summary()
+	result.Profile = profileName
+	logRunEnd(sink, result)
+	if reportExport != "" {
+		recorder.writeReport()
+	}
+	if summaryFormat != "" {
+		printSummary(result)
+	}
+	if metricsFile != "" {
+		writeMetrics(result)
+	}
+	sendNotifications(notifiers, result)
+	if errors.Is(err, syncer.ErrNotInSync) {
+		return err // The differences are listed above
+	}
+	if err != nil {
+		return i18n.Errorf("sync.failed", err) // Wrap error for context
+	}
+	if assertInSync {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "\n"+theme.PaintErr(theme.Success, i18n.T("sync.completed")))
+	if dryRun {
+		fmt.Fprintln(os.Stderr, i18n.T("sync.dry_run_note"))
+	}
+	return nil // Return nil for successful execution
+}
+
+// hashCPULimitUsage describes --hash-cpu-limit for every command that has it.
+const hashCPULimitUsage = "Hash at most this many files in parallel, optionally at a lower CPU priority, e.g. 2 or 2,nice=10 (nice level on Linux only); copy workers are not affected"
+
+// parseHashCPULimit parses --hash-cpu-limit, shared by the commands that
+// hash files.
+func parseHashCPULimit() (syncer.HashCPULimit, error) {
+	if hashCPULimit == "" {
+		return syncer.HashCPULimit{}, nil
+	}
+	limit, err := syncer.ParseHashCPULimit(hashCPULimit)
+	if err != nil {
+		return limit, err
+	}
+	if limit.Nice != 0 && runtime.GOOS != "linux" {
+		fmt.Fprintln(os.Stderr, "Warning: The nice level of --hash-cpu-limit is only applied on Linux")
+	}
+	return limit, nil
+}
+
+// checkSecurityFlags rejects --selinux and --caps where the attributes don't
+// exist.
+func checkSecurityFlags() error {
+	if (selinux || fileCaps) && !seclabel.Supported {
+		return fmt.Errorf("--selinux and --caps are only supported on Linux")
+	}
+	return nil
+}
+
+// notInSync passes on the error of a sync, keeping cobra from printing the
+// usage below the differences --assert-in-sync found.
+func notInSync(cmd *cobra.Command, err error) error {
+	if errors.Is(err, syncer.ErrNotInSync) {
+		cmd.SilenceUsage = true
+	}
+	return err
+}
+
+// checkTempDir returns the absolute path of a --temp-dir, which must not lie
+// inside the target: the plan would delete it as not in the source.
+func checkTempDir(dir, targetPath string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("invalid temp directory '%s': %w", dir, err)
+	}
+	if rel, err := filepath.Rel(targetPath, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("--temp-dir %s must not be inside the target", dir)
+	}
+	return dir, nil
+}
+
+// cliExcludes returns the default ignores (unless --no-default-ignores),
+// the --exclude patterns and those of the --preset presets. The defaults come
+// first so that a later !pattern can re-include one of them.
+func cliExcludes() ([]string, error) {
+	patterns, err := ignore.PresetPatterns(presetNames)
+	if err != nil {
+		return nil, err
+	}
+	var excludes []string
+	if !noDefaults {
+		excludes = ignore.Defaults()
+	}
+	return append(append(excludes, excludePatterns...), patterns...), nil
+}
+
+// printSummary prints the final report in the --summary-format format.
+func printSummary(s *summary.Summary) {
+	text, err := s.Format(summaryFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not format summary: %v\n", err)
+		return
+	}
+	fmt.Print("\n" + text)
+}
+
+// resolvePair makes source and target absolute and validates them: the source
+// must be an existing directory, the target must be a directory if it exists,
+// and the target can't be the source or live inside it.
+func resolvePair(source, target string) (string, string, error) {
+	if manifest.IsURL(source) {
+		// A published tree (see the manifest command) is checked when its
+		// manifest is fetched
+		targetPath, err := filepath.Abs(target)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid target path '%s': %w", target, err)
+		}
+		if info, err := os.Stat(targetPath); err == nil && !info.IsDir() {
+			return "", "", fmt.Errorf("target path '%s' exists but is not a directory", targetPath)
+		}
+		return source, targetPath, nil
+	}
+
+	sourcePath, err := filepath.Abs(source)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid source path '%s': %w", source, err)
+	}
+	targetPath, err := filepath.Abs(target)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid target path '%s': %w", target, err)
+	}
+
+	// Basic validation: source must exist and be a directory
+	sourceInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", fmt.Errorf("source path '%s' does not exist", sourcePath)
+		}
+		return "", "", fmt.Errorf("could not stat source path '%s': %w", sourcePath, err)
+	}
+	if !sourceInfo.IsDir() {
+		return "", "", fmt.Errorf("source path '%s' is not a directory", sourcePath)
+	}
+
+	// Target validation: if it exists, must be a directory
+	targetInfo, err := os.Stat(targetPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", "", fmt.Errorf("could not stat target path '%s': %w", targetPath, err)
+		}
+		// Target doesn't exist, which is fine, it will be created
+	} else if !targetInfo.IsDir() {
+		return "", "", fmt.Errorf("target path '%s' exists but is not a directory", targetPath)
+	}
+
+	// Prevent syncing a directory to itself or a subdirectory of itself
+	if sourcePath == targetPath {
+		return "", "", fmt.Errorf("source and target paths cannot be the same")
+	}
+	rel, err := filepath.Rel(sourcePath, targetPath)
+	if err == nil && !filepath.IsAbs(rel) && len(rel) > 0 && rel[0] != '.' {
+		return "", "", fmt.Errorf("target path '%s' cannot be inside the source path '%s'", targetPath, sourcePath)
+	}
+
+	return sourcePath, targetPath, nil
+}
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
@@ -104,6 +662,93 @@ func Execute() error {
 
 func init() {
 	// Define flags
-	rootCmd.Flags().StringSliceVarP(&excludePatterns, "exclude", "e", []string{}, "Patterns to exclude (can be specified multiple times)")
-	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without actually performing any actions")
+	rootCmd.PersistentFlags().StringSliceVarP(&excludePatterns, "exclude", "e", []string{}, "Patterns to exclude (can be specified multiple times)")
+	rootCmd.PersistentFlags().BoolVar(&noDefaults, "no-default-ignores", false, "Don't exclude temporary and metadata files by default ("+strings.Join(ignore.Defaults(), ", ")+")")
+	rootCmd.PersistentFlags().StringSliceVar(&presetNames, "preset", nil, "Exclude common VCS, dependency and build directories: "+strings.Join(ignore.Presets(), ", ")+" (comma-separated)")
+	rootCmd.PersistentFlags().BoolVar(&gitignore, "respect-gitignore", false, "Also exclude what .gitignore files in the source (root and nested) exclude")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default is config.json in the user config directory, e.g. ~/.config/sync-dir/)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Load options, source and target from this config profile")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Never color the output (also set by a non-empty NO_COLOR variable)")
+	rootCmd.PersistentFlags().BoolVar(&debugLog, "debug", false, "Also print debug records, such as the planner's decision on every path and the sizes, mtimes and checksums it was based on")
+	rootCmd.PersistentFlags().BoolVar(&plainProgress, "plain-progress", false, "Report progress as a plain text line every 10 seconds instead of redrawn bars and spinners (for screen readers and dumb terminals; automatic with TERM=dumb)")
+	rootCmd.PersistentFlags().StringVar(&language, "lang", "", "Language of messages: "+strings.Join(i18n.Languages(), ", ")+" (default from LC_ALL, LC_MESSAGES or LANG)")
+	addSyncFlags(rootCmd)
+}
+
+// addSyncFlags defines the flags controlling a sync run. They are shared by the
+// root command and the sync subcommand.
+func addSyncFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be done without actually performing any actions")
+	cmd.Flags().StringArrayVar(&explainPaths, "explain", nil, "Explain why the plan does what it does with this path, relative to the source and target (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&planFilter, "plan-filter", nil, "List only these actions with the plan: add, update, delete, rename (comma-separated)")
+	cmd.Flags().StringVar(&planSort, "plan-sort", "action", "Order of the actions listed with the plan: action, path, size (largest first) or type (file extension)")
+	cmd.Flags().IntVar(&planLimit, "plan-limit", syncer.DefaultPlanLimit, "List at most this many actions with the plan (0 = all)")
+	cmd.Flags().StringVar(&planExport, "plan-export", "", "Write the plan to this file before it is confirmed, one action per row: CSV, or TSV if the name ends in .tsv")
+	cmd.Flags().StringVar(&reportExport, "report-export", "", "Write every planned action with its result, duration and error to this file after the run: CSV, or TSV if the name ends in .tsv")
+	cmd.Flags().StringArrayVar(&targetFlags, "target", nil, "Sync to this target, sharing one scan of the source and its checksums with the other targets (can be specified multiple times; all arguments are then sources)")
+	cmd.Flags().BoolVarP(&assumeYes, "yes", "y", false, "Proceed without asking for confirmation (plans over a --confirm-if-* threshold still ask)")
+	cmd.Flags().BoolVar(&assertInSync, "assert-in-sync", false, "Change nothing; list every difference and exit with an error if the target is not in sync with the source")
+	cmd.Flags().IntVar(&confirmDeletes, "confirm-if-deletes", -1, "Proceed automatically unless the plan deletes more than N items")
+	cmd.Flags().IntVar(&confirmChanges, "confirm-if-changes", -1, "Proceed automatically unless the plan has more than N actions")
+	cmd.Flags().StringVar(&churnWarning, "churn-warning", "25%", "Flag plans changing more than this share of the target's files as unusual (0 = never); unusual plans are confirmed even under a --confirm-if-* threshold")
+	cmd.Flags().StringVar(&massChangeGuard, "mass-change-guard", "abort", "What to do when the plan replaces many target files by files under a new extension, as ransomware encrypting the source would: abort, warn or off")
+	cmd.Flags().BoolVar(&deleteToTrash, "delete-to-trash", false, "Move items deleted from the target to the OS trash (Recycle Bin on Windows) instead of removing them")
+	cmd.Flags().BoolVar(&dedupeTarget, "dedupe-target", false, "After syncing, replace identical files within the target by hard links and report the space reclaimed")
+	cmd.Flags().BoolVar(&timesDirs, "times-dirs", false, "After syncing, give the target's directories the modification times of the source's (only those that differ are changed)")
+	cmd.Flags().BoolVar(&requireMounted, "require-mounted", false, "Refuse to run unless the target is a mount point, so an unmounted backup drive isn't filled in on the filesystem underneath")
+	cmd.Flags().StringVar(&requireFile, "require-file", "", "Refuse to run unless this marker file exists in the target, e.g. .backup-volume")
+	cmd.Flags().IntVar(&minSourceFiles, "min-source-files", 1, "Refuse to delete from the target if the source holds fewer files than this (catches an unmounted or wrong source)")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Delete from the target even if the source holds fewer files than --min-source-files")
+	cmd.Flags().BoolVar(&casMode, "cas", false, "Back up into a content-addressed store at the target: each file content stored once under objects/, plus a snapshot per run")
+	cmd.Flags().StringVar(&imagePath, "image", "", "Mount this disk image file on the target for the run (created with --image-size if missing; Linux needs root, macOS uses hdiutil)")
+	cmd.Flags().Var(&imageSize, "image-size", "Size of the --image to create if it doesn't exist (e.g. 100G)")
+	cmd.Flags().Var(&bufferSize, "buffer-size", "Copy buffer size per file operation (e.g. 256K, 4M)")
+	cmd.Flags().StringVar(&seedDir, "seed-dir", "", "Take files from this local directory instead of the source when it holds them (same path, size and mtime, or same checksum as known from earlier runs); hard linked on the target's filesystem, copied otherwise")
+	cmd.Flags().StringVar(&manifestPubKey, "manifest-key", "", "Require a published (URL) source's manifest to be signed with this public key, given as text or as the .pub file written by \"sync-dir serve --sign-key\"")
+	cmd.Flags().StringVar(&proxyURL, "proxy", "", "Fetch a published (URL) source through this proxy: http://, https:// or socks5://host:port, with user:password@ if it requires them (default: HTTPS_PROXY/HTTP_PROXY/NO_PROXY from the environment)")
+	cmd.Flags().StringVar(&caCert, "ca-cert", "", "Also trust the CA certificates in this PEM file for a published (URL) source served over HTTPS, e.g. a corporate CA")
+	cmd.Flags().StringVar(&clientCert, "client-cert", "", "Present the certificate in this PEM file to a published (URL) source that requires client certificates")
+	cmd.Flags().StringVar(&clientKey, "client-key", "", "Private key of --client-cert, as a PEM file (default: read from the --client-cert file)")
+	cmd.Flags().Var(&targetQuota, "target-quota", "Soft limit on the total size of the target's files, e.g. 200G: plans that would exceed it are refused and copies that would cross it fail (0 = none)")
+	cmd.Flags().StringVar(&tempDir, "temp-dir", "", "Write temp files here instead of next to their destination, e.g. when the target is short of inodes or quota (renamed into place on the target's filesystem, copied into place otherwise)")
+	cmd.Flags().BoolVar(&partial, "partial", false, "Keep the data of copies that fail midway in a "+syncer.DefaultPartialDir+" directory next to the file, and resume them by appending once the data there is verified to be the start of the source file")
+	cmd.Flags().StringVar(&partialDir, "partial-dir", "", "Name of the directories --partial keeps partial data in (implies --partial; default "+syncer.DefaultPartialDir+")")
+	cmd.Flags().BoolVar(&appendResume, "append-resume", false, "Complete target files that are shorter than their source and hold its start (verified by hashing that range of both) by appending the rest in place instead of copying them whole")
+	cmd.Flags().StringVar(&spotCheck, "spot-check", "", "After syncing, verify this share of the copied files, picked at random, by hashing source and copy, e.g. 1% (cheaper than hashing every copy)")
+	cmd.Flags().BoolVar(&stageAndSwap, "stage-and-swap", false, "Apply the changes to a hard-linked clone of the target next to it and swap the clone into place once all succeeded, so readers never see a half-synced target (atomic on Linux)")
+	cmd.Flags().IntVar(&maxDepth, "max-depth", 0, "Only sync entries down to this depth, 1 being the entries at the top of source and target: directories at the limit are synced, their contents neither copied nor deleted (0 = no limit)")
+	cmd.Flags().IntVar(&minDepth, "min-depth", 0, "Only sync entries at this depth or deeper, 1 being the entries at the top of source and target: shallower files are neither copied nor deleted, shallower directories are only created as needed (0 = all)")
+	cmd.Flags().StringArrayVar(&follow, "follow", nil, "Sync source symlinks matching this pattern that point to directories as those directories, e.g. 'data' (can be specified multiple times; other symlinks are left as they are)")
+	cmd.Flags().BoolVar(&incremental, "incremental", false, "Reuse the previous scan for directories whose mtime is unchanged (faster on slow mounts; in-place edits in otherwise unchanged directories are missed)")
+	cmd.Flags().StringVar(&fsQuirks, "fs-quirks", "", "Work around target filesystem limitations: smb (2s mtime tolerance, no chmod, ignore chtimes failures, retry EBUSY)")
+	cmd.Flags().BoolVar(&sanitizeNames, "sanitize-names", false, "On FAT, exFAT, NTFS and SMB targets, sync source paths with names they can't hold (invalid characters, reserved names like CON, overlong names) under valid ones")
+	cmd.Flags().StringSliceVar(&includeTypes, "include-type", nil, "Only sync source files of these content types, e.g. 'image/*' or 'application/pdf' (can be specified multiple times)")
+	cmd.Flags().StringSliceVar(&excludeTypes, "exclude-type", nil, "Leave out source files of these content types, e.g. 'video/*' (can be specified multiple times); types come from file extensions")
+	cmd.Flags().BoolVar(&sniffTypes, "sniff-types", false, "Read the first bytes of files whose extension doesn't tell their content type, for --include-type/--exclude-type")
+	cmd.Flags().StringSliceVar(&alwaysHash, "always-hash", nil, "Compare files matching these patterns by checksum even when size and mtime match, e.g. '*.db' (can be specified multiple times)")
+	cmd.Flags().BoolVar(&skipHotDBs, "skip-hot-databases", false, "Don't copy files that look like databases in use (SQLite with -wal/-shm, locked Access files, InnoDB files)")
+	cmd.Flags().BoolVar(&allowHotDBs, "allow-hot-databases", false, "Copy files that look like databases in use without warning")
+	cmd.Flags().BoolVar(&checkOpenFiles, "check-open-files", false, "Before copying, warn about source files other processes have open for writing, e.g. active logs (Linux)")
+	cmd.Flags().BoolVar(&skipOpenFiles, "skip-open-files", false, "Don't copy source files other processes have open for writing; their target copies are left as they are (Linux)")
+	cmd.Flags().BoolVar(&xattrMarkers, "xattr-markers", false, "Tag copied files with user.syncdir.hash and user.syncdir.src_mtime xattrs, reused by later comparisons and scrub (Linux, macOS)")
+	cmd.Flags().BoolVar(&selinux, "selinux", false, "Give target items the SELinux security contexts of their source items, e.g. when mirroring system images (Linux; relabeling needs the policy's permission)")
+	cmd.Flags().BoolVar(&fileCaps, "caps", false, "Give target files the file capabilities of their source files and remove those the source lacks (Linux; needs CAP_SETFCAP)")
+	cmd.Flags().BoolVar(&fakeSuper, "fake-super", false, "For unprivileged syncs: record each item's owner, group, permissions and device number in a user.syncdir.stat xattr of its copy, and store device files, FIFOs and sockets as empty placeholder files (Linux, macOS)")
+	cmd.Flags().BoolVar(&fromFakeSuper, "from-fake-super", false, "Restore a target written with --fake-super: recreate the special files and give every item the owner, group and permissions recorded on it (needs root; Linux, macOS)")
+	cmd.Flags().IntVar(&hashWorkers, "hash-workers", 0, "Files hashed in parallel while comparing source and target (0 = one per CPU)")
+	cmd.Flags().StringVar(&hashCPULimit, "hash-cpu-limit", "", hashCPULimitUsage)
+	cmd.Flags().StringVar(&pprofAddr, "pprof", "", "Serve the runtime profiles of net/http/pprof on this address during the run, e.g. localhost:6060, to diagnose CPU and memory use")
+	cmd.Flags().StringVar(&traceFile, "trace-file", "", "Write a JSON line to this file for every path the planner decides on, with the sizes, mtimes and checksums it compared and the decision, plus all other log records")
+	cmd.Flags().Var(&memLimit, "mem-limit", "Soft cap on memory, e.g. 2G: the garbage collector works harder near it, and the checksum cache is moved to disk at 75% of it (0 = none)")
+	cmd.Flags().BoolVar(&controlEnabled, "control", false, "Serve a control socket during the run, for sync-dir ctl and GUI front-ends")
+	cmd.Flags().DurationVar(&heartbeatInterval, "heartbeat", 5*time.Minute, "When output goes to a log rather than a terminal, report progress and rate this often (0 = never)")
+	cmd.Flags().StringVar(&logSink, "log-sink", "", "Also report each run to the system log: syslog or journald (with structured job/result fields)")
+	cmd.Flags().StringVar(&summaryFormat, "summary-format", "", "Print a final report as plain, markdown or html (e.g. for mailing it from a hook)")
+	cmd.Flags().StringVar(&metricsFile, "metrics-file", "", "Write the outcome of the run and the count, time and data of its list, stat, read, write, copy and delete operations to this file as Prometheus metrics (e.g. for the node exporter's textfile collector)")
+	cmd.Flags().StringArrayVar(&subtreeMaps, "map", nil, "Sync a source subtree to its own target, as SUBTREE=TARGET (can be specified multiple times)")
+	cmd.Flags().IntVar(&workers, "workers", 0, "Parallel file operations (0 = automatic; can be changed while running, see ctl set)")
+	cmd.Flags().Var(&bwLimit, "bwlimit", "Limit copy bandwidth per second, e.g. 10M (0 = unlimited; can be changed while running)")
+	cmd.Flags().StringVar(&ioPriority, "io-priority", "", "Disk I/O priority of the sync: idle, low or normal (Linux ionice classes; on macOS idle and low both mean background)")
+	cmd.Flags().BoolVar(&serializeDirs, "serialize-dirs", false, "Change one item at a time in each target directory, for filesystems that misbehave when a directory is changed concurrently (other directories still proceed in parallel)")
+	cmd.Flags().IntVar(&sameDiskWorkers, "same-disk-workers", 0, "Parallel operations when source and target are on the same device (0 = automatic: 1 for HDDs, 2 otherwise)")
 }
@@ -0,0 +1,102 @@
+// cmd/scan.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/inventory"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scanFormat string // Format of the inventory: json, jsonl or csv
+	scanOutput string // File the inventory is written to ("" = stdout)
+	scanHash   bool   // Include the SHA256 of every file
+)
+
+// scanCmd lists a directory the way a sync would see it.
+var scanCmd = &cobra.Command{
+	Use:   "scan <dir>",
+	Short: "List the files a sync of a directory would see.",
+	Long: `Scans a directory with the same ignore rules as a sync of it (.sync-ignore,
+--exclude, --preset, --respect-gitignore) and writes the inventory of what is
+left: every file, directory and symlink with its type, size, permissions and
+mtime, and with --hash its SHA256 checksum. Nothing is compared or changed.
+
+Formats: json (one document with the root, the scan time and all entries),
+jsonl (one entry per line, for streaming tools) and csv.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := inventory.CheckFormat(scanFormat); err != nil {
+			return err
+		}
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid directory '%s': %w", args[0], err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", root)
+		}
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+
+		if err := checkSecurityFlags(); err != nil {
+			return err
+		}
+		hashCPU, err := parseHashCPULimit()
+		if err != nil {
+			return err
+		}
+
+		sync := syncer.NewSyncer(root, "", excludes, true)
+		sync.Gitignore = gitignore
+		sync.HashCPU = hashCPU
+		sync.SELinux = selinux
+		sync.Caps = fileCaps
+		sync.Logger = consoleLogger()
+		sync.Observer = newProgressObserver()
+		start := time.Now()
+		files, err := sync.Inventory(scanHash)
+		if err != nil {
+			return fmt.Errorf("scan failed: %w", err)
+		}
+		inv := &inventory.Inventory{Version: inventory.Version, Root: root, Scanned: start.UTC(), Entries: make([]inventory.Entry, len(files))}
+		for i, fi := range files {
+			inv.Entries[i] = inventory.NewEntry(fi)
+		}
+
+		if scanOutput == "" {
+			return inventory.Write(os.Stdout, scanFormat, inv)
+		}
+		file, err := os.Create(scanOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", scanOutput, err)
+		}
+		if err := inventory.Write(file, scanFormat, inv); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %w", scanOutput, err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", scanOutput, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d entries).\n", scanOutput, len(inv.Entries))
+		return nil
+	},
+}
+
+func init() {
+	scanCmd.Flags().StringVarP(&scanFormat, "format", "f", "json", "Format of the inventory: "+strings.Join(inventory.Formats, ", "))
+	scanCmd.Flags().StringVarP(&scanOutput, "output", "o", "", "Write the inventory to this file instead of stdout")
+	scanCmd.Flags().BoolVar(&scanHash, "hash", false, "Include the SHA256 checksum of every file (reads all of them)")
+	scanCmd.Flags().BoolVar(&selinux, "selinux", false, "Include the SELinux security context of every entry (Linux)")
+	scanCmd.Flags().BoolVar(&fileCaps, "caps", false, "Include the capabilities of files that have any, as getcap prints them (Linux)")
+	scanCmd.Flags().StringVar(&hashCPULimit, "hash-cpu-limit", "", hashCPULimitUsage)
+	rootCmd.AddCommand(scanCmd)
+}
@@ -0,0 +1,103 @@
+// cmd/scrub.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+var scrubReportPath string // Where to write the damaged-files report
+
+// scrubCmd verifies a target's content against known checksums (bitrot scan).
+var scrubCmd = &cobra.Command{
+	Use:   "scrub <target>",
+	Short: "Verify target files against recorded checksums to detect silent corruption.",
+	Long: `Hashes every file in the target and compares it with the checksums recorded
+for that file at the same size and modification time, both by earlier scrubs
+and by syncs into this target. A file whose content changed while its size and
+mtime stayed the same is reported as damaged (bitrot). The source is not needed.
+
+Files without a recorded checksum are baselined on the first scrub and verified
+by later ones. Exits with an error if damaged files were found.`,
+	Args:         cobra.ExactArgs(1),
+	SilenceUsage: true, // A damaged-files result isn't a usage error
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetPath, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid target path '%s': %w", args[0], err)
+		}
+		info, err := os.Stat(targetPath)
+		if err != nil {
+			return fmt.Errorf("could not stat target path '%s': %w", targetPath, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("target path '%s' is not a directory", targetPath)
+		}
+
+		fmt.Fprintln(os.Stderr, i18n.T("sync.target", targetPath))
+		hashCPU, err := parseHashCPULimit()
+		if err != nil {
+			return err
+		}
+		report, err := syncer.Scrub(targetPath, hashCPU, consoleLogger())
+		if err != nil {
+			return fmt.Errorf("scrub failed: %w", err)
+		}
+
+		fmt.Println("\n" + i18n.T("scrub.checked", report.Checked, report.Verified, report.Baselined, len(report.Damaged)))
+		if len(report.Modified) > 0 {
+			fmt.Println("\n" + i18n.T("scrub.modified", len(report.Modified)))
+			printSample("~", report.Modified)
+		}
+		if len(report.Missing) > 0 {
+			fmt.Println("\n" + i18n.T("scrub.missing", len(report.Missing)))
+			printSample("-", report.Missing)
+		}
+		if len(report.Errors) > 0 {
+			fmt.Println("\n" + i18n.T("scrub.unreadable", len(report.Errors)))
+			printSample("!", report.Errors)
+		}
+
+		if scrubReportPath != "" {
+			if err := writeScrubReport(scrubReportPath, targetPath, report); err != nil {
+				return fmt.Errorf("failed to write report: %w", err)
+			}
+			fmt.Println("\n" + i18n.T("scrub.report_written", scrubReportPath))
+		}
+
+		if len(report.Damaged) == 0 {
+			fmt.Println("\n" + theme.Paint(theme.Success, i18n.T("scrub.no_damage")))
+			return nil
+		}
+		fmt.Println("\n" + theme.Paint(theme.Failure, i18n.T("scrub.damaged")))
+		labels := i18n.Pad("scrub.expected", "scrub.actual")
+		for _, d := range report.Damaged {
+			fmt.Printf("  %s (%s)\n    %s %s\n    %s %s\n", d.RelPath, i18n.N("scrub.bytes", int(d.Size), d.Size), labels[0], d.Expected, labels[1], d.Actual)
+		}
+		return i18n.Errorf("scrub.damaged_error", len(report.Damaged), targetPath)
+	},
+}
+
+// writeScrubReport writes the damaged files as tab-separated lines:
+// path, size, expected checksum, actual checksum.
+func writeScrubReport(path, targetPath string, report *syncer.ScrubReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# sync-dir scrub of %s: %d checked, %d damaged\n", targetPath, report.Checked, len(report.Damaged))
+	for _, d := range report.Damaged {
+		fmt.Fprintf(&b, "%s\t%d\t%s\t%s\n", d.RelPath, d.Size, d.Expected, d.Actual)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+func init() {
+	scrubCmd.Flags().StringVar(&hashCPULimit, "hash-cpu-limit", "", hashCPULimitUsage)
+	scrubCmd.Flags().StringVar(&scrubReportPath, "report", "", "Also write the damaged-files report to this file")
+	rootCmd.AddCommand(scrubCmd)
+}
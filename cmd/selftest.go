@@ -0,0 +1,92 @@
+// cmd/selftest.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	selftestRounds   int
+	selftestSeed     int64
+	selftestMaxItems int
+	selftestWorkers  int
+	selftestDir      string
+	selftestKeep     bool
+)
+
+// selftestCmd runs the planner and executor against random trees. It is
+// hidden: it checks sync-dir itself, not the user's data.
+var selftestCmd = &cobra.Command{
+	Use:    "selftest",
+	Short:  "Sync random trees and check that the target mirrors the source.",
+	Hidden: true,
+	Long: `Each round generates a random source tree and a target derived from it by
+random edits (changed files, files turned into directories and back, removed
+entries, extra nested subtrees), then plans and applies a sync and checks that
+the target mirrors the source and that planning again finds nothing to do.
+
+Round i uses seed --seed+i; rerun a failed round with --rounds 1 --seed <seed>
+--keep to inspect its trees.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("seed") {
+			selftestSeed = time.Now().UnixNano()
+		}
+		dir := selftestDir
+		if dir == "" {
+			var err error
+			if dir, err = os.MkdirTemp("", "sync-dir-selftest-"); err != nil {
+				return fmt.Errorf("failed to create work directory: %w", err)
+			}
+			if !selftestKeep {
+				defer os.RemoveAll(dir)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Running %d rounds from seed %d in %s\n", selftestRounds, selftestSeed, dir)
+
+		var failed int
+		err := syncer.SelfTest(syncer.SelfTestOptions{
+			Dir:      dir,
+			Seed:     selftestSeed,
+			Rounds:   selftestRounds,
+			MaxItems: selftestMaxItems,
+			Workers:  selftestWorkers,
+			Keep:     selftestKeep,
+		}, func(round syncer.SelfTestRound) {
+			if round.Passed() {
+				return
+			}
+			failed++
+			fmt.Printf("Round with seed %d failed (%d actions):\n", round.Seed, round.Actions)
+			for _, problem := range round.Problems {
+				fmt.Printf("  %s\n", problem)
+			}
+			if selftestKeep {
+				fmt.Printf("  Trees kept in %s\n", round.Dir)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("selftest failed: %w", err)
+		}
+		fmt.Printf("%d of %d rounds passed.\n", selftestRounds-failed, selftestRounds)
+		if failed > 0 {
+			return fmt.Errorf("%d round(s) failed", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	selftestCmd.Flags().IntVar(&selftestRounds, "rounds", 100, "Number of random trees to sync")
+	selftestCmd.Flags().Int64Var(&selftestSeed, "seed", 0, "Seed of the first round (default: random)")
+	selftestCmd.Flags().IntVar(&selftestMaxItems, "max-items", 50, "Maximum entries of a generated source tree")
+	selftestCmd.Flags().IntVar(&selftestWorkers, "workers", 8, "Parallel operations while applying a plan")
+	selftestCmd.Flags().StringVar(&selftestDir, "dir", "", "Directory to generate the trees in (default: a new temp directory)")
+	selftestCmd.Flags().BoolVar(&selftestKeep, "keep", false, "Keep the trees of failed rounds for inspection")
+	rootCmd.AddCommand(selftestCmd)
+}
@@ -0,0 +1,116 @@
+// cmd/serve.go
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/manifest"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen  string        // Address the server listens on
+	serveCert    string        // TLS certificate for serving over HTTPS
+	serveKey     string        // TLS key for serving over HTTPS
+	serveSignKey string        // Private key the manifest is signed with ("" = unsigned)
+	serveRescan  time.Duration // How often the directory is described again (0 = never)
+)
+
+// serveCmd publishes a directory for mirroring over HTTP(S).
+var serveCmd = &cobra.Command{
+	Use:   "serve <dir>",
+	Short: "Serve a directory so it can be mirrored with \"sync-dir sync <url> <target>\".",
+	Long: `Serves a directory and its manifest (see "sync-dir manifest") over HTTP, or
+HTTPS with --tls-cert and --tls-key: the manifest and the files it lists, and
+nothing else. Downloads support range requests so they can resume, and every
+response carries an ETag and a Last-Modified time, so clients and caches
+revalidate with If-None-Match or If-Modified-Since instead of downloading
+again. The source's .sync-ignore and --exclude/--preset patterns leave paths
+out.
+
+With --sign-key, the manifest is signed (served as ` + manifest.FileName + manifest.SignatureSuffix + `),
+and clients refuse it unless the signature matches their --manifest-key.
+
+The manifest is built when the server starts. With --rescan, the directory is
+described again at that interval, hashing only files whose size or mtime
+changed; a file changed since the manifest was built is refused (409) until
+then, as it would fail the checksum clients check it against.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, err := filepath.Abs(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid directory '%s': %w", args[0], err)
+		}
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("'%s' is not a directory", root)
+		}
+		if (serveCert == "") != (serveKey == "") {
+			return fmt.Errorf("--tls-cert and --tls-key must be given together")
+		}
+		if serveRescan < 0 {
+			return fmt.Errorf("--rescan cannot be negative")
+		}
+		var key ed25519.PrivateKey
+		if serveSignKey != "" {
+			if key, err = loadSigningKey(serveSignKey); err != nil {
+				return err
+			}
+		}
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		matcher, err := ignore.NewMatcher(root, excludes, consoleLogger())
+		if err != nil {
+			return fmt.Errorf("failed to load ignore rules: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "Describing %s...\n", root)
+		server, err := manifest.NewServer(root, matcher, key)
+		if err != nil {
+			return err
+		}
+		if serveRescan > 0 {
+			go rescanServed(server, serveRescan)
+		}
+
+		scheme := "http"
+		if serveCert != "" {
+			scheme = "https"
+		}
+		fmt.Fprintf(os.Stderr, "Serving %s (%d entries) at %s://%s/\n", root, len(server.Manifest().Entries), scheme, serveListen)
+		httpServer := &http.Server{Addr: serveListen, Handler: server, ReadHeaderTimeout: 30 * time.Second}
+		if serveCert != "" {
+			return httpServer.ListenAndServeTLS(serveCert, serveKey)
+		}
+		return httpServer.ListenAndServe()
+	},
+}
+
+// rescanServed describes the served directory again every interval.
+func rescanServed(server *manifest.Server, interval time.Duration) {
+	for range time.Tick(interval) {
+		changed, err := server.Rebuild()
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Warning: Could not describe the directory again: %v\n", err)
+		case changed:
+			fmt.Fprintf(os.Stderr, "Note: Published a new manifest (%d entries).\n", len(server.Manifest().Entries))
+		}
+	}
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", ":8443", "Address to listen on")
+	serveCmd.Flags().StringVar(&serveCert, "tls-cert", "", "Certificate file for serving over HTTPS (with --tls-key)")
+	serveCmd.Flags().StringVar(&serveKey, "tls-key", "", "Private key file for serving over HTTPS (with --tls-cert)")
+	serveCmd.Flags().StringVar(&serveSignKey, "sign-key", "", "Sign the manifest with the private key in this file, generating it (and FILE.pub) if it doesn't exist")
+	serveCmd.Flags().DurationVar(&serveRescan, "rescan", 0, "Describe the directory again at this interval to publish changes, e.g. 5m (0 = never)")
+	rootCmd.AddCommand(serveCmd)
+}
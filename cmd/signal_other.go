@@ -0,0 +1,13 @@
+// cmd/signal_other.go
+//go:build !unix
+
+package cmd
+
+import "github.com/jeepinbird/sync-dir/pkg/syncer"
+
+// handleSignals does nothing: there is no SIGUSR1 or SIGHUP on this
+// platform, so a running sync can only be steered through the control socket
+// (sync-dir ctl).
+func handleSignals(sync *syncer.Syncer) func() {
+	return func() {}
+}
@@ -0,0 +1,42 @@
+// cmd/signal_unix.go
+//go:build unix
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+)
+
+// handleSignals lets a running sync be steered with signals: SIGUSR1 pauses
+// or resumes it, SIGHUP reloads the worker and bandwidth limits from the
+// profile. The returned function stops the handling.
+func handleSignals(sync *syncer.Syncer) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if sig == syscall.SIGHUP {
+					if err := reloadLimits(sync); err != nil {
+						fmt.Fprintf(os.Stderr, "\nWarning: Reload failed: %v\n", err)
+					}
+					continue
+				}
+				sync.TogglePause()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
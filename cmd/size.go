@@ -0,0 +1,70 @@
+// cmd/size.go
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteSize is a flag value holding a size in bytes. It accepts plain numbers
+// or numbers with a binary unit suffix, e.g. "512K", "4M", "1G" or "1MiB".
+type byteSize int64
+
+func (b *byteSize) String() string {
+	return formatByteSize(int64(*b))
+}
+
+func (b *byteSize) Set(value string) error {
+	n, err := parseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*b = byteSize(n)
+	return nil
+}
+
+func (b *byteSize) Type() string {
+	return "size"
+}
+
+// byteUnits maps unit suffixes (upper case, without a trailing "B"/"IB") to multipliers.
+var byteUnits = map[string]int64{
+	"":  1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// parseByteSize parses a size such as "64K", "1M" or "2GiB" into bytes.
+func parseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "I")
+
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+	number, unit := s[:i], strings.TrimSpace(s[i:])
+
+	multiplier, ok := byteUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 512K, 4M, 1G)", value)
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 512K, 4M, 1G)", value)
+	}
+	return int64(n * float64(multiplier)), nil
+}
+
+// formatByteSize renders n using the largest unit that divides it evenly.
+func formatByteSize(n int64) string {
+	for _, unit := range []string{"T", "G", "M", "K"} {
+		if m := byteUnits[unit]; n >= m && n%m == 0 {
+			return fmt.Sprintf("%d%s", n/m, unit)
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,97 @@
+// cmd/status.go
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/spf13/cobra"
+)
+
+// statusSampleLimit is how many paths are listed per change category.
+const statusSampleLimit = 10
+
+// statusCmd reports drift since the last sync without executing anything.
+var statusCmd = &cobra.Command{
+	Use:   "status <source> <target>",
+	Short: "Report what changed in source and target since the last sync.",
+	Long: `Compares the source and the target against the snapshot recorded by the last
+successful sync of this pair and reports what changed on each side, without
+modifying anything. Changes in the target are out-of-band modifications that
+the next sync would revert.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sourcePath, targetPath, err := resolvePair(args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		excludes, err := cliExcludes()
+		if err != nil {
+			return err
+		}
+		sync := syncer.NewSyncer(sourcePath, targetPath, excludes, true)
+		sync.Gitignore = gitignore
+		sync.Logger = consoleLogger()
+		sync.Observer = newProgressObserver()
+		report, err := sync.Status()
+		if err != nil {
+			return fmt.Errorf("status failed: %w", err)
+		}
+
+		fmt.Fprintln(os.Stderr, i18n.T("sync.source", sourcePath))
+		fmt.Fprintln(os.Stderr, i18n.T("sync.target", targetPath))
+		if report.LastRun != nil {
+			result := i18n.T("status.succeeded")
+			if report.LastRun.Error != "" {
+				result = i18n.T("status.failed", report.LastRun.Error)
+			}
+			if report.LastRun.DryRun {
+				result += " " + i18n.T("status.dry_run")
+			}
+			fmt.Println(i18n.T("status.last_run", report.LastRun.End.Format(time.RFC1123), result))
+		}
+		if report.Interrupted {
+			fmt.Println(i18n.T("status.interrupted"))
+		}
+		if !report.Synced {
+			fmt.Println(i18n.T("status.never_synced"))
+			return nil
+		}
+
+		fmt.Println(i18n.T("status.last_sync", report.SnapshotTime.Format(time.RFC1123)))
+		printDrift(i18n.T("status.source_changes"), report.Source)
+		printDrift(i18n.T("status.target_drift"), report.Target)
+		if report.Source.Empty() && report.Target.Empty() {
+			fmt.Println("\n" + theme.Paint(theme.Success, i18n.T("status.no_drift")))
+		}
+		return nil
+	},
+}
+
+// printDrift prints the counts and a sample of paths of one Drift.
+func printDrift(title string, drift syncer.Drift) {
+	fmt.Printf("\n%s: %s\n", title, i18n.T("status.drift_counts", len(drift.Added), len(drift.Modified), len(drift.Removed)))
+	printSample(theme.Paint(theme.Add, "+"), drift.Added)
+	printSample(theme.Paint(theme.Update, "~"), drift.Modified)
+	printSample(theme.Paint(theme.Delete, "-"), drift.Removed)
+}
+
+// printSample lists up to statusSampleLimit paths with a marker.
+func printSample(marker string, paths []string) {
+	for i, path := range paths {
+		if i == statusSampleLimit {
+			fmt.Printf("  %s %s\n", marker, i18n.T("sample.more", len(paths)-statusSampleLimit))
+			break
+		}
+		fmt.Printf("  %s %s\n", marker, path)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
@@ -0,0 +1,97 @@
+// cmd/sync.go
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Merge flags (sync subcommand only)
+	mergeSources    bool     // Allow several sources merged into one target
+	collisionPolicy string   // How to resolve a path present in several sources
+	mergeInto       []string // SOURCE=SUBDIR mappings of sources into target subdirectories
+
+	// syncCmd is the explicit form of the root command, which also accepts
+	// several sources to merge into one target.
+	syncCmd = &cobra.Command{
+		Use:   "sync <source>... <target>",
+		Short: "Synchronize one source, or several merged sources, into a target.",
+		Long: `Synchronizes a source directory into a target, exactly like running sync-dir
+without a subcommand.
+
+The source may also be the http:// or https:// URL of a tree published with a
+manifest (see the manifest command), or of the manifest itself; its files are
+downloaded with range requests, so interrupted downloads resume.
+
+With --merge, several sources can be merged into one target. Each source keeps
+its own .sync-ignore file, and --merge-into SOURCE=SUBDIR places a source
+under a subdirectory of the target instead of the target root. When the same
+path comes from more than one source, --collision decides the outcome:
+  error        abort and list the colliding paths (default)
+  newest-wins  use the most recently modified item
+  priority     use the item from the source listed first`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && profileName != "" {
+				return nil // Source and target come from the profile
+			}
+			if len(targetFlags) > 0 {
+				return cobra.MinimumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sources, targets, err := profileArgs(args)
+			if err != nil {
+				return err
+			}
+			if len(sources) > 1 && !mergeSources {
+				return fmt.Errorf("syncing %d sources into one target requires --merge", len(sources))
+			}
+			return notInSync(cmd, runSync(sources, targets))
+		},
+	}
+)
+
+// configureMerge turns the merge flags into the Syncer's merge configuration.
+func configureMerge(sync *syncer.Syncer, sourcePaths []string) error {
+	policy, err := syncer.ParseCollisionPolicy(collisionPolicy)
+	if err != nil {
+		return err
+	}
+
+	subdirs := make(map[string]string)
+	for _, mapping := range mergeInto {
+		source, subdir, ok := strings.Cut(mapping, "=")
+		if !ok || subdir == "" {
+			return fmt.Errorf("invalid --merge-into %q (expected SOURCE=SUBDIR)", mapping)
+		}
+		sourcePath, err := filepath.Abs(source)
+		if err != nil {
+			return fmt.Errorf("invalid source path '%s': %w", source, err)
+		}
+		subdirs[sourcePath] = subdir
+	}
+
+	for _, sourcePath := range sourcePaths {
+		sync.MergeSources = append(sync.MergeSources, syncer.MergeSource{Root: sourcePath, Subdir: subdirs[sourcePath]})
+		delete(subdirs, sourcePath)
+	}
+	for sourcePath := range subdirs {
+		return fmt.Errorf("--merge-into refers to %s, which is not one of the sources", sourcePath)
+	}
+	sync.Collision = policy
+	return nil
+}
+
+func init() {
+	addSyncFlags(syncCmd)
+	syncCmd.Flags().BoolVar(&mergeSources, "merge", false, "Merge several sources into the target")
+	syncCmd.Flags().StringVar(&collisionPolicy, "collision", "error", "What to do when several sources provide the same path: error, newest-wins or priority")
+	syncCmd.Flags().StringSliceVar(&mergeInto, "merge-into", nil, "Map a source into a target subdirectory, as SOURCE=SUBDIR (can be specified multiple times)")
+	rootCmd.AddCommand(syncCmd)
+}
@@ -0,0 +1,292 @@
+// pkg/bench/bench.go
+// Package bench measures sync throughput on synthetic trees, so that the
+// effect of a change on performance can be measured and compared with an
+// earlier build.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+)
+
+// Scenario describes a synthetic source tree.
+type Scenario struct {
+	Name   string
+	Files  int   // Files in the tree
+	Size   int64 // Bytes per file
+	Depth  int   // Directory levels below the root
+	Fanout int   // Subdirectories of each directory above the last level
+}
+
+// Scenarios are the standard trees: many small files, a few huge ones, and
+// a deep, narrow tree.
+var Scenarios = []Scenario{
+	{Name: "small-files", Files: 20000, Size: 4 << 10, Depth: 2, Fanout: 16},
+	{Name: "huge-files", Files: 4, Size: 256 << 20},
+	{Name: "deep-tree", Files: 2000, Size: 1 << 10, Depth: 64, Fanout: 1},
+}
+
+// Passes of each scenario: a first sync into an empty target copies
+// everything, a second one finds nothing to do and only scans and plans.
+const (
+	PassCopy   = "copy"
+	PassResync = "resync"
+)
+
+// Result is the measurement of one pass of a scenario.
+type Result struct {
+	Scenario string        `json:"scenario"`
+	Pass     string        `json:"pass"`
+	Files    int           `json:"files"`
+	Bytes    int64         `json:"bytes"`       // Bytes copied
+	Planning time.Duration `json:"planning_ns"` // Scanning and planning
+	Total    time.Duration `json:"total_ns"`
+}
+
+// FilesPerSecond is how many files of the tree the pass handled per second.
+func (r Result) FilesPerSecond() float64 {
+	return float64(r.Files) / r.Total.Seconds()
+}
+
+// BytesPerSecond is the copy throughput of the pass (0 if nothing was copied).
+func (r Result) BytesPerSecond() float64 {
+	return float64(r.Bytes) / r.Total.Seconds()
+}
+
+func (r Result) String() string {
+	line := fmt.Sprintf("%-12s %-7s %7d files  %8s  total %8s  planning %8s  %9.0f files/s",
+		r.Scenario, r.Pass, r.Files, summary.FormatBytes(r.Bytes),
+		r.Total.Round(time.Millisecond), r.Planning.Round(time.Millisecond), r.FilesPerSecond())
+	if r.Bytes > 0 {
+		line += fmt.Sprintf("  %s/s", summary.FormatBytes(int64(r.BytesPerSecond())))
+	}
+	return line
+}
+
+// Options configures Run.
+type Options struct {
+	Dir       string     // Where the trees are generated, one subdirectory per scenario
+	Scale     float64    // Multiplies the file counts and sizes of the scenarios (1 = as defined)
+	Scenarios []Scenario // Scenarios to run (Scenarios if empty)
+	// NewSyncer returns the syncer measured for a pass, configured as the
+	// caller wants to benchmark it. Its Observer is replaced.
+	NewSyncer func(source, target string) *syncer.Syncer
+}
+
+// Run generates the tree of each scenario, syncs it into an empty target and
+// then again, and calls report with the measurement of every pass. Trees are
+// generated from a fixed seed, so every run measures the same data; they are
+// removed once measured.
+func Run(opts Options, report func(Result)) error {
+	scenarios := opts.Scenarios
+	if len(scenarios) == 0 {
+		scenarios = Scenarios
+	}
+	for _, scenario := range scenarios {
+		if opts.Scale > 0 {
+			scenario = scenario.scaled(opts.Scale)
+		}
+		if err := runScenario(opts, scenario, report); err != nil {
+			return fmt.Errorf("%s: %w", scenario.Name, err)
+		}
+	}
+	return nil
+}
+
+// Find returns the standard scenario of that name.
+func Find(name string) (Scenario, error) {
+	var names []string
+	for _, scenario := range Scenarios {
+		if scenario.Name == name {
+			return scenario, nil
+		}
+		names = append(names, scenario.Name)
+	}
+	return Scenario{}, fmt.Errorf("unknown scenario %q (valid: %s)", name, strings.Join(names, ", "))
+}
+
+// scaled returns the scenario with its file count and size multiplied by factor.
+func (s Scenario) scaled(factor float64) Scenario {
+	s.Files = max(1, int(float64(s.Files)*factor))
+	s.Size = int64(float64(s.Size) * factor)
+	return s
+}
+
+func runScenario(opts Options, scenario Scenario, report func(Result)) error {
+	dir := filepath.Join(opts.Dir, scenario.Name)
+	source, target := filepath.Join(dir, "source"), filepath.Join(dir, "target")
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear %s: %w", dir, err)
+	}
+	defer os.RemoveAll(dir)
+	if err := generate(source, scenario); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+
+	for _, pass := range []string{PassCopy, PassResync} {
+		result, err := measure(opts.NewSyncer(source, target))
+		if err != nil {
+			return fmt.Errorf("%s pass: %w", pass, err)
+		}
+		result.Scenario, result.Pass, result.Files = scenario.Name, pass, scenario.Files
+		report(result)
+	}
+	return nil
+}
+
+// measure runs s and times it.
+func measure(s *syncer.Syncer) (Result, error) {
+	timer := &phaseTimer{}
+	s.Observer = timer
+	start := time.Now()
+	err := s.Run()
+	total := time.Since(start)
+	if err != nil {
+		return Result{}, err
+	}
+	planning := total
+	if !timer.planned.IsZero() {
+		planning = timer.planned.Sub(start)
+	}
+	return Result{Bytes: timer.copied, Planning: planning, Total: total}, nil
+}
+
+// phaseTimer notes when the plan was ready and counts the bytes copied.
+type phaseTimer struct {
+	syncer.NopObserver
+	mu      sync.Mutex
+	planned time.Time
+	copied  int64
+}
+
+func (t *phaseTimer) OnPlanReady(*syncer.SyncPlan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.planned = time.Now()
+}
+
+func (t *phaseTimer) OnBytesCopied(n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.copied += n
+}
+
+// generate creates the tree of a scenario under root: the directories, then
+// the files spread evenly over them, with reproducible content and mtimes.
+func generate(root string, scenario Scenario) error {
+	dirs := []string{root}
+	level := []string{root}
+	for depth := 0; depth < scenario.Depth; depth++ {
+		var next []string
+		for _, parent := range level {
+			for i := 0; i < max(1, scenario.Fanout); i++ {
+				next = append(next, filepath.Join(parent, fmt.Sprintf("d%02d", i)))
+			}
+		}
+		dirs = append(dirs, next...)
+		level = next
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	data := make([]byte, min(scenario.Size, 1<<20))
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < scenario.Files; i++ {
+		path := filepath.Join(dirs[i%len(dirs)], fmt.Sprintf("f%06d.dat", i))
+		if err := writeFile(path, scenario.Size, data, rng); err != nil {
+			return err
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			return fmt.Errorf("failed to set mtime of %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// writeFile writes size pseudo-random bytes to path, using buf for chunks.
+func writeFile(path string, size int64, buf []byte, rng *rand.Rand) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	for written := int64(0); written < size; {
+		chunk := buf[:min(int64(len(buf)), size-written)]
+		for i := range chunk {
+			chunk[i] = byte(rng.Uint32())
+		}
+		n, err := file.Write(chunk)
+		written += int64(n)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Save writes results to path as JSON, to serve as a baseline later.
+func Save(path string, results []Result) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load reads results written by Save.
+func Load(path string) ([]Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var results []Result
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return results, nil
+}
+
+// Regressions compares results with a baseline and describes the passes
+// whose file throughput dropped by more than tolerance (0.2 = 20%). Passes
+// missing from either side, or measured on a different number of files, are
+// not compared.
+func Regressions(baseline, results []Result, tolerance float64) []string {
+	before := make(map[string]Result, len(baseline))
+	for _, r := range baseline {
+		before[r.Scenario+"/"+r.Pass] = r
+	}
+	var regressions []string
+	for _, r := range results {
+		old, ok := before[r.Scenario+"/"+r.Pass]
+		if !ok || old.Files != r.Files || old.Total <= 0 || r.Total <= 0 {
+			continue
+		}
+		if r.FilesPerSecond() < old.FilesPerSecond()*(1-tolerance) {
+			regressions = append(regressions, fmt.Sprintf("%s %s: %.0f files/s, down %.0f%% from %.0f",
+				r.Scenario, r.Pass, r.FilesPerSecond(), 100*(1-r.FilesPerSecond()/old.FilesPerSecond()), old.FilesPerSecond()))
+		}
+	}
+	return regressions
+}
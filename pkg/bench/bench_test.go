@@ -0,0 +1,106 @@
+// pkg/bench/bench_test.go
+package bench
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+)
+
+// Trees of the benchmarks: the standard scenarios, scaled down so that an
+// iteration takes milliseconds rather than seconds.
+var (
+	benchSmall = Scenario{Name: "small-files", Files: 2000, Size: 4 << 10, Depth: 2, Fanout: 8}
+	benchHuge  = Scenario{Name: "huge-files", Files: 2, Size: 32 << 20}
+)
+
+// newBenchSyncer returns a quiet syncer whose state is kept in stateDir.
+func newBenchSyncer(source, target, stateDir string) *syncer.Syncer {
+	s := syncer.NewSyncer(source, target, nil, false)
+	s.AssumeYes = true
+	s.Stdout, s.Stderr = io.Discard, io.Discard
+	s.StateDir = stateDir
+	return s
+}
+
+// generateTree generates the tree of scenario once for the benchmark.
+func generateTree(b *testing.B, scenario Scenario) string {
+	b.Helper()
+	source := filepath.Join(b.TempDir(), "source")
+	if err := generate(source, scenario); err != nil {
+		b.Fatal(err)
+	}
+	return source
+}
+
+// BenchmarkScan measures scanning a tree of small files.
+func BenchmarkScan(b *testing.B) {
+	source := generateTree(b, benchSmall)
+	stateDir := b.TempDir()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := syncer.NewSyncer(source, "", nil, true)
+		s.Stdout, s.Stderr = io.Discard, io.Discard
+		s.StateDir = stateDir
+		files, err := s.Inventory(false)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(files) < benchSmall.Files {
+			b.Fatalf("scanned %d entries, want at least %d", len(files), benchSmall.Files)
+		}
+	}
+}
+
+// BenchmarkPlan measures scanning and planning against a target already in
+// sync, which finds nothing to do.
+func BenchmarkPlan(b *testing.B) {
+	source := generateTree(b, benchSmall)
+	target := filepath.Join(b.TempDir(), "target")
+	stateDir := b.TempDir()
+	if err := newBenchSyncer(source, target, stateDir).Run(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s := newBenchSyncer(source, target, stateDir)
+		s.DryRun = true
+		if err := s.Run(); err != nil {
+			b.Fatal(err)
+		}
+		if changes := s.Summary().Changes(); changes != 0 {
+			b.Fatalf("planned %d changes for a target in sync", changes)
+		}
+	}
+}
+
+// BenchmarkCopy measures syncing into an empty target, for many small files
+// and for a few huge ones.
+func BenchmarkCopy(b *testing.B) {
+	for _, scenario := range []Scenario{benchSmall, benchHuge} {
+		b.Run(scenario.Name, func(b *testing.B) {
+			source := generateTree(b, scenario)
+			work := b.TempDir()
+			b.SetBytes(int64(scenario.Files) * scenario.Size)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				target := filepath.Join(work, fmt.Sprintf("target-%d", i))
+				stateDir := filepath.Join(work, fmt.Sprintf("state-%d", i)) // Cold every time
+				b.StartTimer()
+				if err := newBenchSyncer(source, target, stateDir).Run(); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				if err := os.RemoveAll(target); err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
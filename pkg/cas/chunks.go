@@ -0,0 +1,130 @@
+// pkg/cas/chunks.go
+package cas
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// indexDir holds the chunk list of every file stored as chunks, as
+// index/<first 2 hex digits>/<sha256 of the whole file>. The chunks themselves
+// are ordinary objects.
+const indexDir = "index"
+
+// Chunk is one piece of a file stored as chunks.
+type Chunk struct {
+	Hash string
+	Size int64
+}
+
+// indexPath returns where the chunk list of the file with the given hash is
+// stored.
+func (s *Store) indexPath(hash string) string {
+	return filepath.Join(s.Root, indexDir, hash[:2], hash)
+}
+
+// HasContent reports whether the file content with the given hash is stored,
+// either whole or as chunks.
+func (s *Store) HasContent(hash string) bool {
+	if s.HasObject(hash) {
+		return true
+	}
+	_, err := os.Stat(s.indexPath(hash))
+	return err == nil
+}
+
+// Chunks returns the chunk list of the file content with the given hash, or
+// nil if it is stored whole.
+func (s *Store) Chunks(hash string) ([]Chunk, error) {
+	path := s.indexPath(hash)
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close %s: %v\n", path, err)
+		}
+	}()
+
+	var chunks []Chunk
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		sum, sizeText, ok := strings.Cut(scanner.Text(), " ")
+		size, err := strconv.ParseInt(sizeText, 10, 64)
+		if !ok || err != nil || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid chunk list %s", path)
+		}
+		chunks = append(chunks, Chunk{Hash: sum, Size: size})
+	}
+	return chunks, scanner.Err()
+}
+
+// putChunked stores the content read from r as content-defined chunks,
+// skipping those stored already, then records the chunk list under the hash
+// of the whole content.
+func (s *Store) putChunked(r io.Reader) (*PutResult, error) {
+	result := &PutResult{}
+	whole := sha256.New()
+	var chunks []Chunk
+	c := newChunker(r)
+	for {
+		data, err := c.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		whole.Write(data)
+		sum := sha256.Sum256(data)
+		chunk := Chunk{Hash: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+		if !s.HasObject(chunk.Hash) {
+			if err := s.writeObject(chunk.Hash, data); err != nil {
+				return nil, err
+			}
+			result.Written += chunk.Size
+			result.NewChunks++
+		}
+		chunks = append(chunks, chunk)
+		result.Size += chunk.Size
+	}
+	result.Hash = hex.EncodeToString(whole.Sum(nil))
+	result.Chunks = len(chunks)
+	if _, err := os.Stat(s.indexPath(result.Hash)); err == nil {
+		return result, nil // Stored meanwhile under the same content
+	}
+
+	var list strings.Builder
+	for _, chunk := range chunks {
+		fmt.Fprintf(&list, "%s %d\n", chunk.Hash, chunk.Size)
+	}
+	path := s.indexPath(result.Hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, []byte(list.String())); err != nil {
+		return nil, err
+	}
+	result.Added = true
+	return result, nil
+}
+
+// writeObject stores data under hash.
+func (s *Store) writeObject(hash string, data []byte) error {
+	path := s.objectPath(hash)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
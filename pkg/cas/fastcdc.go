@@ -0,0 +1,110 @@
+// pkg/cas/fastcdc.go
+package cas
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// Chunk sizes of the content-defined chunking of large files (FastCDC with
+// normalized chunking). Changing any of them, or the gear table, changes
+// where files are cut and so defeats deduplication against existing chunks.
+const (
+	MinChunkSize   = 512 << 10
+	AvgChunkSize   = 1 << 20
+	MaxChunkSize   = 8 << 20
+	ChunkThreshold = 4 << 20 // Files at least this large are stored as chunks
+)
+
+// Cut point masks: harder to match before the average size, easier after, so
+// chunk sizes cluster around the average. The bits are taken from the top of
+// the fingerprint, which depends on the last 64 bytes read.
+var (
+	avgBits = bits.TrailingZeros(AvgChunkSize)
+	maskS   = topBits(avgBits + 2)
+	maskL   = topBits(avgBits - 2)
+)
+
+// topBits returns a mask of the n most significant bits.
+func topBits(n int) uint64 {
+	return ^uint64(0) << (64 - n)
+}
+
+// gear maps each byte to a pseudo-random 64-bit value. It is generated from a
+// fixed seed (splitmix64) so that it never changes.
+var gear = func() (table [256]uint64) {
+	state := uint64(0x5eed5eed5eed5eed)
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// cutPoint returns the length of the chunk at the start of data, which holds
+// at least MaxChunkSize bytes unless it is the end of the file.
+func cutPoint(data []byte) int {
+	n := len(data)
+	if n <= MinChunkSize {
+		return n
+	}
+	if n > MaxChunkSize {
+		n = MaxChunkSize
+	}
+	normal := min(AvgChunkSize, n)
+
+	var fp uint64
+	i := MinChunkSize
+	for ; i < normal; i++ {
+		fp = fp<<1 + gear[data[i]]
+		if fp&maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = fp<<1 + gear[data[i]]
+		if fp&maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// chunker splits a stream into content-defined chunks.
+type chunker struct {
+	r          io.Reader
+	buf        []byte
+	start, end int // Unconsumed data is buf[start:end]
+	eof        bool
+}
+
+func newChunker(r io.Reader) *chunker {
+	return &chunker{r: r, buf: make([]byte, MaxChunkSize)}
+}
+
+// next returns the next chunk, which is only valid until the following call,
+// or io.EOF after the last one.
+func (c *chunker) next() ([]byte, error) {
+	if c.end-c.start < MaxChunkSize && !c.eof {
+		c.end = copy(c.buf, c.buf[c.start:c.end])
+		c.start = 0
+		n, err := io.ReadFull(c.r, c.buf[c.end:])
+		c.end += n
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			c.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if c.start == c.end {
+		return nil, io.EOF
+	}
+	n := cutPoint(c.buf[c.start:c.end])
+	chunk := c.buf[c.start : c.start+n]
+	c.start += n
+	return chunk, nil
+}
@@ -0,0 +1,182 @@
+// pkg/cas/fastcdc_test.go
+package cas
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/iotest"
+)
+
+// testData returns n pseudo-random bytes that are the same on every run and
+// Go version (splitmix64).
+func testData(n int, seed uint64) []byte {
+	data := make([]byte, n)
+	state := seed
+	for i := 0; i < n; i += 8 {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		z ^= z >> 31
+		for j := 0; j < 8 && i+j < n; j++ {
+			data[i+j] = byte(z >> (8 * j))
+		}
+	}
+	return data
+}
+
+// chunkSizes splits data with the chunker, reading it in small pieces.
+func chunkSizes(t *testing.T, data []byte) []int {
+	t.Helper()
+	var sizes []int
+	var joined []byte
+	c := newChunker(iotest.HalfReader(bytes.NewReader(data)))
+	for {
+		chunk, err := c.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, len(chunk))
+		joined = append(joined, chunk...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatalf("the chunks of %d bytes don't add up to the data", len(data))
+	}
+	return sizes
+}
+
+func TestCutPoint(t *testing.T) {
+	data := testData(3*MaxChunkSize, 1)
+	for _, n := range []int{0, 1, MinChunkSize, MinChunkSize + 1, AvgChunkSize, MaxChunkSize, MaxChunkSize + 1, 3 * MaxChunkSize} {
+		cut := cutPoint(data[:n])
+		switch {
+		case n <= MinChunkSize:
+			if cut != n {
+				t.Errorf("cutPoint of %d bytes = %d, want all of them", n, cut)
+			}
+		case cut <= MinChunkSize || cut > min(n, MaxChunkSize):
+			t.Errorf("cutPoint of %d bytes = %d, want more than %d and at most %d", n, cut, MinChunkSize, min(n, MaxChunkSize))
+		}
+	}
+
+	// Data without any cut point is cut at the maximum size
+	if cut := cutPoint(make([]byte, 2*MaxChunkSize)); cut != MaxChunkSize {
+		t.Errorf("cutPoint of zeros = %d, want %d", cut, MaxChunkSize)
+	}
+}
+
+func TestChunkerSizes(t *testing.T) {
+	sizes := chunkSizes(t, testData(40<<20, 2))
+	total := 0
+	for i, size := range sizes {
+		total += size
+		if i < len(sizes)-1 && (size <= MinChunkSize || size > MaxChunkSize) {
+			t.Errorf("chunk %d has %d bytes, want more than %d and at most %d", i, size, MinChunkSize, MaxChunkSize)
+		}
+	}
+	if avg := total / len(sizes); avg < AvgChunkSize/2 || avg > 2*AvgChunkSize {
+		t.Errorf("average chunk size %d, want about %d", avg, AvgChunkSize)
+	}
+}
+
+// The cut points depend on the chunk sizes and the gear table only. If this
+// fails, files are cut elsewhere than before and no longer share chunks with
+// those stored by earlier versions.
+func TestChunkBoundariesStable(t *testing.T) {
+	got := chunkSizes(t, testData(12<<20, 3))
+	want := []int{1130158, 1351472, 1481705, 1218077, 1127285, 1820099, 1400790, 1083131, 1178463, 791732}
+	if !slices.Equal(got, want) {
+		t.Errorf("chunk sizes %v, want %v", got, want)
+	}
+}
+
+// Inserting bytes only changes the chunk around them: the cut points after it
+// follow the content.
+func TestChunkBoundariesShift(t *testing.T) {
+	data := testData(24<<20, 4)
+	edited := slices.Concat(data[:5<<20], []byte("inserted bytes"), data[5<<20:])
+
+	hashes := func(data []byte) map[string]bool {
+		result := make(map[string]bool)
+		c := newChunker(bytes.NewReader(data))
+		for {
+			chunk, err := c.next()
+			if err != nil {
+				return result
+			}
+			sum := sha256.Sum256(chunk)
+			result[hex.EncodeToString(sum[:])] = true
+		}
+	}
+	before, after := hashes(data), hashes(edited)
+	changed := 0
+	for hash := range after {
+		if !before[hash] {
+			changed++
+		}
+	}
+	if changed == 0 || changed > 2 {
+		t.Errorf("%d of %d chunks changed after an insertion, want 1 or 2", changed, len(after))
+	}
+}
+
+// A large file is stored as chunks, and storing it again changed in one place
+// only adds the chunks that changed.
+func TestPutChunked(t *testing.T) {
+	store, err := Init(filepath.Join(t.TempDir(), "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := testData(ChunkThreshold+6<<20, 5)
+	path := filepath.Join(t.TempDir(), "big")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := store.Put(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	if first.Hash != hex.EncodeToString(sum[:]) || first.Size != int64(len(data)) || !first.Added {
+		t.Errorf("Put = %+v, want the hash and size of the whole file", first)
+	}
+	if first.Chunks < 2 || first.NewChunks != first.Chunks {
+		t.Errorf("Put stored %d chunks, %d new, want several, all new", first.Chunks, first.NewChunks)
+	}
+	chunks, err := store.Chunks(first.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var size int64
+	for _, chunk := range chunks {
+		size += chunk.Size
+		if !store.HasObject(chunk.Hash) {
+			t.Errorf("chunk %s is listed but not stored", chunk.Hash)
+		}
+	}
+	if len(chunks) != first.Chunks || size != first.Size {
+		t.Errorf("chunk list of %d chunks and %d bytes, want %d and %d", len(chunks), size, first.Chunks, first.Size)
+	}
+
+	copy(data[len(data)/2:], "changed in the middle")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := store.Put(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Added || second.NewChunks == 0 || second.NewChunks > 2 || second.Written >= second.Size/2 {
+		t.Errorf("Put of the changed file = %+v, want one or two new chunks", second)
+	}
+}
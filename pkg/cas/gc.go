@@ -0,0 +1,123 @@
+// pkg/cas/gc.go
+package cas
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// GCResult reports what CollectGarbage removed, or would remove in a dry run.
+type GCResult struct {
+	Kept    []string // Snapshots kept, oldest first
+	Removed []string // Snapshots removed by the retention policy, oldest first
+	Objects int      // Unreferenced objects: whole files and chunks
+	Indexes int      // Unreferenced chunk lists
+	Temps   int      // Temp files left by interrupted writes
+	Bytes   int64    // Space reclaimed
+}
+
+// CollectGarbage removes the snapshots the retention policy doesn't keep
+// (none if policy is nil or has no rules), then the objects and chunk lists that no
+// remaining snapshot refers to, including those left by a backup that failed
+// before writing its snapshot. It holds the store lock while it runs. Every
+// kept snapshot must be readable, since an unreadable one could refer to
+// anything.
+func (s *Store) CollectGarbage(policy *Retention, dryRun bool) (*GCResult, error) {
+	unlock, err := s.Lock("garbage collection")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	result := &GCResult{}
+	result.Kept, err = s.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+	if policy != nil && !policy.Empty() {
+		if result.Kept, result.Removed, err = policy.Select(result.Kept); err != nil {
+			return nil, err
+		}
+	}
+	live, err := s.liveContent(result.Kept)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		for _, id := range result.Removed {
+			if err := s.removeSnapshot(id); err != nil {
+				return result, fmt.Errorf("failed to remove snapshot %s: %w", id, err)
+			}
+		}
+	}
+
+	for _, dir := range []string{objectsDir, indexDir} {
+		err := filepath.WalkDir(filepath.Join(s.Root, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == filepath.Join(s.Root, dir) {
+					return filepath.SkipDir // Stores made before chunking have no index
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			temp := isTemp(d.Name())
+			if !temp && live[d.Name()] {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !dryRun {
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+			}
+			switch {
+			case temp:
+				result.Temps++
+			case dir == indexDir:
+				result.Indexes++
+			default:
+				result.Objects++
+			}
+			result.Bytes += info.Size()
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("garbage collection failed: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// liveContent returns the hashes of everything the given snapshots refer to:
+// the content of their files, stored whole or as a chunk list, and the chunks
+// of those lists.
+func (s *Store) liveContent(ids []string) (map[string]bool, error) {
+	live := make(map[string]bool)
+	for _, id := range ids {
+		snap, err := s.LoadSnapshot(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range snap.Entries {
+			if entry.Type != TypeFile || live[entry.Hash] {
+				continue
+			}
+			live[entry.Hash] = true
+			chunks, err := s.Chunks(entry.Hash)
+			if err != nil {
+				return nil, err
+			}
+			for _, chunk := range chunks {
+				live[chunk.Hash] = true
+			}
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,43 @@
+// pkg/cas/lock.go
+package cas
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lockFileName is the lock file held by a backup or garbage collection.
+const lockFileName = "lock"
+
+// Lock takes the store's lock, which keeps garbage collection from removing
+// objects a backup is about to refer to. It fails if the lock is held; a lock
+// left by a crashed process has to be removed by hand, which the error
+// explains. purpose is recorded in the lock for that message.
+func (s *Store) Lock(purpose string) (unlock func(), err error) {
+	path := filepath.Join(s.Root, lockFileName)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			holder, _ := os.ReadFile(path)
+			return nil, fmt.Errorf("store %s is locked by %s (remove %s if that process is gone)", s.Root, strings.TrimSpace(string(holder)), path)
+		}
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	_, err = fmt.Fprintf(file, "%s, pid %d on %s, since %s\n", purpose, os.Getpid(), host, time.Now().Format(time.RFC3339))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return func() {
+		if err := os.Remove(path); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not remove store lock %s: %v\n", path, err)
+		}
+	}, nil
+}
@@ -0,0 +1,84 @@
+// pkg/cas/retention.go
+package cas
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Retention decides which snapshots garbage collection keeps. A snapshot is
+// kept if any rule keeps it. Each periodic rule keeps the newest snapshot of
+// each of the last N periods (days, weeks, months, in local time) that have
+// snapshots.
+type Retention struct {
+	Last    int // Keep the N newest snapshots
+	Daily   int
+	Weekly  int
+	Monthly int
+}
+
+// Empty reports whether r has no rules, i.e. keeps nothing.
+func (r Retention) Empty() bool {
+	return r.Last <= 0 && r.Daily <= 0 && r.Weekly <= 0 && r.Monthly <= 0
+}
+
+// Select splits ids (oldest first, as returned by Snapshots) into the
+// snapshots to keep and those to remove, both oldest first.
+func (r Retention) Select(ids []string) (keep, remove []string, err error) {
+	times := make(map[string]time.Time, len(ids))
+	for _, id := range ids {
+		t, err := snapshotTime(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		times[id] = t.Local()
+	}
+
+	kept := make(map[string]bool)
+	rules := []struct {
+		n      int
+		period func(id string, t time.Time) string // Snapshots of the same period compete
+	}{
+		{r.Last, func(id string, t time.Time) string { return id }},
+		{r.Daily, func(id string, t time.Time) string { return t.Format("2006-01-02") }},
+		{r.Weekly, func(id string, t time.Time) string { y, w := t.ISOWeek(); return fmt.Sprintf("%d-W%02d", y, w) }},
+		{r.Monthly, func(id string, t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, rule := range rules {
+		seen := make(map[string]bool)
+		for i := len(ids) - 1; i >= 0 && len(seen) < rule.n; i-- { // Newest first
+			if period := rule.period(ids[i], times[ids[i]]); !seen[period] {
+				seen[period] = true
+				kept[ids[i]] = true
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if kept[id] {
+			keep = append(keep, id)
+		} else {
+			remove = append(remove, id)
+		}
+	}
+	return keep, remove, nil
+}
+
+// snapshotTime returns the time encoded in a snapshot ID (see SaveSnapshot).
+func snapshotTime(id string) (time.Time, error) {
+	stamp, _, _ := strings.Cut(id, "-")
+	t, err := time.Parse("20060102T150405Z", stamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snapshot ID %q", id)
+	}
+	return t, nil
+}
+
+// removeSnapshot deletes a snapshot manifest. The content it alone referred
+// to is reclaimed by the garbage collection that follows.
+func (s *Store) removeSnapshot(id string) error {
+	return os.Remove(filepath.Join(s.Root, snapshotsDir, id+snapshotExt))
+}
@@ -0,0 +1,130 @@
+// pkg/cas/snapshot.go
+package cas
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshotExt ends the file name of every snapshot manifest.
+const snapshotExt = ".json.gz"
+
+// Entry types of a snapshot.
+const (
+	TypeFile    = "file"
+	TypeDir     = "dir"
+	TypeSymlink = "symlink"
+)
+
+// Snapshot is the manifest of one backup: the tree as it was, with the hash
+// of the content of every file.
+type Snapshot struct {
+	ID      string    `json:"id"` // Assigned by Save, from the time
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source"`
+	Entries []Entry   `json:"entries"` // Sorted by path
+}
+
+// Entry is one item of a snapshot.
+type Entry struct {
+	Path    string      `json:"path"` // Relative to the source, slash-separated
+	Type    string      `json:"type"` // TypeFile, TypeDir or TypeSymlink
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime"`
+	Size    int64       `json:"size,omitempty"`
+	Hash    string      `json:"hash,omitempty"` // SHA256 of a file's content
+	Link    string      `json:"link,omitempty"` // Target of a symlink
+}
+
+// Files returns the file entries by path.
+func (snap *Snapshot) Files() map[string]Entry {
+	files := make(map[string]Entry)
+	for _, e := range snap.Entries {
+		if e.Type == TypeFile {
+			files[e.Path] = e
+		}
+	}
+	return files
+}
+
+// Snapshots returns the IDs of the stored snapshots, oldest first.
+func (s *Store) Snapshots() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.Root, snapshotsDir))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), snapshotExt); ok && !isTemp(entry.Name()) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids) // IDs start with the UTC time, see Save
+	return ids, nil
+}
+
+// LoadSnapshot reads the snapshot with the given ID.
+func (s *Store) LoadSnapshot(id string) (*Snapshot, error) {
+	path := filepath.Join(s.Root, snapshotsDir, id+snapshotExt)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close %s: %v\n", path, err)
+		}
+	}()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+	}
+	var snap Snapshot
+	if err := json.NewDecoder(reader).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", id, err)
+	}
+	return &snap, nil
+}
+
+// LatestSnapshot returns the most recent snapshot, or nil if there is none.
+func (s *Store) LatestSnapshot() (*Snapshot, error) {
+	ids, err := s.Snapshots()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	return s.LoadSnapshot(ids[len(ids)-1])
+}
+
+// SaveSnapshot assigns snap an ID from its time and writes it. Every object
+// it refers to must be stored already.
+func (s *Store) SaveSnapshot(snap *Snapshot) error {
+	sort.Slice(snap.Entries, func(i, j int) bool { return snap.Entries[i].Path < snap.Entries[j].Path })
+	base := snap.Time.UTC().Format("20060102T150405Z")
+	for n := 1; ; n++ {
+		snap.ID = base
+		if n > 1 {
+			snap.ID += "-" + strconv.Itoa(n)
+		}
+		path := filepath.Join(s.Root, snapshotsDir, snap.ID+snapshotExt)
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if err := json.NewEncoder(zw).Encode(snap); err != nil {
+				return err
+			}
+			if err := zw.Close(); err != nil {
+				return err
+			}
+			return writeFileAtomic(path, buf.Bytes())
+		}
+	}
+}
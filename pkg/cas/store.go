@@ -0,0 +1,198 @@
+// pkg/cas/store.go
+// Package cas keeps versioned backups of a directory tree in a
+// content-addressed store: the content of every file is stored once under
+// objects/, named by its SHA256, and each backup adds a snapshot listing the
+// tree with the hash of every file. Unchanged content is shared by all the
+// snapshots that contain it, so keeping many of them costs little space.
+package cas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Layout of a store directory.
+const (
+	markerFileName = "sync-dir-store.json" // Identifies a directory as a store
+	objectsDir     = "objects"             // Contents, as objects/<first 2 hex digits>/<sha256>
+	snapshotsDir   = "snapshots"           // One <id>.json.gz manifest per backup
+	storeVersion   = 1
+)
+
+// ErrNotStore is returned when opening a directory that isn't a store.
+var ErrNotStore = errors.New("not a sync-dir content-addressed store")
+
+// Store is a content-addressed store rooted at a directory.
+type Store struct {
+	Root string
+}
+
+// storeMarker is the content of the marker file.
+type storeMarker struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// Open opens an existing store.
+func Open(root string) (*Store, error) {
+	data, err := os.ReadFile(filepath.Join(root, markerFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%s: %w", root, ErrNotStore)
+		}
+		return nil, err
+	}
+	var marker storeMarker
+	if err := json.Unmarshal(data, &marker); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(root, markerFileName), err)
+	}
+	if marker.Version != storeVersion {
+		return nil, fmt.Errorf("%s: unsupported store version %d", root, marker.Version)
+	}
+	return &Store{Root: root}, nil
+}
+
+// Init opens the store at root, creating it if root doesn't exist or is an
+// empty directory. Any other directory is refused, so that a backup never
+// mixes its objects into unrelated files.
+func Init(root string) (*Store, error) {
+	store, err := Open(root)
+	if !errors.Is(err, ErrNotStore) {
+		return store, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("%s: %w (and not empty)", root, ErrNotStore)
+	}
+
+	for _, dir := range []string{objectsDir, indexDir, snapshotsDir} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.MarshalIndent(storeMarker{Version: storeVersion, Created: time.Now()}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(filepath.Join(root, markerFileName), data); err != nil {
+		return nil, err
+	}
+	return &Store{Root: root}, nil
+}
+
+// objectPath returns where the content with the given hash is stored.
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.Root, objectsDir, hash[:2], hash)
+}
+
+// HasObject reports whether content with the given hash is stored.
+func (s *Store) HasObject(hash string) bool {
+	if len(hash) < 2 {
+		return false
+	}
+	_, err := os.Stat(s.objectPath(hash))
+	return err == nil
+}
+
+// PutResult describes the content stored by Put.
+type PutResult struct {
+	Hash      string // SHA256 of the whole content
+	Size      int64
+	Added     bool  // The content wasn't stored before
+	Written   int64 // Bytes added to the store, less than Size if chunks were stored already
+	Chunks    int   // Chunks the content was split into (0 = stored whole)
+	NewChunks int   // Chunks that weren't stored before
+}
+
+// Put copies the file at path into the store, hashing it while copying.
+// Files of ChunkThreshold bytes or more are split into content-defined chunks
+// (see Chunks), so that a large file changed in places only adds the chunks
+// that changed; smaller files are stored whole.
+func (s *Store) Put(path string) (*PutResult, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := src.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close %s: %v\n", path, err)
+		}
+	}()
+	info, err := src.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() >= ChunkThreshold {
+		return s.putChunked(src)
+	}
+	return s.putWhole(src)
+}
+
+// putWhole stores the content read from r as a single object.
+func (s *Store) putWhole(r io.Reader) (*PutResult, error) {
+	tmp, err := os.CreateTemp(filepath.Join(s.Root, objectsDir), ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, sum), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+
+	result := &PutResult{Hash: hex.EncodeToString(sum.Sum(nil)), Size: n}
+	if s.HasContent(result.Hash) {
+		_ = os.Remove(tmp.Name())
+		return result, nil
+	}
+	dest := s.objectPath(result.Hash)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+	result.Added, result.Written = true, n
+	return result, nil
+}
+
+// isTemp reports whether name is a temp file left by an interrupted write.
+func isTemp(name string) bool {
+	return strings.HasPrefix(name, ".tmp-")
+}
+
+// writeFileAtomic replaces path with data via a temp file and rename.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
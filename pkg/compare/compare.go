@@ -0,0 +1,56 @@
+// pkg/compare/compare.go
+package compare
+
+import (
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// Verdict is the outcome of comparing a file present in both source and
+// target: it differs, why it was judged the same, or that the comparer
+// couldn't tell.
+type Verdict int
+
+const (
+	Differs         Verdict = iota // The target needs an update
+	SameSizeMtime                  // Same size and mtime
+	WithinTolerance                // Same size, mtimes within the tolerance
+	SameChecksum                   // Same size and checksum
+	SameMtime                      // Same mtime; size and content weren't compared
+	Undecided                      // Inconclusive; left to the next comparer of a Chain
+)
+
+// Comparer decides whether a target file differs from its source file. New
+// strategies (a hash kept in an xattr, a git blob id, ...) implement it and
+// are combined with the others in a Chain.
+type Comparer interface {
+	Compare(source, target *fileinfo.FileInfo) (Verdict, error)
+}
+
+// Chain asks its comparers in order and returns the first verdict that isn't
+// Undecided, or Undecided if none decides. An error ends the comparison.
+type Chain []Comparer
+
+func (c Chain) Compare(source, target *fileinfo.FileInfo) (Verdict, error) {
+	for _, comparer := range c {
+		verdict, err := comparer.Compare(source, target)
+		if err != nil || verdict != Undecided {
+			return verdict, err
+		}
+	}
+	return Undecided, nil
+}
+
+// SameModTime reports whether two mtimes are equal at second precision, or at
+// most tolerance apart.
+func SameModTime(a, b time.Time, tolerance time.Duration) bool {
+	if a.Truncate(time.Second).Equal(b.Truncate(time.Second)) {
+		return true
+	}
+	diff := a.Sub(b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= tolerance
+}
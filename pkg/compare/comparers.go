@@ -0,0 +1,72 @@
+// pkg/compare/comparers.go
+package compare
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// QuickComparer decides by size and mtime where they are conclusive: files of
+// other sizes differ, and files of the same size with the same mtime (or
+// mtimes at most Tolerance apart) are the same. Files of the same size with
+// other mtimes are Undecided.
+type QuickComparer struct {
+	Tolerance time.Duration // Mtimes this far apart still match (0 = same second only)
+}
+
+func (c QuickComparer) Compare(source, target *fileinfo.FileInfo) (Verdict, error) {
+	switch {
+	case source.Size != target.Size:
+		return Differs, nil
+	case SameModTime(source.ModTime, target.ModTime, 0):
+		return SameSizeMtime, nil
+	case c.Tolerance > 0 && SameModTime(source.ModTime, target.ModTime, c.Tolerance):
+		return WithinTolerance, nil
+	default:
+		return Undecided, nil
+	}
+}
+
+// ChecksumComparer compares content: files of other sizes differ, files of
+// the same size are the same if their checksums match.
+type ChecksumComparer struct {
+	Sum func(path string) (string, error) // Checksum of the file at path
+}
+
+func (c ChecksumComparer) Compare(source, target *fileinfo.FileInfo) (Verdict, error) {
+	if source.Size != target.Size {
+		return Differs, nil
+	}
+	sourceSum, err := c.Sum(source.AbsPath)
+	if err != nil {
+		return Differs, fmt.Errorf("failed to calculate checksum for source %s: %w", source.RelPath, err)
+	}
+	targetSum, err := c.Sum(target.AbsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Differs, nil // Gone since the scan: copy it again
+		}
+		return Differs, fmt.Errorf("failed to calculate checksum for target %s: %w", target.RelPath, err)
+	}
+	if sourceSum != targetSum {
+		return Differs, nil
+	}
+	return SameChecksum, nil
+}
+
+// MetadataComparer decides by mtime alone, for target files whose size and
+// content are expected to differ from the source, e.g. because they are
+// transformed on copy and only carry the source mtime.
+type MetadataComparer struct {
+	Tolerance time.Duration // Mtimes this far apart still match (0 = same second only)
+}
+
+func (c MetadataComparer) Compare(source, target *fileinfo.FileInfo) (Verdict, error) {
+	if SameModTime(source.ModTime, target.ModTime, c.Tolerance) {
+		return SameMtime, nil
+	}
+	return Differs, nil
+}
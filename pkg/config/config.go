@@ -0,0 +1,154 @@
+// pkg/config/config.go
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FileName is the name of the config file inside the user config directory.
+const FileName = "config.json"
+
+// Config is the contents of the sync-dir config file.
+type Config struct {
+	Profiles map[string]*Profile `json:"profiles"`
+	// Colors customizes the colors of the output by role, e.g. "delete":
+	// "red-bold", plus "background": "dark", "light" or "auto".
+	Colors map[string]string `json:"colors,omitempty"`
+	// Vars are values for ${NAME} references in all profiles, e.g. "base":
+	// "/backups/${HOSTNAME}". Profile variables override them; other names
+	// are looked up in the environment.
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// Profile is a named set of options for one sync job.
+type Profile struct {
+	// Extends names a profile this one is based on: its settings apply unless
+	// this profile sets them too.
+	Extends string `json:"extends,omitempty"`
+	// Vars are values for ${NAME} references, overriding the config's.
+	Vars map[string]string `json:"vars,omitempty"`
+
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+	// Targets are further targets the source is synced to in the same run,
+	// sharing one scan of it.
+	Targets []string `json:"targets,omitempty"`
+	// Flags holds command-line flag values by flag name, e.g. "exclude": ["*.log"]
+	// or "dry-run": true. Flags given on the command line take precedence.
+	Flags      map[string]any  `json:"flags,omitempty"`
+	Transforms []TransformRule `json:"transforms,omitempty"`
+	Rules      []ActionRule    `json:"rules,omitempty"`
+	Tiers      []TierRule      `json:"tiers,omitempty"`
+	Notify     []Notifier      `json:"notify,omitempty"`
+}
+
+// Notifier posts a message about each run to a chat webhook.
+type Notifier struct {
+	Type     string `json:"type"`               // slack, discord or teams
+	URL      string `json:"url"`                // Incoming webhook URL
+	Template string `json:"template,omitempty"` // Go text/template over the run summary; a default is used if empty
+	On       string `json:"on,omitempty"`       // always (default), failure or changes
+}
+
+// TransformRule applies built-in filters and/or an external command to the
+// content of files matching Pattern while they are copied to the target.
+type TransformRule struct {
+	Pattern string   `json:"pattern"`           // gitignore-style pattern, e.g. "*.log"
+	Filters []string `json:"filters,omitempty"` // Built-in filters, applied in order
+	Command string   `json:"command,omitempty"` // Shell command reading stdin and writing stdout, applied last
+}
+
+// ActionRule changes how files matching Pattern are planned or copied, e.g.
+// {"action": "skip-delete", "pattern": "*.tmp"}.
+type ActionRule struct {
+	Action  string `json:"action"`  // compress, skip-delete or always-copy
+	Pattern string `json:"pattern"` // gitignore-style pattern, e.g. "*.log"
+}
+
+// TierRule sends the source files older or larger than given to a target of
+// their own, e.g. {"older_than": "1y", "target": "/archive/docs"}. A file
+// goes to the first tier it matches; the others go to the profile's target.
+type TierRule struct {
+	Target     string `json:"target"`
+	OlderThan  string `json:"older_than,omitempty"`  // Age by mtime, e.g. "90d", "2w", "1y" or "36h"
+	LargerThan string `json:"larger_than,omitempty"` // Size, e.g. "500M"
+}
+
+// DefaultPath returns the config file location in the user config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync-dir", FileName), nil
+}
+
+// Load reads and parses a config file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Profile returns the named profile, merged onto the profiles it extends and
+// with its ${NAME} references substituted.
+func (c *Config) Profile(name string) (*Profile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok || profile == nil {
+		var names []string
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(names, ", "))
+	}
+	resolved, err := c.resolve(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := newExpander(mergeMaps(c.Vars, resolved.Vars))
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	if err := expandProfile(resolved, vars); err != nil {
+		return nil, fmt.Errorf("profile %q, %w", name, err)
+	}
+	return resolved, nil
+}
+
+// FlagValues converts a profile flag value into the string form(s) accepted by
+// the command line: lists give one value per element, everything else one value.
+func FlagValues(value any) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []any:
+		var values []string
+		for _, item := range v {
+			itemValues, err := FlagValues(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported value %v of type %T", value, value)
+	}
+}
@@ -0,0 +1,260 @@
+// pkg/config/resolve.go
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// resolve returns the named profile merged onto the profiles it extends, with
+// its variables collected along the way. chain holds the profiles being
+// resolved, to catch cycles.
+func (c *Config) resolve(name string, chain []string) (*Profile, error) {
+	for i, n := range chain {
+		if n == name {
+			return nil, fmt.Errorf("profile %q: extends cycle %s", chain[0], strings.Join(append(chain[i:], name), " -> "))
+		}
+	}
+	profile := c.Profiles[name]
+	if profile.Extends == "" {
+		return merge(&Profile{}, profile), nil
+	}
+	if base, ok := c.Profiles[profile.Extends]; !ok || base == nil {
+		return nil, fmt.Errorf("profile %q extends unknown profile %q", name, profile.Extends)
+	}
+	base, err := c.resolve(profile.Extends, append(chain, name))
+	if err != nil {
+		return nil, err
+	}
+	return merge(base, profile), nil
+}
+
+// merge returns base overridden by child: source and targets when set, flags
+// and variables by name, and transforms, rules, tiers and notifiers as whole
+// lists.
+func merge(base, child *Profile) *Profile {
+	merged := *base
+	merged.Extends = ""
+	if child.Source != "" {
+		merged.Source = child.Source
+	}
+	if child.Target != "" {
+		merged.Target = child.Target
+	}
+	if child.Targets != nil {
+		merged.Targets = child.Targets
+	}
+	merged.Flags = mergeMaps(base.Flags, child.Flags)
+	merged.Vars = mergeMaps(base.Vars, child.Vars)
+	if child.Transforms != nil {
+		merged.Transforms = child.Transforms
+	}
+	if child.Rules != nil {
+		merged.Rules = child.Rules
+	}
+	if child.Tiers != nil {
+		merged.Tiers = child.Tiers
+	}
+	if child.Notify != nil {
+		merged.Notify = child.Notify
+	}
+	return &merged
+}
+
+func mergeMaps[V any](base, child map[string]V) map[string]V {
+	if base == nil && child == nil {
+		return nil
+	}
+	merged := make(map[string]V, len(base)+len(child))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range child {
+		merged[k] = v
+	}
+	return merged
+}
+
+// expandProfile substitutes the ${NAME} references in the profile's source,
+// target, string flag values, transform commands and notifier URLs.
+func expandProfile(profile *Profile, vars *expander) error {
+	var err error
+	if profile.Source, err = vars.expand(profile.Source); err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	if profile.Target, err = vars.expand(profile.Target); err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	targets := make([]string, len(profile.Targets))
+	for i, target := range profile.Targets {
+		if targets[i], err = vars.expand(target); err != nil {
+			return fmt.Errorf("targets: %w", err)
+		}
+	}
+	if profile.Targets != nil {
+		profile.Targets = targets
+	}
+	for name, value := range profile.Flags {
+		if profile.Flags[name], err = vars.expandValue(value); err != nil {
+			return fmt.Errorf("flag %q: %w", name, err)
+		}
+	}
+	transforms := make([]TransformRule, len(profile.Transforms))
+	for i, rule := range profile.Transforms {
+		if rule.Command, err = vars.expand(rule.Command); err != nil {
+			return fmt.Errorf("transform %q: %w", rule.Pattern, err)
+		}
+		transforms[i] = rule
+	}
+	if profile.Transforms != nil {
+		profile.Transforms = transforms
+	}
+	tiers := make([]TierRule, len(profile.Tiers))
+	for i, tier := range profile.Tiers {
+		if tier.Target, err = vars.expand(tier.Target); err != nil {
+			return fmt.Errorf("tier target: %w", err)
+		}
+		tiers[i] = tier
+	}
+	if profile.Tiers != nil {
+		profile.Tiers = tiers
+	}
+	notifiers := make([]Notifier, len(profile.Notify))
+	for i, n := range profile.Notify {
+		if n.URL, err = vars.expand(n.URL); err != nil {
+			return fmt.Errorf("%s notifier: %w", n.Type, err)
+		}
+		notifiers[i] = n
+	}
+	if profile.Notify != nil {
+		profile.Notify = notifiers
+	}
+	return nil
+}
+
+// expander substitutes ${NAME} and ${NAME:-default} references. A name is
+// looked up in the profile's variables, then the config's, then the
+// environment; HOSTNAME falls back to the machine's host name, since shells
+// rarely export it. $${ stands for a literal ${.
+type expander struct {
+	vars     map[string]string
+	expanded map[string]string // Variables with their references substituted
+	active   map[string]bool   // Variables being expanded, to catch cycles
+}
+
+func newExpander(vars map[string]string) (*expander, error) {
+	for name := range vars {
+		if !validVarName(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+	return &expander{vars: vars, expanded: make(map[string]string), active: make(map[string]bool)}, nil
+}
+
+func (e *expander) expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		if i > 0 && s[i-1] == '$' { // $${ is a literal ${
+			b.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		b.WriteString(s[:i])
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated %q", s[i:])
+		}
+		ref := s[i+2 : i+end]
+		s = s[i+end+1:]
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if !validVarName(name) {
+			return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+		}
+		value, ok, err := e.lookup(name)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			if !hasDefault {
+				return "", fmt.Errorf("undefined variable ${%s} (set it in \"vars\" or the environment)", name)
+			}
+			value = def
+		}
+		b.WriteString(value)
+	}
+}
+
+// expandValue substitutes references in a flag value: a string, or the
+// strings of a list.
+func (e *expander) expandValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return e.expand(v)
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			expanded, err := e.expandValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = expanded
+		}
+		return items, nil
+	default:
+		return value, nil
+	}
+}
+
+func (e *expander) lookup(name string) (string, bool, error) {
+	if value, ok := e.expanded[name]; ok {
+		return value, true, nil
+	}
+	if raw, ok := e.vars[name]; ok {
+		if e.active[name] {
+			return "", false, fmt.Errorf("variable %q refers to itself", name)
+		}
+		e.active[name] = true
+		value, err := e.expand(raw)
+		delete(e.active, name)
+		if err != nil {
+			return "", false, fmt.Errorf("variable %q: %w", name, err)
+		}
+		e.expanded[name] = value
+		return value, true, nil
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true, nil
+	}
+	if name == "HOSTNAME" {
+		if host, err := os.Hostname(); err == nil {
+			return host, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// validVarName reports whether name is a letter or underscore followed by
+// letters, digits and underscores, like a shell variable.
+func validVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+// pkg/config/resolve_test.go
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// parse reads a config from its JSON text.
+func parse(t *testing.T, text string) *Config {
+	t.Helper()
+	var cfg Config
+	if err := json.Unmarshal([]byte(text), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return &cfg
+}
+
+func TestProfileInheritance(t *testing.T) {
+	t.Setenv("SYNC_DIR_TEST_ROOT", "/env")
+	tests := []struct {
+		name    string
+		config  string
+		profile string
+		want    string // The resolved profile as JSON
+	}{
+		{
+			name:    "no base",
+			config:  `{"profiles": {"docs": {"source": "/a", "target": "/b", "flags": {"exclude": ["*.log"]}}}}`,
+			profile: "docs",
+			want:    `{"source":"/a","target":"/b","flags":{"exclude":["*.log"]}}`,
+		},
+		{
+			name: "settings overridden, flags merged by name",
+			config: `{"profiles": {
+				"base": {"source": "/a", "target": "/b", "flags": {"exclude": ["*.log"], "dry-run": true}},
+				"docs": {"extends": "base", "source": "/c", "flags": {"dry-run": false, "verbose": true}}}}`,
+			profile: "docs",
+			want:    `{"source":"/c","target":"/b","flags":{"dry-run":false,"exclude":["*.log"],"verbose":true}}`,
+		},
+		{
+			name: "lists replaced whole along a chain, by empty ones too",
+			config: `{"profiles": {
+				"all":  {"target": "/t", "rules": [{"action": "skip-delete", "pattern": "*.tmp"}], "targets": ["/u"]},
+				"base": {"extends": "all", "transforms": [{"pattern": "*.txt", "filters": ["crlf"]}], "targets": []},
+				"docs": {"extends": "base", "source": "/s", "rules": [{"action": "compress", "pattern": "*.csv"}]}}}`,
+			profile: "docs",
+			want: `{"source":"/s","target":"/t","transforms":[{"pattern":"*.txt","filters":["crlf"]}],` +
+				`"rules":[{"action":"compress","pattern":"*.csv"}]}`,
+		},
+		{
+			name: "variables of the config, the bases and the profile",
+			config: `{"vars": {"root": "/data", "host": "h0"}, "profiles": {
+				"base": {"vars": {"host": "h1", "dir": "${root}/${host}"}, "source": "${dir}", "target": "/b/${host}"},
+				"docs": {"extends": "base", "vars": {"host": "h2"}, "flags": {"exclude": ["${host}.log", "$${literal}"]}}}}`,
+			profile: "docs",
+			want:    `{"source":"/data/h2","target":"/b/h2","flags":{"exclude":["h2.log","${literal}"]}}`,
+		},
+		{
+			name: "environment and defaults",
+			config: `{"profiles": {"docs": {"source": "${SYNC_DIR_TEST_ROOT}/docs",
+				"target": "${SYNC_DIR_TEST_UNSET:-/fallback}", "notify": [{"type": "slack", "url": "https://hooks/${SYNC_DIR_TEST_ROOT}"}]}}}`,
+			profile: "docs",
+			want:    `{"source":"/env/docs","target":"/fallback","notify":[{"type":"slack","url":"https://hooks//env"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		cfg := parse(t, tt.config)
+		for round := 1; round <= 2; round++ { // Resolving leaves the config as it was
+			profile, err := cfg.Profile(tt.profile)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+				break
+			}
+			profile.Vars = nil // Compared through their effect only
+			got, err := json.Marshal(profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("%s (round %d):\n got %s\nwant %s", tt.name, round, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		profile string
+		want    string
+	}{
+		{
+			name:    "unknown profile",
+			config:  `{"profiles": {"b": {}, "a": {}}}`,
+			profile: "c",
+			want:    `profile "c" not found (available: a, b)`,
+		},
+		{
+			name:    "unknown base",
+			config:  `{"profiles": {"a": {"extends": "nope"}}}`,
+			profile: "a",
+			want:    `profile "a" extends unknown profile "nope"`,
+		},
+		{
+			name:    "cycle",
+			config:  `{"profiles": {"a": {"extends": "b"}, "b": {"extends": "c"}, "c": {"extends": "b"}}}`,
+			profile: "a",
+			want:    `extends cycle b -> c -> b`,
+		},
+		{
+			name:    "undefined variable",
+			config:  `{"profiles": {"a": {"source": "/${SYNC_DIR_TEST_UNSET}"}}}`,
+			profile: "a",
+			want:    `undefined variable ${SYNC_DIR_TEST_UNSET}`,
+		},
+		{
+			name:    "variable referring to itself",
+			config:  `{"vars": {"x": "${y}"}, "profiles": {"a": {"vars": {"y": "${x}"}, "source": "${x}"}}}`,
+			profile: "a",
+			want:    `refers to itself`,
+		},
+		{
+			name:    "invalid variable name",
+			config:  `{"profiles": {"a": {"vars": {"1x": "v"}}}}`,
+			profile: "a",
+			want:    `invalid variable name "1x"`,
+		},
+		{
+			name:    "unterminated reference",
+			config:  `{"profiles": {"a": {"target": "/b/${x"}}}`,
+			profile: "a",
+			want:    `unterminated "${x"`,
+		},
+	}
+	for _, tt := range tests {
+		_, err := parse(t, tt.config).Profile(tt.profile)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want one containing %q", tt.name, err, tt.want)
+		}
+	}
+}
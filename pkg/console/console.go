@@ -0,0 +1,49 @@
+// pkg/console/console.go
+// Package console describes the terminal output goes to: whether it may be
+// colored and whether lines can be redrawn in place.
+package console
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+// colorDisabled is set by DisableColor (--no-color).
+var colorDisabled bool
+
+// DisableColor turns colors off for the rest of the run.
+func DisableColor() {
+	colorDisabled = true
+}
+
+// Color reports whether output to f may use ANSI colors: they weren't turned
+// off by DisableColor or a non-empty NO_COLOR variable (https://no-color.org),
+// f is a terminal and that terminal isn't dumb.
+func Color(f *os.File) bool {
+	if colorDisabled || os.Getenv("NO_COLOR") != "" || Dumb() {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Dumb reports whether TERM names a terminal that can't move the cursor, on
+// which progress bars redrawn in place turn into garbage.
+func Dumb() bool {
+	return os.Getenv("TERM") == "dumb"
+}
+
+// Width returns the number of columns of the terminal f is, or 0 if f isn't
+// a terminal.
+func Width(f *os.File) int {
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || width <= 0 {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,236 @@
+// pkg/control/control.go
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/state"
+)
+
+// streamInterval is how often a streamed method sends an update.
+const streamInterval = 500 * time.Millisecond
+
+// Request is one call over the control socket, sent as a JSON line.
+type Request struct {
+	Method string            `json:"method"`
+	Params map[string]string `json:"params,omitempty"` // Method arguments, e.g. {"workers": "4"}
+	Stream bool              `json:"stream,omitempty"` // Repeat the call every streamInterval until Done
+}
+
+// Response answers a Request, as a JSON line. Streamed calls get one
+// Response per update.
+type Response struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+	Done   bool            `json:"done,omitempty"` // No more updates will follow
+}
+
+// Handler serves the methods of a control socket. done reports that the
+// process has nothing more to report, which ends streams.
+type Handler func(req Request) (result any, done bool, err error)
+
+// Server accepts control connections on a unix socket.
+type Server struct {
+	listener net.Listener
+	path     string
+	handler  Handler
+}
+
+// SocketDir returns the directory holding the sockets of running syncs.
+func SocketDir() (string, error) {
+	base, err := state.BaseDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "control"), nil
+}
+
+// DefaultSocketPath returns the socket path for the current process.
+func DefaultSocketPath() (string, error) {
+	dir, err := SocketDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, strconv.Itoa(os.Getpid())+".sock"), nil
+}
+
+// Listen creates the socket at path and serves it in the background.
+func Listen(path string, handler Handler) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(path) // Left over from a crashed process with the same pid
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	s := &Server{listener: listener, path: path, handler: handler}
+	go s.serve()
+	return s, nil
+}
+
+// Path returns the socket path.
+func (s *Server) Path() string {
+	return s.path
+}
+
+// Close stops accepting connections and removes the socket.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	_ = os.Remove(s.path)
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return // Listener closed
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers the requests of one connection until it is closed.
+func (s *Server) handle(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+	}()
+	encoder := json.NewEncoder(conn)
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			_ = encoder.Encode(Response{Error: "invalid request: " + err.Error(), Done: true})
+			continue
+		}
+		for {
+			resp := s.call(req)
+			if !req.Stream {
+				resp.Done = true
+			}
+			if err := encoder.Encode(resp); err != nil {
+				return // Client went away
+			}
+			if resp.Done {
+				break
+			}
+			time.Sleep(streamInterval)
+		}
+	}
+}
+
+func (s *Server) call(req Request) Response {
+	result, done, err := s.handler(req)
+	if err != nil {
+		return Response{Error: err.Error(), Done: true}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return Response{Error: err.Error(), Done: true}
+	}
+	return Response{Result: data, Done: done}
+}
+
+// ErrClosed is returned when the sync process closes the connection, which
+// happens when it exits.
+var ErrClosed = errors.New("connection closed by the sync process")
+
+// Client is a connection to a control socket.
+type Client struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+// Dial connects to the control socket at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, scanner: bufio.NewScanner(conn)}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Call invokes a method and decodes its result into v.
+func (c *Client) Call(method string, v any) error {
+	return c.CallWith(method, nil, v)
+}
+
+// CallWith invokes a method with parameters and decodes its result into v.
+func (c *Client) CallWith(method string, params map[string]string, v any) error {
+	return c.send(Request{Method: method, Params: params}, func(result json.RawMessage) error {
+		if v == nil {
+			return nil
+		}
+		return json.Unmarshal(result, v)
+	})
+}
+
+// Stream invokes a method and passes each result to fn until the server
+// reports that no more updates follow. With repeat false there is only one.
+func (c *Client) Stream(method string, repeat bool, fn func(json.RawMessage) error) error {
+	return c.send(Request{Method: method, Stream: repeat}, fn)
+}
+
+func (c *Client) send(req Request, fn func(json.RawMessage) error) error {
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		return err
+	}
+	for c.scanner.Scan() {
+		var resp Response
+		if err := json.Unmarshal(c.scanner.Bytes(), &resp); err != nil {
+			return fmt.Errorf("invalid response: %w", err)
+		}
+		if resp.Error != "" {
+			return errors.New(resp.Error)
+		}
+		if err := fn(resp.Result); err != nil {
+			return err
+		}
+		if resp.Done {
+			return nil
+		}
+	}
+	if err := c.scanner.Err(); err != nil {
+		return err
+	}
+	return ErrClosed
+}
+
+// Sockets lists the control sockets of running syncs.
+func Sockets() ([]string, error) {
+	dir, err := SocketDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sockets []string
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".sock") {
+			sockets = append(sockets, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(sockets)
+	return sockets, nil
+}
@@ -0,0 +1,37 @@
+// pkg/fileinfo/checksum.go
+package fileinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SHA256 computes the hex SHA256 checksum of a file's content.
+func SHA256(path string) (string, error) {
+	sum, _, err := SHA256WithSize(path)
+	return sum, err
+}
+
+// SHA256WithSize computes the hex SHA256 checksum of a file's content and
+// returns it with the number of bytes read.
+func SHA256WithSize(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err // Return error directly, including os.IsNotExist
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close %s: %v\n", path, err)
+		}
+	}()
+
+	hash := sha256.New()
+	n, err := io.Copy(hash, file)
+	if err != nil {
+		return "", n, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
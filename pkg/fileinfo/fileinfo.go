@@ -2,7 +2,6 @@
 package fileinfo
 
 import (
-	"fmt"
 	"io/fs"
 	"os"
 	//"path/filepath"
@@ -18,6 +17,8 @@ type FileInfo struct {
 	ModTime  time.Time   // Modification time
 	IsDir    bool        // True if it's a directory
 	Checksum string      // SHA256 checksum (calculated on demand)
+	SELinux  string      // SELinux security context (read with Syncer.SELinux)
+	Caps     []byte      // Raw file capabilities (read with Syncer.Caps)
 }
 
 // New creates a FileInfo struct from fs.FileInfo and paths.
@@ -56,46 +57,3 @@ func (fi *FileInfo) Exists() bool {
 func (fi *FileInfo) IsSymlink() bool {
 	return fi.Mode&fs.ModeSymlink != 0
 }
-
-// NeedsUpdate checks if the target file needs to be updated from the source file.
-// It compares ModTime, Size, and optionally Checksum.
-func (fi *FileInfo) NeedsUpdate(targetFi *FileInfo, calculateChecksum func(path string) (string, error)) (bool, error) {
-	if fi.IsDir != targetFi.IsDir {
-		return true, nil // Type mismatch always needs update (will involve delete + add)
-	}
-	if fi.IsDir {
-		return false, nil // Directories themselves don't "update" based on content time/size
-	}
-
-	// Compare ModTime and Size first (common cases)
-	// Use a tolerance for ModTime comparison across different filesystems/clocks
-	// Note: Some systems have low-resolution timestamps. A small tolerance helps.
-	// Go's time comparison is exact, so we check if they are *not* equal.
-	// We truncate to second precision as sub-second precision varies wildly.
-	timeDiffers := fi.ModTime.Truncate(time.Second) != targetFi.ModTime.Truncate(time.Second)
-	sizeDiffers := fi.Size != targetFi.Size
-
-	if sizeDiffers {
-		return true, nil // Different size always means update
-	}
-
-	if timeDiffers {
-		// Same size, different time: Need checksum verification
-		sourceChecksum, err := calculateChecksum(fi.AbsPath)
-		if err != nil {
-			return false, fmt.Errorf("failed to calculate checksum for source %s: %w", fi.RelPath, err)
-		}
-		targetChecksum, err := calculateChecksum(targetFi.AbsPath)
-		if err != nil {
-			// If target checksum fails (e.g., file gone missing), assume update needed
-			if os.IsNotExist(err) {
-				return true, nil
-			}
-			return false, fmt.Errorf("failed to calculate checksum for target %s: %w", targetFi.RelPath, err)
-		}
-		return sourceChecksum != targetChecksum, nil
-	}
-
-	// Same size, same time (within tolerance): Assume no update needed
-	return false, nil
-}
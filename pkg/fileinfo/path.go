@@ -0,0 +1,17 @@
+// pkg/fileinfo/path.go
+package fileinfo
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// ExistingAncestor returns path or its nearest ancestor that exists, e.g. to
+// learn the filesystem a path not created yet will be on.
+func ExistingAncestor(path string) string {
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if _, err := os.Stat(p); err == nil || filepath.Dir(p) == p {
+			return p
+		}
+	}
+}
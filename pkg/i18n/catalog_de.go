@@ -0,0 +1,335 @@
+// pkg/i18n/catalog_de.go
+package i18n
+
+// de holds the German messages.
+var de = map[string]Message{
+	// Sync run
+	"sync.source":          {Other: "Quelle: %s"},
+	"sync.target":          {Other: "Ziel: %s"},
+	"sync.excludes":        {Other: "Ausschlüsse (Befehlszeile): %v"},
+	"sync.presets":         {Other: "Ausschluss-Vorlagen: %s"},
+	"sync.gitignore":       {Other: ".gitignore-Dateien werden beachtet"},
+	"sync.dry_run_mode":    {Other: "--- PROBELAUF ---"},
+	"sync.starting":        {Other: "Synchronisierung wird gestartet..."},
+	"sync.finished":        {Other: "Synchronisierung erfolgreich beendet."},
+	"sync.finished_errors": {Other: "Synchronisierung mit %d Fehler(n) beendet:\n- %s"},
+	"sync.completed":       {Other: "Synchronisierung erfolgreich abgeschlossen."},
+	"sync.dry_run_note":    {Other: "(Probelauf - es wurde nichts geändert)"},
+	"sync.failed":          {Other: "Synchronisierung fehlgeschlagen: %w"},
+
+	// Scanning
+	"role.source":             {Other: "Quelle"},
+	"role.source_n":           {Other: "Quelle %s"},
+	"role.target":             {Other: "Ziel"},
+	"scan.stats":              {Other: "Durchsuchung von %s (%s): %d Dateien, %d Verzeichnisse, %d symbolische Links, %s"},
+	"scan.ignored":            {Other: "%d durch Ausschlussregeln ignoriert, %d nicht lesbar"},
+	"scan.finished":           {One: "Durchsuchung von %s beendet. %d Eintrag gefunden.", Other: "Durchsuchung von %s beendet. %d Einträge gefunden."},
+	"scan.target_error":       {Other: "Das Zielverzeichnis konnte nicht durchsucht werden"},
+	"scan.reused":             {One: "%d zwischengespeicherter Eintrag für %s wiederverwendet.", Other: "%d zwischengespeicherte Einträge für %s wiederverwendet."},
+	"ignore.loaded":           {Other: "%d Muster aus %s geladen"},
+	"ignore.loaded_gitignore": {Other: "%d Muster aus %d %s-Dateien geladen"},
+
+	// Progress bars
+	"progress.scanning":      {Other: "Durchsuche %s..."},
+	"progress.planning":      {Other: "Plane..."},
+	"progress.comparing":     {Other: "Vergleiche Dateien..."},
+	"progress.syncing":       {Other: "Synchronisiere Dateien..."},
+	"progress.deduplicating": {Other: "Dedupliziere..."},
+	"progress.backing_up":    {Other: "Sichere..."},
+	"progress.verifying":     {Other: "Prüfe Dateien..."},
+
+	// Plan
+	"plan.comparing":       {Other: "Vergleiche Quelle und Ziel..."},
+	"plan.case_difference": {Other: "Unterschied nur in Groß-/Kleinschreibung: %s -> %s"},
+	"plan.complete":        {Other: "Vergleich abgeschlossen. Plan: %d Hinzufügungen, %d Aktualisierungen, %d Löschungen, %d Umbenennungen."},
+	"plan.unchanged":       {Other: "Unverändert: %s"},
+	"plan.none":            {Other: "Keine Aktionen nötig. Quelle und Ziel sind bereits synchron."},
+	"plan.header":          {Other: "--- Synchronisierungsplan ---"},
+	"plan.counts":          {Other: "Hinzufügen: %d, Aktualisieren: %d, Löschen: %d, Umbenennen: %d"},
+	"plan.samples":         {Other: "Beispielaktionen:"},
+	"plan.case_only":       {Other: "%s -> %s (nur Groß-/Kleinschreibung)"},
+	"plan.more":            {One: "... und %d weitere Aktion", Other: "... und %d weitere Aktionen"},
+	"plan.filtered":        {One: "... %d Aktion anderer Art nicht aufgeführt (--plan-filter)", Other: "... %d Aktionen anderer Art nicht aufgeführt (--plan-filter)"},
+	"plan.to_trash":        {Other: "Gelöschte Einträge werden in den Papierkorb verschoben."},
+	"plan.dry_run":         {Other: "Probelauf: Es werden keine Änderungen vorgenommen."},
+	"action.add":           {Other: "NEU"},
+	"action.update":        {Other: "ÄNDERN"},
+	"action.delete":        {Other: "LÖSCHEN"},
+	"action.rename":        {Other: "UMBENENNEN"},
+	"action.none":          {Other: "KEINE"},
+	"disk.shared":          {One: "Quelle und Ziel liegen auf demselben Gerät (%s); %d Operation gleichzeitig.", Other: "Quelle und Ziel liegen auf demselben Gerät (%s); %d Operationen gleichzeitig."},
+	"disk.unknown":         {Other: "unbekannter Typ"},
+	"disk.rotational":      {Other: "rotierend"},
+	"disk.non_rotational":  {Other: "nicht rotierend"},
+
+	// --explain
+	"explain.header":            {Other: "Warum:"},
+	"explain.case_rename":       {Other: "wird von %s in %s umbenannt: die Namen unterscheiden sich nur in Groß-/Kleinschreibung, die das Ziel nicht unterscheidet"},
+	"explain.not_in_target":     {Other: "nicht im Ziel"},
+	"explain.replaces":          {Other: "das Ziel hat ein(e) %s, wo die Quelle ein(e) %s hat"},
+	"explain.not_in_source":     {Other: "nicht in der Quelle"},
+	"explain.replaced":          {Other: "ein(e) %s im Ziel, ein(e) %s in der Quelle"},
+	"explain.size":              {Other: "Größe unterscheidet sich (Quelle %s, Ziel %s)"},
+	"explain.checksums":         {Other: "gleiche Größe, Änderungszeit weicht um %s ab, Prüfsummen unterscheiden sich"},
+	"explain.always_hash":       {Other: "gleiche Größe, Prüfsummen unterscheiden sich (--always-hash)"},
+	"explain.transformed_mtime": {Other: "beim Kopieren transformiert, Änderungszeit weicht um %s ab"},
+	"explain.same_size_mtime":   {Other: "gleiche Größe und Änderungszeit"},
+	"explain.tolerance":         {Other: "gleiche Größe, Änderungszeiten %s auseinander, innerhalb der Toleranz von %s"},
+	"explain.same_checksum":     {Other: "gleiche Größe und Prüfsumme (Änderungszeiten %s auseinander)"},
+	"explain.transformed_same":  {Other: "beim Kopieren transformiert, das Ziel trägt die Änderungszeit der Quelle"},
+	"explain.directories":       {Other: "auf beiden Seiten ein Verzeichnis"},
+	"explain.deleted_with":      {Other: "wird mit seinem Verzeichnis %s gelöscht"},
+	"explain.outside_spec":      {Other: "nicht in der %s des Ziels aufgeführt"},
+	"explain.excluded":          {Other: "durch die Ausschlussregeln ausgeschlossen"},
+	"explain.not_found":         {Other: "weder in der Quelle noch im Ziel"},
+	"explain.left_out":          {Other: "nicht im Plan (z. B. eine Datenbank in Benutzung, siehe --skip-hot-databases)"},
+	"explain.rule_skip_delete":  {Other: "nicht in der Quelle, von einer skip-delete-Regel behalten"},
+	"explain.rule_always_copy":  {Other: "passt zu einer always-copy-Regel"},
+	"explain.file":              {Other: "Datei"},
+	"explain.directory":         {Other: "Verzeichnis"},
+
+	// Ungewöhnliche Änderungen
+	"annotate.header":     {One: "%d ungewöhnliche Änderung in diesem Plan, bitte prüfen:", Other: "%d ungewöhnliche Änderungen in diesem Plan, bitte prüfen:"},
+	"annotate.shrink":     {Other: "schrumpft um %d%% (von %s auf %s)"},
+	"annotate.top_delete": {One: "Verzeichnis der obersten Ebene wird mit %d Eintrag gelöscht", Other: "Verzeichnis der obersten Ebene wird mit %d Einträgen gelöscht"},
+	"annotate.exec_on":    {Other: "wird ausführbar"},
+	"annotate.exec_off":   {Other: "ist nicht mehr ausführbar"},
+	"annotate.churn":      {Other: "der Plan ändert %d%% der Dateien im Ziel (%d von %d)"},
+
+	// Confirmation
+	"confirm.deletes":    {One: "Der Plan löscht %d Eintrag, mehr als die %d ohne Bestätigung erlaubten.", Other: "Der Plan löscht %d Einträge, mehr als die %d ohne Bestätigung erlaubten."},
+	"confirm.changes":    {One: "Der Plan hat %d Aktion, mehr als die %d ohne Bestätigung erlaubten.", Other: "Der Plan hat %d Aktionen, mehr als die %d ohne Bestätigung erlaubten."},
+	"confirm.unusual":    {Other: "Bestätigung erforderlich, weil der Plan ungewöhnliche Änderungen enthält."},
+	"confirm.skipped":    {Other: "Fortfahren ohne Bestätigung."},
+	"prompt.proceed":     {Other: "Mit der Synchronisierung fortfahren? [J/n]: "},
+	"prompt.yes":         {Other: "j,ja"},
+	"prompt.aborted":     {Other: "Synchronisierung vom Benutzer abgebrochen."},
+	"prompt.read_failed": {Other: "Bestätigung konnte nicht gelesen werden: %w"},
+
+	// Target and source guards
+	"guard.target_missing":   {Other: "Ziel %s existiert nicht (ist das Laufwerk eingehängt?)"},
+	"guard.not_mounted":      {Other: "Ziel %s ist kein Einhängepunkt (ist das Laufwerk eingehängt?); es wird nicht in das darunterliegende Dateisystem synchronisiert"},
+	"guard.marker_missing":   {Other: "Markierungsdatei %s nicht gefunden (ist das Laufwerk eingehängt?); legen Sie sie auf dem vorgesehenen Laufwerk an, um dorthin zu synchronisieren"},
+	"guard.source_empty":     {Other: "die Quelle enthält %d Datei(en), weniger als das Minimum von %d, aber der Plan löscht %d Eintrag/Einträge im Ziel; ist die Quelle eingehängt? Mit --force trotzdem synchronisieren"},
+	"guard.mass_change":      {Other: "der Plan ersetzt %d der %d Dateien im Ziel durch Dateien mit neuem Inhalt und neuer Endung (meist %s), wie es Ransomware täte, die die Quelle verschlüsselt hat; prüfen Sie die Quelle und verwenden Sie --mass-change-guard=warn, wenn die Änderung gewollt ist"},
+	"guard.mass_change_warn": {Other: "Der Plan ersetzt %d der %d Dateien im Ziel durch Dateien mit neuem Inhalt und neuer Endung (meist %s), wie es Ransomware täte, die die Quelle verschlüsselt hat."},
+	"guard.no_extension":     {Other: "keine"},
+
+	// Zielkontingent
+	"quota.usage_quota":    {Other: "Belegung des Ziels: jetzt %s, nach der Synchronisierung %s (%.0f%% des Kontingents von %s)"},
+	"quota.usage_free":     {Other: "Belegung des Ziels: jetzt %s, nach der Synchronisierung %s (%s frei im Dateisystem)"},
+	"quota.deletes_first":  {Other: "Das Ziel ist nahe an seiner Grenze; Löschungen laufen, bevor etwas kopiert wird."},
+	"quota.exceeded":       {Other: "der Plan würde das Ziel auf %s bringen, über sein Kontingent von %s; schaffen Sie Platz im Ziel oder erhöhen Sie --target-quota"},
+	"quota.no_space":       {Other: "Der Plan fügt dem Ziel %s hinzu, aber sein Dateisystem hat nur %s frei."},
+	"quota.action_refused": {Other: "%s nicht kopiert: das Ziel würde %s erreichen, über sein Kontingent von %s"},
+
+	// Inhaltstypen
+	"plan.type_excluded": {One: "%d Quelldatei wegen ihres Inhaltstyps ausgelassen (--include-type/--exclude-type).", Other: "%d Quelldateien wegen ihres Inhaltstyps ausgelassen (--include-type/--exclude-type)."},
+
+	// Aktionsregeln
+	"plan.kept_by_rule": {One: "%d in der Quelle fehlendes Element des Ziels wegen skip-delete-Regeln behalten.", Other: "%d in der Quelle fehlende Elemente des Ziels wegen skip-delete-Regeln behalten."},
+
+	// Namen im Ziel
+	"names.problems":      {One: "%d Quellpfad kann auf dem %s-Dateisystem des Ziels nicht unverändert angelegt werden:", Other: "%d Quellpfade können auf dem %s-Dateisystem des Ziels nicht unverändert angelegt werden:"},
+	"names.more":          {One: "... und %d weiterer", Other: "... und %d weitere"},
+	"names.hint":          {Other: "Mit --sanitize-names werden sie unter den vorgeschlagenen Namen synchronisiert."},
+	"names.sanitized":     {One: "%d Quellpfad unter einem Namen synchronisiert, den das %s-Dateisystem des Ziels akzeptiert (--sanitize-names).", Other: "%d Quellpfade unter Namen synchronisiert, die das %s-Dateisystem des Ziels akzeptiert (--sanitize-names)."},
+	"names.restored":      {One: "%d Pfad unter dem ursprünglichen Namen aus der %s der Quelle synchronisiert.", Other: "%d Pfade unter den ursprünglichen Namen aus der %s der Quelle synchronisiert."},
+	"names.invalid_char":  {Other: "ungültiges Zeichen %q"},
+	"names.reserved":      {Other: "reservierter Name"},
+	"names.trailing":      {Other: "endet mit Punkt oder Leerzeichen"},
+	"names.name_too_long": {Other: "Name länger als %d Zeichen"},
+	"names.path_too_long": {Other: "Pfad länger als %d Zeichen"},
+
+	// Zielspezifikation
+	"spec.loaded":        {One: "Nur der %d in der %s des Ziels aufgeführte Pfad wird gespiegelt.", Other: "Nur die %d in der %s des Ziels aufgeführten Pfade werden gespiegelt."},
+	"spec.merge_ignored": {Other: "Die %s des Ziels wird beim Zusammenführen mehrerer Quellen ignoriert."},
+
+	// Saatverzeichnis
+	"seed.result": {One: "Saat: %d Datei (%s) aus dem Saatverzeichnis statt aus der Quelle übernommen, %d fest verlinkt.", Other: "Saat: %d Dateien (%s) aus dem Saatverzeichnis statt aus der Quelle übernommen, %d fest verlinkt."},
+
+	// Disk images
+	"image.created":   {Other: "%s-Abbild %s erstellt"},
+	"image.mounted":   {Other: "Abbild %s in %s eingehängt"},
+	"image.unmounted": {Other: "Abbild %s ausgehängt"},
+
+	// --spot-check
+	"spotcheck.sampled": {One: "Stichprobe: %d geprüfte Kopie von %d stimmt mit der Quelle überein; mit 95 %% Konfidenz sind weniger als %s der Kopien fehlerhaft.", Other: "Stichprobe: %d geprüfte Kopien von %d stimmen mit der Quelle überein; mit 95 %% Konfidenz sind weniger als %s der Kopien fehlerhaft."},
+	"spotcheck.all":     {Other: "Stichprobe: alle kopierten Dateien (%d) stimmen mit der Quelle überein."},
+	"spotcheck.bad":     {One: "Stichprobe: %d von %d geprüften Kopien stimmt nicht mit der Quelle überein (sie wird beim nächsten Sync erneut kopiert):", Other: "Stichprobe: %d von %d geprüften Kopien stimmen nicht mit der Quelle überein (sie werden beim nächsten Sync erneut kopiert):"},
+
+	// --assert-in-sync
+	"assert.in_sync":     {Other: "Quelle und Ziel sind synchron."},
+	"assert.differences": {One: "%d Unterschied zwischen Quelle und Ziel:", Other: "%d Unterschiede zwischen Quelle und Ziel:"},
+
+	// Verzeichniszeiten
+	"dirtimes.restored": {One: "Änderungszeit von %d Verzeichnis wiederhergestellt.", Other: "Änderungszeiten von %d Verzeichnissen wiederhergestellt."},
+
+	// Sicherheitskontexte, Capabilities und --fake-super
+	"security.labeled":   {One: "%d Element hat den SELinux-Kontext seiner Quelle erhalten.", Other: "%d Elemente haben die SELinux-Kontexte ihrer Quellen erhalten."},
+	"security.capped":    {One: "%d Datei hat die Capabilities ihrer Quelle erhalten.", Other: "%d Dateien haben die Capabilities ihrer Quellen erhalten."},
+	"fakesuper.recorded": {One: "Eigentümer und Berechtigungen von %d Element für --from-fake-super festgehalten.", Other: "Eigentümer und Berechtigungen von %d Elementen für --from-fake-super festgehalten."},
+	"fakesuper.applied":  {One: "Festgehaltene Eigentümer und Berechtigungen von %d Element wiederhergestellt.", Other: "Festgehaltene Eigentümer und Berechtigungen von %d Elementen wiederhergestellt."},
+
+	// Dedupe and content-addressed backups
+	"dedupe.result":    {Other: "Deduplizierung: %d Dateien in %d Gruppen durch harte Links ersetzt, %s freigegeben."},
+	"backup.comparing": {Other: "Vergleiche mit Schnappschuss %s"},
+	"backup.dry_run":   {Other: "Sicherung (Probelauf): %d Dateien, %d mit neuem Inhalt (%s), %d bereits gespeichert."},
+	"backup.result":    {Other: "Schnappschuss %s: %d Dateien, %d mit neuem Inhalt (%s gespeichert), %d bereits gespeichert."},
+
+	// Summary reports and notifications
+	"summary.failed":   {One: "fehlgeschlagen (%d Fehler)", Other: "fehlgeschlagen (%d Fehler)"},
+	"summary.dry_run":  {Other: "Probelauf, nichts geändert"},
+	"summary.in_sync":  {Other: "bereits synchron"},
+	"summary.aborted":  {Other: "abgebrochen, nichts geändert"},
+	"summary.success":  {Other: "erfolgreich"},
+	"summary.profile":  {Other: "Profil"},
+	"summary.source":   {Other: "Quelle"},
+	"summary.target":   {Other: "Ziel"},
+	"summary.started":  {Other: "Beginn"},
+	"summary.duration": {Other: "Dauer"},
+	"summary.changes":  {Other: "Änderungen"},
+	"summary.counts":   {Other: "%d hinzugefügt, %d aktualisiert, %d gelöscht, %d umbenannt"},
+	"summary.added":    {Other: "Hinzugefügt"},
+	"summary.updated":  {Other: "Aktualisiert"},
+	"summary.deleted":  {Other: "Gelöscht"},
+	"summary.renamed":  {Other: "Umbenannt"},
+	"summary.skipped":  {Other: "Unverändert"},
+	"summary.copied":   {Other: "Kopiert"},
+	"summary.errors":   {Other: "Fehler"},
+	"notify.counts":    {Other: "%d hinzugefügt, %d aktualisiert, %d gelöscht, %d umbenannt, %s kopiert in %s"},
+	"notify.error":     {Other: "Fehler"},
+
+	// Operation stats in summary reports
+	"summary.operations": {Other: "Operationen"},
+	"summary.op":         {Other: "%s %d (Ø %s)"},
+	"summary.op_rate":    {Other: "%s %d (Ø %s, %s/s)"},
+
+	// Files left alone as identical
+	"unchanged.counts":      {Other: "%d von %d verglichenen Dateien"},
+	"unchanged.size_mtime":  {Other: "%d nach Größe und Änderungszeit"},
+	"unchanged.tolerance":   {Other: "%d innerhalb der Zeittoleranz"},
+	"unchanged.checksum":    {Other: "%d nach Prüfsumme"},
+	"unchanged.transformed": {Other: "%d transformiert, nach Änderungszeit"},
+
+	// status
+	"sample.more":           {Other: "... und %d weitere"},
+	"status.succeeded":      {Other: "erfolgreich"},
+	"status.failed":         {Other: "fehlgeschlagen: %s"},
+	"status.dry_run":        {Other: "(Probelauf)"},
+	"status.last_run":       {Other: "Letzter Lauf: %s, %s"},
+	"status.interrupted":    {Other: "Warnung: Die letzte Synchronisierung wurde vor dem Ende unterbrochen."},
+	"status.never_synced":   {Other: "Für dieses Paar ist noch keine erfolgreiche Synchronisierung verzeichnet."},
+	"status.last_sync":      {Other: "Letzte erfolgreiche Synchronisierung: %s"},
+	"status.source_changes": {Other: "Änderungen an der Quelle seit der letzten Synchronisierung"},
+	"status.target_drift":   {Other: "Abweichungen im Ziel (Änderungen von außen)"},
+	"status.drift_counts":   {Other: "%d hinzugefügt, %d geändert, %d entfernt"},
+	"status.no_drift":       {Other: "Keine Abweichung: Quelle und Ziel sind seit der letzten Synchronisierung unverändert."},
+
+	// preflight
+	"preflight.checked":      {Other: "%d Quelldateien auf Lesbarkeit und %d Zielverzeichnisse auf Schreibbarkeit geprüft."},
+	"preflight.scan_skipped": {One: "%d Eintrag konnte beim Durchsuchen nicht gelesen werden (siehe Warnungen oben).", Other: "%d Einträge konnten beim Durchsuchen nicht gelesen werden (siehe Warnungen oben)."},
+	"preflight.unreadable":   {Other: "Nicht lesbare Quelldateien"},
+	"preflight.not_writable": {Other: "Nicht beschreibbare Zielverzeichnisse"},
+	"preflight.ok":           {Other: "Keine Probleme gefunden."},
+	"preflight.problems":     {Other: "%d Problem(e) gefunden"},
+
+	// preview
+	"preview.kept":    {Other: "unverändert"},
+	"preview.extra":   {Other: "nur im Ziel"},
+	"preview.added":   {Other: "neu"},
+	"preview.updated": {Other: "aktualisiert"},
+	"preview.renamed": {Other: "umbenannt"},
+	"preview.totals":  {Other: "Nach der Synchronisierung enthielte das Ziel %d Dateien und %d Verzeichnisse, insgesamt %s."},
+
+	// cross-check
+	"crosscheck.replica_a": {Other: "Replikat A: %s"},
+	"crosscheck.replica_b": {Other: "Replikat B: %s"},
+	"crosscheck.only_a":    {Other: "Nur in A"},
+	"crosscheck.only_b":    {Other: "Nur in B"},
+	"crosscheck.newer_a":   {Other: "Abweichend, neuer in A"},
+	"crosscheck.newer_b":   {Other: "Abweichend, neuer in B"},
+	"crosscheck.identical": {One: "%d Datei ist in beiden Replikaten identisch.", Other: "%d Dateien sind in beiden Replikaten identisch."},
+	"crosscheck.latest":    {Other: "Neueste Datei in A: %s; in B: %s"},
+	"crosscheck.none":      {Other: "keine"},
+	"crosscheck.in_sync":   {Other: "Die Replikate sind synchron."},
+	"crosscheck.stale_a":   {Other: "Replikat A scheint veraltet: B enthält neuere Änderungen, die A fehlen."},
+	"crosscheck.stale_b":   {Other: "Replikat B scheint veraltet: A enthält neuere Änderungen, die B fehlen."},
+	"crosscheck.diverged":  {Other: "Die Replikate sind auseinandergelaufen: Jedes enthält Änderungen, die dem anderen fehlen."},
+	"crosscheck.differ":    {Other: "die Replikate unterscheiden sich in %d Element(en)"},
+
+	// scrub
+	"scrub.checked":        {Other: "%d Dateien geprüft: %d bestätigt, %d neu erfasst, %d beschädigt."},
+	"scrub.modified":       {Other: "Seit der letzten Prüfung geändert (Größe oder Zeitstempel geändert, neu erfasst): %d"},
+	"scrub.missing":        {Other: "Seit der letzten Prüfung verschwunden: %d"},
+	"scrub.unreadable":     {Other: "Nicht lesbar: %d"},
+	"scrub.report_written": {Other: "Bericht nach %s geschrieben"},
+	"scrub.no_damage":      {Other: "Keine beschädigten Dateien gefunden."},
+	"scrub.damaged":        {Other: "BESCHÄDIGTE Dateien (Inhalt geändert ohne Änderung von Größe oder Zeitstempel):"},
+	"scrub.bytes":          {One: "%d Byte", Other: "%d Bytes"},
+	"scrub.expected":       {Other: "erwartet"},
+	"scrub.actual":         {Other: "tatsächlich"},
+	"scrub.damaged_error":  {Other: "%d beschädigte Datei(en) in %s gefunden"},
+
+	// clean
+	"clean.removed":       {Other: "Entfernt: %s"},
+	"clean.would_remove":  {Other: "Würde entfernen: %s"},
+	"clean.kept":          {Other: "Behalten (möglicherweise in Benutzung): %s"},
+	"clean.total":         {Other: "%d verwaiste temporäre Datei(en) entfernt, %d behalten."},
+	"clean.total_dry_run": {Other: "Würde %d verwaiste temporäre Datei(en) entfernen, %d behalten."},
+
+	// gc
+	"gc.store":         {Other: "Speicher: %s"},
+	"gc.removed":       {Other: "Schnappschuss %s entfernt"},
+	"gc.would_remove":  {Other: "Würde Schnappschuss %s entfernen"},
+	"gc.kept":          {Other: "%d Schnappschuss/Schnappschüsse behalten, %d entfernt."},
+	"gc.kept_dry_run":  {Other: "%d Schnappschuss/Schnappschüsse behalten, würde %d entfernen."},
+	"gc.swept":         {Other: "%d nicht referenzierte(s) Objekt(e), %d Chunk-Liste(n) und %d temporäre Datei(en) entfernt: %s."},
+	"gc.swept_dry_run": {Other: "Würde %d nicht referenzierte(s) Objekt(e), %d Chunk-Liste(n) und %d temporäre Datei(en) entfernen: %s."},
+
+	// heartbeat
+	"heartbeat.line": {Other: "Läuft seit %s: %s, %s/s seit der letzten Meldung"},
+
+	// ctl
+	"ctl.socket":        {Other: "Steuer-Socket: %s"},
+	"ctl.unreachable":   {Other: "nicht erreichbar (%v)"},
+	"ctl.job":           {Other: "PID %d, Auftrag %s, %s"},
+	"ctl.none":          {Other: "Keine laufenden Synchronisierungen mit Steuer-Socket."},
+	"ctl.done":          {Other: "fertig (der Synchronisierungsprozess wurde beendet)"},
+	"ctl.label_job":     {Other: "Auftrag:"},
+	"ctl.label_source":  {Other: "Quelle:"},
+	"ctl.label_target":  {Other: "Ziel:"},
+	"ctl.label_started": {Other: "Beginn:"},
+	"ctl.label_status":  {Other: "Status:"},
+	"ctl.label_limits":  {Other: "Grenzen:"},
+	"ctl.unlimited":     {Other: "unbegrenzt"},
+	"ctl.automatic":     {Other: "automatisch"},
+	"ctl.limits":        {Other: "Worker %s, Bandbreite %s"},
+	"ctl.paused":        {Other: "(pausiert)"},
+	"ctl.progress":      {Other: "%s: %d/%d Aktionen, %s/%s"},
+
+	// Run control (pause, ctl set)
+	"control.paused":           {Other: "Pausiert: Die Worker halten nach ihrer aktuellen Datei an. Fortsetzen, um weiterzumachen."},
+	"control.resumed":          {Other: "Fortgesetzt."},
+	"control.workers":          {Other: "Parallele Operationen auf %d gesetzt."},
+	"control.workers_range":    {Other: "die Anzahl der Worker muss zwischen 1 und %d liegen"},
+	"control.bwlimit":          {Other: "Bandbreitenbegrenzung auf %s/s gesetzt."},
+	"control.bwlimit_removed":  {Other: "Bandbreitenbegrenzung aufgehoben."},
+	"control.bwlimit_negative": {Other: "die Bandbreitenbegrenzung darf nicht negativ sein"},
+
+	// Warnings and notes of a run
+	"journal.resuming":       {Other: "Hinweis: Die vorige Synchronisierung dieses Paars wurde nicht beendet; dieser Lauf schließt sie ab."},
+	"journal.start_failed":   {Other: "Warnung: Das Synchronisierungsjournal konnte nicht gestartet werden: %v"},
+	"stage.remove_failed":    {Other: "Warnung: Das Staging-Verzeichnis %s konnte nicht entfernt werden: %v"},
+	"stage.discarded":        {Other: "Hinweis: Nicht alle Aktionen waren erfolgreich, daher wurde das vorbereitete Ziel verworfen; das Ziel ist unverändert."},
+	"tempdir.other_fs":       {Other: "Hinweis: Das temporäre Verzeichnis %s liegt nicht im Dateisystem des Ziels; Dateien werden dort vorbereitet und dann an ihren Platz kopiert."},
+	"hotdb.warning":          {One: "Warnung: %d Datei sieht wie eine Datenbank in Benutzung aus; eine Kopie, die während des Schreibens entsteht, kann beschädigt sein:", Other: "Warnung: %d Dateien sehen wie Datenbanken in Benutzung aus; Kopien, die während des Schreibens entstehen, können beschädigt sein:"},
+	"hotdb.hint":             {Other: "Beenden Sie die Anwendung oder nutzen Sie ihr Sicherungswerkzeug, überspringen Sie sie mit --skip-hot-databases oder unterdrücken Sie dies mit --allow-hot-databases."},
+	"hotdb.skipping":         {Other: "Sie werden übersprungen (--skip-hot-databases); ihre Kopien im Ziel bleiben unverändert."},
+	"openfiles.check_failed": {Other: "Warnung: Zum Schreiben geöffnete Dateien konnten nicht geprüft werden: %v"},
+	"openfiles.warning":      {One: "Warnung: %d Datei ist von einem anderen Prozess zum Schreiben geöffnet; eine Kopie, die während des Schreibens entsteht, kann unvollständig sein:", Other: "Warnung: %d Dateien sind von anderen Prozessen zum Schreiben geöffnet; Kopien, die während des Schreibens entstehen, können unvollständig sein:"},
+	"openfiles.hint":         {Other: "Lassen Sie sie zuerst fertig werden oder überspringen Sie sie mit --skip-open-files."},
+	"openfiles.skipping":     {Other: "Sie werden übersprungen (--skip-open-files); ihre Kopien im Ziel bleiben unverändert."},
+	"memlimit.spill_failed":  {Other: "Warnung: Der Prüfsummen-Cache konnte nicht auf die Festplatte ausgelagert werden: %v"},
+	"memlimit.spilled":       {Other: "Hinweis: Der Speicherverbrauch nähert sich --mem-limit; der Prüfsummen-Cache wurde auf die Festplatte ausgelagert."},
+}
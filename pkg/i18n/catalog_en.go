@@ -0,0 +1,336 @@
+// pkg/i18n/catalog_en.go
+package i18n
+
+// en holds the English messages. Every key must be here: the other catalogs
+// fall back to them.
+var en = map[string]Message{
+	// Sync run
+	"sync.source":          {Other: "Source: %s"},
+	"sync.target":          {Other: "Target: %s"},
+	"sync.excludes":        {Other: "CLI Exclusions: %v"},
+	"sync.presets":         {Other: "Exclusion presets: %s"},
+	"sync.gitignore":       {Other: "Respecting .gitignore files"},
+	"sync.dry_run_mode":    {Other: "--- DRY RUN MODE ---"},
+	"sync.starting":        {Other: "Starting synchronization..."},
+	"sync.finished":        {Other: "Synchronization finished successfully."},
+	"sync.finished_errors": {Other: "synchronization finished with %d error(s):\n- %s"},
+	"sync.completed":       {Other: "Sync completed successfully."},
+	"sync.dry_run_note":    {Other: "(Dry run - no changes were actually made)"},
+	"sync.failed":          {Other: "sync failed: %w"},
+
+	// Scanning
+	"role.source":             {Other: "source"},
+	"role.source_n":           {Other: "source %s"},
+	"role.target":             {Other: "target"},
+	"scan.stats":              {Other: "Scan of %s (%s): %d files, %d directories, %d symlinks, %s"},
+	"scan.ignored":            {Other: "%d ignored by exclude rules, %d unreadable"},
+	"scan.finished":           {One: "Finished scanning %s. Found %d item.", Other: "Finished scanning %s. Found %d items."},
+	"scan.target_error":       {Other: "Could not scan the target directory"},
+	"scan.reused":             {One: "Reused %d cached entry for %s.", Other: "Reused %d cached entries for %s."},
+	"ignore.loaded":           {Other: "Loaded %d patterns from %s"},
+	"ignore.loaded_gitignore": {Other: "Loaded %d patterns from %d %s files"},
+
+	// Progress bars
+	"progress.scanning":      {Other: "Scanning %s..."},
+	"progress.planning":      {Other: "Planning..."},
+	"progress.comparing":     {Other: "Comparing files..."},
+	"progress.syncing":       {Other: "Syncing files..."},
+	"progress.deduplicating": {Other: "Deduplicating..."},
+	"progress.backing_up":    {Other: "Backing up..."},
+	"progress.verifying":     {Other: "Verifying files..."},
+
+	// Plan
+	"plan.comparing":       {Other: "Comparing source and target..."},
+	"plan.case_difference": {Other: "Case-only difference: %s -> %s"},
+	"plan.complete":        {Other: "Comparison complete. Plan: %d Adds, %d Updates, %d Deletes, %d Renames."},
+	"plan.unchanged":       {Other: "Unchanged: %s"},
+	"plan.none":            {Other: "No actions needed. Source and target are already in sync."},
+	"plan.header":          {Other: "--- Sync Plan ---"},
+	"plan.counts":          {Other: "Adds: %d, Updates: %d, Deletes: %d, Renames: %d"},
+	"plan.samples":         {Other: "Sample actions:"},
+	"plan.case_only":       {Other: "%s -> %s (case only)"},
+	"plan.more":            {One: "... and %d more action", Other: "... and %d more actions"},
+	"plan.filtered":        {One: "... %d action of other types not listed (--plan-filter)", Other: "... %d actions of other types not listed (--plan-filter)"},
+	"plan.to_trash":        {Other: "Deleted items will be moved to the trash."},
+	"plan.dry_run":         {Other: "Dry run: No changes will be made."},
+	"action.add":           {Other: "ADD"},
+	"action.update":        {Other: "UPDATE"},
+	"action.delete":        {Other: "DELETE"},
+	"action.rename":        {Other: "RENAME"},
+	"action.none":          {Other: "NONE"},
+	"disk.shared":          {One: "Source and target share a device (%s); using %d parallel operation.", Other: "Source and target share a device (%s); using %d parallel operations."},
+	"disk.unknown":         {Other: "unknown type"},
+	"disk.rotational":      {Other: "rotational"},
+	"disk.non_rotational":  {Other: "non-rotational"},
+
+	// --explain
+	"explain.header":            {Other: "Why:"},
+	"explain.case_rename":       {Other: "renamed from %s to %s: the names differ only in case and the target is case-insensitive"},
+	"explain.not_in_target":     {Other: "not in the target"},
+	"explain.replaces":          {Other: "the target has a %s where the source has a %s"},
+	"explain.not_in_source":     {Other: "not in the source"},
+	"explain.replaced":          {Other: "a %s in the target, a %s in the source"},
+	"explain.size":              {Other: "size differs (source %s, target %s)"},
+	"explain.checksums":         {Other: "same size, mtime differs by %s, checksums differ"},
+	"explain.always_hash":       {Other: "same size, checksums differ (--always-hash)"},
+	"explain.transformed_mtime": {Other: "transformed on copy, mtime differs by %s"},
+	"explain.same_size_mtime":   {Other: "same size and mtime"},
+	"explain.tolerance":         {Other: "same size, mtimes %s apart, within the tolerance of %s"},
+	"explain.same_checksum":     {Other: "same size and checksum (mtimes %s apart)"},
+	"explain.transformed_same":  {Other: "transformed on copy, the target carries the source mtime"},
+	"explain.directories":       {Other: "a directory on both sides"},
+	"explain.deleted_with":      {Other: "deleted with its directory %s"},
+	"explain.outside_spec":      {Other: "not listed in the target's %s"},
+	"explain.excluded":          {Other: "excluded by the exclude rules"},
+	"explain.not_found":         {Other: "in neither the source nor the target"},
+	"explain.left_out":          {Other: "left out of the plan (e.g. a database in use, see --skip-hot-databases)"},
+	"explain.rule_skip_delete":  {Other: "not in the source, kept by a skip-delete rule"},
+	"explain.rule_always_copy":  {Other: "matches an always-copy rule"},
+	"explain.file":              {Other: "file"},
+	"explain.directory":         {Other: "directory"},
+
+	// Unusual changes
+	"annotate.header":     {One: "%d unusual change in this plan, please review:", Other: "%d unusual changes in this plan, please review:"},
+	"annotate.shrink":     {Other: "shrinks by %d%% (%s to %s)"},
+	"annotate.top_delete": {One: "top-level directory deleted with %d item inside", Other: "top-level directory deleted with %d items inside"},
+	"annotate.exec_on":    {Other: "becomes executable"},
+	"annotate.exec_off":   {Other: "is no longer executable"},
+	"annotate.churn":      {Other: "the plan changes %d%% of the target's files (%d of %d)"},
+
+	// Confirmation
+	"confirm.deletes":    {One: "Plan deletes %d item, more than the %d allowed without confirmation.", Other: "Plan deletes %d items, more than the %d allowed without confirmation."},
+	"confirm.changes":    {One: "Plan has %d action, more than the %d allowed without confirmation.", Other: "Plan has %d actions, more than the %d allowed without confirmation."},
+	"confirm.unusual":    {Other: "Asking for confirmation because the plan has unusual changes."},
+	"confirm.skipped":    {Other: "Proceeding without confirmation."},
+	"prompt.proceed":     {Other: "Proceed with synchronization? [Y/n]: "},
+	"prompt.yes":         {Other: "y,yes"},
+	"prompt.aborted":     {Other: "Synchronization aborted by user."},
+	"prompt.read_failed": {Other: "failed to read confirmation: %w"},
+
+	// Target and source guards
+	"guard.target_missing":   {Other: "target %s does not exist (is the volume mounted?)"},
+	"guard.not_mounted":      {Other: "target %s is not a mount point (is the volume mounted?); refusing to sync onto the filesystem underneath"},
+	"guard.marker_missing":   {Other: "marker file %s not found (is the volume mounted?); create it on the intended volume to allow syncing there"},
+	"guard.source_empty":     {Other: "the source holds %d file(s), fewer than the minimum of %d, but the plan deletes %d item(s) from the target; is the source mounted? Use --force to sync anyway"},
+	"guard.mass_change":      {Other: "the plan replaces %d of the target's %d files by files with new content under a new extension (mostly %s), as ransomware encrypting the source would; check the source, then use --mass-change-guard=warn if the change is intended"},
+	"guard.mass_change_warn": {Other: "The plan replaces %d of the target's %d files by files with new content under a new extension (mostly %s), as ransomware encrypting the source would."},
+	"guard.no_extension":     {Other: "none"},
+
+	// Target quota
+	"quota.usage_quota":    {Other: "Target usage: %s now, %s after sync (%.0f%% of the %s quota)"},
+	"quota.usage_free":     {Other: "Target usage: %s now, %s after sync (%s free on its filesystem)"},
+	"quota.deletes_first":  {Other: "Target is near its limit; deletions run before anything is copied."},
+	"quota.exceeded":       {Other: "the plan would bring the target to %s, over its quota of %s; free up space in the target or raise --target-quota"},
+	"quota.no_space":       {Other: "The plan adds %s to the target, but its filesystem only has %s free."},
+	"quota.action_refused": {Other: "refused to copy %s: the target would reach %s, over its quota of %s"},
+
+	// Content types
+	"plan.type_excluded": {One: "%d source file left out by content type (--include-type/--exclude-type).", Other: "%d source files left out by content type (--include-type/--exclude-type)."},
+
+	// Action rules
+	"plan.kept_by_rule": {One: "%d target item missing from the source kept by skip-delete rules.", Other: "%d target items missing from the source kept by skip-delete rules."},
+
+	// Target names
+	"names.problems":      {One: "%d source path can't be created as is on the target's %s filesystem:", Other: "%d source paths can't be created as is on the target's %s filesystem:"},
+	"names.more":          {Other: "... and %d more"},
+	"names.hint":          {Other: "Run with --sanitize-names to sync them under the suggested names."},
+	"names.sanitized":     {One: "%d source path synced under a name the target's %s filesystem accepts (--sanitize-names).", Other: "%d source paths synced under names the target's %s filesystem accepts (--sanitize-names)."},
+	"names.restored":      {One: "%d path synced under the original name recorded in the source's %s.", Other: "%d paths synced under the original names recorded in the source's %s."},
+	"names.invalid_char":  {Other: "invalid character %q"},
+	"names.reserved":      {Other: "reserved name"},
+	"names.trailing":      {Other: "ends in a dot or space"},
+	"names.name_too_long": {Other: "name longer than %d characters"},
+	"names.path_too_long": {Other: "path longer than %d characters"},
+
+	// Target spec
+	"spec.loaded":        {One: "Mirroring only the %d path listed in the target's %s.", Other: "Mirroring only the %d paths listed in the target's %s."},
+	"spec.merge_ignored": {Other: "The target's %s is ignored when merging several sources."},
+
+	// Seed directory
+	"seed.result": {One: "Seed: %d file (%s) taken from the seed directory instead of the source, %d hard linked.", Other: "Seed: %d files (%s) taken from the seed directory instead of the source, %d hard linked."},
+
+	// Disk images
+	"image.created":   {Other: "Created %s image %s"},
+	"image.mounted":   {Other: "Mounted image %s on %s"},
+	"image.unmounted": {Other: "Unmounted image %s"},
+
+	// --spot-check
+	"spotcheck.sampled": {One: "Spot check: %d sampled copy of %d matches the source; with 95%% confidence fewer than %s of the copies are bad.", Other: "Spot check: %d sampled copies of %d match the source; with 95%% confidence fewer than %s of the copies are bad."},
+	"spotcheck.all":     {Other: "Spot check: every copied file (%d) matches the source."},
+	"spotcheck.bad":     {One: "Spot check: %d of %d sampled copies does not match the source (it is copied again by the next sync):", Other: "Spot check: %d of %d sampled copies do not match the source (they are copied again by the next sync):"},
+
+	// --assert-in-sync
+	"assert.in_sync":     {Other: "Source and target are in sync."},
+	"assert.differences": {One: "%d difference between source and target:", Other: "%d differences between source and target:"},
+
+	// Directory times
+	"dirtimes.restored": {One: "Restored the modification time of %d directory.", Other: "Restored the modification times of %d directories."},
+
+	// Security contexts, capabilities and --fake-super
+	"security.labeled":   {One: "Gave %d item the SELinux context of its source.", Other: "Gave %d items the SELinux contexts of their sources."},
+	"security.capped":    {One: "Gave %d file the capabilities of its source.", Other: "Gave %d files the capabilities of their sources."},
+	"fakesuper.recorded": {One: "Recorded the owner and permissions of %d item for --from-fake-super.", Other: "Recorded the owners and permissions of %d items for --from-fake-super."},
+	"fakesuper.applied":  {One: "Restored the recorded owner and permissions of %d item.", Other: "Restored the recorded owners and permissions of %d items."},
+
+	// Dedupe and content-addressed backups
+	"dedupe.result":    {Other: "Dedupe: %d files in %d groups replaced by hard links, %s reclaimed."},
+	"backup.comparing": {Other: "Comparing with snapshot %s"},
+	"backup.dry_run":   {Other: "Backup (dry run): %d files, %d with new content (%s), %d already stored."},
+	"backup.result":    {Other: "Snapshot %s: %d files, %d with new content (%s stored), %d already stored."},
+
+	// Summary reports and notifications
+	"summary.failed":   {One: "failed (%d error)", Other: "failed (%d errors)"},
+	"summary.dry_run":  {Other: "dry run, nothing changed"},
+	"summary.in_sync":  {Other: "already in sync"},
+	"summary.aborted":  {Other: "aborted, nothing changed"},
+	"summary.success":  {Other: "success"},
+	"summary.profile":  {Other: "Profile"},
+	"summary.source":   {Other: "Source"},
+	"summary.target":   {Other: "Target"},
+	"summary.started":  {Other: "Started"},
+	"summary.duration": {Other: "Duration"},
+	"summary.changes":  {Other: "Changes"},
+	"summary.counts":   {Other: "%d added, %d updated, %d deleted, %d renamed"},
+	"summary.added":    {Other: "Added"},
+	"summary.updated":  {Other: "Updated"},
+	"summary.deleted":  {Other: "Deleted"},
+	"summary.renamed":  {Other: "Renamed"},
+	"summary.skipped":  {Other: "Unchanged"},
+	"summary.copied":   {Other: "Copied"},
+	"summary.errors":   {Other: "Errors"},
+	"notify.counts":    {Other: "%d added, %d updated, %d deleted, %d renamed, %s copied in %s"},
+	"notify.error":     {Other: "error"},
+
+	// Operation stats in summary reports
+	"summary.operations": {Other: "Operations"},
+	"summary.op":         {Other: "%s %d (%s avg)"},
+	"summary.op_rate":    {Other: "%s %d (%s avg, %s/s)"},
+
+	// Files left alone as identical
+	"unchanged.counts":      {Other: "%d of %d compared files"},
+	"unchanged.size_mtime":  {Other: "%d by size and mtime"},
+	"unchanged.tolerance":   {Other: "%d within the mtime tolerance"},
+	"unchanged.checksum":    {Other: "%d by checksum"},
+	"unchanged.transformed": {Other: "%d transformed, by mtime"},
+
+	// status
+	"sample.more":           {Other: "... and %d more"},
+	"status.succeeded":      {Other: "succeeded"},
+	"status.failed":         {Other: "failed: %s"},
+	"status.dry_run":        {Other: "(dry run)"},
+	"status.last_run":       {Other: "Last run: %s, %s"},
+	"status.interrupted":    {Other: "Warning: The last sync was interrupted before it finished."},
+	"status.never_synced":   {Other: "No successful sync of this pair has been recorded yet."},
+	"status.last_sync":      {Other: "Last successful sync: %s"},
+	"status.source_changes": {Other: "Source changes since last sync"},
+	"status.target_drift":   {Other: "Target drift (out-of-band changes)"},
+	"status.drift_counts":   {Other: "%d added, %d modified, %d removed"},
+	"status.no_drift":       {Other: "No drift: source and target are unchanged since the last sync."},
+
+	// preflight
+	"preflight.checked":      {Other: "Checked %d source files for reading and %d target directories for writing."},
+	"preflight.scan_skipped": {One: "%d entry could not be read while scanning (see the warnings above).", Other: "%d entries could not be read while scanning (see the warnings above)."},
+	"preflight.unreadable":   {Other: "Unreadable source files"},
+	"preflight.not_writable": {Other: "Target directories that can't be written"},
+	"preflight.ok":           {Other: "No problems found."},
+	"preflight.problems":     {Other: "%d problem(s) found"},
+
+	// preview
+	"preview.kept":    {Other: "unchanged"},
+	"preview.extra":   {Other: "only in target"},
+	"preview.added":   {Other: "new"},
+	"preview.updated": {Other: "updated"},
+	"preview.renamed": {Other: "renamed"},
+	"preview.totals":  {Other: "After the sync the target would hold %d files and %d directories, %s in total."},
+
+	// cross-check
+	"crosscheck.replica_a": {Other: "Replica A: %s"},
+	"crosscheck.replica_b": {Other: "Replica B: %s"},
+	"crosscheck.only_a":    {Other: "Only in A"},
+	"crosscheck.only_b":    {Other: "Only in B"},
+	"crosscheck.newer_a":   {Other: "Differing, newer in A"},
+	"crosscheck.newer_b":   {Other: "Differing, newer in B"},
+	"crosscheck.identical": {One: "%d file is identical in both replicas.", Other: "%d files are identical in both replicas."},
+	"crosscheck.latest":    {Other: "Newest file in A: %s; in B: %s"},
+	"crosscheck.none":      {Other: "none"},
+	"crosscheck.in_sync":   {Other: "The replicas are in sync."},
+	"crosscheck.stale_a":   {Other: "Replica A looks stale: B holds newer changes that A lacks."},
+	"crosscheck.stale_b":   {Other: "Replica B looks stale: A holds newer changes that B lacks."},
+	"crosscheck.diverged":  {Other: "The replicas have diverged: each holds changes the other lacks."},
+	"crosscheck.differ":    {Other: "the replicas differ in %d item(s)"},
+
+	// scrub
+	"scrub.checked":        {Other: "Checked %d files: %d verified, %d newly recorded, %d damaged."},
+	"scrub.modified":       {Other: "Modified since the last scrub (size or mtime changed, re-recorded): %d"},
+	"scrub.missing":        {Other: "Missing since the last scrub: %d"},
+	"scrub.unreadable":     {Other: "Unreadable: %d"},
+	"scrub.report_written": {Other: "Report written to %s"},
+	"scrub.no_damage":      {Other: "No damaged files found."},
+	"scrub.damaged":        {Other: "DAMAGED files (content changed without a size or mtime change):"},
+	"scrub.bytes":          {One: "%d byte", Other: "%d bytes"},
+	"scrub.expected":       {Other: "expected"},
+	"scrub.actual":         {Other: "actual"},
+	"scrub.damaged_error":  {Other: "%d damaged file(s) found in %s"},
+
+	// clean
+	"clean.removed":       {Other: "Removed: %s"},
+	"clean.would_remove":  {Other: "Would remove: %s"},
+	"clean.kept":          {Other: "Kept (possibly in use): %s"},
+	"clean.total":         {Other: "Removed %d orphaned temp file(s), kept %d."},
+	"clean.total_dry_run": {Other: "Would remove %d orphaned temp file(s), kept %d."},
+
+	// gc
+	"gc.store":         {Other: "Store: %s"},
+	"gc.removed":       {Other: "Removed snapshot %s"},
+	"gc.would_remove":  {Other: "Would remove snapshot %s"},
+	"gc.kept":          {Other: "Kept %d snapshot(s), removed %d."},
+	"gc.kept_dry_run":  {Other: "Kept %d snapshot(s), would remove %d."},
+	"gc.swept":         {Other: "Removed %d unreferenced object(s), %d chunk list(s) and %d temp file(s): %s."},
+	"gc.swept_dry_run": {Other: "Would remove %d unreferenced object(s), %d chunk list(s) and %d temp file(s): %s."},
+
+	// heartbeat
+	"heartbeat.line": {Other: "Still running after %s: %s, %s/s since the last report"},
+
+	// ctl
+	"ctl.socket":        {Other: "Control socket: %s"},
+	"ctl.unreachable":   {Other: "unreachable (%v)"},
+	"ctl.job":           {Other: "pid %d, job %s, %s"},
+	"ctl.none":          {Other: "No running syncs with a control socket."},
+	"ctl.done":          {Other: "done (the sync process exited)"},
+	"ctl.label_job":     {Other: "Job:"},
+	"ctl.label_source":  {Other: "Source:"},
+	"ctl.label_target":  {Other: "Target:"},
+	"ctl.label_started": {Other: "Started:"},
+	"ctl.label_status":  {Other: "Status:"},
+	"ctl.label_limits":  {Other: "Limits:"},
+	"ctl.unlimited":     {Other: "unlimited"},
+	"ctl.automatic":     {Other: "automatic"},
+	"ctl.limits":        {Other: "workers %s, bandwidth %s"},
+	"ctl.paused":        {Other: "(paused)"},
+	"ctl.progress":      {Other: "%s: %d/%d actions, %s/%s"},
+
+	// Run control (pause, ctl set)
+	"control.paused":           {Other: "Paused: workers stop after their current file. Resume to continue."},
+	"control.resumed":          {Other: "Resumed."},
+	"control.workers":          {Other: "Parallel operations set to %d."},
+	"control.workers_range":    {Other: "worker count must be between 1 and %d"},
+	"control.bwlimit":          {Other: "Bandwidth limit set to %s/s."},
+	"control.bwlimit_removed":  {Other: "Bandwidth limit removed."},
+	"control.bwlimit_negative": {Other: "bandwidth limit cannot be negative"},
+
+	// Warnings and notes of a run
+	"journal.resuming":       {Other: "Note: The previous sync of this pair did not finish; it is completed by this run."},
+	"journal.start_failed":   {Other: "Warning: Could not start sync journal: %v"},
+	"stage.remove_failed":    {Other: "Warning: Could not remove the staging directory %s: %v"},
+	"stage.discarded":        {Other: "Note: Not all actions succeeded, so the staged target was discarded; the target is unchanged."},
+	"tempdir.other_fs":       {Other: "Note: Temp directory %s is not on the target's filesystem; files are staged there and then copied into place."},
+	"hotdb.warning":          {One: "Warning: %d file looks like a database in use; copies taken while it is written may be corrupt:", Other: "Warning: %d files look like databases in use; copies taken while they are written may be corrupt:"},
+	"hotdb.hint":             {Other: "Stop the application or use its backup tool, skip them with --skip-hot-databases, or silence this with --allow-hot-databases."},
+	"hotdb.skipping":         {Other: "Skipping them (--skip-hot-databases); their target copies are left as they are."},
+	"openfiles.check_failed": {Other: "Warning: Could not check for files open for writing: %v"},
+	"openfiles.warning":      {One: "Warning: %d file is open for writing by another process; a copy taken while it is written may be torn:", Other: "Warning: %d files are open for writing by other processes; copies taken while they are written may be torn:"},
+	"openfiles.hint":         {Other: "Let them finish first, or skip them with --skip-open-files."},
+	"openfiles.skipping":     {Other: "Skipping them (--skip-open-files); their target copies are left as they are."},
+	"memlimit.spill_failed":  {Other: "Warning: Could not spill the checksum cache to disk: %v"},
+	"memlimit.spilled":       {Other: "Note: Memory use is nearing --mem-limit; the checksum cache was moved to disk."},
+}
@@ -0,0 +1,335 @@
+// pkg/i18n/catalog_es.go
+package i18n
+
+// es holds the Spanish messages.
+var es = map[string]Message{
+	// Sync run
+	"sync.source":          {Other: "Origen: %s"},
+	"sync.target":          {Other: "Destino: %s"},
+	"sync.excludes":        {Other: "Exclusiones de la línea de comandos: %v"},
+	"sync.presets":         {Other: "Conjuntos de exclusiones: %s"},
+	"sync.gitignore":       {Other: "Respetando los archivos .gitignore"},
+	"sync.dry_run_mode":    {Other: "--- MODO DE PRUEBA ---"},
+	"sync.starting":        {Other: "Iniciando la sincronización..."},
+	"sync.finished":        {Other: "Sincronización terminada correctamente."},
+	"sync.finished_errors": {Other: "la sincronización terminó con %d error(es):\n- %s"},
+	"sync.completed":       {Other: "Sincronización completada correctamente."},
+	"sync.dry_run_note":    {Other: "(Prueba: no se ha modificado nada)"},
+	"sync.failed":          {Other: "la sincronización falló: %w"},
+
+	// Scanning
+	"role.source":             {Other: "origen"},
+	"role.source_n":           {Other: "origen %s"},
+	"role.target":             {Other: "destino"},
+	"scan.stats":              {Other: "Análisis de %s (%s): %d archivos, %d directorios, %d enlaces simbólicos, %s"},
+	"scan.ignored":            {Other: "%d ignorados por reglas de exclusión, %d ilegibles"},
+	"scan.finished":           {One: "Análisis de %s terminado. Se encontró %d elemento.", Other: "Análisis de %s terminado. Se encontraron %d elementos."},
+	"scan.target_error":       {Other: "No se pudo analizar el directorio de destino"},
+	"scan.reused":             {One: "Reutilizada %d entrada en caché para %s.", Other: "Reutilizadas %d entradas en caché para %s."},
+	"ignore.loaded":           {Other: "Cargados %d patrones de %s"},
+	"ignore.loaded_gitignore": {Other: "Cargados %d patrones de %d archivos %s"},
+
+	// Progress bars
+	"progress.scanning":      {Other: "Analizando %s..."},
+	"progress.planning":      {Other: "Planificando..."},
+	"progress.comparing":     {Other: "Comparando archivos..."},
+	"progress.syncing":       {Other: "Sincronizando archivos..."},
+	"progress.deduplicating": {Other: "Deduplicando..."},
+	"progress.backing_up":    {Other: "Respaldando..."},
+	"progress.verifying":     {Other: "Verificando archivos..."},
+
+	// Plan
+	"plan.comparing":       {Other: "Comparando origen y destino..."},
+	"plan.case_difference": {Other: "Diferencia solo en mayúsculas/minúsculas: %s -> %s"},
+	"plan.complete":        {Other: "Comparación terminada. Plan: %d altas, %d actualizaciones, %d eliminaciones, %d renombrados."},
+	"plan.unchanged":       {Other: "Sin cambios: %s"},
+	"plan.none":            {Other: "No hace falta ninguna acción. Origen y destino ya están sincronizados."},
+	"plan.header":          {Other: "--- Plan de sincronización ---"},
+	"plan.counts":          {Other: "Altas: %d, Actualizaciones: %d, Eliminaciones: %d, Renombrados: %d"},
+	"plan.samples":         {Other: "Acciones de ejemplo:"},
+	"plan.case_only":       {Other: "%s -> %s (solo mayúsculas/minúsculas)"},
+	"plan.more":            {One: "... y %d acción más", Other: "... y %d acciones más"},
+	"plan.filtered":        {One: "... %d acción de otros tipos no listada (--plan-filter)", Other: "... %d acciones de otros tipos no listadas (--plan-filter)"},
+	"plan.to_trash":        {Other: "Los elementos eliminados se moverán a la papelera."},
+	"plan.dry_run":         {Other: "Prueba: no se hará ningún cambio."},
+	"action.add":           {Other: "AÑADIR"},
+	"action.update":        {Other: "ACTUALIZAR"},
+	"action.delete":        {Other: "ELIMINAR"},
+	"action.rename":        {Other: "RENOMBRAR"},
+	"action.none":          {Other: "NINGUNA"},
+	"disk.shared":          {One: "Origen y destino comparten un dispositivo (%s); se usa %d operación en paralelo.", Other: "Origen y destino comparten un dispositivo (%s); se usan %d operaciones en paralelo."},
+	"disk.unknown":         {Other: "tipo desconocido"},
+	"disk.rotational":      {Other: "rotacional"},
+	"disk.non_rotational":  {Other: "no rotacional"},
+
+	// --explain
+	"explain.header":            {Other: "Por qué:"},
+	"explain.case_rename":       {Other: "se renombra de %s a %s: los nombres solo difieren en mayúsculas y el destino no las distingue"},
+	"explain.not_in_target":     {Other: "no está en el destino"},
+	"explain.replaces":          {Other: "el destino tiene un %s donde el origen tiene un %s"},
+	"explain.not_in_source":     {Other: "no está en el origen"},
+	"explain.replaced":          {Other: "un %s en el destino, un %s en el origen"},
+	"explain.size":              {Other: "el tamaño difiere (origen %s, destino %s)"},
+	"explain.checksums":         {Other: "mismo tamaño, la fecha difiere en %s, las sumas de verificación difieren"},
+	"explain.always_hash":       {Other: "mismo tamaño, las sumas de verificación difieren (--always-hash)"},
+	"explain.transformed_mtime": {Other: "transformado al copiar, la fecha difiere en %s"},
+	"explain.same_size_mtime":   {Other: "mismo tamaño y fecha de modificación"},
+	"explain.tolerance":         {Other: "mismo tamaño, fechas separadas por %s, dentro de la tolerancia de %s"},
+	"explain.same_checksum":     {Other: "mismo tamaño y suma de verificación (fechas separadas por %s)"},
+	"explain.transformed_same":  {Other: "transformado al copiar, el destino lleva la fecha del origen"},
+	"explain.directories":       {Other: "un directorio en ambos lados"},
+	"explain.deleted_with":      {Other: "se elimina con su directorio %s"},
+	"explain.outside_spec":      {Other: "no figura en el %s del destino"},
+	"explain.excluded":          {Other: "excluido por las reglas de exclusión"},
+	"explain.not_found":         {Other: "no está ni en el origen ni en el destino"},
+	"explain.left_out":          {Other: "fuera del plan (p. ej. una base de datos en uso, ver --skip-hot-databases)"},
+	"explain.rule_skip_delete":  {Other: "no está en el origen, se conserva por una regla skip-delete"},
+	"explain.rule_always_copy":  {Other: "coincide con una regla always-copy"},
+	"explain.file":              {Other: "archivo"},
+	"explain.directory":         {Other: "directorio"},
+
+	// Cambios inusuales
+	"annotate.header":     {One: "%d cambio inusual en este plan, revíselo:", Other: "%d cambios inusuales en este plan, revíselos:"},
+	"annotate.shrink":     {Other: "se reduce un %d%% (de %s a %s)"},
+	"annotate.top_delete": {One: "directorio de primer nivel eliminado con %d elemento dentro", Other: "directorio de primer nivel eliminado con %d elementos dentro"},
+	"annotate.exec_on":    {Other: "pasa a ser ejecutable"},
+	"annotate.exec_off":   {Other: "deja de ser ejecutable"},
+	"annotate.churn":      {Other: "el plan cambia el %d%% de los archivos del destino (%d de %d)"},
+
+	// Confirmation
+	"confirm.deletes":    {One: "El plan elimina %d elemento, más de los %d permitidos sin confirmación.", Other: "El plan elimina %d elementos, más de los %d permitidos sin confirmación."},
+	"confirm.changes":    {One: "El plan tiene %d acción, más de las %d permitidas sin confirmación.", Other: "El plan tiene %d acciones, más de las %d permitidas sin confirmación."},
+	"confirm.unusual":    {Other: "Se pide confirmación porque el plan tiene cambios inusuales."},
+	"confirm.skipped":    {Other: "Continuando sin confirmación."},
+	"prompt.proceed":     {Other: "¿Continuar con la sincronización? [S/n]: "},
+	"prompt.yes":         {Other: "s,si,sí"},
+	"prompt.aborted":     {Other: "Sincronización cancelada por el usuario."},
+	"prompt.read_failed": {Other: "no se pudo leer la confirmación: %w"},
+
+	// Target and source guards
+	"guard.target_missing":   {Other: "el destino %s no existe (¿está montado el volumen?)"},
+	"guard.not_mounted":      {Other: "el destino %s no es un punto de montaje (¿está montado el volumen?); no se sincroniza sobre el sistema de archivos que hay debajo"},
+	"guard.marker_missing":   {Other: "no se encontró el archivo marcador %s (¿está montado el volumen?); créelo en el volumen correcto para permitir sincronizar allí"},
+	"guard.source_empty":     {Other: "el origen contiene %d archivo(s), menos del mínimo de %d, pero el plan elimina %d elemento(s) del destino; ¿está montado el origen? Use --force para sincronizar de todos modos"},
+	"guard.mass_change":      {Other: "el plan sustituye %d de los %d archivos del destino por archivos con contenido nuevo y una extensión nueva (sobre todo %s), como haría un ransomware que cifrara el origen; revise el origen y use --mass-change-guard=warn si el cambio es intencionado"},
+	"guard.mass_change_warn": {Other: "El plan sustituye %d de los %d archivos del destino por archivos con contenido nuevo y una extensión nueva (sobre todo %s), como haría un ransomware que cifrara el origen."},
+	"guard.no_extension":     {Other: "ninguna"},
+
+	// Cuota del destino
+	"quota.usage_quota":    {Other: "Uso del destino: %s ahora, %s tras sincronizar (%.0f%% de la cuota de %s)"},
+	"quota.usage_free":     {Other: "Uso del destino: %s ahora, %s tras sincronizar (%s libres en su sistema de archivos)"},
+	"quota.deletes_first":  {Other: "El destino está cerca de su límite; las eliminaciones se ejecutan antes de copiar nada."},
+	"quota.exceeded":       {Other: "el plan llevaría el destino a %s, por encima de su cuota de %s; libere espacio en el destino o aumente --target-quota"},
+	"quota.no_space":       {Other: "El plan añade %s al destino, pero su sistema de archivos solo tiene %s libres."},
+	"quota.action_refused": {Other: "no se copió %s: el destino llegaría a %s, por encima de su cuota de %s"},
+
+	// Tipos de contenido
+	"plan.type_excluded": {One: "%d archivo del origen excluido por tipo de contenido (--include-type/--exclude-type).", Other: "%d archivos del origen excluidos por tipo de contenido (--include-type/--exclude-type)."},
+
+	// Reglas de acción
+	"plan.kept_by_rule": {One: "%d elemento del destino ausente en el origen conservado por reglas skip-delete.", Other: "%d elementos del destino ausentes en el origen conservados por reglas skip-delete."},
+
+	// Nombres en el destino
+	"names.problems":      {One: "%d ruta del origen no se puede crear tal cual en el sistema de archivos %s del destino:", Other: "%d rutas del origen no se pueden crear tal cual en el sistema de archivos %s del destino:"},
+	"names.more":          {Other: "... y %d más"},
+	"names.hint":          {Other: "Ejecute con --sanitize-names para sincronizarlas con los nombres sugeridos."},
+	"names.sanitized":     {One: "%d ruta del origen sincronizada con un nombre que acepta el sistema de archivos %s del destino (--sanitize-names).", Other: "%d rutas del origen sincronizadas con nombres que acepta el sistema de archivos %s del destino (--sanitize-names)."},
+	"names.restored":      {One: "%d ruta sincronizada con el nombre original registrado en el %s del origen.", Other: "%d rutas sincronizadas con los nombres originales registrados en el %s del origen."},
+	"names.invalid_char":  {Other: "carácter no válido %q"},
+	"names.reserved":      {Other: "nombre reservado"},
+	"names.trailing":      {Other: "termina en punto o espacio"},
+	"names.name_too_long": {Other: "nombre de más de %d caracteres"},
+	"names.path_too_long": {Other: "ruta de más de %d caracteres"},
+
+	// Especificación del destino
+	"spec.loaded":        {One: "Solo se refleja %d ruta, la indicada en el %s del destino.", Other: "Solo se reflejan %d rutas, las indicadas en el %s del destino."},
+	"spec.merge_ignored": {Other: "El %s del destino se ignora al fusionar varios orígenes."},
+
+	// Directorio semilla
+	"seed.result": {One: "Semilla: %d archivo (%s) tomado del directorio semilla en lugar del origen, %d enlazado.", Other: "Semilla: %d archivos (%s) tomados del directorio semilla en lugar del origen, %d enlazados."},
+
+	// Disk images
+	"image.created":   {Other: "Creada imagen de %s %s"},
+	"image.mounted":   {Other: "Imagen %s montada en %s"},
+	"image.unmounted": {Other: "Imagen %s desmontada"},
+
+	// --spot-check
+	"spotcheck.sampled": {One: "Comprobación por muestreo: %d copia de %d coincide con el origen; con un 95%% de confianza, menos del %s de las copias son incorrectas.", Other: "Comprobación por muestreo: %d copias de %d coinciden con el origen; con un 95%% de confianza, menos del %s de las copias son incorrectas."},
+	"spotcheck.all":     {Other: "Comprobación por muestreo: todos los archivos copiados (%d) coinciden con el origen."},
+	"spotcheck.bad":     {One: "Comprobación por muestreo: %d de %d copias comprobadas no coincide con el origen (se copiará de nuevo en la próxima sincronización):", Other: "Comprobación por muestreo: %d de %d copias comprobadas no coinciden con el origen (se copiarán de nuevo en la próxima sincronización):"},
+
+	// --assert-in-sync
+	"assert.in_sync":     {Other: "El origen y el destino están sincronizados."},
+	"assert.differences": {One: "%d diferencia entre origen y destino:", Other: "%d diferencias entre origen y destino:"},
+
+	// Fechas de directorios
+	"dirtimes.restored": {One: "Restaurada la fecha de modificación de %d directorio.", Other: "Restauradas las fechas de modificación de %d directorios."},
+
+	// Contextos de seguridad, capacidades y --fake-super
+	"security.labeled":   {One: "Aplicado a %d elemento el contexto SELinux de su origen.", Other: "Aplicados a %d elementos los contextos SELinux de sus orígenes."},
+	"security.capped":    {One: "Aplicadas a %d archivo las capacidades de su origen.", Other: "Aplicadas a %d archivos las capacidades de sus orígenes."},
+	"fakesuper.recorded": {One: "Registrados el propietario y los permisos de %d elemento para --from-fake-super.", Other: "Registrados los propietarios y permisos de %d elementos para --from-fake-super."},
+	"fakesuper.applied":  {One: "Restaurados el propietario y los permisos registrados de %d elemento.", Other: "Restaurados los propietarios y permisos registrados de %d elementos."},
+
+	// Dedupe and content-addressed backups
+	"dedupe.result":    {Other: "Deduplicación: %d archivos en %d grupos sustituidos por enlaces duros, %s recuperados."},
+	"backup.comparing": {Other: "Comparando con la instantánea %s"},
+	"backup.dry_run":   {Other: "Copia (prueba): %d archivos, %d con contenido nuevo (%s), %d ya almacenados."},
+	"backup.result":    {Other: "Instantánea %s: %d archivos, %d con contenido nuevo (%s almacenados), %d ya almacenados."},
+
+	// Summary reports and notifications
+	"summary.failed":   {One: "fallida (%d error)", Other: "fallida (%d errores)"},
+	"summary.dry_run":  {Other: "prueba, nada cambiado"},
+	"summary.in_sync":  {Other: "ya sincronizado"},
+	"summary.aborted":  {Other: "cancelada, nada cambiado"},
+	"summary.success":  {Other: "correcta"},
+	"summary.profile":  {Other: "Perfil"},
+	"summary.source":   {Other: "Origen"},
+	"summary.target":   {Other: "Destino"},
+	"summary.started":  {Other: "Inicio"},
+	"summary.duration": {Other: "Duración"},
+	"summary.changes":  {Other: "Cambios"},
+	"summary.counts":   {Other: "%d añadidos, %d actualizados, %d eliminados, %d renombrados"},
+	"summary.added":    {Other: "Añadidos"},
+	"summary.updated":  {Other: "Actualizados"},
+	"summary.deleted":  {Other: "Eliminados"},
+	"summary.renamed":  {Other: "Renombrados"},
+	"summary.skipped":  {Other: "Sin cambios"},
+	"summary.copied":   {Other: "Copiado"},
+	"summary.errors":   {Other: "Errores"},
+	"notify.counts":    {Other: "%d añadidos, %d actualizados, %d eliminados, %d renombrados, %s copiados en %s"},
+	"notify.error":     {Other: "error"},
+
+	// Operation stats in summary reports
+	"summary.operations": {Other: "Operaciones"},
+	"summary.op":         {Other: "%s %d (media %s)"},
+	"summary.op_rate":    {Other: "%s %d (media %s, %s/s)"},
+
+	// Files left alone as identical
+	"unchanged.counts":      {Other: "%d de %d archivos comparados"},
+	"unchanged.size_mtime":  {Other: "%d por tamaño y fecha de modificación"},
+	"unchanged.tolerance":   {Other: "%d dentro de la tolerancia de fecha"},
+	"unchanged.checksum":    {Other: "%d por suma de verificación"},
+	"unchanged.transformed": {Other: "%d transformados, por fecha"},
+
+	// status
+	"sample.more":           {Other: "... y %d más"},
+	"status.succeeded":      {Other: "correcta"},
+	"status.failed":         {Other: "fallida: %s"},
+	"status.dry_run":        {Other: "(prueba)"},
+	"status.last_run":       {Other: "Última ejecución: %s, %s"},
+	"status.interrupted":    {Other: "Aviso: La última sincronización se interrumpió antes de terminar."},
+	"status.never_synced":   {Other: "Todavía no se ha registrado ninguna sincronización correcta de este par."},
+	"status.last_sync":      {Other: "Última sincronización correcta: %s"},
+	"status.source_changes": {Other: "Cambios en el origen desde la última sincronización"},
+	"status.target_drift":   {Other: "Desviación del destino (cambios externos)"},
+	"status.drift_counts":   {Other: "%d añadidos, %d modificados, %d eliminados"},
+	"status.no_drift":       {Other: "Sin desviación: origen y destino no han cambiado desde la última sincronización."},
+
+	// preflight
+	"preflight.checked":      {Other: "Comprobados %d archivos de origen para lectura y %d directorios de destino para escritura."},
+	"preflight.scan_skipped": {One: "%d entrada no se pudo leer durante el análisis (vea los avisos anteriores).", Other: "%d entradas no se pudieron leer durante el análisis (vea los avisos anteriores)."},
+	"preflight.unreadable":   {Other: "Archivos de origen ilegibles"},
+	"preflight.not_writable": {Other: "Directorios de destino sin permiso de escritura"},
+	"preflight.ok":           {Other: "No se encontraron problemas."},
+	"preflight.problems":     {Other: "se encontraron %d problema(s)"},
+
+	// preview
+	"preview.kept":    {Other: "sin cambios"},
+	"preview.extra":   {Other: "solo en destino"},
+	"preview.added":   {Other: "nuevo"},
+	"preview.updated": {Other: "actualizado"},
+	"preview.renamed": {Other: "renombrado"},
+	"preview.totals":  {Other: "Tras la sincronización el destino contendría %d archivos y %d directorios, %s en total."},
+
+	// cross-check
+	"crosscheck.replica_a": {Other: "Réplica A: %s"},
+	"crosscheck.replica_b": {Other: "Réplica B: %s"},
+	"crosscheck.only_a":    {Other: "Solo en A"},
+	"crosscheck.only_b":    {Other: "Solo en B"},
+	"crosscheck.newer_a":   {Other: "Distintos, más recientes en A"},
+	"crosscheck.newer_b":   {Other: "Distintos, más recientes en B"},
+	"crosscheck.identical": {One: "%d archivo es idéntico en ambas réplicas.", Other: "%d archivos son idénticos en ambas réplicas."},
+	"crosscheck.latest":    {Other: "Archivo más reciente en A: %s; en B: %s"},
+	"crosscheck.none":      {Other: "ninguno"},
+	"crosscheck.in_sync":   {Other: "Las réplicas están sincronizadas."},
+	"crosscheck.stale_a":   {Other: "La réplica A parece desactualizada: B tiene cambios más recientes que faltan en A."},
+	"crosscheck.stale_b":   {Other: "La réplica B parece desactualizada: A tiene cambios más recientes que faltan en B."},
+	"crosscheck.diverged":  {Other: "Las réplicas han divergido: cada una tiene cambios que faltan en la otra."},
+	"crosscheck.differ":    {Other: "las réplicas difieren en %d elemento(s)"},
+
+	// scrub
+	"scrub.checked":        {Other: "Comprobados %d archivos: %d verificados, %d registrados por primera vez, %d dañados."},
+	"scrub.modified":       {Other: "Modificados desde la última verificación (tamaño o fecha cambiados, registrados de nuevo): %d"},
+	"scrub.missing":        {Other: "Desaparecidos desde la última verificación: %d"},
+	"scrub.unreadable":     {Other: "Ilegibles: %d"},
+	"scrub.report_written": {Other: "Informe escrito en %s"},
+	"scrub.no_damage":      {Other: "No se encontraron archivos dañados."},
+	"scrub.damaged":        {Other: "Archivos DAÑADOS (contenido cambiado sin cambio de tamaño ni de fecha):"},
+	"scrub.bytes":          {One: "%d byte", Other: "%d bytes"},
+	"scrub.expected":       {Other: "esperado"},
+	"scrub.actual":         {Other: "real"},
+	"scrub.damaged_error":  {Other: "se encontraron %d archivo(s) dañado(s) en %s"},
+
+	// clean
+	"clean.removed":       {Other: "Eliminado: %s"},
+	"clean.would_remove":  {Other: "Se eliminaría: %s"},
+	"clean.kept":          {Other: "Conservado (posiblemente en uso): %s"},
+	"clean.total":         {Other: "Eliminados %d archivo(s) temporal(es) huérfano(s), conservados %d."},
+	"clean.total_dry_run": {Other: "Se eliminarían %d archivo(s) temporal(es) huérfano(s), conservados %d."},
+
+	// gc
+	"gc.store":         {Other: "Almacén: %s"},
+	"gc.removed":       {Other: "Eliminada la instantánea %s"},
+	"gc.would_remove":  {Other: "Se eliminaría la instantánea %s"},
+	"gc.kept":          {Other: "Conservadas %d instantánea(s), eliminadas %d."},
+	"gc.kept_dry_run":  {Other: "Conservadas %d instantánea(s), se eliminarían %d."},
+	"gc.swept":         {Other: "Eliminados %d objeto(s) sin referencias, %d lista(s) de fragmentos y %d archivo(s) temporal(es): %s."},
+	"gc.swept_dry_run": {Other: "Se eliminarían %d objeto(s) sin referencias, %d lista(s) de fragmentos y %d archivo(s) temporal(es): %s."},
+
+	// heartbeat
+	"heartbeat.line": {Other: "Sigue en marcha tras %s: %s, %s/s desde el último informe"},
+
+	// ctl
+	"ctl.socket":        {Other: "Socket de control: %s"},
+	"ctl.unreachable":   {Other: "inaccesible (%v)"},
+	"ctl.job":           {Other: "pid %d, tarea %s, %s"},
+	"ctl.none":          {Other: "No hay sincronizaciones en curso con socket de control."},
+	"ctl.done":          {Other: "terminado (el proceso de sincronización salió)"},
+	"ctl.label_job":     {Other: "Tarea:"},
+	"ctl.label_source":  {Other: "Origen:"},
+	"ctl.label_target":  {Other: "Destino:"},
+	"ctl.label_started": {Other: "Inicio:"},
+	"ctl.label_status":  {Other: "Estado:"},
+	"ctl.label_limits":  {Other: "Límites:"},
+	"ctl.unlimited":     {Other: "sin límite"},
+	"ctl.automatic":     {Other: "automático"},
+	"ctl.limits":        {Other: "trabajadores %s, ancho de banda %s"},
+	"ctl.paused":        {Other: "(en pausa)"},
+	"ctl.progress":      {Other: "%s: %d/%d acciones, %s/%s"},
+
+	// Run control (pause, ctl set)
+	"control.paused":           {Other: "En pausa: los trabajadores se detienen tras su archivo actual. Reanude para continuar."},
+	"control.resumed":          {Other: "Reanudado."},
+	"control.workers":          {Other: "Operaciones en paralelo fijadas en %d."},
+	"control.workers_range":    {Other: "el número de trabajadores debe estar entre 1 y %d"},
+	"control.bwlimit":          {Other: "Límite de ancho de banda fijado en %s/s."},
+	"control.bwlimit_removed":  {Other: "Límite de ancho de banda eliminado."},
+	"control.bwlimit_negative": {Other: "el límite de ancho de banda no puede ser negativo"},
+
+	// Warnings and notes of a run
+	"journal.resuming":       {Other: "Nota: La sincronización anterior de este par no terminó; esta ejecución la completa."},
+	"journal.start_failed":   {Other: "Advertencia: No se pudo iniciar el diario de sincronización: %v"},
+	"stage.remove_failed":    {Other: "Advertencia: No se pudo eliminar el directorio de preparación %s: %v"},
+	"stage.discarded":        {Other: "Nota: No todas las acciones tuvieron éxito, así que se descartó el destino preparado; el destino no ha cambiado."},
+	"tempdir.other_fs":       {Other: "Nota: El directorio temporal %s no está en el sistema de archivos del destino; los archivos se preparan allí y luego se copian a su lugar."},
+	"hotdb.warning":          {One: "Advertencia: %d archivo parece una base de datos en uso; una copia tomada mientras se escribe puede estar dañada:", Other: "Advertencia: %d archivos parecen bases de datos en uso; las copias tomadas mientras se escriben pueden estar dañadas:"},
+	"hotdb.hint":             {Other: "Detenga la aplicación o use su herramienta de copia de seguridad, omítalos con --skip-hot-databases o silencie esto con --allow-hot-databases."},
+	"hotdb.skipping":         {Other: "Se omiten (--skip-hot-databases); sus copias en el destino se dejan como están."},
+	"openfiles.check_failed": {Other: "Advertencia: No se pudo comprobar qué archivos están abiertos para escritura: %v"},
+	"openfiles.warning":      {One: "Advertencia: %d archivo está abierto para escritura por otro proceso; una copia tomada mientras se escribe puede quedar incompleta:", Other: "Advertencia: %d archivos están abiertos para escritura por otros procesos; las copias tomadas mientras se escriben pueden quedar incompletas:"},
+	"openfiles.hint":         {Other: "Deje que terminen primero u omítalos con --skip-open-files."},
+	"openfiles.skipping":     {Other: "Se omiten (--skip-open-files); sus copias en el destino se dejan como están."},
+	"memlimit.spill_failed":  {Other: "Advertencia: No se pudo volcar la caché de sumas de comprobación al disco: %v"},
+	"memlimit.spilled":       {Other: "Nota: El uso de memoria se acerca a --mem-limit; la caché de sumas de comprobación se movió al disco."},
+}
@@ -0,0 +1,166 @@
+// pkg/i18n/i18n.go
+// Package i18n holds the user-facing messages of sync-dir in every supported
+// language, so that output can be localized and each string lives in one
+// place. Messages are looked up by key in the current language; a key missing
+// from a language falls back to English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultLanguage is used when no supported language is configured.
+const DefaultLanguage = "en"
+
+// Message is the text of one message, in fmt.Sprintf syntax. Messages about a
+// count (see N) may have a singular form in One; Other is used for every other
+// count and by T.
+type Message struct {
+	One   string
+	Other string
+}
+
+// catalogs maps each supported language to its messages.
+var catalogs = map[string]map[string]Message{
+	"en": en,
+	"es": es,
+	"de": de,
+}
+
+// current is the language of all messages, set once at startup.
+var current = DefaultLanguage
+
+// Languages returns the supported language codes, sorted.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// SetLanguage selects the language of all messages, as a code such as "de" or
+// a locale such as "de_DE.UTF-8". An empty lang detects it (see Detect).
+func SetLanguage(lang string) error {
+	if lang == "" {
+		current = Detect()
+		return nil
+	}
+	code := languageOf(lang)
+	if _, ok := catalogs[code]; !ok {
+		return fmt.Errorf("unsupported language %q (supported: %s)", lang, strings.Join(Languages(), ", "))
+	}
+	current = code
+	return nil
+}
+
+// Language returns the current language code.
+func Language() string {
+	return current
+}
+
+// Detect returns the language of the locale, taken from the first of LC_ALL,
+// LC_MESSAGES and LANG that is set (the POSIX precedence), or DefaultLanguage
+// if that locale isn't supported.
+func Detect() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		locale := os.Getenv(name)
+		if locale == "" {
+			continue
+		}
+		if code := languageOf(locale); catalogs[code] != nil {
+			return code
+		}
+		return DefaultLanguage // e.g. "C" or "POSIX"
+	}
+	return DefaultLanguage
+}
+
+// languageOf reduces a locale such as "es_MX.UTF-8" or "de-AT" to its
+// language code.
+func languageOf(locale string) string {
+	code := strings.ToLower(locale)
+	if i := strings.IndexAny(code, "_-.@"); i >= 0 {
+		code = code[:i]
+	}
+	return code
+}
+
+// T returns the message with the given key in the current language, formatted
+// with args.
+func T(key string, args ...any) string {
+	return format(lookup(key).Other, args)
+}
+
+// N is T for a message about n items: it uses the singular form if n is 1
+// (the rule of every supported language). n is only passed to the format if it
+// is also among args.
+func N(key string, n int, args ...any) string {
+	msg := lookup(key)
+	text := msg.Other
+	if n == 1 && msg.One != "" {
+		text = msg.One
+	}
+	return format(text, args)
+}
+
+// Errorf is fmt.Errorf with the message with the given key as the format, so
+// %w wraps errors as usual.
+func Errorf(key string, args ...any) error {
+	return fmt.Errorf(lookup(key).Other, args...)
+}
+
+// Pad returns the messages with the given keys, each padded with spaces to the
+// width of the longest, for labels shown in a column.
+func Pad(keys ...string) []string {
+	labels := make([]string, len(keys))
+	width := 0
+	for i, key := range keys {
+		labels[i] = T(key)
+		width = max(width, utf8.RuneCountInString(labels[i]))
+	}
+	for i, label := range labels {
+		labels[i] = label + strings.Repeat(" ", width-utf8.RuneCountInString(label))
+	}
+	return labels
+}
+
+// Confirmed reports whether answer to a [Y/n] prompt accepts it: an empty
+// answer or one of the words of "prompt.yes" (English ones always work).
+func Confirmed(answer string) bool {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" {
+		return true
+	}
+	for _, word := range strings.Split(T("prompt.yes")+","+en["prompt.yes"].Other, ",") {
+		if answer == word {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the message with the given key in the current language,
+// falling back to English and then to the key itself, so that a missing
+// message shows up instead of printing nothing.
+func lookup(key string) Message {
+	if msg, ok := catalogs[current][key]; ok {
+		return msg
+	}
+	if msg, ok := en[key]; ok {
+		return msg
+	}
+	return Message{Other: key}
+}
+
+func format(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,123 @@
+// pkg/ignore/gitignore.go
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// GitignoreFileName is the name of the per-directory git ignore files read by
+// LoadGitignores.
+const GitignoreFileName = ".gitignore"
+
+// LoadGitignores reads the .gitignore files of sourceDir and all its
+// subdirectories, as git would: each file's patterns are relative to its own
+// directory and apply only below it, and deeper files take precedence over
+// shallower ones. Directories excluded by the rules loaded so far are not
+// searched, nor is .git. Paths are then excluded if either the .sync-ignore
+// and CLI patterns or the .gitignore files exclude them. What it loaded and
+// files it couldn't read are reported to log (nil = nowhere).
+func (m *Matcher) LoadGitignores(sourceDir string, log *slog.Logger) error {
+	log = orDiscard(log)
+	m.gitignores = make(map[string][]patternRule)
+	files, patterns := 0, 0
+	err := filepath.WalkDir(sourceDir, func(absPath string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil // Unreadable entries are reported by the scan
+		}
+		relDir, err := filepath.Rel(sourceDir, absPath)
+		if err != nil {
+			return err
+		}
+		if relDir != "." && (d.Name() == ".git" || m.MatchesDir(relDir)) {
+			return filepath.SkipDir
+		}
+		rules, err := readGitignore(filepath.Join(absPath, GitignoreFileName), log)
+		if err != nil {
+			log.Warn("Could not read", "path", filepath.Join(absPath, GitignoreFileName), "err", err)
+			return nil
+		}
+		if len(rules) > 0 {
+			dir := filepath.ToSlash(relDir)
+			for _, line := range rules {
+				rule := compileRule(line)
+				m.gitignores[dir] = append(m.gitignores[dir], rule)
+				m.gitignoreLines = append(m.gitignoreLines, dir+"\x00"+strings.TrimPrefix(line, "!"))
+			}
+			files++
+			patterns += len(rules)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search %s files: %w", GitignoreFileName, err)
+	}
+	if files > 0 {
+		log.Info(i18n.T("ignore.loaded_gitignore", patterns, files, GitignoreFileName))
+	}
+	return nil
+}
+
+// readGitignore returns the patterns of a .gitignore file, or none if it
+// doesn't exist.
+func readGitignore(filePath string, log *slog.Logger) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Error("Could not close", "path", filePath, "err", err)
+		}
+	}()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, scanner.Err()
+}
+
+// gitignored applies the .gitignore files of unixPath's ancestors, from the
+// root down, each to the path relative to its own directory. The last
+// matching rule decides.
+func (m *Matcher) gitignored(unixPath string, isDir bool) bool {
+	var dirs []string
+	for dir := path.Dir(unixPath); dir != "."; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+	dirs = append(dirs, ".")
+
+	ignored := false
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules := m.gitignores[dirs[i]]
+		if len(rules) == 0 {
+			continue
+		}
+		rel := unixPath
+		if dirs[i] != "." {
+			rel = strings.TrimPrefix(unixPath, dirs[i]+"/")
+		}
+		for _, rule := range rules {
+			if rule.matches(rel, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
@@ -3,25 +3,47 @@ package ignore
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/sabhiram/go-gitignore" // Using this library for pattern matching
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
 )
 
 const IgnoreFileName = ".sync-ignore"
 
+// discardLogger stands in for a missing logger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// orDiscard returns log, or a logger dropping everything if it is nil.
+func orDiscard(log *slog.Logger) *slog.Logger {
+	if log == nil {
+		return discardLogger
+	}
+	return log
+}
+
 // Matcher holds the ignore patterns.
 type Matcher struct {
-	ignoreMatcher *ignore.GitIgnore
-	cliPatterns   []string // Store raw CLI patterns for potential logging/debugging
+	index       *patternIndex
+	cliPatterns []string // Store raw CLI patterns for potential logging/debugging
+	patterns    []string // All compiled patterns, in order
+	// .gitignore rules by slash-separated directory relative to the source
+	// ("." for the root), when LoadGitignores was used
+	gitignores     map[string][]patternRule
+	gitignoreLines []string // The same rules, for Fingerprint
+	spec           *Spec    // Paths the target mirrors, from its .sync-spec
 }
 
 // NewMatcher creates a Matcher by reading .sync-ignore from the source directory
-// and combining it with CLI exclude patterns.
-func NewMatcher(sourceDir string, cliExcludes []string) (*Matcher, error) {
+// and combining it with CLI exclude patterns. What it loaded is reported to log
+// (nil = nowhere).
+func NewMatcher(sourceDir string, cliExcludes []string, log *slog.Logger) (*Matcher, error) {
+	log = orDiscard(log)
 	ignoreFilePath := filepath.Join(sourceDir, IgnoreFileName)
 	var patterns []string
 
@@ -36,7 +58,7 @@ func NewMatcher(sourceDir string, cliExcludes []string) (*Matcher, error) {
 		}
 		defer func() {
 			if err := file.Close(); err != nil {
-				fmt.Printf("Error closing %s: %v\n", ignoreFilePath, err)
+				log.Error("Could not close", "path", ignoreFilePath, "err", err)
 			}
 		}()
 
@@ -51,29 +73,94 @@ func NewMatcher(sourceDir string, cliExcludes []string) (*Matcher, error) {
 		if err := scanner.Err(); err != nil {
 			return nil, fmt.Errorf("failed to read %s: %w", IgnoreFileName, err)
 		}
-		fmt.Printf("Loaded %d patterns from %s\n", len(patterns)-len(cliExcludes), IgnoreFileName)
+		log.Info(i18n.T("ignore.loaded", len(patterns)-len(cliExcludes), IgnoreFileName))
 	} else if !os.IsNotExist(err) {
 		// Error other than file not existing
 		return nil, fmt.Errorf("failed to stat %s: %w", IgnoreFileName, err)
 	}
 
-	// Compile patterns using go-gitignore
+	// Compile patterns using go-gitignore, indexed so that large pattern
+	// sets don't slow down every path
 	// Note: go-gitignore expects patterns relative to the base directory (sourceDir)
-	matcher := ignore.CompileIgnoreLines(patterns...)
+	index := compilePatterns(patterns)
 
 	return &Matcher{
-		ignoreMatcher: matcher,
-		cliPatterns:   cliExcludes, // Keep original CLI patterns if needed
+		index:       index,
+		cliPatterns: cliExcludes, // Keep original CLI patterns if needed
+		patterns:    patterns,
 	}, nil
 }
 
-// Matches checks if a given path (relative to the source directory) should be ignored.
-func (m *Matcher) Matches(relPath string) bool {
-	if m.ignoreMatcher == nil {
+// Compile creates a Matcher from patterns alone, without reading a
+// .sync-ignore file. It is used for pattern lists other than exclusions.
+func Compile(patterns []string) *Matcher {
+	return &Matcher{
+		index:    compilePatterns(patterns),
+		patterns: patterns,
+	}
+}
+
+// MatchesFile checks if a file or symlink at a given path (relative to the
+// source directory) should be ignored. Like MatchesDir, it only looks at the
+// path itself: callers walking a tree don't ask about the contents of ignored
+// directories.
+func (m *Matcher) MatchesFile(relPath string) bool {
+	return m.matches(relPath, false)
+}
+
+// MatchesDir checks if a directory at a given path should be ignored, and
+// with it everything below it, which callers skip without matching it: as in
+// git, a later "!pattern" can't re-include a path inside an ignored
+// directory. Patterns ending in a slash match the directory itself, not only
+// its contents.
+func (m *Matcher) MatchesDir(relPath string) bool {
+	return m.matches(relPath, true)
+}
+
+// InIgnoredDir reports whether a parent directory of relPath is ignored, for
+// callers asking about a path without walking down to it.
+func (m *Matcher) InIgnoredDir(relPath string) bool {
+	for dir := filepath.Dir(relPath); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if m.MatchesDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Matcher) matches(relPath string, isDir bool) bool {
+	if m == nil || m.index == nil {
 		return false // No patterns loaded
 	}
 	// go-gitignore expects paths with OS-specific separators, but internally
 	// often works better with '/'. Let's normalize for safety.
 	unixPath := filepath.ToSlash(relPath)
-	return m.ignoreMatcher.MatchesPath(unixPath)
+	if m.index.match(unixPath, isDir) || m.OutsideSpec(relPath) {
+		return true
+	}
+	return len(m.gitignores) > 0 && m.gitignored(unixPath, isDir)
+}
+
+// Fingerprint returns a stable hash of all loaded patterns, so callers caching
+// scan results can tell when the ignore rules changed.
+func (m *Matcher) Fingerprint() string {
+	if m == nil {
+		return ""
+	}
+	hash := sha256.New()
+	for _, pattern := range m.patterns {
+		hash.Write([]byte(pattern))
+		hash.Write([]byte{'\n'})
+	}
+	for _, line := range m.gitignoreLines {
+		hash.Write([]byte(GitignoreFileName + "\x00" + line))
+		hash.Write([]byte{'\n'})
+	}
+	if m.spec != nil {
+		for _, line := range m.spec.lines {
+			hash.Write([]byte(SpecFileName + "\x00" + line))
+			hash.Write([]byte{'\n'})
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
 }
@@ -0,0 +1,198 @@
+// pkg/ignore/index.go
+package ignore
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sabhiram/go-gitignore"
+)
+
+// patternRule is one pattern, compiled on its own so that negations
+// ("!pattern") can be told from a plain non-match, which go-gitignore doesn't
+// report.
+type patternRule struct {
+	matcher *ignore.GitIgnore // The pattern without its "!"
+	negate  bool
+	dirOnly bool // Ends in a slash
+}
+
+// compileRule compiles one pattern line.
+func compileRule(line string) patternRule {
+	rule := patternRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate, line = true, line[1:]
+	}
+	rule.matcher = ignore.CompileIgnoreLines(line)
+	rule.dirOnly = isDirOnly(line)
+	return rule
+}
+
+// isDirOnly reports whether a pattern line matches only directories, such as
+// "build/". go-gitignore matches such patterns only against what's inside
+// the directories, so they are tried on the directories themselves with a
+// slash appended.
+func isDirOnly(line string) bool {
+	return strings.HasSuffix(line, "/") && !strings.HasSuffix(line, "**/")
+}
+
+// matches reports whether the pattern matches the slash-separated unixPath,
+// whether or not it is negated.
+func (r patternRule) matches(unixPath string, isDir bool) bool {
+	if isDir && r.dirOnly {
+		unixPath += "/"
+	}
+	return r.matcher.MatchesPath(unixPath)
+}
+
+// anchoredByGlob matches the patterns go-gitignore anchors to the root
+// although they don't start with a slash, such as "docs/*.html".
+var anchoredByGlob = regexp.MustCompile(`([^\/+])/.*\*\.`)
+
+// patternIndex matches a path against a list of patterns without trying each
+// of them. The last pattern matching a path decides, so it tries the
+// patterns from the last one and stops at the first match; and it only tries
+// those the path's names can match. Most patterns of large pattern sets start
+// with a literal name ("node_modules", "/build/out") or an extension
+// ("*.log"), which match only paths having a directory or file of that name,
+// so they are indexed by it. The rest are tried on every path.
+type patternIndex struct {
+	rules    []patternRule
+	names    map[string][]int // Unanchored patterns by their leading name
+	exts     map[string][]int // Unanchored patterns leading with "*.ext", by the text after the last dot
+	anchored *trieNode        // Patterns anchored to the root, by their leading literal names
+	others   []int            // Patterns tried on every path, in order
+}
+
+// trieNode is a directory in the index of anchored patterns. Walking a path
+// down the trie stops at the first name without a node, so the patterns of
+// other subtrees are never looked at.
+type trieNode struct {
+	children map[string]*trieNode
+	rules    []int // Patterns whose leading literal names end here
+}
+
+// compilePatterns builds the index of patterns. Empty lines and comments are
+// left out, as go-gitignore does.
+func compilePatterns(patterns []string) *patternIndex {
+	x := &patternIndex{
+		names:    make(map[string][]int),
+		exts:     make(map[string][]int),
+		anchored: &trieNode{},
+	}
+	for _, line := range patterns {
+		line = strings.Trim(strings.TrimRight(line, "\r"), " ")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := patternRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate, line = true, line[1:]
+			if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+				line = line[1:] // go-gitignore drops it as an escape
+			}
+		}
+		rule.matcher = ignore.CompileIgnoreLines(line)
+		rule.dirOnly = isDirOnly(line)
+		x.rules = append(x.rules, rule)
+		x.add(len(x.rules)-1, line)
+	}
+	return x
+}
+
+// add indexes rule i, whose line without its "!" is body, following the way
+// go-gitignore turns lines into regular expressions.
+func (x *patternIndex) add(i int, body string) {
+	anchored := strings.HasPrefix(body, "/") || anchoredByGlob.MatchString(body)
+	body = strings.TrimPrefix(body, "/")
+	if strings.HasPrefix(body, "**/") {
+		anchored = false
+	}
+	if anchored {
+		node := x.anchored
+		for _, name := range strings.Split(body, "/") {
+			if !isLiteral(name) {
+				break
+			}
+			node = node.child(name)
+		}
+		if node == x.anchored {
+			x.others = append(x.others, i)
+		} else {
+			node.rules = append(node.rules, i)
+		}
+		return
+	}
+	for strings.HasPrefix(body, "**/") {
+		body = body[len("**/"):]
+	}
+	name, _, _ := strings.Cut(body, "/")
+	switch {
+	case isLiteral(name):
+		x.names[name] = append(x.names[name], i)
+	case strings.HasPrefix(name, "*") && isLiteral(name[1:]) && strings.Contains(name, "."):
+		ext := name[strings.LastIndexByte(name, '.')+1:]
+		x.exts[ext] = append(x.exts[ext], i)
+	default:
+		x.others = append(x.others, i)
+	}
+}
+
+// child returns the node of name below n, adding it if needed.
+func (n *trieNode) child(name string) *trieNode {
+	if n.children == nil {
+		n.children = make(map[string]*trieNode)
+	}
+	c, ok := n.children[name]
+	if !ok {
+		c = &trieNode{}
+		n.children[name] = c
+	}
+	return c
+}
+
+// isLiteral reports whether name matches only itself in a pattern.
+func isLiteral(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `*?[]{}()+|^$\`)
+}
+
+// match reports whether the last pattern matching the slash-separated
+// unixPath excludes it.
+func (x *patternIndex) match(unixPath string, isDir bool) bool {
+	var hits []int
+	node := x.anchored
+	for rest := unixPath; rest != ""; {
+		var name string
+		name, rest, _ = strings.Cut(rest, "/")
+		hits = append(hits, x.names[name]...)
+		if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+			hits = append(hits, x.exts[name[dot+1:]]...)
+		}
+		if node != nil {
+			if node = node.children[name]; node != nil {
+				hits = append(hits, node.rules...)
+			}
+		}
+	}
+	slices.Sort(hits)
+
+	// Indexed patterns and the others, merged from the last one
+	i, j, last := len(hits)-1, len(x.others)-1, -1
+	for i >= 0 || j >= 0 {
+		var next int
+		if j < 0 || (i >= 0 && hits[i] > x.others[j]) {
+			next, i = hits[i], i-1
+		} else {
+			next, j = x.others[j], j-1
+		}
+		if next == last {
+			continue // Indexed by several names of the path
+		}
+		last = next
+		if rule := x.rules[next]; rule.matches(unixPath, isDir) {
+			return !rule.negate
+		}
+	}
+	return false
+}
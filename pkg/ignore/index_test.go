@@ -0,0 +1,163 @@
+// pkg/ignore/index_test.go
+package ignore
+
+import (
+	"strings"
+	"testing"
+
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// plainMatch matches a path the way it was before patterns were indexed:
+// files against all the patterns compiled by go-gitignore, and directories
+// pattern by pattern, with a slash appended for the patterns ending in one.
+func plainMatch(patterns []string, unixPath string, isDir bool) bool {
+	if !isDir {
+		return gitignore.CompileIgnoreLines(patterns...).MatchesPath(unixPath)
+	}
+	excluded := false
+	for _, line := range patterns {
+		negate := strings.HasPrefix(line, "!")
+		line = strings.TrimPrefix(line, "!")
+		path := unixPath
+		if isDirOnly(line) {
+			path += "/"
+		}
+		if gitignore.CompileIgnoreLines(line).MatchesPath(path) {
+			excluded = !negate
+		}
+	}
+	return excluded
+}
+
+type matchCase struct {
+	path  string
+	isDir bool
+	want  bool
+}
+
+var indexTests = []struct {
+	name     string
+	patterns []string
+	cases    []matchCase
+}{
+	{
+		name:     "negation",
+		patterns: []string{"*.log", "!keep.log", "tmp", "!tmp/keep", "!never-excluded.txt"},
+		cases: []matchCase{
+			{"debug.log", false, true},
+			{"sub/debug.log", false, true},
+			{"keep.log", false, false},
+			{"sub/keep.log", false, false},
+			{"tmp", true, true},
+			{"tmp/file", false, true},
+			{"tmp/keep", false, false},
+			{"never-excluded.txt", false, false},
+			{"notes.txt", false, false},
+		},
+	},
+	{
+		name:     "anchored",
+		patterns: []string{"/build", "/docs/internal", "docs/*.html", "out/bin"},
+		cases: []matchCase{
+			{"build", true, true},
+			{"build/a.o", false, true},
+			{"sub/build", true, false},
+			{"docs/internal", true, true},
+			{"sub/docs/internal", true, false},
+			{"docs/index.html", false, true},
+			{"sub/docs/index.html", false, false},
+			{"docs/index.txt", false, false},
+			{"out/bin", true, true},
+			{"sub/out/bin", true, true},
+		},
+	},
+	{
+		name:     "double star",
+		patterns: []string{"**/cache", "/**/vendor", "src/**/gen", "assets/**", "a/**/b/**/c.txt"},
+		cases: []matchCase{
+			{"cache", true, true},
+			{"x/y/cache", true, true},
+			{"vendor", true, true},
+			{"x/vendor", true, true},
+			{"src/gen", true, true},
+			{"src/x/y/gen", true, true},
+			{"lib/gen", true, false},
+			{"assets/img/logo.png", false, true},
+			{"assets", true, true}, // go-gitignore matches the directory itself too
+			{"a/b/c.txt", false, true},
+			{"a/x/b/y/z/c.txt", false, true},
+			{"a/c.txt", false, false},
+		},
+	},
+	{
+		name:     "trailing slash",
+		patterns: []string{"node_modules/", "/dist/", "**/logs/", "*.d/"},
+		cases: []matchCase{
+			{"node_modules", true, true},
+			{"web/node_modules", true, true},
+			{"node_modules", false, false},
+			{"node_modules/pkg/index.js", false, true},
+			{"dist", true, true},
+			{"sub/dist", true, false},
+			{"dist", false, false},
+			{"logs", true, true},
+			{"x/logs", true, true},
+			{"conf.d", true, true},
+			{"conf.d", false, false},
+		},
+	},
+	{
+		name:     "character classes",
+		patterns: []string{"*.[oa]", "file[0-9].txt", "[Bb]in", "/Makefile.[0-9]*", "?.tmp"},
+		cases: []matchCase{
+			{"main.o", false, true},
+			{"lib/libx.a", false, true},
+			{"main.c", false, false},
+			{"file1.txt", false, true},
+			{"dir/file7.txt", false, true},
+			{"fileX.txt", false, false},
+			{"bin", true, true},
+			{"Bin", true, true},
+			{"sub/bin", true, true},
+			{"win", true, false},
+			{"Makefile.1", false, true},
+			{"sub/Makefile.1", false, false},
+			{"x.tmp", false, false}, // go-gitignore takes "?" literally
+			{"?.tmp", false, true},
+		},
+	},
+}
+
+func TestIndexMatchesPlainMatcher(t *testing.T) {
+	for _, tt := range indexTests {
+		index := compilePatterns(tt.patterns)
+		for _, c := range tt.cases {
+			got := index.match(c.path, c.isDir)
+			if plain := plainMatch(tt.patterns, c.path, c.isDir); got != plain {
+				t.Errorf("%s: match(%q, dir=%v) = %v, go-gitignore says %v", tt.name, c.path, c.isDir, got, plain)
+			}
+			if got != c.want {
+				t.Errorf("%s: match(%q, dir=%v) = %v, want %v", tt.name, c.path, c.isDir, got, c.want)
+			}
+		}
+	}
+}
+
+// All the patterns together, so that indexed patterns and those tried on
+// every path compete for the last match.
+func TestIndexMatchesPlainMatcherCombined(t *testing.T) {
+	var patterns []string
+	for _, tt := range indexTests {
+		patterns = append(patterns, tt.patterns...)
+	}
+	patterns = append(patterns, "!src/**/gen/keep.go", "!/build/keep", "# comment", "")
+	index := compilePatterns(patterns)
+	for _, tt := range indexTests {
+		for _, c := range append(tt.cases, matchCase{path: "src/x/gen/keep.go"}, matchCase{path: "build/keep"}) {
+			if got, plain := index.match(c.path, c.isDir), plainMatch(patterns, c.path, c.isDir); got != plain {
+				t.Errorf("match(%q, dir=%v) = %v, go-gitignore says %v", c.path, c.isDir, got, plain)
+			}
+		}
+	}
+}
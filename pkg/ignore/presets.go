@@ -0,0 +1,71 @@
+// pkg/ignore/presets.go
+package ignore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// presets are named sets of exclude patterns for common development trees:
+// version control metadata, dependency caches and build output that can be
+// recreated from the sources. Names that are only ever used for directories
+// are listed without a trailing slash, so the directory itself is skipped
+// rather than just its contents; generic names such as "build/" keep the
+// slash so that files of that name are still synced.
+var presets = map[string][]string{
+	"git":    {".git"},
+	"vcs":    {".git", ".hg", ".svn", ".bzr", "_darcs", "CVS"},
+	"node":   {"node_modules", ".npm", ".yarn/cache", ".pnpm-store", ".next", ".nuxt", ".parcel-cache", ".turbo"},
+	"python": {"__pycache__", "*.py[cod]", ".venv", "venv/", ".tox", ".nox", ".mypy_cache", ".pytest_cache", ".ruff_cache", "*.egg-info", ".eggs"},
+	"go":     {"*.test", "*.prof", "/bin/"},
+	"rust":   {"target/"},
+	"java":   {"target/", ".gradle", "build/", "*.class"},
+	"build":  {"build/", "dist/", "out/", "*.o", "*.obj", "*.a", "*.so", "*.dylib", "*.dll"},
+	"editor": {".idea", ".vscode", "*.swp", "*~", ".DS_Store", "Thumbs.db"},
+}
+
+// defaults are excluded from every sync unless --no-default-ignores is given:
+// files that file managers, editors and office suites create and remove on
+// their own, and that would otherwise be copied and deleted again on every
+// other run.
+var defaults = []string{
+	".DS_Store", "._.DS_Store", "Thumbs.db", "ehthumbs.db", // File manager metadata
+	"*.swp", "*.swo", "*~", ".#*", // Editor swap, backup and lock files
+	".~lock.*#", "~[$]*", // LibreOffice and Microsoft Office lock files
+}
+
+// Defaults returns the patterns excluded by default.
+func Defaults() []string {
+	return append([]string(nil), defaults...)
+}
+
+// Presets returns the names of the available presets, sorted.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetPatterns returns the exclude patterns of the named presets, in order
+// and without duplicates.
+func PresetPatterns(names []string) ([]string, error) {
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		preset, ok := presets[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(Presets(), ", "))
+		}
+		for _, pattern := range preset {
+			if !seen[pattern] {
+				seen[pattern] = true
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns, nil
+}
@@ -0,0 +1,102 @@
+// pkg/ignore/spec.go
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SpecFileName is the file at the top of a target listing the only paths it
+// mirrors, like a sparse checkout.
+const SpecFileName = ".sync-spec"
+
+// Spec is the content of a .sync-spec file: paths (directories with all they
+// contain, or files) to include, and paths inside them to leave out again,
+// written with a leading "!". Everything else is left out.
+type Spec struct {
+	include []string // Slash-separated, without trailing slashes
+	exclude []string
+	lines   []string // As written, for Fingerprint
+}
+
+// LoadSpec reads the .sync-spec file at the top of root. It returns nil if
+// there is none.
+func LoadSpec(root string) (*Spec, error) {
+	file, err := os.Open(filepath.Join(root, SpecFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open %s: %w", SpecFileName, err)
+	}
+	defer file.Close()
+
+	spec := &Spec{}
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negated := strings.HasPrefix(line, "!")
+		p := path.Clean(strings.Trim(strings.TrimPrefix(line, "!"), "/"))
+		if p == "." || p == ".." || strings.HasPrefix(p, "../") {
+			return nil, fmt.Errorf("%s line %d: %q is not a path inside the tree", SpecFileName, lineNo, line)
+		}
+		if negated {
+			spec.exclude = append(spec.exclude, p)
+		} else {
+			spec.include = append(spec.include, p)
+		}
+		spec.lines = append(spec.lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SpecFileName, err)
+	}
+	if len(spec.include) == 0 {
+		return nil, fmt.Errorf("%s lists no paths to include", SpecFileName)
+	}
+	return spec, nil
+}
+
+// Len returns the number of paths the spec includes.
+func (s *Spec) Len() int {
+	return len(s.include)
+}
+
+// Includes reports whether relPath is inside an included path and not inside
+// an excluded one, or is a directory leading to an included path.
+func (s *Spec) Includes(relPath string) bool {
+	unixPath := filepath.ToSlash(relPath)
+	for _, p := range s.exclude {
+		if within(unixPath, p) {
+			return false
+		}
+	}
+	for _, p := range s.include {
+		if within(unixPath, p) || strings.HasPrefix(p, unixPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether p is dir or lies below it.
+func within(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// SetSpec makes the matcher also match every path the spec leaves out.
+func (m *Matcher) SetSpec(spec *Spec) {
+	m.spec = spec
+}
+
+// OutsideSpec reports whether relPath is left out by the spec set with
+// SetSpec.
+func (m *Matcher) OutsideSpec(relPath string) bool {
+	return m != nil && m.spec != nil && !m.spec.Includes(relPath)
+}
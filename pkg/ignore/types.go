@@ -0,0 +1,127 @@
+// pkg/ignore/types.go
+package ignore
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// sniffLen is how much of a file is read to sniff its type, as much as
+// http.DetectContentType looks at.
+const sniffLen = 512
+
+// extensionTypes covers common media, archive and document extensions that
+// the system's MIME tables may lack; mime.TypeByExtension answers the rest.
+var extensionTypes = map[string]string{
+	".mp4": "video/mp4", ".m4v": "video/mp4", ".mkv": "video/x-matroska", ".webm": "video/webm",
+	".mov": "video/quicktime", ".avi": "video/x-msvideo", ".wmv": "video/x-ms-wmv", ".mpg": "video/mpeg",
+	".mpeg": "video/mpeg", ".ts": "video/mp2t", ".3gp": "video/3gpp",
+	".mp3": "audio/mpeg", ".m4a": "audio/mp4", ".flac": "audio/flac", ".wav": "audio/wav",
+	".ogg": "audio/ogg", ".opus": "audio/opus", ".aac": "audio/aac",
+	".jpg": "image/jpeg", ".jpeg": "image/jpeg", ".png": "image/png", ".gif": "image/gif",
+	".webp": "image/webp", ".heic": "image/heic", ".tif": "image/tiff", ".tiff": "image/tiff",
+	".bmp": "image/bmp", ".svg": "image/svg+xml", ".raw": "image/x-raw", ".cr2": "image/x-canon-cr2",
+	".nef": "image/x-nikon-nef", ".dng": "image/x-adobe-dng",
+	".zip": "application/zip", ".gz": "application/gzip", ".tgz": "application/gzip",
+	".bz2": "application/x-bzip2", ".xz": "application/x-xz", ".zst": "application/zstd",
+	".7z": "application/x-7z-compressed", ".rar": "application/vnd.rar", ".tar": "application/x-tar",
+	".iso": "application/x-iso9660-image", ".pdf": "application/pdf",
+	".txt": "text/plain", ".md": "text/markdown", ".csv": "text/csv", ".html": "text/html",
+	".json": "application/json", ".xml": "application/xml",
+}
+
+// TypeFilter selects files by content (MIME) type, such as video/mp4. The
+// type comes from the file's extension; with sniffing, files whose extension
+// says nothing have their first bytes read instead. Files of unknown type are
+// application/octet-stream.
+type TypeFilter struct {
+	include []string // Patterns a file's type must match, if any
+	exclude []string // Patterns a file's type must not match
+	sniff   bool
+}
+
+// NewTypeFilter returns a filter keeping the files whose type matches an
+// include pattern (all files without any) and no exclude pattern. Patterns
+// are a type (image/png) or all subtypes of one (video/*). It returns nil if
+// there are no patterns.
+func NewTypeFilter(include, exclude []string, sniff bool) (*TypeFilter, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	for _, pattern := range append(append([]string(nil), include...), exclude...) {
+		major, minor, ok := strings.Cut(pattern, "/")
+		if !ok || major == "" || minor == "" || strings.Contains(minor, "/") || major == "*" {
+			return nil, fmt.Errorf("invalid content type pattern %q (expected e.g. video/* or image/png)", pattern)
+		}
+	}
+	return &TypeFilter{include: lower(include), exclude: lower(exclude), sniff: sniff}, nil
+}
+
+func lower(patterns []string) []string {
+	out := make([]string, len(patterns))
+	for i, p := range patterns {
+		out[i] = strings.ToLower(p)
+	}
+	return out
+}
+
+// Excludes reports whether the file at relPath is left out, and its type.
+// open gives access to the file's content for sniffing; it is only called
+// when the extension doesn't tell the type, and may be nil if the content
+// can't be read.
+func (f *TypeFilter) Excludes(relPath string, open func() (io.ReadCloser, error)) (bool, string) {
+	mimeType := TypeByExtension(relPath)
+	if mimeType == "" && f.sniff && open != nil {
+		mimeType = sniff(open)
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	if matchesAny(f.exclude, mimeType) {
+		return true, mimeType
+	}
+	return len(f.include) > 0 && !matchesAny(f.include, mimeType), mimeType
+}
+
+// TypeByExtension returns the MIME type of a file name's extension, without
+// parameters, or "" if it is unknown.
+func TypeByExtension(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		return ""
+	}
+	if t, ok := extensionTypes[ext]; ok {
+		return t
+	}
+	t, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+	return strings.TrimSpace(t)
+}
+
+// sniff guesses a type from the first bytes of a file ("" if unreadable).
+func sniff(open func() (io.ReadCloser, error)) string {
+	file, err := open()
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	buf := make([]byte, sniffLen)
+	n, _ := io.ReadFull(file, buf)
+	if n == 0 {
+		return ""
+	}
+	t, _, _ := strings.Cut(http.DetectContentType(buf[:n]), ";")
+	return t
+}
+
+func matchesAny(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		if pattern == mimeType || (strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, pattern[:len(pattern)-1])) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+// pkg/image/image_darwin.go
+package image
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// imageTypes maps the image file extensions hdiutil keeps unchanged to the
+// image type created for them.
+var imageTypes = map[string]string{
+	".dmg":          "UDIF",
+	".sparseimage":  "SPARSE",
+	".sparsebundle": "SPARSEBUNDLE",
+}
+
+// create makes an APFS disk image of size bytes.
+func create(path string, size int64) error {
+	imageType, ok := imageTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return fmt.Errorf("image name must end in .dmg, .sparseimage or .sparsebundle")
+	}
+	kib := strconv.FormatInt((size+1023)/1024, 10) + "k"
+	return run("hdiutil", "create", "-quiet", "-size", kib, "-type", imageType, "-fs", "APFS", "-volname", "sync-dir", path)
+}
+
+func attach(path, mountPoint string, readOnly bool) error {
+	args := []string{"attach", "-quiet", "-nobrowse", "-mountpoint", mountPoint}
+	if readOnly {
+		args = append(args, "-readonly")
+	}
+	return run("hdiutil", append(args, path)...)
+}
+
+func detach(mountPoint string) error {
+	return run("hdiutil", "detach", "-quiet", mountPoint)
+}
@@ -0,0 +1,45 @@
+// pkg/image/image_linux.go
+package image
+
+import (
+	"fmt"
+	"os"
+)
+
+// create makes a sparse file of size bytes and formats it as ext4.
+func create(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = file.Truncate(size)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = run("mkfs.ext4", "-q", "-F", "-L", "sync-dir", path)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// attach mounts the image through a loop device, which the kernel releases
+// again when it is unmounted.
+func attach(path, mountPoint string, readOnly bool) error {
+	options := "loop"
+	if readOnly {
+		options += ",ro"
+	}
+	err := run("mount", "-o", options, path, mountPoint)
+	if err != nil && os.Geteuid() != 0 {
+		return fmt.Errorf("%w (mounting a loop device usually requires root)", err)
+	}
+	return err
+}
+
+func detach(mountPoint string) error {
+	return run("umount", mountPoint)
+}
@@ -0,0 +1,16 @@
+// pkg/image/image_other.go
+//go:build !linux && !darwin
+
+package image
+
+func create(path string, size int64) error {
+	return ErrUnsupported
+}
+
+func attach(path, mountPoint string, readOnly bool) error {
+	return ErrUnsupported
+}
+
+func detach(mountPoint string) error {
+	return ErrUnsupported
+}
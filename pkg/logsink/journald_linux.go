@@ -0,0 +1,77 @@
+// pkg/logsink/journald_linux.go
+//go:build linux
+
+package logsink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// journalSocket is where journald accepts native protocol datagrams.
+const journalSocket = "/run/systemd/journal/socket"
+
+// journaldSink writes entries with the journald native protocol, keeping
+// fields as separate, queryable journal fields (journalctl JOB=nightly).
+type journaldSink struct {
+	conn *net.UnixConn
+	tag  string
+}
+
+func openJournald(tag string) (Sink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: journalSocket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to journald: %w", err)
+	}
+	return &journaldSink{conn: conn, tag: tag}, nil
+}
+
+func (j *journaldSink) Log(priority Priority, message string, fields map[string]string) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(int(priority)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", j.tag)
+	for key, value := range fields {
+		writeJournalField(&b, journalFieldName(key), value)
+	}
+	_, err := j.conn.Write(b.Bytes())
+	return err
+}
+
+func (j *journaldSink) Close() error {
+	return j.conn.Close()
+}
+
+// writeJournalField encodes one field. Values containing newlines use the
+// binary form: name, newline, little-endian 64-bit length, value.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(b, "%s=%s\n", name, value)
+		return
+	}
+	b.WriteString(name)
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalFieldName turns a field key into a valid journal field name:
+// uppercase letters, digits and underscores, not starting with an underscore.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	return strings.TrimLeft(name, "_")
+}
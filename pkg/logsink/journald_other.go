@@ -0,0 +1,10 @@
+// pkg/logsink/journald_other.go
+//go:build !linux
+
+package logsink
+
+import "fmt"
+
+func openJournald(tag string) (Sink, error) {
+	return nil, fmt.Errorf("journald is only available on Linux")
+}
@@ -0,0 +1,59 @@
+// pkg/logsink/logsink.go
+package logsink
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Priority is the severity of a log entry, using syslog levels.
+type Priority int
+
+const (
+	Error   Priority = 3
+	Warning Priority = 4
+	Info    Priority = 6
+)
+
+// Sink sends log entries to a system logging facility. Fields are structured
+// data (e.g. job name and result): journald stores them as separate fields,
+// syslog appends them to the message as key=value pairs.
+type Sink interface {
+	Log(priority Priority, message string, fields map[string]string) error
+	Close() error
+}
+
+// Kinds lists the names accepted by Open.
+var Kinds = []string{"syslog", "journald"}
+
+// Open connects to the named logging facility. tag identifies the program
+// (syslog tag, journald SYSLOG_IDENTIFIER).
+func Open(kind, tag string) (Sink, error) {
+	switch kind {
+	case "syslog":
+		return openSyslog(tag)
+	case "journald":
+		return openJournald(tag)
+	default:
+		return nil, fmt.Errorf("unknown log sink %q (valid: %s)", kind, strings.Join(Kinds, ", "))
+	}
+}
+
+// formatFields renders fields as sorted key=value pairs, quoting values with spaces.
+func formatFields(fields map[string]string) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		v := fields[k]
+		if strings.ContainsAny(v, " \t\"") {
+			v = fmt.Sprintf("%q", v)
+		}
+		parts = append(parts, strings.ToLower(k)+"="+v)
+	}
+	return strings.Join(parts, " ")
+}
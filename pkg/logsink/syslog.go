@@ -0,0 +1,40 @@
+// pkg/logsink/syslog.go
+//go:build !windows && !plan9
+
+package logsink
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+// syslogSink writes to the local syslog daemon.
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func openSyslog(tag string) (Sink, error) {
+	writer, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) Log(priority Priority, message string, fields map[string]string) error {
+	if len(fields) > 0 {
+		message += " " + formatFields(fields)
+	}
+	switch priority {
+	case Error:
+		return s.writer.Err(message)
+	case Warning:
+		return s.writer.Warning(message)
+	default:
+		return s.writer.Info(message)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,10 @@
+// pkg/logsink/syslog_other.go
+//go:build windows || plan9
+
+package logsink
+
+import "fmt"
+
+func openSyslog(tag string) (Sink, error) {
+	return nil, fmt.Errorf("syslog is not available on this platform")
+}
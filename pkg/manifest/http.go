@@ -0,0 +1,236 @@
+// pkg/manifest/http.go
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// attempts is how often a download is tried before giving up; every retry
+// resumes where the previous attempt stopped.
+const attempts = 4
+
+// client fetches manifests and files; Configure replaces it.
+var client = func() *http.Client {
+	transport, _ := newTransport(Transport{}) // Can't fail without options
+	return &http.Client{Transport: transport}
+}()
+
+// IsURL reports whether a source names a published tree rather than a local
+// directory.
+func IsURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// Remote is a published tree being read from its URL.
+type Remote struct {
+	Manifest *Manifest
+	base     *url.URL // Directory the manifest's paths are relative to
+}
+
+// Open fetches the manifest of the tree at rawURL: the URL of the tree, whose
+// manifest is FileName inside it, or of a manifest (ending in .json), whose
+// paths are relative to its directory. With a key, the manifest must carry a
+// signature by it (see SignatureSuffix).
+func Open(rawURL string, key ed25519.PublicKey) (*Remote, error) {
+	manifestURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source URL %s: %w", rawURL, err)
+	}
+	base := *manifestURL
+	if strings.HasSuffix(manifestURL.Path, ".json") {
+		base.Path = path.Dir(manifestURL.Path) + "/"
+		base.RawPath = ""
+	} else {
+		manifestURL = manifestURL.JoinPath(FileName)
+	}
+
+	data, err := fetch(manifestURL.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	if key != nil {
+		signature, err := fetch(manifestURL.String() + SignatureSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch manifest signature: %w", err)
+		}
+		if err := verifySignature(data, signature, key); err != nil {
+			return nil, fmt.Errorf("%s: %w", manifestURL, err)
+		}
+	}
+	m, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestURL, err)
+	}
+	return &Remote{Manifest: m, base: &base}, nil
+}
+
+// fetch returns the body of a small document, such as a manifest.
+func fetch(docURL string) ([]byte, error) {
+	resp, err := client.Get(docURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", docURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// URL returns the URL of a file of the tree.
+func (r *Remote) URL(relPath string) string {
+	return r.base.JoinPath(strings.Split(relPath, "/")...).String()
+}
+
+// Finished reports to the server that the tree was mirrored (see DonePath).
+// Servers that don't track their clients ignore it, and so do failures.
+func (r *Remote) Finished() {
+	resp, err := client.Post(r.base.JoinPath(DonePath).String(), "text/plain", nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// Probe checks that a published file can be downloaded, without doing so.
+func Probe(fileURL string) error {
+	resp, err := client.Head(fileURL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
+
+// Download fetches fileURL into path, resuming what an earlier, interrupted
+// download left there, and checks that the result has the entry's size and
+// checksum. A file that fails the check is removed; if it was resumed, the
+// download starts over once, as the part already there may have been stale.
+// progress, if set, is told about every chunk received.
+func Download(fileURL, path string, entry Entry, progress func(int64)) error {
+	_, statErr := os.Stat(path)
+	resumed := statErr == nil
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		var retry bool
+		if retry, err = downloadOnce(fileURL, path, entry.Size, progress); err == nil || !retry {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := verify(path, entry); err != nil {
+		_ = os.Remove(path)
+		if resumed {
+			return Download(fileURL, path, entry, progress)
+		}
+		return err
+	}
+	return nil
+}
+
+// downloadOnce continues the download of fileURL into path from the length
+// already there. It reports whether a failure is worth retrying.
+func downloadOnce(fileURL, path string, size int64, progress func(int64)) (retry bool, err error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return false, err
+	}
+	if offset > size { // Not a prefix of this file
+		if err := file.Truncate(0); err != nil {
+			return false, err
+		}
+		offset, _ = file.Seek(0, io.SeekStart)
+	}
+	if offset == size {
+		return false, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fileURL, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+	case resp.StatusCode == http.StatusOK:
+		// The server sent the whole file (no range support): start over
+		if err := file.Truncate(0); err != nil {
+			return false, err
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+	default:
+		return resp.StatusCode >= 500, fmt.Errorf("failed to download %s: %s", fileURL, resp.Status)
+	}
+
+	var body io.Reader = resp.Body
+	if progress != nil {
+		body = io.TeeReader(body, progressFunc(progress))
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		return true, fmt.Errorf("failed to download %s: %w", fileURL, err)
+	}
+	return false, nil
+}
+
+// verify checks a downloaded file against its manifest entry.
+func verify(path string, entry Entry) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() != entry.Size {
+		return fmt.Errorf("downloaded %s has %d bytes, the manifest says %d", entry.Path, info.Size(), entry.Size)
+	}
+	if entry.SHA256 == "" {
+		return nil
+	}
+	sum, err := fileinfo.SHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, entry.SHA256) {
+		return fmt.Errorf("downloaded %s does not match its checksum in the manifest", entry.Path)
+	}
+	return nil
+}
+
+// progressFunc adapts a progress callback to an io.Writer.
+type progressFunc func(int64)
+
+func (f progressFunc) Write(p []byte) (int, error) {
+	f(int64(len(p)))
+	return len(p), nil
+}
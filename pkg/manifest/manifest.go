@@ -0,0 +1,205 @@
+// pkg/manifest/manifest.go
+// Package manifest publishes directory trees over HTTP(S). A manifest lists
+// every file and directory of a tree with its size, mtime, mode and checksum;
+// served next to the files (by `sync-dir serve` or any static web server),
+// it lets sync-dir mirror the tree from its URL without listing directories,
+// downloading files with range requests so that interrupted transfers
+// resume. A manifest may be signed, so mirrors can check where it came from.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+)
+
+// FileName is the name of the manifest in the root of a published tree.
+const FileName = ".sync-manifest.json"
+
+// Version is the manifest format written by Build.
+const Version = 1
+
+// Manifest describes a published tree.
+type Manifest struct {
+	Version   int       `json:"version"`
+	Generated time.Time `json:"generated"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Entry is a file or directory of the tree.
+type Entry struct {
+	Path    string      `json:"path"` // Slash-separated, relative to the root
+	Dir     bool        `json:"dir,omitempty"`
+	Size    int64       `json:"size,omitempty"`
+	ModTime time.Time   `json:"mtime"`
+	Mode    fs.FileMode `json:"mode"`             // Permission bits
+	SHA256  string      `json:"sha256,omitempty"` // Hex checksum of files
+}
+
+// Build walks root and describes its files and directories, hashing every
+// file (one per CPU at a time). Paths matched by matcher (nil = none) and the manifest itself are
+// left out; symlinks and special files can't be published and are skipped.
+func Build(root string, matcher *ignore.Matcher) (*Manifest, error) {
+	return Rebuild(root, matcher, nil)
+}
+
+// Rebuild is Build for a tree described before by prev: files with the size
+// and mtime prev lists keep its checksum instead of being hashed again, and
+// if nothing changed, the manifest keeps prev's Generated time.
+func Rebuild(root string, matcher *ignore.Matcher, prev *Manifest) (*Manifest, error) {
+	known := make(map[string]Entry)
+	if prev != nil {
+		for _, entry := range prev.Entries {
+			known[entry.Path] = entry
+		}
+	}
+	m := &Manifest{Version: Version, Generated: time.Now().UTC()}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == FileName {
+			return nil
+		}
+		if matcher != nil && d.IsDir() && matcher.MatchesDir(relPath) {
+			return filepath.SkipDir
+		}
+		if matcher != nil && !d.IsDir() && matcher.MatchesFile(relPath) {
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := Entry{Path: filepath.ToSlash(relPath), Dir: d.IsDir(), ModTime: info.ModTime().UTC(), Mode: info.Mode().Perm()}
+		if !entry.Dir {
+			entry.Size = info.Size()
+			if old, ok := known[entry.Path]; ok && !old.Dir && old.Size == entry.Size && old.ModTime.Equal(entry.ModTime) {
+				entry.SHA256 = old.SHA256
+			}
+		}
+		m.Entries = append(m.Entries, entry)
+		return nil
+	})
+	if err == nil {
+		err = hashEntries(root, m.Entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe %s: %w", root, err)
+	}
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Path < m.Entries[j].Path })
+	if prev != nil && sameEntries(prev.Entries, m.Entries) {
+		m.Generated = prev.Generated
+	}
+	return m, nil
+}
+
+// hashEntries computes the checksums entries lack, one file per CPU at a
+// time.
+func hashEntries(root string, entries []Entry) error {
+	todo := make(chan *Entry)
+	errs := make(chan error, 1)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range todo {
+				sum, err := fileinfo.SHA256(filepath.Join(root, filepath.FromSlash(entry.Path)))
+				if err != nil {
+					select {
+					case errs <- err:
+					default:
+					}
+					continue
+				}
+				entry.SHA256 = sum
+			}
+		}()
+	}
+	for i := range entries {
+		if !entries[i].Dir && entries[i].SHA256 == "" {
+			todo <- &entries[i]
+		}
+	}
+	close(todo)
+	wg.Wait()
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// sameEntries reports whether two sorted entry lists describe the same tree.
+func sameEntries(a, b []Entry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Path != b[i].Path || a[i].Dir != b[i].Dir || a[i].Size != b[i].Size || !a[i].ModTime.Equal(b[i].ModTime) ||
+			a[i].Mode != b[i].Mode || a[i].SHA256 != b[i].SHA256 {
+			return false
+		}
+	}
+	return true
+}
+
+// Write writes m as indented JSON.
+func (m *Manifest) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(m)
+}
+
+// Parse reads a manifest and checks that its paths stay inside the tree.
+func Parse(r io.Reader) (*Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version < 1 || m.Version > Version {
+		return nil, fmt.Errorf("unsupported manifest version %d (this build reads up to %d)", m.Version, Version)
+	}
+	for _, entry := range m.Entries {
+		if !validPath(entry.Path) {
+			return nil, fmt.Errorf("invalid manifest: path %q leaves the tree", entry.Path)
+		}
+	}
+	return &m, nil
+}
+
+// validPath reports whether a manifest path is relative, clean and inside
+// the tree.
+func validPath(path string) bool {
+	if path == "" || strings.HasPrefix(path, "/") || strings.Contains(path, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
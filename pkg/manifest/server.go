@@ -0,0 +1,234 @@
+// pkg/manifest/server.go
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+)
+
+// Server publishes a tree over HTTP: its manifest as FileName (signed as
+// FileName+SignatureSuffix when it has a key), and the files the manifest
+// lists, with range requests. Anything else, including files added since the
+// manifest was built, is not found. Responses carry an ETag and a
+// Last-Modified time, so clients polling the manifest get 304 Not Modified
+// until the tree changes. Many clients can mirror the tree at once from the
+// one description; the server counts what each was sent.
+type Server struct {
+	Done    func(client Client) // Told when a client reports it finished mirroring (nil = reports not accepted)
+	root    string
+	matcher *ignore.Matcher
+	key     ed25519.PrivateKey // nil = unsigned
+	mu      sync.RWMutex
+	current *published
+	clients map[string]*Client
+}
+
+// DonePath is where a client reports, with a POST, that it finished
+// mirroring the tree.
+const DonePath = ".sync-done"
+
+// Client is what a server knows about one client, by IP address.
+type Client struct {
+	Addr     string
+	Files    int   // File downloads served, including partial ones
+	Bytes    int64 // Bytes of files served
+	Finished bool  // The client reported it finished
+}
+
+// published is the manifest the server is publishing, ready to be served.
+type published struct {
+	manifest  *Manifest
+	data      []byte
+	signature []byte // nil = unsigned
+	etag      string
+	files     map[string]Entry
+}
+
+// NewServer describes root (leaving out what matcher matches, nil = none) and
+// returns a server publishing it, signing its manifest with key (nil = none).
+func NewServer(root string, matcher *ignore.Matcher, key ed25519.PrivateKey) (*Server, error) {
+	s := &Server{root: root, matcher: matcher, key: key, clients: make(map[string]*Client)}
+	if _, err := s.Rebuild(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Manifest returns the manifest being published.
+func (s *Server) Manifest() *Manifest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.manifest
+}
+
+// Rebuild describes the tree again, hashing only files whose size or mtime
+// changed, and publishes the result. It reports whether the tree changed.
+func (s *Server) Rebuild() (bool, error) {
+	var prev *Manifest
+	if s.current != nil {
+		prev = s.Manifest()
+	}
+	m, err := Rebuild(s.root, s.matcher, prev)
+	if err != nil {
+		return false, err
+	}
+	if prev != nil && m.Generated.Equal(prev.Generated) {
+		return false, nil
+	}
+
+	var data bytes.Buffer
+	_ = m.Write(&data) // Writing to memory can't fail
+	sum := sha256.Sum256(data.Bytes())
+	p := &published{
+		manifest: m,
+		data:     data.Bytes(),
+		etag:     `"` + hex.EncodeToString(sum[:16]) + `"`,
+		files:    make(map[string]Entry, len(m.Entries)),
+	}
+	if s.key != nil {
+		p.signature = Sign(p.data, s.key)
+	}
+	for _, entry := range m.Entries {
+		if !entry.Dir {
+			p.files[entry.Path] = entry
+		}
+	}
+	s.mu.Lock()
+	s.current = p
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Clients returns what the server knows about its clients, by address.
+func (s *Server) Clients() []Client {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	clients := make([]Client, 0, len(s.clients))
+	for _, c := range s.clients {
+		clients = append(clients, *c)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Addr < clients[j].Addr })
+	return clients
+}
+
+// client returns the record of the client making r, creating it if needed.
+// The caller holds s.mu.
+func (s *Server) client(r *http.Request) *Client {
+	addr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		addr = r.RemoteAddr
+	}
+	c, ok := s.clients[addr]
+	if !ok {
+		c = &Client{Addr: addr}
+		s.clients[addr] = c
+	}
+	return c
+}
+
+// finished records that the client making r reported it finished.
+func (s *Server) finished(w http.ResponseWriter, r *http.Request) {
+	if s.Done == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	c := s.client(r)
+	c.Finished = true
+	done := *c
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+	s.Done(done)
+}
+
+// countingWriter counts the bytes of a response body.
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// ServeHTTP serves the manifest, its signature and the tree's files, and
+// accepts reports at DonePath.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && r.URL.Path == "/"+DonePath {
+		s.finished(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.RLock()
+	p := s.current
+	s.mu.RUnlock()
+
+	relPath := strings.TrimPrefix(r.URL.Path, "/")
+	switch relPath {
+	case FileName:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache") // Revalidated on every use
+		w.Header().Set("ETag", p.etag)
+		http.ServeContent(w, r, FileName, p.manifest.Generated, bytes.NewReader(p.data))
+		return
+	case FileName + SignatureSuffix:
+		if p.signature == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", strings.TrimSuffix(p.etag, `"`)+`-sig"`)
+		http.ServeContent(w, r, relPath, p.manifest.Generated, bytes.NewReader(p.signature))
+		return
+	}
+
+	entry, ok := p.files[relPath]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	file, err := os.Open(filepath.Join(s.root, filepath.FromSlash(relPath)))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+		// Its content would fail the checksum clients check it against
+		http.Error(w, "changed since the manifest was built", http.StatusConflict)
+		return
+	}
+	w.Header().Set("ETag", `"`+entry.SHA256+`"`)
+	counter := &countingWriter{ResponseWriter: w}
+	http.ServeContent(counter, r, relPath, info.ModTime(), file)
+	if r.Method == http.MethodGet && counter.n > 0 {
+		s.mu.Lock()
+		c := s.client(r)
+		c.Files++
+		c.Bytes += counter.n
+		s.mu.Unlock()
+	}
+}
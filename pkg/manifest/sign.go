@@ -0,0 +1,80 @@
+// pkg/manifest/sign.go
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SignatureSuffix is appended to the name of a manifest for its signature:
+// the base64 Ed25519 signature of the manifest's exact bytes.
+const SignatureSuffix = ".sig"
+
+// Sign returns the signature of a manifest's bytes, as published next to it.
+func Sign(data []byte, key ed25519.PrivateKey) []byte {
+	return []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n")
+}
+
+// verifySignature checks a published signature of a manifest's bytes.
+func verifySignature(data, signature []byte, key ed25519.PublicKey) error {
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil || !ed25519.Verify(key, data, sig) {
+		return errors.New("the manifest's signature does not match the key")
+	}
+	return nil
+}
+
+// LoadSigningKey reads the private key in path, or generates one there (with
+// the public key in path+".pub") if the file doesn't exist. It reports
+// whether the key was generated.
+func LoadSigningKey(path string) (ed25519.PrivateKey, bool, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, false, fmt.Errorf("%s is not a signing key", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), false, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, false, err
+	}
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key.Seed())+"\n"), 0600); err != nil {
+		return nil, false, fmt.Errorf("failed to write signing key: %w", err)
+	}
+	if err := os.WriteFile(path+".pub", []byte(EncodePublicKey(pub)+"\n"), 0644); err != nil {
+		return nil, false, fmt.Errorf("failed to write public key: %w", err)
+	}
+	return key, true, nil
+}
+
+// EncodePublicKey returns the text form of a public key, as ParsePublicKey
+// reads it.
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParsePublicKey reads a public key given as its text form or as a file
+// holding it (such as the .pub file written by LoadSigningKey).
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	text := s
+	if data, err := os.ReadFile(s); err == nil {
+		text = string(data)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%s is neither a public key nor a file holding one", s)
+	}
+	return ed25519.PublicKey(key), nil
+}
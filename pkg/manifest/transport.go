@@ -0,0 +1,89 @@
+// pkg/manifest/transport.go
+package manifest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Transport configures how published trees are fetched, e.g. through a
+// corporate proxy or from a server whose certificate a private CA issued.
+type Transport struct {
+	Proxy      string // http://, https:// or socks5:// URL ("" = HTTPS_PROXY etc. from the environment)
+	CACert     string // PEM file of CA certificates trusted besides the system's
+	ClientCert string // PEM certificate presented to servers that ask for one
+	ClientKey  string // Its private key ("" = in ClientCert)
+}
+
+// Configure sets up the client used by Open, Probe, Download and Finished.
+func Configure(opts Transport) error {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return err
+	}
+	client = &http.Client{Transport: transport}
+	return nil
+}
+
+// newTransport returns the transport of the client. There is no overall
+// timeout, as files may be large, but a server that stops responding is
+// given up on.
+func newTransport(opts Transport) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   30 * time.Second,
+		ResponseHeaderTimeout: 60 * time.Second,
+		MaxIdleConnsPerHost:   16,
+	}
+	if opts.Proxy != "" {
+		proxyURL, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %s: %w", opts.Proxy, err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("invalid proxy URL %s: scheme must be http, https, socks5 or socks5h", opts.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	if opts.CACert == "" && opts.ClientCert == "" {
+		return transport, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts.CACert != "" {
+		pem, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool() // E.g. on systems without a readable store
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", opts.CACert)
+		}
+		config.RootCAs = pool
+	}
+	if opts.ClientCert != "" {
+		key := opts.ClientKey
+		if key == "" {
+			key = opts.ClientCert
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = config
+	return transport, nil
+}
@@ -0,0 +1,134 @@
+// pkg/notify/notify.go
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/config"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// requestTimeout bounds each webhook call, so an unreachable chat service
+// can't hold up the end of a sync.
+const requestTimeout = 15 * time.Second
+
+// discordLimit is the maximum length of a Discord message, in characters.
+const discordLimit = 2000
+
+// DefaultTemplate is the message used when a notifier has no template.
+const DefaultTemplate = `sync-dir {{if .Profile}}[{{.Profile}}] {{end}}{{.Outcome}}: {{.Source}} -> {{.Target}}
+{{t "notify.counts" .Adds .Updates .Deletes .Renames (bytes .Bytes) .Duration}}
+{{- range .Errors}}
+{{t "notify.error"}}: {{.}}{{end}}`
+
+// Notifier posts run summaries to one chat webhook.
+type Notifier struct {
+	kind     string
+	url      string
+	template string
+	on       string
+	client   *http.Client
+}
+
+// New validates a notifier from the config file.
+func New(cfg config.Notifier) (*Notifier, error) {
+	switch cfg.Type {
+	case "slack", "discord", "teams":
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q (valid: slack, discord, teams)", cfg.Type)
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("%s notifier without a url", cfg.Type)
+	}
+	on := cfg.On
+	switch on {
+	case "":
+		on = "always"
+	case "always", "failure", "changes":
+	default:
+		return nil, fmt.Errorf("%s notifier: invalid \"on\" value %q (valid: always, failure, changes)", cfg.Type, cfg.On)
+	}
+	tmpl := cfg.Template
+	if tmpl == "" {
+		tmpl = DefaultTemplate
+	}
+	if _, err := (&summary.Summary{}).Render(tmpl); err != nil {
+		return nil, fmt.Errorf("%s notifier: %w", cfg.Type, err)
+	}
+	return &Notifier{
+		kind:     cfg.Type,
+		url:      cfg.URL,
+		template: tmpl,
+		on:       on,
+		client:   &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// Type returns the notifier's service name.
+func (n *Notifier) Type() string {
+	return n.kind
+}
+
+// Wants reports whether the notifier is configured to report this run.
+func (n *Notifier) Wants(s *summary.Summary) bool {
+	switch n.on {
+	case "failure":
+		return len(s.Errors) > 0
+	case "changes":
+		return len(s.Errors) > 0 || (s.Executed && s.Changes() > 0)
+	default:
+		return true
+	}
+}
+
+// Send posts the summary to the webhook.
+func (n *Notifier) Send(s *summary.Summary) error {
+	text, err := s.Render(n.template)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(n.payload(text))
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s webhook returned %s: %s", n.kind, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// payload wraps the message in the JSON body each service expects.
+func (n *Notifier) payload(text string) any {
+	switch n.kind {
+	case "discord":
+		if runes := []rune(text); len(runes) > discordLimit {
+			text = string(runes[:discordLimit-3]) + "..."
+		}
+		return map[string]string{"content": text}
+	case "teams":
+		// Office 365 connector card; Teams renders its text as Markdown,
+		// where line breaks need two trailing spaces
+		return map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  "sync-dir run",
+			"text":     strings.ReplaceAll(text, "\n", "  \n"),
+		}
+	default: // slack
+		return map[string]string{"text": text}
+	}
+}
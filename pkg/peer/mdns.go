@@ -0,0 +1,278 @@
+// pkg/peer/mdns.go
+package peer
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Peers are found with DNS-SD over multicast DNS (RFC 6762, 6763), the way
+// printers and file shares are: a receiver answers queries for ServiceType
+// with its instance name and port. Only what that needs is implemented.
+
+// ServiceType is the DNS-SD service receivers announce.
+const ServiceType = "_sync-dir._tcp.local."
+
+const (
+	typePTR = 12
+	typeTXT = 16
+	typeSRV = 33
+	classIN = 1
+	mdnsTTL = 120
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Peer is a receiver found on the network.
+type Peer struct {
+	Name string // Instance name, as given to push
+	Addr string // host:port to connect to
+}
+
+// Announce answers queries for ServiceType with name and port until stop is
+// closed. Answers are sent straight back to whoever asked, so browsers don't
+// need to bind the mDNS port.
+func Announce(name string, port int, stop <-chan struct{}) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-stop
+		conn.Close()
+	}()
+
+	instance := escapeLabel(name) + "." + ServiceType
+	answer := mdnsAnswer(instance, port)
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-stop:
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			continue
+		}
+		if asksFor(buf[:n], ServiceType) || asksFor(buf[:n], instance) {
+			_, _ = conn.WriteToUDP(answer, from)
+		}
+	}
+}
+
+// Browse asks the network for receivers and collects the answers that
+// arrive within timeout, sorted by name.
+func Browse(timeout time.Duration) ([]Peer, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, err := conn.WriteToUDP(mdnsQuery(ServiceType), mdnsGroup); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]Peer)
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 9000)
+	for {
+		_ = conn.SetReadDeadline(deadline)
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break // Timed out
+		}
+		if p, ok := parseAnswer(buf[:n], from.IP); ok {
+			found[p.Name] = p
+		}
+	}
+	peers := make([]Peer, 0, len(found))
+	for _, p := range found {
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+	return peers, nil
+}
+
+// Resolve finds the receiver called name.
+func Resolve(name string, timeout time.Duration) (Peer, error) {
+	peers, err := Browse(timeout)
+	if err != nil {
+		return Peer{}, err
+	}
+	for _, p := range peers {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return Peer{}, errors.New("no receiver called " + name + " answered on the local network")
+}
+
+// mdnsQuery is a PTR question for service.
+func mdnsQuery(service string) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[4:], 1) // One question
+	msg = appendName(msg, service)
+	return binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(msg, typePTR), classIN)
+}
+
+// mdnsAnswer is the response announcing instance: its PTR, SRV and TXT
+// records. The address is taken from where the response comes from.
+func mdnsAnswer(instance string, port int) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[2:], 0x8400) // Authoritative response
+	binary.BigEndian.PutUint16(msg[6:], 3)      // Answers
+
+	msg = appendRecord(msg, ServiceType, typePTR, appendName(nil, instance))
+	srv := make([]byte, 6) // Priority and weight 0
+	binary.BigEndian.PutUint16(srv[4:], uint16(port))
+	msg = appendRecord(msg, instance, typeSRV, appendName(srv, "sync-dir.local."))
+	txt := "v=" + strconv.Itoa(ProtocolVersion)
+	return appendRecord(msg, instance, typeTXT, append([]byte{byte(len(txt))}, txt...))
+}
+
+func appendRecord(msg []byte, name string, rrType uint16, data []byte) []byte {
+	msg = appendName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, rrType)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	msg = binary.BigEndian.AppendUint32(msg, mdnsTTL)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(data)))
+	return append(msg, data...)
+}
+
+// appendName appends a domain name in wire format, without compression.
+// Dots escaped with a backslash belong to the label.
+func appendName(msg []byte, name string) []byte {
+	for _, label := range splitName(name) {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0)
+}
+
+// splitName splits a dotted name into its labels.
+func splitName(name string) []string {
+	var labels []string
+	var label strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '\\' && i+1 < len(name):
+			i++
+			label.WriteByte(name[i])
+		case name[i] == '.':
+			labels = append(labels, label.String())
+			label.Reset()
+		default:
+			label.WriteByte(name[i])
+		}
+	}
+	if label.Len() > 0 {
+		labels = append(labels, label.String())
+	}
+	return labels
+}
+
+func escapeLabel(label string) string {
+	label = strings.ReplaceAll(label, `\`, `\\`)
+	return strings.ReplaceAll(label, ".", `\.`)
+}
+
+// readName reads the (possibly compressed) name at off in msg, returning it
+// dotted and escaped, and the offset after it.
+func readName(msg []byte, off int) (string, int, error) {
+	var name strings.Builder
+	end := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errors.New("truncated name")
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return name.String(), end, nil
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(msg) || jumps > 16 {
+				return "", 0, errors.New("bad name pointer")
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+			jumps++
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errors.New("truncated label")
+			}
+			name.WriteString(escapeLabel(string(msg[off+1 : off+1+n])))
+			name.WriteByte('.')
+			off += 1 + n
+		}
+	}
+}
+
+// asksFor reports whether msg is a query with a question about name.
+func asksFor(msg []byte, name string) bool {
+	if len(msg) < 12 || msg[2]&0x80 != 0 {
+		return false
+	}
+	off := 12
+	for i := 0; i < int(binary.BigEndian.Uint16(msg[4:])); i++ {
+		q, next, err := readName(msg, off)
+		if err != nil || next+4 > len(msg) {
+			return false
+		}
+		if strings.EqualFold(q, name) {
+			return true
+		}
+		off = next + 4
+	}
+	return false
+}
+
+// parseAnswer reads a receiver's announcement, sent from ip.
+func parseAnswer(msg []byte, ip net.IP) (Peer, bool) {
+	if len(msg) < 12 || msg[2]&0x80 == 0 {
+		return Peer{}, false
+	}
+	off := 12
+	for i := 0; i < int(binary.BigEndian.Uint16(msg[4:])); i++ { // Skip questions
+		_, next, err := readName(msg, off)
+		if err != nil {
+			return Peer{}, false
+		}
+		off = next + 4
+	}
+	records := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+	for i := 0; i < records; i++ {
+		owner, next, err := readName(msg, off)
+		if err != nil || next+10 > len(msg) {
+			return Peer{}, false
+		}
+		rrType := binary.BigEndian.Uint16(msg[next:])
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		data := next + 10
+		if data+length > len(msg) {
+			return Peer{}, false
+		}
+		if rrType == typeSRV && length >= 6 && strings.HasSuffix(strings.ToLower(owner), "."+ServiceType) {
+			port := binary.BigEndian.Uint16(msg[data+4:])
+			instance := splitName(strings.TrimSuffix(owner, "."+ServiceType))
+			if len(instance) == 1 {
+				return Peer{Name: instance[0], Addr: net.JoinHostPort(ip.String(), strconv.Itoa(int(port)))}, true
+			}
+		}
+		off = data + length
+	}
+	return Peer{}, false
+}
@@ -0,0 +1,141 @@
+// pkg/peer/peer.go
+// Package peer syncs a directory straight to another machine on the LAN. The
+// receiving machine runs a receiver, which announces itself over multicast
+// DNS; the sending one finds it by name, compares the two trees and pushes
+// the difference over a small TCP protocol, without SSH or file shares.
+//
+// The protocol is a gob stream: the pusher sends requests, and the receiver
+// answers each one, except for the chunks of a file being sent. Nothing is
+// encrypted; the receiver only checks a shared token, so it's meant for
+// trusted networks.
+package peer
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ProtocolVersion is the version of the protocol; peers speaking another one
+// refuse to talk.
+const ProtocolVersion = 2
+
+// DefaultPort is the TCP port receivers listen on unless told otherwise.
+const DefaultPort = 7844
+
+// chunkSize is how much of a file is sent per message.
+const chunkSize = 1 << 20
+
+// Entry is a file or directory on either side.
+type Entry struct {
+	Path    string // Slash-separated, relative to the synced directory
+	Dir     bool
+	Size    int64
+	ModTime time.Time
+	Mode    fs.FileMode // Permission bits; receivers ignore any other bits sent
+}
+
+// Operations a pusher requests.
+const (
+	opHello  = "hello"  // Version, Token and Path: the directory to sync into
+	opList   = "list"   // List the directory
+	opMkdir  = "mkdir"  // Create Entry, a directory
+	opPut    = "put"    // Receive Entry, a file, in the chunks that follow
+	opChunk  = "chunk"  // Data of the file being put; an empty one ends it
+	opDelete = "delete" // Remove Path, with everything below it
+	opHash   = "hash"   // Return the SHA256 of each of Paths, computed on the receiver
+	opTouch  = "touch"  // Set the mode and mtime of Entry, whose content is already right
+	opDone   = "done"   // Finish: set the mtimes of the directories created
+)
+
+type request struct {
+	Op      string
+	Version int
+	Token   string
+	Path    string
+	Paths   []string
+	Entry   Entry
+	Data    []byte
+}
+
+type response struct {
+	Error   string
+	Entries []Entry
+	Sums    []string // Hex SHA256 per path of a hash request ("" = unreadable)
+}
+
+// conn is one end of a connection.
+type conn struct {
+	net.Conn
+	w   *bufio.Writer
+	enc *gob.Encoder
+	dec *gob.Decoder
+}
+
+func newConn(c net.Conn) *conn {
+	w := bufio.NewWriterSize(c, chunkSize+4096)
+	return &conn{Conn: c, w: w, enc: gob.NewEncoder(w), dec: gob.NewDecoder(bufio.NewReader(c))}
+}
+
+// send writes a message and flushes it.
+func (c *conn) send(v any) error {
+	if err := c.enc.Encode(v); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+// validPath reports whether a path from the other side is relative, clean
+// and stays inside the synced directory.
+func validPath(path string) bool {
+	if path == "" || strings.HasPrefix(path, "/") || strings.Contains(path, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(path, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// localPath turns a path from the other side into one below root. A symlink
+// already below root could lead out of it, so none of the directories on the
+// way may be one; the last name may, as it's replaced or removed rather than
+// followed (see notSymlink for the operations that would follow it).
+func localPath(root, path string) (string, error) {
+	if !validPath(path) {
+		return "", fmt.Errorf("invalid path %q", path)
+	}
+	parts := strings.Split(path, "/")
+	dir := root
+	for i, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			break // Nothing below it exists either
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", fmt.Errorf("invalid path %q: %s is a symlink", path, strings.Join(parts[:i+1], "/"))
+		}
+	}
+	return filepath.Join(root, filepath.FromSlash(path)), nil
+}
+
+// notSymlink fails if path is a symlink, for operations that would follow
+// it out of the synced directory.
+func notSymlink(path string) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		return fmt.Errorf("%s is a symlink", path)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+// pkg/peer/peer_test.go
+package peer
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"file", true},
+		{"dir/file", true},
+		{"dir/.hidden", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"..", false},
+		{"../outside", false},
+		{"dir/../../outside", false},
+		{"dir/./file", false},
+		{"dir//file", false},
+		{"dir/", false},
+		{`dir\..\outside`, false},
+	}
+	for _, tt := range tests {
+		if got := validPath(tt.path); got != tt.want {
+			t.Errorf("validPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// A symlink below the receive directory is never followed out of it.
+func TestLocalPathSymlink(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+	if _, err := localPath(root, "link/file"); err == nil {
+		t.Error("localPath accepted a path through a symlink")
+	}
+	if _, err := localPath(root, "link"); err != nil {
+		t.Errorf("localPath rejected the symlink itself: %v", err)
+	}
+	if _, err := localPath(root, "missing/dir/file"); err != nil {
+		t.Errorf("localPath rejected a path not created yet: %v", err)
+	}
+}
+
+// startReceiver serves a receiver on a loopback port and returns its address.
+func startReceiver(t *testing.T, r *Receiver) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() { _ = r.Serve(l) }()
+	return l.Addr().String()
+}
+
+func TestWrongToken(t *testing.T) {
+	addr := startReceiver(t, &Receiver{Root: t.TempDir(), Token: "secret"})
+	session, err := Dial(addr, "guess", "")
+	if err == nil {
+		session.Close()
+		t.Fatal("Dial succeeded with a wrong token")
+	}
+	if !strings.Contains(err.Error(), "wrong token") {
+		t.Errorf("Dial error = %v, want a wrong token error", err)
+	}
+}
+
+// writeFile creates a file, with its parents, and sets its mtime.
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// push runs a full push of local into the receiver at addr.
+func push(t *testing.T, addr, local string) {
+	t.Helper()
+	entries, err := Scan(local, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := Dial(addr, "secret", "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	remote, err := session.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := PlanPush(entries, remote, nil)
+	if err := session.CompareContent(plan, local, remote); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Apply(plan, local, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPushRoundTrip(t *testing.T) {
+	local, root := t.TempDir(), t.TempDir()
+	addr := startReceiver(t, &Receiver{Root: root, Token: "secret"})
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	writeFile(t, filepath.Join(local, "a.txt"), "alpha", mtime)
+	writeFile(t, filepath.Join(local, "sub", "b.txt"), "bravo", mtime)
+	writeFile(t, filepath.Join(local, "sub", "deep", "c.txt"), "charlie", mtime)
+	writeFile(t, filepath.Join(local, "gone.txt"), "deleted later", mtime)
+
+	push(t, addr, local)
+
+	if err := os.Remove(filepath.Join(local, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(local, "a.txt"), "ALPHA", mtime.Add(time.Hour))
+	push(t, addr, local)
+
+	mirror := filepath.Join(root, "mirror")
+	for rel, want := range map[string]string{"a.txt": "ALPHA", "sub/b.txt": "bravo", "sub/deep/c.txt": "charlie"} {
+		path := filepath.Join(mirror, filepath.FromSlash(rel))
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", rel, err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", rel, got, want)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if local, _ := os.Stat(filepath.Join(local, filepath.FromSlash(rel))); !info.ModTime().Equal(local.ModTime()) {
+			t.Errorf("%s: mtime %v, want %v", rel, info.ModTime(), local.ModTime())
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(mirror, "gone.txt")); !os.IsNotExist(err) {
+		t.Errorf("gone.txt still on the receiver: %v", err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(mirror, ".~sync-dir.*"))
+	if len(leftovers) > 0 {
+		t.Errorf("temp files left on the receiver: %v", leftovers)
+	}
+}
+
+// A pusher can neither write through a symlink on the receiver nor set bits
+// other than permissions.
+func TestReceiverConfinement(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	addr := startReceiver(t, &Receiver{Root: root, Token: "secret"})
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+	session, err := Dial(addr, "secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	if _, err := session.call(request{Op: opMkdir, Entry: Entry{Path: "link/dir", Dir: true, Mode: 0755}}); err == nil {
+		t.Error("mkdir through a symlink succeeded")
+	}
+	if _, err := session.call(request{Op: opPut, Entry: Entry{Path: "link/file", Mode: 0644}}); err == nil {
+		t.Error("put through a symlink succeeded")
+	}
+	if _, err := session.call(request{Op: opTouch, Entry: Entry{Path: "link", Mode: 0777}}); err == nil {
+		t.Error("touch of a symlink succeeded")
+	}
+	if _, err := session.call(request{Op: opMkdir, Entry: Entry{Path: "setuid", Dir: true, Mode: os.ModeSetuid | os.ModeSetgid | os.ModeSticky | 0755}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.call(request{Op: opDone}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("the pusher wrote outside the receive directory: %v", entries)
+	}
+	if info, err := os.Stat(outside); err != nil || info.Mode().Perm() == 0777 {
+		t.Errorf("the pusher changed the mode of a directory outside: %v %v", info.Mode(), err)
+	}
+	info, err := os.Stat(filepath.Join(root, "setuid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if special := info.Mode() & (os.ModeSetuid | os.ModeSetgid | os.ModeSticky); special != 0 {
+		t.Errorf("pushed directory has mode %v, want no special bits", info.Mode())
+	}
+}
@@ -0,0 +1,317 @@
+// pkg/peer/push.go
+package peer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+)
+
+// Session is a push in progress, connected to a receiver.
+type Session struct {
+	c *conn
+}
+
+// Dial connects to the receiver at addr and opens the directory dir below
+// its root (empty = the root itself).
+func Dial(addr, token, dir string) (*Session, error) {
+	c, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{c: newConn(c)}
+	if _, err := s.call(request{Op: opHello, Version: ProtocolVersion, Token: token, Path: dir}); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("receiver refused the push: %w", err)
+	}
+	return s, nil
+}
+
+// Close ends the session without finishing it.
+func (s *Session) Close() error {
+	return s.c.Close()
+}
+
+// call sends a request and waits for its response.
+func (s *Session) call(req request) (response, error) {
+	if err := s.c.send(req); err != nil {
+		return response{}, err
+	}
+	var resp response
+	if err := s.c.dec.Decode(&resp); err != nil {
+		return response{}, err
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// List describes the receiver's directory.
+func (s *Session) List() ([]Entry, error) {
+	resp, err := s.call(request{Op: opList})
+	return resp.Entries, err
+}
+
+// Scan describes the files and directories below root, leaving out what
+// matcher matches (nil = nothing). Symlinks and special files can't be
+// pushed and are skipped.
+func Scan(root string, matcher *ignore.Matcher) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if matcher != nil && d.IsDir() && matcher.MatchesDir(relPath) {
+			return filepath.SkipDir
+		}
+		if matcher != nil && !d.IsDir() && matcher.MatchesFile(relPath) {
+			return nil
+		}
+		if !d.IsDir() && !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := Entry{Path: filepath.ToSlash(relPath), Dir: d.IsDir(), ModTime: info.ModTime(), Mode: info.Mode().Perm()}
+		if !entry.Dir {
+			entry.Size = info.Size()
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// Plan is what a push changes on the receiver.
+type Plan struct {
+	Deletes []Entry // Top-most paths only; everything below goes with them
+	Mkdirs  []Entry
+	Adds    []Entry
+	Updates []Entry
+	Touches []Entry // Files with the same content, whose mode or mtime is set
+	Bytes   int64   // Bytes to send
+}
+
+// Changes is the number of entries the plan adds, updates, touches or
+// deletes.
+func (p *Plan) Changes() int {
+	return len(p.Deletes) + len(p.Mkdirs) + len(p.Adds) + len(p.Updates) + len(p.Touches)
+}
+
+// excludes reports whether matcher (nil = nothing) leaves out a remote entry,
+// whose parents it doesn't leave out.
+func excludes(matcher *ignore.Matcher, entry Entry) bool {
+	relPath := filepath.FromSlash(entry.Path)
+	switch {
+	case matcher == nil:
+		return false
+	case entry.Dir:
+		return matcher.MatchesDir(relPath)
+	default:
+		return matcher.MatchesFile(relPath)
+	}
+}
+
+// PlanPush compares the local and remote trees. Files whose size or mtime
+// differ are sent; remote entries missing locally are deleted, except those
+// matcher matches (nil = nothing), which are left alone like excluded files
+// in a local target.
+func PlanPush(local, remote []Entry, matcher *ignore.Matcher) *Plan {
+	plan := &Plan{}
+	have := make(map[string]Entry, len(remote))
+	excluded := make(map[string]bool)
+	for _, entry := range remote { // Sorted, so parents come first
+		if excluded[path.Dir(entry.Path)] || excludes(matcher, entry) {
+			excluded[entry.Path] = true
+			continue
+		}
+		have[entry.Path] = entry
+	}
+
+	wanted := make(map[string]bool, len(local))
+	for _, entry := range local {
+		old, ok := have[entry.Path]
+		if ok && old.Dir != entry.Dir {
+			ok = false // Replaced by the other type: deleted, then created
+		} else {
+			wanted[entry.Path] = true
+		}
+		switch {
+		case entry.Dir && !ok:
+			plan.Mkdirs = append(plan.Mkdirs, entry)
+		case entry.Dir:
+		case !ok:
+			plan.Adds = append(plan.Adds, entry)
+			plan.Bytes += entry.Size
+		case old.Size != entry.Size || !old.ModTime.Equal(entry.ModTime) || old.Mode != entry.Mode:
+			plan.Updates = append(plan.Updates, entry)
+			plan.Bytes += entry.Size
+		}
+	}
+	deleted := make(map[string]bool)
+	for _, entry := range remote {
+		if _, ok := have[entry.Path]; !ok || wanted[entry.Path] {
+			continue
+		}
+		if !deleted[path.Dir(entry.Path)] {
+			plan.Deletes = append(plan.Deletes, entry)
+		}
+		deleted[entry.Path] = true
+	}
+	sort.Slice(plan.Deletes, func(i, j int) bool { return plan.Deletes[i].Path < plan.Deletes[j].Path })
+	return plan
+}
+
+// hashBatch is how many files the receiver is asked to hash per request.
+const hashBatch = 256
+
+// CompareContent checks the updates of plan whose size is unchanged: the
+// receiver hashes its copies, and those with the content of the local file
+// become touches, which set mode and mtime without sending anything. Only
+// checksums cross the network.
+func (s *Session) CompareContent(plan *Plan, root string, remote []Entry) error {
+	sizes := make(map[string]int64, len(remote))
+	for _, entry := range remote {
+		sizes[entry.Path] = entry.Size
+	}
+	var candidates, updates []Entry
+	for _, entry := range plan.Updates {
+		if size, ok := sizes[entry.Path]; ok && size == entry.Size {
+			candidates = append(candidates, entry)
+		} else {
+			updates = append(updates, entry)
+		}
+	}
+
+	for len(candidates) > 0 {
+		batch := candidates[:min(hashBatch, len(candidates))]
+		candidates = candidates[len(batch):]
+		paths := make([]string, len(batch))
+		for i, entry := range batch {
+			paths[i] = entry.Path
+		}
+		resp, err := s.call(request{Op: opHash, Paths: paths})
+		if err != nil {
+			return fmt.Errorf("receiver failed to hash files: %w", err)
+		}
+		for i, entry := range batch {
+			sum, err := fileinfo.SHA256(filepath.Join(root, filepath.FromSlash(entry.Path)))
+			if err == nil && i < len(resp.Sums) && resp.Sums[i] == sum {
+				plan.Touches = append(plan.Touches, entry)
+				plan.Bytes -= entry.Size
+			} else {
+				updates = append(updates, entry)
+			}
+		}
+	}
+	plan.Updates = updates
+	return nil
+}
+
+// Apply carries out plan: deletes first, then directories, touches, then
+// files, read from root. progress, if set, is told about every entry done and the bytes
+// sent. A file that fails is reported and skipped; the errors are returned
+// together at the end.
+func (s *Session) Apply(plan *Plan, root string, progress func(entry Entry, sent int64)) error {
+	var errs []error
+	report := func(entry Entry, sent int64, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Path, err))
+		}
+		if progress != nil {
+			progress(entry, sent)
+		}
+	}
+
+	for _, entry := range plan.Deletes {
+		_, err := s.call(request{Op: opDelete, Path: entry.Path})
+		report(entry, 0, err)
+	}
+	for _, entry := range plan.Mkdirs {
+		_, err := s.call(request{Op: opMkdir, Entry: entry})
+		report(entry, 0, err)
+	}
+	for _, entry := range plan.Touches {
+		_, err := s.call(request{Op: opTouch, Entry: entry})
+		report(entry, 0, err)
+	}
+	for _, entry := range append(append([]Entry(nil), plan.Adds...), plan.Updates...) {
+		err := s.sendFile(root, entry)
+		var lost net.Error
+		if errors.As(err, &lost) || errors.Is(err, io.EOF) {
+			return fmt.Errorf("connection to the receiver lost: %w", err)
+		}
+		report(entry, entry.Size, err)
+	}
+	if _, err := s.call(request{Op: opDone}); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// sendFile sends a local file in chunks. If it changed size while being
+// read, the receiver refuses it.
+func (s *Session) sendFile(root string, entry Entry) error {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(entry.Path)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := s.call(request{Op: opPut, Entry: entry}); err != nil {
+		return err
+	}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			if err := s.c.send(request{Op: opChunk, Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			// End the file so the receiver drops it, then report the read error
+			_, _ = s.call(request{Op: opChunk})
+			return err
+		}
+	}
+	_, err = s.call(request{Op: opChunk})
+	return err
+}
+
+// ParseTarget splits a push target "peer:path" into the receiver's name and
+// the directory below its root.
+func ParseTarget(target string) (name, dir string, err error) {
+	name, dir, ok := strings.Cut(target, ":")
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid push target %q (expected peer:/path)", target)
+	}
+	return name, strings.Trim(dir, "/"), nil
+}
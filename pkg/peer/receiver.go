@@ -0,0 +1,324 @@
+// pkg/peer/receiver.go
+package peer
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/syncer"
+)
+
+// Receiver accepts pushes into directories below Root.
+type Receiver struct {
+	Root  string
+	Token string                                            // Pushers must present this token
+	Log   func(remote net.Addr, format string, args ...any) // Told about every session (nil = nobody)
+}
+
+// Serve handles the connections accepted on l until it fails.
+func (r *Receiver) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer c.Close()
+			if err := r.handle(newConn(c)); err != nil {
+				r.log(c.RemoteAddr(), "Session failed: %v", err)
+			}
+		}()
+	}
+}
+
+func (r *Receiver) log(remote net.Addr, format string, args ...any) {
+	if r.Log != nil {
+		r.Log(remote, format, args...)
+	}
+}
+
+// session is the state of one push.
+type session struct {
+	dir     string           // Directory being synced into
+	created map[string]Entry // Directories created, whose mtime is set at the end
+	file    *os.File         // File being received, under a temp name
+	entry   Entry            // The entry of file
+	written int64            // Bytes of file received so far
+	failed  error            // Why writing file failed, reported when it ends
+}
+
+func (r *Receiver) handle(c *conn) error {
+	var hello request
+	if err := c.dec.Decode(&hello); err != nil {
+		return err
+	}
+	s, err := r.open(hello)
+	if err != nil {
+		_ = c.send(response{Error: err.Error()})
+		return err
+	}
+	if err := c.send(response{}); err != nil {
+		return err
+	}
+	r.log(c.RemoteAddr(), "Receiving into %s", s.dir)
+	defer s.abort()
+
+	for {
+		var req request
+		if err := c.dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("the pusher hung up")
+			}
+			return err
+		}
+		if req.Op == opChunk && len(req.Data) > 0 {
+			s.write(req.Data)
+			continue
+		}
+		var resp response
+		var err error
+		switch req.Op {
+		case opList:
+			resp.Entries, err = list(s.dir)
+		case opMkdir:
+			err = s.mkdir(req.Entry)
+		case opPut:
+			err = s.put(req.Entry)
+		case opChunk:
+			err = s.finish()
+		case opDelete:
+			err = s.remove(req.Path)
+		case opHash:
+			resp.Sums, err = s.hash(req.Paths)
+		case opTouch:
+			err = s.touch(req.Entry)
+		case opDone:
+			err = s.done()
+		default:
+			err = fmt.Errorf("unknown operation %q", req.Op)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err := c.send(resp); err != nil {
+			return err
+		}
+		if req.Op == opDone {
+			r.log(c.RemoteAddr(), "Push into %s finished", s.dir)
+			return nil
+		}
+	}
+}
+
+// open checks a pusher's hello and prepares the directory it syncs into.
+func (r *Receiver) open(hello request) (*session, error) {
+	if hello.Op != opHello || hello.Version != ProtocolVersion {
+		return nil, fmt.Errorf("unsupported protocol version %d (this receiver speaks %d)", hello.Version, ProtocolVersion)
+	}
+	if subtle.ConstantTimeCompare([]byte(hello.Token), []byte(r.Token)) != 1 {
+		return nil, errors.New("wrong token")
+	}
+	dir := r.Root
+	if path := strings.Trim(hello.Path, "/"); path != "" {
+		var err error
+		if dir, err = localPath(r.Root, path); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if dir != r.Root {
+		if err := notSymlink(dir); err != nil {
+			return nil, err
+		}
+	}
+	return &session{dir: dir, created: make(map[string]Entry)}, nil
+}
+
+// list describes the files and directories below dir. Symlinks, special
+// files and temp files are left out.
+func list(dir string) ([]Entry, error) {
+	var entries []Entry
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir || strings.HasPrefix(d.Name(), syncer.TempPrefix) || (!d.IsDir() && !d.Type().IsRegular()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		relPath, _ := filepath.Rel(dir, path)
+		entry := Entry{Path: filepath.ToSlash(relPath), Dir: d.IsDir(), ModTime: info.ModTime(), Mode: info.Mode().Perm()}
+		if !entry.Dir {
+			entry.Size = info.Size()
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, err
+}
+
+func (s *session) mkdir(entry Entry) error {
+	path, err := localPath(s.dir, entry.Path)
+	if err != nil {
+		return err
+	}
+	if err := notSymlink(path); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path, entry.Mode.Perm()|0700); err != nil {
+		return err
+	}
+	s.created[path] = entry
+	return nil
+}
+
+// put starts receiving a file next to its destination.
+func (s *session) put(entry Entry) error {
+	s.abort()
+	path, err := localPath(s.dir, entry.Path)
+	if err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), fmt.Sprintf("%s%d.*", syncer.TempPrefix, os.Getpid()))
+	if err != nil {
+		return err
+	}
+	s.file, s.entry, s.written, s.failed = file, entry, 0, nil
+	return nil
+}
+
+// write appends a chunk to the file being received. Chunks get no response,
+// so a failure is remembered until the file ends.
+func (s *session) write(data []byte) {
+	if s.file == nil {
+		if s.failed == nil {
+			s.failed = errors.New("no file is being received")
+		}
+		return
+	}
+	n, err := s.file.Write(data)
+	s.written += int64(n)
+	if err != nil {
+		s.failed = err
+		s.abort()
+	}
+}
+
+// finish puts the file received in place, with its mode and mtime.
+func (s *session) finish() error {
+	if s.file == nil {
+		err := s.failed
+		if err == nil {
+			err = errors.New("no file is being received")
+		}
+		return err
+	}
+	file, entry := s.file, s.entry
+	s.file = nil
+	tempPath := file.Name()
+	err := file.Close()
+	if err == nil && s.written != entry.Size {
+		err = fmt.Errorf("received %d bytes of %s, expected %d", s.written, entry.Path, entry.Size)
+	}
+	if err == nil {
+		err = os.Chmod(tempPath, entry.Mode.Perm())
+	}
+	if err == nil {
+		err = os.Chtimes(tempPath, entry.ModTime, entry.ModTime)
+	}
+	if err == nil {
+		var path string
+		if path, err = localPath(s.dir, entry.Path); err == nil {
+			err = os.Rename(tempPath, path)
+		}
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+	}
+	return err
+}
+
+// abort drops the file being received, if any.
+func (s *session) abort() {
+	if s.file != nil {
+		s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
+
+func (s *session) remove(relPath string) error {
+	path, err := localPath(s.dir, relPath)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(path)
+}
+
+// hash returns the checksums of files, so the pusher can tell whether they
+// need to be sent without the receiver sending their content. A file that
+// can't be read, or is a symlink, gets an empty checksum.
+func (s *session) hash(relPaths []string) ([]string, error) {
+	sums := make([]string, len(relPaths))
+	for i, relPath := range relPaths {
+		path, err := localPath(s.dir, relPath)
+		if err != nil {
+			return nil, err
+		}
+		if notSymlink(path) == nil {
+			sums[i], _ = fileinfo.SHA256(path)
+		}
+	}
+	return sums, nil
+}
+
+// touch gives a file with the right content the pusher's mode and mtime.
+func (s *session) touch(entry Entry) error {
+	path, err := localPath(s.dir, entry.Path)
+	if err != nil {
+		return err
+	}
+	if err := notSymlink(path); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, entry.Mode.Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(path, entry.ModTime, entry.ModTime)
+}
+
+// done sets the mode and mtime of the directories created, deepest first, now
+// that nothing is written into them anymore.
+func (s *session) done() error {
+	paths := make([]string, 0, len(s.created))
+	for path := range s.created {
+		paths = append(paths, path)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(paths)))
+	var errs []error
+	for _, path := range paths {
+		entry := s.created[path]
+		if err := os.Chmod(path, entry.Mode.Perm()); err != nil {
+			errs = append(errs, err)
+		}
+		if err := os.Chtimes(path, entry.ModTime, entry.ModTime); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,247 @@
+// pkg/progress/progress.go
+package progress
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/schollz/progressbar/v3"
+)
+
+// DefaultBatch is how many items are counted before the bar is redrawn.
+const DefaultBatch = 1000
+
+// PlainInterval is how often plain progress prints a line.
+const PlainInterval = 10 * time.Second
+
+// plain replaces bars and spinners by plain text lines (see SetPlain).
+var plain bool
+
+// drawn counts the bars and spinners on screen (see Active).
+var drawn atomic.Int32
+
+// Active reports whether a bar or spinner is being drawn, so that whatever
+// else is printed must start on a new line.
+func Active() bool {
+	return drawn.Load() > 0
+}
+
+// SetPlain selects plain progress: instead of bars and spinners redrawn in
+// place, a line of text is printed every PlainInterval while a phase runs and
+// once when it ends, if it lasted that long. This suits screen readers, dumb
+// terminals and logs.
+func SetPlain(enabled bool) {
+	plain = enabled
+}
+
+// unit is what a Progress counts.
+type unit int
+
+const (
+	items unit = iota
+	bytes
+)
+
+// Progress reports the advance of a phase on stderr. Updates are batched so
+// that counting millions of cheap items costs next to nothing. All methods
+// are safe for concurrent use and on a nil *Progress, which reports nothing.
+type Progress struct {
+	mu          sync.Mutex
+	bar         *progressbar.ProgressBar // nil in plain mode
+	description string
+	current     string // Item being worked on, shown after the description
+	columns     int    // Terminal width the label was fitted to
+	barWidth    int
+	unit        unit
+	total       int64 // -1 if unknown
+	done        int64
+	pending     int64 // Items counted but not yet shown
+	batch       int64
+	lastLine    time.Time // When plain mode last printed a line
+	printed     bool      // Plain mode printed a line for this phase
+	finished    bool
+}
+
+// New starts a progress bar for total items.
+func New(description string, total int64) *Progress {
+	return start(description, items, total, DefaultBatch,
+		progressbar.OptionShowCount(),
+		progressbar.OptionThrottle(100*time.Millisecond),
+	)
+}
+
+// NewBytes starts a progress bar for total bytes.
+func NewBytes(description string, total int64) *Progress {
+	return start(description, bytes, total, 1,
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionThrottle(100*time.Millisecond),
+	)
+}
+
+// NewSpinner starts a spinner counting items whose total isn't known.
+func NewSpinner(description string) *Progress {
+	return start(description, items, -1, 1,
+		progressbar.OptionSpinnerType(14),
+		progressbar.OptionShowCount(),
+	)
+}
+
+func start(description string, u unit, total, batch int64, options ...progressbar.Option) *Progress {
+	p := &Progress{description: description, unit: u, total: total, batch: batch, lastLine: time.Now()}
+	if !plain {
+		p.columns = terminalWidth()
+		p.barWidth = barWidth
+		if p.columns > 0 && p.columns < narrowColumns {
+			p.barWidth = narrowBarWidth
+		}
+		options = append(options,
+			progressbar.OptionSetDescription(theme.PaintErr(theme.Progress, p.label(p.columns))),
+			progressbar.OptionSetWidth(p.barWidth),
+			progressbar.OptionClearOnFinish(),
+		)
+		if p.columns > 0 {
+			options = append(options,
+				progressbar.OptionSetWriter(clearingWriter{os.Stderr}),
+				progressbar.OptionUseANSICodes(true),
+			)
+		} else {
+			options = append(options, progressbar.OptionSetWriter(os.Stderr))
+		}
+		p.bar = progressbar.NewOptions64(total, options...)
+		drawn.Add(1)
+	}
+	return p
+}
+
+// Add counts n finished items.
+func (p *Progress) Add(n int) {
+	p.Add64(int64(n))
+}
+
+// Add64 counts n finished items (or bytes).
+func (p *Progress) Add64(n int64) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending += n
+	if p.pending >= p.batch {
+		p.flush()
+	}
+}
+
+// Describe changes the description, e.g. when a new stage of the phase starts.
+func (p *Progress) Describe(description string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flush()
+	p.description = description
+	p.current = ""
+	p.relabel()
+}
+
+// Current shows the name of the item being worked on after the description,
+// shortened to fit the terminal. Plain mode doesn't show it.
+func (p *Progress) Current(name string) {
+	if p == nil || p.bar == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = name
+	p.relabel()
+}
+
+// relabel redraws the bar with its label fitted to the current terminal
+// width. p.mu must be held.
+func (p *Progress) relabel() {
+	if p.bar == nil {
+		return
+	}
+	p.columns = terminalWidth()
+	p.bar.Describe(theme.PaintErr(theme.Progress, p.label(p.columns)))
+}
+
+// Finish shows the remaining items and removes the bar.
+func (p *Progress) Finish() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.finished {
+		return
+	}
+	p.finished = true
+	p.flush()
+	if p.bar == nil {
+		if p.printed {
+			p.printLine()
+		}
+		return
+	}
+	drawn.Add(-1)
+	if p.total == 0 {
+		// progressbar can't finish a bar of nothing, e.g. the bytes of a
+		// plan that only deletes
+		if err := p.bar.Clear(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not clear progress bar: %v\n", err)
+		}
+		return
+	}
+	if err := p.bar.Finish(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not finish progress bar: %v\n", err)
+	}
+}
+
+// flush moves the pending count to the bar. p.mu must be held.
+func (p *Progress) flush() {
+	if p.pending == 0 {
+		return
+	}
+	if p.total > 0 && p.done+p.pending > p.total {
+		// More than expected, e.g. compressed copies of tiny files
+		p.pending = p.total - p.done
+	}
+	p.done += p.pending
+	if p.bar != nil && p.columns != terminalWidth() {
+		p.relabel() // The terminal was resized
+	}
+	if p.bar != nil {
+		if err := p.bar.Add64(p.pending); err != nil {
+			fmt.Fprintf(os.Stderr, "\nError: Could not update progress bar: %v\n", err)
+		}
+	} else if time.Since(p.lastLine) >= PlainInterval {
+		p.printLine()
+	}
+	p.pending = 0
+}
+
+// printLine prints the state of the phase as a plain line. p.mu must be held.
+func (p *Progress) printLine() {
+	count := fmt.Sprint(p.done)
+	if p.unit == bytes {
+		count = summary.FormatBytes(p.done)
+	}
+	switch {
+	case p.total > 0 && p.unit == bytes:
+		count += "/" + summary.FormatBytes(p.total)
+	case p.total > 0:
+		count += fmt.Sprintf("/%d", p.total)
+	}
+	if p.total > 0 {
+		count += fmt.Sprintf(" (%d%%)", p.done*100/p.total)
+	}
+	fmt.Fprintf(os.Stderr, "%s %s\n", p.description, count)
+	p.lastLine = time.Now()
+	p.printed = true
+}
@@ -0,0 +1,49 @@
+// pkg/progress/progress_test.go
+package progress
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStderr returns what fn writes to stderr.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	defer func() { os.Stderr = stderr }()
+	fn()
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// A plan copying no bytes, e.g. one that only deletes, still starts and
+// finishes the byte bar.
+func TestFinishZeroBytePlan(t *testing.T) {
+	for _, plainMode := range []bool{false, true} {
+		SetPlain(plainMode)
+		out := captureStderr(t, func() {
+			p := NewBytes("Syncing files...", 0)
+			p.Finish()
+		})
+		if strings.Contains(out, "Error") {
+			t.Errorf("plain=%v: finishing a zero-byte bar printed %q", plainMode, out)
+		}
+		if Active() {
+			t.Errorf("plain=%v: bar still counted as drawn after Finish", plainMode)
+		}
+	}
+	SetPlain(false)
+}
@@ -0,0 +1,8 @@
+// pkg/progress/resize_other.go
+//go:build !unix
+
+package progress
+
+// watchResize does nothing: without SIGWINCH, the width read at the start is
+// kept.
+func watchResize(update func()) {}
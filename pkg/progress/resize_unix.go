@@ -0,0 +1,21 @@
+// pkg/progress/resize_unix.go
+//go:build unix
+
+package progress
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchResize calls update whenever the terminal is resized.
+func watchResize(update func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	go func() {
+		for range signals {
+			update()
+		}
+	}()
+}
@@ -0,0 +1,162 @@
+// pkg/progress/width.go
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jeepinbird/sync-dir/pkg/console"
+	"github.com/rivo/uniseg"
+)
+
+// Widths of the bar itself, between its brackets, on normal and on narrow
+// (fewer than narrowColumns) terminals.
+const (
+	barWidth       = 15
+	narrowBarWidth = 5
+	narrowColumns  = 80
+)
+
+// minName is the narrowest a current-item name is shown; with less room the
+// name is left out rather than cut to an unreadable stub.
+const minName = 8
+
+var (
+	// width is the number of columns of the terminal on stderr, 0 if unknown.
+	// It follows resizes (see watchResize).
+	width     atomic.Int64
+	widthOnce sync.Once
+)
+
+// terminalWidth returns the current width of the terminal on stderr, reading
+// it and starting to watch for resizes on first use.
+func terminalWidth() int {
+	widthOnce.Do(func() {
+		width.Store(int64(console.Width(os.Stderr)))
+		watchResize(func() {
+			width.Store(int64(console.Width(os.Stderr)))
+		})
+	})
+	return int(width.Load())
+}
+
+// reserve returns how many columns a bar needs besides its description: the
+// percentage, the bar, the count or rate and the times, at their widest.
+func (p *Progress) reserve() int {
+	const percent, times = len(" 100% "), len(" [1h59m59s:1h59m59s] ")
+	switch {
+	case p.total < 0: // Spinner, count and elapsed time
+		return len("⠋ ") + len(" (9999999/-)") + len(" [1h59m59s] ")
+	case p.unit == bytes:
+		return percent + p.barWidth + 2 + len(" (999 MB/s)") + times
+	default:
+		return percent + p.barWidth + 2 + len(fmt.Sprintf(" (%d/%d)", p.total, p.total)) + times
+	}
+}
+
+// label returns the description followed by the current item, fitted into
+// the columns the terminal leaves next to the counts so that the line never
+// wraps. Names are cut in the middle, keeping their start and extension.
+func (p *Progress) label(columns int) string {
+	label := p.description
+	if p.current != "" {
+		label += " " + p.current
+	}
+	if columns <= 0 {
+		return label
+	}
+	room := columns - p.reserve()
+	descWidth := uniseg.StringWidth(p.description)
+	switch {
+	case p.current != "" && room-descWidth-1 >= minName:
+		return p.description + " " + cutMiddle(p.current, room-descWidth-1)
+	case room >= descWidth:
+		return p.description
+	default:
+		return cutEnd(p.description, room)
+	}
+}
+
+// cutMiddle shortens s to at most max columns by replacing its middle with
+// an ellipsis.
+func cutMiddle(s string, max int) string {
+	if uniseg.StringWidth(s) <= max {
+		return s
+	}
+	if max < 3 {
+		return cutEnd(s, max)
+	}
+	clusters := graphemes(s)
+	head := takeColumns(clusters, (max-1)/2)
+	tailRoom := max - 1 - uniseg.StringWidth(head)
+	var tail []string
+	for i, used := len(clusters)-1, 0; i >= 0; i-- {
+		used += uniseg.StringWidth(clusters[i])
+		if used > tailRoom {
+			break
+		}
+		tail = append([]string{clusters[i]}, tail...)
+	}
+	return head + "…" + strings.Join(tail, "")
+}
+
+// cutEnd shortens s to at most max columns by replacing its end with an
+// ellipsis.
+func cutEnd(s string, max int) string {
+	if uniseg.StringWidth(s) <= max {
+		return s
+	}
+	if max <= 0 {
+		return ""
+	}
+	return takeColumns(graphemes(s), max-1) + "…"
+}
+
+// graphemes splits s into the characters the terminal shows.
+func graphemes(s string) []string {
+	var clusters []string
+	state := -1
+	for s != "" {
+		var cluster string
+		cluster, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// takeColumns joins the leading clusters that fit into max columns.
+func takeColumns(clusters []string, max int) string {
+	var b strings.Builder
+	used := 0
+	for _, c := range clusters {
+		used += uniseg.StringWidth(c)
+		if used > max {
+			break
+		}
+		b.WriteString(c)
+	}
+	return b.String()
+}
+
+// clearingWriter clears the rest of the line whenever a bar is redrawn, so a
+// shorter line leaves nothing of the previous one behind. Bars use it on
+// terminals instead of padding with spaces, which wraps once the terminal
+// got narrower than the longest line drawn.
+type clearingWriter struct {
+	w io.Writer
+}
+
+func (c clearingWriter) Write(b []byte) (int, error) {
+	if len(b) > 0 && b[0] == '\r' {
+		if _, err := io.WriteString(c.w, "\r\033[K"); err != nil {
+			return 0, err
+		}
+		n, err := c.w.Write(b[1:])
+		return n + 1, err
+	}
+	return c.w.Write(b)
+}
@@ -0,0 +1,75 @@
+// pkg/report/report.go
+// Package report exports sync plans and their outcome as CSV or TSV, one row
+// per action, for review in a spreadsheet or for audit trails.
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Results of an action in a report.
+const (
+	Succeeded = "ok"
+	Failed    = "failed"
+	NotRun    = "not run" // Dry run, declined plan, or the run stopped first
+)
+
+// Row is one action of a plan and, in a report, its outcome.
+type Row struct {
+	Action   string // add, update, delete or rename
+	Path     string // Relative to the target; directories end in a slash
+	Size     int64  // Of the file copied or deleted (0 for directories)
+	ModTime  time.Time
+	Result   string
+	Duration time.Duration
+	Error    string
+}
+
+// Write writes rows to path, as TSV if its extension is .tsv and as CSV
+// otherwise. With results, each row also has its result, duration (in
+// seconds) and error; without, only the plan columns are written.
+func Write(path string, rows []Row, results bool) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		w.Comma = '\t'
+	}
+	header := []string{"action", "path", "size", "mtime"}
+	if results {
+		header = append(header, "result", "duration", "error")
+	}
+	_ = w.Write(header)
+	for _, row := range rows {
+		record := []string{row.Action, row.Path, strconv.FormatInt(row.Size, 10), formatTime(row.ModTime)}
+		if results {
+			duration := ""
+			if row.Result != NotRun {
+				duration = fmt.Sprintf("%.3f", row.Duration.Seconds())
+			}
+			record = append(record, row.Result, duration, row.Error)
+		}
+		_ = w.Write(record) // Errors are sticky, see Flush
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,86 @@
+// pkg/rules/rules.go
+// Package rules holds the action rules of a profile: patterns whose files are
+// planned or copied differently from the rest, such as temp files that are
+// never deleted from the target or logs that are stored compressed.
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/config"
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+)
+
+// Actions a rule can apply to the files matching its pattern.
+const (
+	Compress   = "compress"    // Copy gzip-compressed, as a gzip transform would
+	SkipDelete = "skip-delete" // Never delete from the target when missing from the source
+	AlwaysCopy = "always-copy" // Copy even when the target copy looks identical
+)
+
+var actions = []string{Compress, SkipDelete, AlwaysCopy}
+
+// Rules matches paths against the patterns of each action. A path can match
+// rules of several actions; they all apply.
+type Rules struct {
+	patterns map[string][]string
+	matchers map[string]*ignore.Matcher
+}
+
+// New compiles the rules. It returns nil if there are none.
+func New(rules []config.ActionRule) (*Rules, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	r := &Rules{patterns: make(map[string][]string), matchers: make(map[string]*ignore.Matcher)}
+	for _, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("%s rule without a pattern", rule.Action)
+		}
+		if !known(rule.Action) {
+			return nil, fmt.Errorf("unknown action %q for %q (available: %s)", rule.Action, rule.Pattern, strings.Join(actions, ", "))
+		}
+		r.patterns[rule.Action] = append(r.patterns[rule.Action], rule.Pattern)
+	}
+	for action, patterns := range r.patterns {
+		r.matchers[action] = ignore.Compile(patterns)
+	}
+	return r, nil
+}
+
+func known(action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Applies reports whether a rule of the action matches relPath. It is false
+// for nil Rules.
+func (r *Rules) Applies(action, relPath string) bool {
+	if r == nil {
+		return false
+	}
+	return r.matchers[action].MatchesFile(relPath)
+}
+
+// Has reports whether there are rules of the action.
+func (r *Rules) Has(action string) bool {
+	return r != nil && len(r.patterns[action]) > 0
+}
+
+// Transforms returns the compress rules as transform rules, one per pattern
+// in the order given.
+func (r *Rules) Transforms() []config.TransformRule {
+	if r == nil {
+		return nil
+	}
+	var transforms []config.TransformRule
+	for _, pattern := range r.patterns[Compress] {
+		transforms = append(transforms, config.TransformRule{Pattern: pattern, Filters: []string{"gzip"}})
+	}
+	return transforms
+}
@@ -0,0 +1,106 @@
+// pkg/seclabel/seclabel.go
+// Package seclabel reads and writes the Linux security attributes of files
+// that system images depend on: SELinux security contexts and file
+// capabilities, both kept in extended attributes of the security namespace.
+package seclabel
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsupported is returned on platforms without these attributes.
+var ErrUnsupported = errors.New("SELinux contexts and file capabilities are only supported on Linux")
+
+const (
+	xattrSELinux = "security.selinux"
+	xattrCaps    = "security.capability"
+)
+
+// Layout of security.capability (struct vfs_cap_data in linux/capability.h)
+const (
+	capRevisionMask = 0xFF000000
+	capRevision1    = 0x01000000 // One 32-bit word of capabilities
+	capRevision2    = 0x02000000 // Two words
+	capRevision3    = 0x03000000 // Two words and the root uid of a user namespace
+	capEffective    = 0x000001   // The permitted capabilities are raised on exec
+)
+
+// capNames are the names of the capabilities by number, as getcap prints
+// them.
+var capNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner",
+	"cap_fsetid", "cap_kill", "cap_setgid", "cap_setuid", "cap_setpcap",
+	"cap_linux_immutable", "cap_net_bind_service", "cap_net_broadcast",
+	"cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace",
+	"cap_sys_pacct", "cap_sys_admin", "cap_sys_boot", "cap_sys_nice",
+	"cap_sys_resource", "cap_sys_time", "cap_sys_tty_config", "cap_mknod",
+	"cap_lease", "cap_audit_write", "cap_audit_control", "cap_setfcap",
+	"cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf",
+	"cap_checkpoint_restore",
+}
+
+// FormatCaps renders the raw security.capability attribute of a file like
+// getcap does, e.g. "cap_net_bind_service,cap_net_raw=ep". Capabilities
+// with the same flags are grouped; "" stands for no capabilities.
+func FormatCaps(raw []byte) string {
+	if len(raw) < 4 {
+		return ""
+	}
+	magic := binary.LittleEndian.Uint32(raw)
+	words := 2
+	switch magic & capRevisionMask {
+	case capRevision1:
+		words = 1
+	case capRevision2, capRevision3:
+	default:
+		return fmt.Sprintf("unknown(%x)", raw)
+	}
+	if len(raw) < 4+8*words {
+		return fmt.Sprintf("unknown(%x)", raw)
+	}
+
+	var groups []string
+	members := make(map[string][]string)
+	for word := 0; word < words; word++ {
+		permitted := binary.LittleEndian.Uint32(raw[4+8*word:])
+		inheritable := binary.LittleEndian.Uint32(raw[8+8*word:])
+		for bit := 0; bit < 32; bit++ {
+			p, i := permitted&(1<<bit) != 0, inheritable&(1<<bit) != 0
+			if !p && !i {
+				continue
+			}
+			flags := ""
+			if magic&capEffective != 0 {
+				flags += "e"
+			}
+			if i {
+				flags += "i"
+			}
+			if p {
+				flags += "p"
+			}
+			name := fmt.Sprintf("cap_%d", 32*word+bit)
+			if n := 32*word + bit; n < len(capNames) {
+				name = capNames[n]
+			}
+			if members[flags] == nil {
+				groups = append(groups, flags)
+			}
+			members[flags] = append(members[flags], name)
+		}
+	}
+	parts := make([]string, len(groups))
+	for i, flags := range groups {
+		parts[i] = strings.Join(members[flags], ",") + "=" + flags
+	}
+	text := strings.Join(parts, " ")
+	if magic&capRevisionMask == capRevision3 && len(raw) >= 24 {
+		text += fmt.Sprintf(" [rootid=%d]", binary.LittleEndian.Uint32(raw[20:]))
+	}
+	return text
+}
@@ -0,0 +1,68 @@
+// pkg/seclabel/seclabel_linux.go
+package seclabel
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// Supported reports whether the attributes can be read and written here.
+const Supported = true
+
+// Label returns the SELinux security context of the item at path, e.g.
+// "system_u:object_r:bin_t:s0", without following a symlink. It is "" if
+// the item has none (SELinux disabled or a filesystem without labels).
+func Label(path string) (string, error) {
+	value, err := get(path, xattrSELinux, unix.Lgetxattr)
+	return string(bytes.TrimRight(value, "\x00")), err
+}
+
+// SetLabel gives the item at path the SELinux security context label,
+// without following a symlink. Relabeling needs the relabelfrom and
+// relabelto permissions of the policy.
+func SetLabel(path, label string) error {
+	return unix.Lsetxattr(path, xattrSELinux, append([]byte(label), 0), 0)
+}
+
+// Caps returns the raw capabilities of the file at path (see FormatCaps),
+// or nil if it has none.
+func Caps(path string) ([]byte, error) {
+	return get(path, xattrCaps, unix.Getxattr)
+}
+
+// SetCaps gives the file at path the raw capabilities caps, or removes its
+// capabilities if caps is nil. This needs CAP_SETFCAP.
+func SetCaps(path string, caps []byte) error {
+	if caps == nil {
+		if err := unix.Removexattr(path, xattrCaps); err != nil && !errors.Is(err, unix.ENODATA) {
+			return err
+		}
+		return nil
+	}
+	return unix.Setxattr(path, xattrCaps, caps, 0)
+}
+
+// get reads the attribute name of path with getxattr, sizing the buffer to
+// the value; a missing attribute is nil.
+func get(path, name string, getxattr func(string, string, []byte) (int, error)) ([]byte, error) {
+	for {
+		size, err := getxattr(path, name, nil)
+		if errors.Is(err, unix.ENODATA) || errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := getxattr(path, name, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue // Changed in between
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
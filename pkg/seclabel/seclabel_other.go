@@ -0,0 +1,23 @@
+// pkg/seclabel/seclabel_other.go
+//go:build !linux
+
+package seclabel
+
+// Supported reports whether the attributes can be read and written here.
+const Supported = false
+
+func Label(path string) (string, error) {
+	return "", ErrUnsupported
+}
+
+func SetLabel(path, label string) error {
+	return ErrUnsupported
+}
+
+func Caps(path string) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func SetCaps(path string, caps []byte) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,205 @@
+// pkg/state/checksums.go
+package state
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	checksumShards     = 256 // Files a spilled cache is split into
+	checksumShardsHeld = 16  // Shards of a spilled cache held in memory at once
+)
+
+// checksumEntry is a cached checksum, valid while size and mtime are unchanged.
+type checksumEntry struct {
+	Size    int64
+	ModTime time.Time
+	Sum     string
+}
+
+// checksumShard is one loaded file of a spilled cache.
+type checksumShard struct {
+	entries map[string]checksumEntry
+	dirty   bool
+}
+
+// ChecksumCache remembers file checksums between runs, keyed by absolute path.
+// It is safe for concurrent use. A cache is held in memory until it is
+// spilled (see Spill); from then on it lives on disk split into shards, of
+// which only a few are loaded at a time, also in later runs.
+type ChecksumCache struct {
+	mu       sync.Mutex
+	path     string
+	entries  map[string]checksumEntry // nil once spilled
+	dirty    bool
+	spillDir string                    // Directory of the shards of a spilled cache
+	shards   map[uint32]*checksumShard // Loaded shards of a spilled cache
+}
+
+// LoadChecksums opens the pair's checksum cache. A missing or unreadable cache
+// simply starts out empty. A spilled cache stays on disk.
+func (p *Pair) LoadChecksums() *ChecksumCache {
+	c := &ChecksumCache{
+		path:     filepath.Join(p.Dir, checksumsFileName),
+		spillDir: filepath.Join(p.Dir, checksumsDirName),
+	}
+	if info, err := os.Stat(c.spillDir); err == nil && info.IsDir() {
+		c.shards = make(map[uint32]*checksumShard)
+		return c
+	}
+	c.entries = make(map[string]checksumEntry)
+	if found, err := readGob(c.path, &c.entries); err != nil || !found {
+		c.entries = make(map[string]checksumEntry)
+	}
+	return c
+}
+
+// Get returns the cached checksum of absPath if its size and mtime still match.
+func (c *ChecksumCache) Get(absPath string, size int64, modTime time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := c.entries
+	if c.shards != nil {
+		entries = c.shard(absPath).entries
+	}
+	entry, ok := entries[absPath]
+	if !ok || entry.Size != size || !entry.ModTime.Equal(modTime) {
+		return "", false
+	}
+	return entry.Sum, true
+}
+
+// Put stores the checksum of absPath at the given size and mtime.
+func (c *ChecksumCache) Put(absPath string, size int64, modTime time.Time, sum string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := checksumEntry{Size: size, ModTime: modTime, Sum: sum}
+	if c.shards != nil {
+		shard := c.shard(absPath)
+		shard.entries[absPath] = entry
+		shard.dirty = true
+		return
+	}
+	c.entries[absPath] = entry
+	c.dirty = true
+}
+
+// Save writes the cache back to disk if it changed.
+func (c *ChecksumCache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.shards != nil {
+		for index, shard := range c.shards {
+			if err := c.saveShard(index, shard); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if !c.dirty {
+		return nil
+	}
+	if err := writeGob(c.path, c.entries); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// Spill moves a cache held in memory to disk, to bound the memory of runs
+// over huge trees. Its entries are written into shards and released, and
+// from then on loaded a shard at a time. Spilling a spilled cache does
+// nothing.
+func (c *ChecksumCache) Spill() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.shards != nil {
+		return nil
+	}
+	partitions := make([]map[string]checksumEntry, checksumShards)
+	for i := range partitions {
+		partitions[i] = make(map[string]checksumEntry)
+	}
+	for path, entry := range c.entries {
+		partitions[shardOf(path)][path] = entry
+	}
+
+	// Written next to the final directory and renamed into place, so an
+	// interrupted spill leaves the cache as it was
+	tmp := c.spillDir + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmp, 0700); err != nil {
+		return err
+	}
+	for i, entries := range partitions {
+		if err := writeGob(filepath.Join(tmp, shardName(uint32(i))), entries); err != nil {
+			_ = os.RemoveAll(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, c.spillDir); err != nil {
+		_ = os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	c.entries, c.dirty = nil, false
+	c.shards = make(map[uint32]*checksumShard)
+	return nil
+}
+
+// shard returns the loaded shard holding path, loading it first and
+// unloading another one if checksumShardsHeld are loaded. An unreadable
+// shard starts out empty. The caller holds c.mu.
+func (c *ChecksumCache) shard(path string) *checksumShard {
+	index := shardOf(path)
+	if shard, ok := c.shards[index]; ok {
+		return shard
+	}
+	if len(c.shards) >= checksumShardsHeld {
+		for other, shard := range c.shards {
+			if err := c.saveShard(other, shard); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Could not save checksum cache shard: %v\n", err)
+			}
+			delete(c.shards, other)
+			break
+		}
+	}
+	shard := &checksumShard{entries: make(map[string]checksumEntry)}
+	if found, err := readGob(filepath.Join(c.spillDir, shardName(index)), &shard.entries); err != nil || !found {
+		shard.entries = make(map[string]checksumEntry)
+	}
+	c.shards[index] = shard
+	return shard
+}
+
+// saveShard writes a loaded shard back to disk if it changed.
+func (c *ChecksumCache) saveShard(index uint32, shard *checksumShard) error {
+	if !shard.dirty {
+		return nil
+	}
+	if err := writeGob(filepath.Join(c.spillDir, shardName(index)), shard.entries); err != nil {
+		return err
+	}
+	shard.dirty = false
+	return nil
+}
+
+// shardOf returns the shard of a spilled cache holding path.
+func shardOf(path string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(path))
+	return h.Sum32() % checksumShards
+}
+
+func shardName(index uint32) string {
+	return fmt.Sprintf("%02x.gob", index)
+}
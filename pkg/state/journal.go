@@ -0,0 +1,98 @@
+// pkg/state/journal.go
+package state
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Journal is an append-only log of the run in progress. A run writes "begin"
+// when it starts executing and "end" when it finishes, so a journal without an
+// "end" line belongs to a run that crashed or was killed.
+type Journal struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// BeginJournal starts a fresh journal for a new run.
+func (p *Pair) BeginJournal() (*Journal, error) {
+	file, err := os.OpenFile(filepath.Join(p.Dir, journalFileName), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	j := &Journal{file: file}
+	if err := j.Record("begin", time.Now().Format(time.RFC3339)); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+// Record appends one entry to the journal.
+func (j *Journal) Record(kind string, fields ...string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, err := fmt.Fprintf(j.file, "%s\t%s\n", kind, strings.Join(fields, "\t"))
+	return err
+}
+
+// Sync flushes the journal to stable storage.
+func (j *Journal) Sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Sync()
+}
+
+// Finish marks the run as complete and closes the journal.
+func (j *Journal) Finish() error {
+	if err := j.Record("end", time.Now().Format(time.RFC3339)); err != nil {
+		_ = j.file.Close()
+		return err
+	}
+	return j.file.Close()
+}
+
+// Abort closes the journal without marking the run complete, e.g. because
+// some actions failed and the next run has to finish the job.
+func (j *Journal) Abort() error {
+	return j.file.Close()
+}
+
+// JournalEntries returns the entries of the last run's journal.
+func (p *Pair) JournalEntries() ([][]string, error) {
+	file, err := os.Open(filepath.Join(p.Dir, journalFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close journal: %v\n", err)
+		}
+	}()
+
+	var entries [][]string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			entries = append(entries, strings.Split(line, "\t"))
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Interrupted reports whether the last run started executing but never finished.
+func (p *Pair) Interrupted() bool {
+	entries, err := p.JournalEntries()
+	if err != nil || len(entries) == 0 {
+		return false
+	}
+	return entries[len(entries)-1][0] != "end"
+}
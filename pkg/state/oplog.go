@@ -0,0 +1,93 @@
+// pkg/state/oplog.go
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const oplogFileName = "oplog.jsonl"
+
+// OpRecord is one change a sync made to a target, as kept in the target's
+// operations log.
+type OpRecord struct {
+	Time   time.Time `json:"time"`
+	Op     string    `json:"op"`             // add, update, delete or rename
+	Path   string    `json:"path"`           // Slash-separated, relative to the target
+	From   string    `json:"from,omitempty"` // Previous path of a renamed item
+	Dir    bool      `json:"dir,omitempty"`
+	Size   int64     `json:"size,omitempty"`
+	SHA256 string    `json:"sha256,omitempty"` // Of the content written or deleted, when known
+	Run    time.Time `json:"run"`              // Start of the run that made the change
+}
+
+// OpLog appends to a target's operations log. It is safe for concurrent use.
+type OpLog struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+	run  time.Time
+}
+
+// OpenOpLog opens the target's operations log for appending the changes of
+// the run started at run. Records are never rewritten or removed, so the log
+// tells when any path changed.
+func (t *Target) OpenOpLog(run time.Time) (*OpLog, error) {
+	file, err := os.OpenFile(filepath.Join(t.Dir, oplogFileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &OpLog{file: file, enc: json.NewEncoder(file), run: run.UTC()}, nil
+}
+
+// Append adds a record of the run; each is written as one line.
+func (l *OpLog) Append(record OpRecord) error {
+	record.Run = l.run
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(record)
+}
+
+// Close flushes the log to disk and closes it.
+func (l *OpLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Sync(); err != nil {
+		_ = l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// Operations calls fn with every record of the target's operations log,
+// oldest first. A missing log has no records.
+func (t *Target) Operations(fn func(OpRecord)) error {
+	file, err := os.Open(filepath.Join(t.Dir, oplogFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close operations log: %v\n", err)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Paths can be long
+	for scanner.Scan() {
+		var record OpRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Skip a torn line from an interrupted write
+		}
+		fn(record)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,286 @@
+// pkg/state/state.go
+package state
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// File names inside a pair's state directory.
+const (
+	pairFileName      = "pair.json"
+	snapshotFileName  = "snapshot.gob"
+	historyFileName   = "history.jsonl"
+	checksumsFileName = "checksums.gob"
+	checksumsDirName  = "checksums.d" // A spilled checksum cache, see ChecksumCache.Spill
+	journalFileName   = "journal.log"
+)
+
+// Pair is the persistent state of one source/target pair. Each pair gets its
+// own directory, named after a hash of both paths, holding the snapshot of the
+// last successful run, a run journal, a checksum cache and the run history.
+type Pair struct {
+	Dir    string `json:"-"` // State directory of this pair
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// RunRecord is one entry of a pair's run history.
+type RunRecord struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	DryRun  bool      `json:"dry_run,omitempty"`
+	Adds    int       `json:"adds"`
+	Updates int       `json:"updates"`
+	Deletes int       `json:"deletes"`
+	Renames int       `json:"renames,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Snapshot is the file list the target was left mirroring after a successful run.
+type Snapshot struct {
+	Time  time.Time
+	Files map[string]*fileinfo.FileInfo
+}
+
+// BaseDir returns the directory holding the state of all pairs. It follows
+// $XDG_STATE_HOME (default ~/.local/state) on Unix-like systems and the user
+// config directory elsewhere.
+func BaseDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "sync-dir"), nil
+	}
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" || runtime.GOOS == "ios" || runtime.GOOS == "plan9" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "sync-dir", "state"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "sync-dir"), nil
+}
+
+// baseOr returns base, or BaseDir if it is empty.
+func baseOr(base string) (string, error) {
+	if base != "" {
+		return base, nil
+	}
+	return BaseDir()
+}
+
+// pairDir returns the state directory for a source/target pair below base.
+func pairDir(base, source, target string) (string, error) {
+	base, err := baseOr(base)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(source + "\x00" + target))
+	return filepath.Join(base, "pairs", hex.EncodeToString(sum[:8])), nil
+}
+
+// Open returns the state of a pair kept below base ("" = BaseDir), creating
+// its directory if needed.
+func Open(base, source, target string) (*Pair, error) {
+	dir, err := pairDir(base, source, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate state directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+	p := &Pair{Dir: dir, Source: source, Target: target}
+	if err := writeJSON(filepath.Join(dir, pairFileName), p); err != nil {
+		return nil, fmt.Errorf("failed to write pair info: %w", err)
+	}
+	return p, nil
+}
+
+// Lookup returns the state of a pair kept below base ("" = BaseDir) without
+// creating anything. It returns nil, nil if the pair was never synced.
+func Lookup(base, source, target string) (*Pair, error) {
+	dir, err := pairDir(base, source, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate state directory: %w", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &Pair{Dir: dir, Source: source, Target: target}, nil
+}
+
+// Pairs lists every pair that has a state directory.
+func Pairs() ([]*Pair, error) {
+	base, err := BaseDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(base, "pairs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var pairs []*Pair
+	for _, entry := range entries {
+		dir := filepath.Join(base, "pairs", entry.Name())
+		data, err := os.ReadFile(filepath.Join(dir, pairFileName))
+		if err != nil {
+			continue // Not a pair directory (or a half-created one)
+		}
+		p := &Pair{Dir: dir}
+		if err := json.Unmarshal(data, p); err != nil {
+			continue
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+// SaveSnapshot records the file list the target mirrors after a successful run.
+func (p *Pair) SaveSnapshot(files map[string]*fileinfo.FileInfo) error {
+	return writeGob(filepath.Join(p.Dir, snapshotFileName), &Snapshot{Time: time.Now(), Files: files})
+}
+
+// LoadSnapshot returns the last snapshot, or nil, nil if there is none.
+func (p *Pair) LoadSnapshot() (*Snapshot, error) {
+	var snapshot Snapshot
+	found, err := readGob(filepath.Join(p.Dir, snapshotFileName), &snapshot)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// AppendHistory adds a run to the pair's history.
+func (p *Pair) AppendHistory(record RunRecord) error {
+	file, err := os.OpenFile(filepath.Join(p.Dir, historyFileName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(record); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// History returns all recorded runs, oldest first.
+func (p *Pair) History() ([]RunRecord, error) {
+	file, err := os.Open(filepath.Join(p.Dir, historyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close history: %v\n", err)
+		}
+	}()
+
+	var records []RunRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record RunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Skip a torn line from an interrupted write
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// writeJSON writes v as indented JSON, atomically.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeGob writes v gob-encoded, atomically.
+func writeGob(path string, v any) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	buffered := bufio.NewWriter(tmp)
+	err = gob.NewEncoder(buffered).Encode(v)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return finishTemp(tmp, path)
+}
+
+// readGob decodes path into v. found is false if the file doesn't exist.
+func readGob(path string, v any) (found bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close %s: %v\n", path, err)
+		}
+	}()
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeFileAtomic replaces path with data via a temp file and rename.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return finishTemp(tmp, path)
+}
+
+// finishTemp closes tmp and renames it over path.
+func finishTemp(tmp *os.File, path string) error {
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
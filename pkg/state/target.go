@@ -0,0 +1,97 @@
+// pkg/state/target.go
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const manifestFileName = "manifest.gob"
+
+// Target is the persistent state of one target directory, independent of the
+// source(s) it is synced from.
+type Target struct {
+	Dir  string // State directory of this target
+	Path string // The target directory itself
+}
+
+// ManifestEntry records the checksum of a target file at a known size and mtime.
+type ManifestEntry struct {
+	Size     int64
+	ModTime  time.Time
+	Sum      string
+	Verified time.Time // When the checksum was last computed
+}
+
+// targetDir returns the state directory for a target directory below base.
+func targetDir(base, path string) (string, error) {
+	base, err := baseOr(base)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(path))
+	return filepath.Join(base, "targets", hex.EncodeToString(sum[:8])), nil
+}
+
+// OpenTarget returns the state of a target directory kept below base ("" =
+// BaseDir), creating it if needed.
+func OpenTarget(base, path string) (*Target, error) {
+	dir, err := targetDir(base, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate state directory: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory %s: %w", dir, err)
+	}
+	return &Target{Dir: dir, Path: path}, nil
+}
+
+// LookupTarget returns the state of a target directory kept below base ("" =
+// BaseDir) without creating anything. It returns nil, nil if nothing was ever
+// recorded for it.
+func LookupTarget(base, path string) (*Target, error) {
+	dir, err := targetDir(base, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate state directory: %w", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &Target{Dir: dir, Path: path}, nil
+}
+
+// LoadManifest returns the target's checksum manifest, keyed by relative path.
+func (t *Target) LoadManifest() (map[string]ManifestEntry, error) {
+	manifest := make(map[string]ManifestEntry)
+	if _, err := readGob(filepath.Join(t.Dir, manifestFileName), &manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// SaveManifest replaces the target's checksum manifest.
+func (t *Target) SaveManifest(manifest map[string]ManifestEntry) error {
+	return writeGob(filepath.Join(t.Dir, manifestFileName), manifest)
+}
+
+// PairsForTarget returns the pairs syncing into target.
+func PairsForTarget(target string) ([]*Pair, error) {
+	pairs, err := Pairs()
+	if err != nil {
+		return nil, err
+	}
+	var matching []*Pair
+	for _, p := range pairs {
+		if p.Target == target {
+			matching = append(matching, p)
+		}
+	}
+	return matching, nil
+}
@@ -0,0 +1,134 @@
+// pkg/summary/format.go
+package summary
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// templateFuncs are available to all summary templates.
+var templateFuncs = map[string]any{
+	"bytes": FormatBytes,
+	"time":  func(t time.Time) string { return t.Format(time.RFC1123) },
+	"t":     i18n.T,
+	"label": plainLabel,
+}
+
+// plainLabels are the labels of the plain text report, aligned in a column.
+var plainLabels = []string{
+	"summary.profile", "summary.source", "summary.target", "summary.started",
+	"summary.duration", "summary.changes", "summary.skipped", "summary.copied",
+	"summary.operations",
+}
+
+// plainLabel returns the label with the given key followed by a colon and
+// padded to align the values after all plainLabels.
+func plainLabel(key string) string {
+	width := 0
+	for _, k := range plainLabels {
+		width = max(width, utf8.RuneCountInString(i18n.T(k)))
+	}
+	text := i18n.T(key) + ":"
+	return text + strings.Repeat(" ", width+2-utf8.RuneCountInString(text))
+}
+
+var plainTemplate = template.Must(template.New("plain").Funcs(templateFuncs).Parse(
+	`sync-dir: {{.Outcome}}
+{{if .Profile}}{{label "summary.profile"}}{{.Profile}}
+{{end}}{{label "summary.source"}}{{.Source}}
+{{label "summary.target"}}{{.Target}}
+{{label "summary.started"}}{{time .Start}}
+{{label "summary.duration"}}{{.Duration}}
+{{label "summary.changes"}}{{t "summary.counts" .Adds .Updates .Deletes .Renames}}
+{{if .Unchanged.Compared}}{{label "summary.skipped"}}{{.Unchanged.Describe}}
+{{end}}{{label "summary.copied"}}{{bytes .Bytes}}
+{{if .Ops}}{{label "summary.operations"}}{{.DescribeOps}}
+{{end}}{{if .Errors}}{{t "summary.errors"}}:
+{{range .Errors}}- {{.}}
+{{end}}{{end}}`))
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(templateFuncs).Parse(
+	`## sync-dir: {{.Outcome}}
+
+| | |
+|---|---|
+{{if .Profile}}| {{t "summary.profile"}} | {{.Profile}} |
+{{end}}| {{t "summary.source"}} | ` + "`{{.Source}}`" + ` |
+| {{t "summary.target"}} | ` + "`{{.Target}}`" + ` |
+| {{t "summary.started"}} | {{time .Start}} |
+| {{t "summary.duration"}} | {{.Duration}} |
+| {{t "summary.added"}} | {{.Adds}} |
+| {{t "summary.updated"}} | {{.Updates}} |
+| {{t "summary.deleted"}} | {{.Deletes}} |
+| {{t "summary.renamed"}} | {{.Renames}} |
+{{if .Unchanged.Compared}}| {{t "summary.skipped"}} | {{.Unchanged.Describe}} |
+{{end}}| {{t "summary.copied"}} | {{bytes .Bytes}} |
+{{if .Ops}}| {{t "summary.operations"}} | {{.DescribeOps}} |
+{{end}}{{if .Errors}}
+### {{t "summary.errors"}}
+
+{{range .Errors}}- {{.}}
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(templateFuncs).Parse(
+	`<html><body>
+<h2>sync-dir: {{.Outcome}}</h2>
+<table>
+{{if .Profile}}<tr><th align="left">{{t "summary.profile"}}</th><td>{{.Profile}}</td></tr>
+{{end}}<tr><th align="left">{{t "summary.source"}}</th><td><code>{{.Source}}</code></td></tr>
+<tr><th align="left">{{t "summary.target"}}</th><td><code>{{.Target}}</code></td></tr>
+<tr><th align="left">{{t "summary.started"}}</th><td>{{time .Start}}</td></tr>
+<tr><th align="left">{{t "summary.duration"}}</th><td>{{.Duration}}</td></tr>
+<tr><th align="left">{{t "summary.added"}}</th><td>{{.Adds}}</td></tr>
+<tr><th align="left">{{t "summary.updated"}}</th><td>{{.Updates}}</td></tr>
+<tr><th align="left">{{t "summary.deleted"}}</th><td>{{.Deletes}}</td></tr>
+<tr><th align="left">{{t "summary.renamed"}}</th><td>{{.Renames}}</td></tr>
+{{if .Unchanged.Compared}}<tr><th align="left">{{t "summary.skipped"}}</th><td>{{.Unchanged.Describe}}</td></tr>
+{{end}}<tr><th align="left">{{t "summary.copied"}}</th><td>{{bytes .Bytes}}</td></tr>
+{{if .Ops}}<tr><th align="left">{{t "summary.operations"}}</th><td>{{.DescribeOps}}</td></tr>
+{{end}}</table>
+{{if .Errors}}<h3>{{t "summary.errors"}}</h3>
+<ul>
+{{range .Errors}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}</body></html>
+`))
+
+// Render executes a user-supplied text/template over the summary. The
+// functions "bytes" (human-readable size), "time" and "t" (a message of the
+// current language) are available.
+func (s *Summary) Render(text string) (string, error) {
+	tmpl, err := template.New("custom").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, s); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Format renders the summary as plain text, Markdown or HTML.
+func (s *Summary) Format(format string) (string, error) {
+	if err := CheckFormat(format); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	var err error
+	switch format {
+	case "markdown":
+		err = markdownTemplate.Execute(&b, s)
+	case "html":
+		err = htmlTemplate.Execute(&b, s)
+	default:
+		err = plainTemplate.Execute(&b, s)
+	}
+	return b.String(), err
+}
@@ -0,0 +1,54 @@
+// pkg/summary/metrics.go
+package summary
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMetrics writes the run as Prometheus metrics in the text exposition
+// format, e.g. for the textfile collector of the node exporter. Every metric
+// describes the last run, so all of them are gauges.
+func (s *Summary) WriteMetrics(w io.Writer) error {
+	var b strings.Builder
+	gauge := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP sync_dir_%s %s\n# TYPE sync_dir_%s gauge\n", name, help, name)
+	}
+	success := 0
+	if len(s.Errors) == 0 {
+		success = 1
+	}
+	gauge("last_run_success", "Whether the last run finished without errors.")
+	fmt.Fprintf(&b, "sync_dir_last_run_success %d\n", success)
+	gauge("last_run_timestamp_seconds", "When the last run ended, in seconds since the epoch.")
+	fmt.Fprintf(&b, "sync_dir_last_run_timestamp_seconds %d\n", s.End.Unix())
+	gauge("last_run_duration_seconds", "How long the last run took.")
+	fmt.Fprintf(&b, "sync_dir_last_run_duration_seconds %.3f\n", s.End.Sub(s.Start).Seconds())
+	gauge("last_run_actions", "Actions planned by the last run, by type.")
+	for _, action := range []struct {
+		name  string
+		count int
+	}{{"add", s.Adds}, {"update", s.Updates}, {"delete", s.Deletes}, {"rename", s.Renames}} {
+		fmt.Fprintf(&b, "sync_dir_last_run_actions{type=%q} %d\n", action.name, action.count)
+	}
+	gauge("last_run_copied_bytes", "Bytes copied into the target by the last run.")
+	fmt.Fprintf(&b, "sync_dir_last_run_copied_bytes %d\n", s.Bytes)
+
+	if len(s.Ops) > 0 {
+		gauge("last_run_operations", "Operations on source and target in the last run, by kind.")
+		for _, op := range s.Ops {
+			fmt.Fprintf(&b, "sync_dir_last_run_operations{op=%q} %d\n", op.Op, op.Count)
+		}
+		gauge("last_run_operation_seconds", "Time spent in operations on source and target in the last run, by kind, summed over concurrent operations.")
+		for _, op := range s.Ops {
+			fmt.Fprintf(&b, "sync_dir_last_run_operation_seconds{op=%q} %.6f\n", op.Op, op.Time.Seconds())
+		}
+		gauge("last_run_operation_bytes", "Data read or written by operations on source and target in the last run, by kind.")
+		for _, op := range s.Ops {
+			fmt.Fprintf(&b, "sync_dir_last_run_operation_bytes{op=%q} %d\n", op.Op, op.Bytes)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
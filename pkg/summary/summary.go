@@ -0,0 +1,240 @@
+// pkg/summary/summary.go
+package summary
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// Summary is the outcome of a sync run, in a form meant to be reported to
+// people (terminal, email, chat).
+type Summary struct {
+	Profile   string // Config profile the run used, if any
+	Source    string
+	Target    string
+	Start     time.Time
+	End       time.Time
+	DryRun    bool
+	Executed  bool // The plan was confirmed and applied
+	Adds      int
+	Updates   int
+	Deletes   int
+	Renames   int
+	Bytes     int64     // Bytes copied into the target
+	Unchanged Unchanged // Files on both sides left alone as identical
+	Errors    []string  // Failed actions, or the error that stopped the run
+	Ops       []OpStats // Operations on source and target, by kind
+}
+
+// Unchanged counts the files present on both source and target that were
+// compared, and those left alone as identical by what showed them to be.
+type Unchanged struct {
+	Compared    int // Files on both sides that were compared
+	SizeMtime   int // Same size and mtime
+	Tolerance   int // Same size, mtimes within the target's tolerance (see --fs-quirks)
+	Checksum    int // Same size and checksum
+	Transformed int // Transformed on copy; the target carries the source mtime
+}
+
+// Total is the number of files left alone as identical.
+func (u Unchanged) Total() int {
+	return u.SizeMtime + u.Tolerance + u.Checksum + u.Transformed
+}
+
+// Add folds the counts of other into u.
+func (u *Unchanged) Add(other Unchanged) {
+	u.Compared += other.Compared
+	u.SizeMtime += other.SizeMtime
+	u.Tolerance += other.Tolerance
+	u.Checksum += other.Checksum
+	u.Transformed += other.Transformed
+}
+
+// Describe renders the counts in the current language, e.g. "120 of 125
+// compared files (118 by size and mtime, 2 by checksum)".
+func (u Unchanged) Describe() string {
+	var reasons []string
+	for _, reason := range []struct {
+		key   string
+		count int
+	}{
+		{"unchanged.size_mtime", u.SizeMtime},
+		{"unchanged.tolerance", u.Tolerance},
+		{"unchanged.checksum", u.Checksum},
+		{"unchanged.transformed", u.Transformed},
+	} {
+		if reason.count > 0 {
+			reasons = append(reasons, i18n.T(reason.key, reason.count))
+		}
+	}
+	text := i18n.T("unchanged.counts", u.Total(), u.Compared)
+	if len(reasons) > 0 {
+		text += " (" + strings.Join(reasons, ", ") + ")"
+	}
+	return text
+}
+
+// Formats lists the names accepted by Format.
+var Formats = []string{"plain", "markdown", "html"}
+
+// Duration is how long the run took.
+func (s *Summary) Duration() time.Duration {
+	return s.End.Sub(s.Start).Round(time.Millisecond)
+}
+
+// Changes is the number of planned actions.
+func (s *Summary) Changes() int {
+	return s.Adds + s.Updates + s.Deletes + s.Renames
+}
+
+// Result describes the outcome in a few words, always in English: log sinks
+// record it as a field that is meant to be queried.
+func (s *Summary) Result() string {
+	switch {
+	case len(s.Errors) > 0:
+		return fmt.Sprintf("failed (%d error(s))", len(s.Errors))
+	case s.DryRun:
+		return "dry run, nothing changed"
+	case s.Changes() == 0:
+		return "already in sync"
+	case !s.Executed:
+		return "aborted, nothing changed"
+	default:
+		return "success"
+	}
+}
+
+// Outcome is Result in the current language, for reports read by people.
+func (s *Summary) Outcome() string {
+	switch {
+	case len(s.Errors) > 0:
+		return i18n.N("summary.failed", len(s.Errors), len(s.Errors))
+	case s.DryRun:
+		return i18n.T("summary.dry_run")
+	case s.Changes() == 0:
+		return i18n.T("summary.in_sync")
+	case !s.Executed:
+		return i18n.T("summary.aborted")
+	default:
+		return i18n.T("summary.success")
+	}
+}
+
+// Add folds the outcome of another run (e.g. a mapped subtree) into s.
+func (s *Summary) Add(other *Summary) {
+	if other == nil {
+		return
+	}
+	if s.Start.IsZero() || (!other.Start.IsZero() && other.Start.Before(s.Start)) {
+		s.Start = other.Start
+	}
+	if other.End.After(s.End) {
+		s.End = other.End
+	}
+	s.Executed = s.Executed || other.Executed
+	s.Adds += other.Adds
+	s.Updates += other.Updates
+	s.Deletes += other.Deletes
+	s.Renames += other.Renames
+	s.Bytes += other.Bytes
+	s.Unchanged.Add(other.Unchanged)
+	s.Errors = append(s.Errors, other.Errors...)
+	s.addOps(other.Ops)
+}
+
+// CheckFormat returns an error if format isn't one of Formats.
+func CheckFormat(format string) error {
+	for _, name := range Formats {
+		if format == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown summary format %q (valid: %s)", format, strings.Join(Formats, ", "))
+}
+
+// FormatBytes renders n with a binary unit, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// OpStats times one kind of operation on the source and target (see the
+// Op* names) over a run.
+type OpStats struct {
+	Op    string
+	Count int64
+	Bytes int64         // Data read or written (0 for operations without data)
+	Time  time.Duration // Total, summed over concurrent operations
+}
+
+// Operation kinds of OpStats.
+const (
+	OpList   = "list"   // Reading a directory (or a published tree's manifest)
+	OpStat   = "stat"   // Getting the info of an entry
+	OpRead   = "read"   // Reading file data: hashing, downloading, copying through a buffer
+	OpWrite  = "write"  // Writing file data through a buffer
+	OpCopy   = "copy"   // Copying file data inside the kernel, read and write at once
+	OpDelete = "delete" // Removing an item (or moving it to the trash)
+)
+
+// Latency is the average duration of one operation.
+func (o OpStats) Latency() time.Duration {
+	if o.Count == 0 {
+		return 0
+	}
+	return o.Time / time.Duration(o.Count)
+}
+
+// Throughput is the data moved per second spent in the operations.
+func (o OpStats) Throughput() int64 {
+	if o.Time <= 0 {
+		return 0
+	}
+	return int64(float64(o.Bytes) / o.Time.Seconds())
+}
+
+// Describe renders the operation count, average latency and, for operations
+// moving data, throughput.
+func (o OpStats) Describe() string {
+	latency := o.Latency().Round(time.Microsecond)
+	if o.Bytes == 0 {
+		return i18n.T("summary.op", o.Op, o.Count, latency)
+	}
+	return i18n.T("summary.op_rate", o.Op, o.Count, latency, FormatBytes(o.Throughput()))
+}
+
+// DescribeOps renders all operations on one line.
+func (s *Summary) DescribeOps() string {
+	parts := make([]string, len(s.Ops))
+	for i, op := range s.Ops {
+		parts[i] = op.Describe()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// addOps merges the operations of another run into s, by kind.
+func (s *Summary) addOps(other []OpStats) {
+	for _, op := range other {
+		i := 0
+		for i < len(s.Ops) && s.Ops[i].Op != op.Op {
+			i++
+		}
+		if i == len(s.Ops) {
+			s.Ops = append(s.Ops, OpStats{Op: op.Op})
+		}
+		s.Ops[i].Count += op.Count
+		s.Ops[i].Bytes += op.Bytes
+		s.Ops[i].Time += op.Time
+	}
+}
@@ -0,0 +1,156 @@
+// pkg/syncer/annotate.go
+package syncer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+)
+
+// DefaultChurnWarning is the share of the target's files a plan may change
+// before it is flagged, unless told otherwise.
+const DefaultChurnWarning = 0.25
+
+const (
+	shrinkFactor    = 10 // Updates to less than a tenth of the old size are flagged
+	churnMinFiles   = 20 // Targets with fewer files are never flagged for churn
+	annotationLimit = 10 // Flagged items listed of each kind
+)
+
+// AnnotationKind is what is unusual about an annotated plan item.
+type AnnotationKind int
+
+const (
+	AnnotateShrink    AnnotationKind = iota // A file shrinks by more than 90%
+	AnnotateTopDelete                       // A top-level directory of the target is deleted
+	AnnotateExecOn                          // A file becomes executable
+	AnnotateExecOff                         // A file stops being executable
+	AnnotateChurn                           // More than ChurnWarning of the target's files change
+)
+
+// Annotation flags an item of a plan that deserves a look before the plan is
+// confirmed, being unlike what a routine sync does.
+type Annotation struct {
+	Kind    AnnotationKind
+	RelPath string // The item ("" for AnnotateChurn)
+	Message string // What is unusual about it
+}
+
+// ParseChurnWarning parses a share of the target's files such as "25%"; "0"
+// turns the warning off.
+func ParseChurnWarning(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "0" {
+		return 0, nil
+	}
+	number, ok := strings.CutSuffix(value, "%")
+	percent, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid churn warning %q: expected a percentage of the target's files from 0 to 100, e.g. 25%%", value)
+	}
+	return percent / 100, nil
+}
+
+// annotate flags the unusual items of plan: files shrinking to less than a
+// tenth of their size, top-level directories deleted with their contents,
+// files gaining or losing the executable bit, and plans changing more than
+// ChurnWarning of the target's files.
+func (s *Syncer) annotate(plan *SyncPlan) []Annotation {
+	var annotations []Annotation
+	changed := 0
+	for _, act := range plan.Actions {
+		if act.Type == Delete && act.TargetInfo != nil && act.TargetInfo.IsDir && !strings.ContainsRune(act.RelPath, filepath.Separator) {
+			n := s.countBelow(act.RelPath)
+			annotations = append(annotations, Annotation{Kind: AnnotateTopDelete, RelPath: act.RelPath, Message: i18n.N("annotate.top_delete", n, n)})
+		}
+		if act.SourceInfo != nil && act.SourceInfo.IsDir || act.TargetInfo != nil && act.TargetInfo.IsDir {
+			continue
+		}
+		changed++
+		if act.Type != Update || !act.SourceInfo.Mode.IsRegular() || !act.TargetInfo.Mode.IsRegular() {
+			continue
+		}
+		if oldSize, newSize := act.TargetInfo.Size, act.SourceInfo.Size; oldSize > 0 && newSize*shrinkFactor < oldSize {
+			shrink := int((oldSize - newSize) * 100 / oldSize)
+			message := i18n.T("annotate.shrink", shrink, summary.FormatBytes(oldSize), summary.FormatBytes(newSize))
+			annotations = append(annotations, Annotation{Kind: AnnotateShrink, RelPath: act.RelPath, Message: message})
+		}
+		wasExec, isExec := act.TargetInfo.Mode&0111 != 0, act.SourceInfo.Mode&0111 != 0
+		switch {
+		case isExec && !wasExec:
+			annotations = append(annotations, Annotation{Kind: AnnotateExecOn, RelPath: act.RelPath, Message: i18n.T("annotate.exec_on")})
+		case wasExec && !isExec:
+			annotations = append(annotations, Annotation{Kind: AnnotateExecOff, RelPath: act.RelPath, Message: i18n.T("annotate.exec_off")})
+		}
+	}
+
+	targetFiles := 0
+	for _, fi := range s.targetFiles {
+		if !fi.IsDir {
+			targetFiles++
+		}
+	}
+	if s.ChurnWarning > 0 && targetFiles >= churnMinFiles && float64(changed) > s.ChurnWarning*float64(targetFiles) {
+		message := i18n.T("annotate.churn", changed*100/targetFiles, changed, targetFiles)
+		annotations = append(annotations, Annotation{Kind: AnnotateChurn, Message: message})
+	}
+	sort.SliceStable(annotations, func(i, j int) bool { return annotations[i].Kind < annotations[j].Kind })
+	return annotations
+}
+
+// countBelow returns how many target items are below the directory relPath.
+func (s *Syncer) countBelow(relPath string) int {
+	prefix := relPath + string(filepath.Separator)
+	n := 0
+	for path := range s.targetFiles {
+		if strings.HasPrefix(path, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+// printAnnotations lists the plan's annotations below the plan, up to
+// annotationLimit of each kind, where they can't be missed.
+func (s *Syncer) printAnnotations(plan *SyncPlan) {
+	if len(plan.Annotations) == 0 {
+		return
+	}
+	fmt.Fprintln(s.stdout(), theme.Paint(theme.Failure, i18n.N("annotate.header", len(plan.Annotations), len(plan.Annotations))))
+	for i := 0; i < len(plan.Annotations); {
+		kind := plan.Annotations[i].Kind
+		n := 0
+		for ; i < len(plan.Annotations) && plan.Annotations[i].Kind == kind; i++ {
+			n++
+			annotation := plan.Annotations[i]
+			switch {
+			case n > annotationLimit:
+			case annotation.RelPath == "":
+				fmt.Fprintf(s.stdout(), "  ! %s\n", annotation.Message)
+			default:
+				fmt.Fprintf(s.stdout(), "  ! %s: %s\n", annotation.RelPath, annotation.Message)
+			}
+		}
+		if n > annotationLimit {
+			fmt.Fprintf(s.stdout(), "  ! %s\n", i18n.T("sample.more", n-annotationLimit))
+		}
+	}
+	fmt.Fprintln(s.stdout(), "-----------------")
+}
+
+// confirmUnusual reports whether annotations require the plan to be
+// confirmed although the thresholds of ConfirmDeletes and ConfirmChanges
+// would skip asking.
+func (s *Syncer) confirmUnusual(plan *SyncPlan) bool {
+	if len(plan.Annotations) == 0 || s.AssumeYes {
+		return false
+	}
+	fmt.Fprintln(s.stderr(), i18n.T("confirm.unusual"))
+	return true
+}
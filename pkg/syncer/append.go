@@ -0,0 +1,90 @@
+// pkg/syncer/append.go
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// appendFile completes dst in place if it holds the start of src, as a copy
+// that was cut off (e.g. by a dropped connection) leaves it: the bytes dst
+// has are verified to hash the same as the same range of src, and only the
+// rest of src is appended. It reports false, having changed nothing, if dst
+// isn't such a prefix or is hard linked elsewhere, so the file is copied
+// whole instead.
+func (e *executor) appendFile(src, dst string, modTime time.Time) (bool, error) {
+	target, err := os.OpenFile(dst, os.O_RDWR, 0)
+	if err != nil {
+		return false, nil
+	}
+	defer func() {
+		if target != nil {
+			_ = target.Close()
+		}
+	}()
+	source, err := os.Open(src)
+	if err != nil {
+		return false, fmt.Errorf("could not open source %s: %w", src, err)
+	}
+	defer source.Close()
+
+	start := time.Now()
+	targetInfo, err := target.Stat()
+	if err != nil {
+		return false, nil
+	}
+	sourceInfo, err := source.Stat()
+	if err != nil {
+		return false, fmt.Errorf("could not stat source %s: %w", src, err)
+	}
+	e.ops.record(summary.OpStat, start, 0)
+	offset := targetInfo.Size()
+	if offset == 0 || offset >= sourceInfo.Size() || linkCount(targetInfo) > 1 {
+		return false, nil // Appending would change the other links too
+	}
+
+	start = time.Now()
+	prefix := sha256.New()
+	same, err := samePrefix(source, target, offset, prefix)
+	e.ops.record(summary.OpRead, start, 2*offset)
+	if err != nil || !same {
+		return false, nil
+	}
+	if _, err := source.Seek(offset, io.SeekStart); err != nil {
+		return false, nil
+	}
+	if _, err := target.Seek(offset, io.SeekStart); err != nil {
+		return false, nil
+	}
+
+	e.live.addBytes(offset) // Kept without copying
+	buf := e.buffers.Get()
+	_, err = io.CopyBuffer(timedWriter{target, e.ops}, io.TeeReader(timedReader{source, e.ops}, io.MultiWriter(prefix, &progressWriter{exec: e})), *buf)
+	e.buffers.Put(buf)
+	if err != nil {
+		return true, fmt.Errorf("could not append data from %s to %s: %w", src, dst, err)
+	}
+	err = target.Close()
+	target = nil
+	if err != nil {
+		return true, fmt.Errorf("could not write %s: %w", dst, err)
+	}
+
+	if err := os.Chtimes(dst, modTime, modTime); err != nil && !e.quirks.IgnoreChtimesErrors {
+		fmt.Fprintf(e.stderr, "\nWarning: Failed to set modification time for %s: %v\n", dst, err)
+	}
+	if e.markers {
+		if err := writeMarkers(dst, hex.EncodeToString(prefix.Sum(nil)), modTime); err != nil {
+			e.markerWarn.Do(func() {
+				fmt.Fprintf(e.stderr, "\nWarning: Could not write xattr markers (first failure: %s): %v\n", dst, err)
+			})
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,42 @@
+// pkg/syncer/assert.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+)
+
+// ErrNotInSync is returned by Run with AssertInSync when the target
+// differs from the source.
+var ErrNotInSync = errors.New("target is not in sync with the source")
+
+// assertInSync lists every action of the plan with why it was planned, so the
+// output is a complete diff of source and target, and fails unless the plan
+// is empty. It takes the place of executing the plan, so nothing is
+// changed.
+func (s *Syncer) assertInSync(plan *SyncPlan) error {
+	if len(s.Explain) > 0 {
+		plan.Explanations = s.explain(plan)
+	}
+	if len(plan.Actions) == 0 {
+		s.printExplanations(plan)
+		fmt.Fprintln(s.stdout(), theme.Paint(theme.Success, i18n.T("assert.in_sync")))
+		return nil
+	}
+
+	fmt.Fprintln(s.stdout(), "\n"+theme.Paint(theme.Failure, i18n.N("assert.differences", len(plan.Actions), len(plan.Actions))))
+	fmt.Fprintln(s.stdout(), i18n.T("plan.counts", plan.Adds, plan.Updates, plan.Deletes, plan.Renames))
+	labels := i18n.Pad("action.add", "action.update", "action.delete", "action.rename")
+	roles := map[SyncActionType]theme.Role{Add: theme.Add, Update: theme.Update, Delete: theme.Delete, Rename: theme.Rename}
+	index := map[SyncActionType]int{Add: 0, Update: 1, Delete: 2, Rename: 3}
+	for _, action := range plan.Actions {
+		label := "[" + theme.Paint(roles[action.Type], labels[index[action.Type]]) + "]"
+		fmt.Fprintf(s.stdout(), "  %s %s: %s\n", label, action.RelPath, s.explainAction(action))
+	}
+	fmt.Fprintln(s.stdout(), "-----------------")
+	s.printExplanations(plan)
+	return ErrNotInSync
+}
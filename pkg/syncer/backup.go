@@ -0,0 +1,222 @@
+// pkg/syncer/backup.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/cas"
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// BackupResult reports what a backup into a content-addressed store did.
+type BackupResult struct {
+	Snapshot string // ID of the snapshot written ("" in a dry run)
+	Files    int    // Files in the snapshot
+	Stored   int    // Files whose content was new to the store
+	Bytes    int64  // Bytes added to the store
+	Reused   int    // Files whose content was stored already
+}
+
+// backup stores the source as a new snapshot of the content-addressed store
+// at TargetRoot (see CAS) instead of mirroring it. Files whose size and mtime
+// match the previous snapshot keep its hash without being read; the others
+// are hashed through the checksum cache and copied only if the store lacks
+// their content. The snapshot is written once all its content is stored.
+func (s *Syncer) backup() error {
+	store, err := s.openStore()
+	if err != nil {
+		return err
+	}
+	if store != nil && !s.DryRun {
+		unlock, err := store.Lock("backup of " + s.SourceRoot)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	s.ignoreMatcher, err = s.newMatcher(s.SourceRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load ignore rules: %w", err)
+	}
+	s.live.setPhase(PhaseScanning)
+	s.scanStats = &scanStatsLog{}
+	s.sourceFiles, err = s.scan(s.SourceRoot, s.ignoreMatcher, "source")
+	if err != nil {
+		return fmt.Errorf("error scanning source directory: %w", err)
+	}
+	s.printScanStats(s.ScanStats())
+
+	var parent map[string]cas.Entry
+	if store != nil {
+		latest, err := store.LatestSnapshot()
+		if err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Could not read the latest snapshot, hashing every file: %v\n", err)
+		} else if latest != nil {
+			parent = latest.Files()
+			fmt.Fprintln(s.stderr(), i18n.T("backup.comparing", latest.ID))
+		}
+	}
+
+	snap := &cas.Snapshot{Time: time.Now(), Source: s.SourceRoot}
+	var files []*fileinfo.FileInfo
+	var totalSize int64
+	var failed []string
+	for relPath, fi := range s.sourceFiles {
+		entry := cas.Entry{Path: filepath.ToSlash(relPath), Mode: fi.Mode, ModTime: fi.ModTime}
+		switch {
+		case fi.IsDir:
+			entry.Type = cas.TypeDir
+		case fi.IsSymlink():
+			entry.Type = cas.TypeSymlink
+			if entry.Link, err = os.Readlink(fi.AbsPath); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %v", relPath, err))
+				continue
+			}
+		default:
+			files = append(files, fi)
+			totalSize += fi.Size
+			continue
+		}
+		snap.Entries = append(snap.Entries, entry)
+	}
+
+	s.executed = !s.DryRun
+	s.live.setPhase(PhaseExecuting)
+	s.live.start(s.TargetRoot, int64(len(files)), totalSize)
+	s.hashes = newHashPool(s.HashWorkers, s.HashCPU)
+	defer s.hashes.Close()
+	hash := s.checksumFunc()
+	bar := s.newBar(i18n.T("progress.backing_up"), int64(len(files)))
+
+	result := &BackupResult{Files: len(files)}
+	dryRunAdded := make(map[string]bool) // New content a dry run has counted
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan *fileinfo.FileInfo)
+	for i := 0; i < s.hashes.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fi := range jobs {
+				s.pause.wait()
+				bar.Current(fi.RelPath)
+				entry, added, written, err := s.backupFile(store, fi, parent[filepath.ToSlash(fi.RelPath)], hash)
+				bar.Add(1)
+				s.live.actionDone()
+				s.live.addBytes(fi.Size)
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, fmt.Sprintf("%s: %v", fi.RelPath, err))
+				} else {
+					snap.Entries = append(snap.Entries, entry)
+					if added && s.DryRun {
+						added = !dryRunAdded[entry.Hash]
+						dryRunAdded[entry.Hash] = true
+					}
+					if added {
+						result.Stored++
+						result.Bytes += written
+					} else {
+						result.Reused++
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, fi := range files {
+		jobs <- fi
+	}
+	close(jobs)
+	wg.Wait()
+	bar.Finish()
+	s.bytesCopied = result.Bytes
+
+	sort.Strings(failed)
+	s.actionErrors = failed
+	if len(failed) > 0 {
+		// Leave the snapshot out: restoring it would silently lack these files
+		return fmt.Errorf("backup finished with %d error(s), no snapshot written:\n- %s", len(failed), strings.Join(failed, "\n- "))
+	}
+	if !s.DryRun {
+		if err := store.SaveSnapshot(snap); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		result.Snapshot = snap.ID
+	}
+	s.printBackupResult(result, s.DryRun)
+	return nil
+}
+
+// openStore opens the store at TargetRoot, creating it unless this is a dry
+// run. A dry run into a store that doesn't exist yet returns nil.
+func (s *Syncer) openStore() (*cas.Store, error) {
+	if !s.DryRun {
+		store, err := cas.Init(s.TargetRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open store: %w", err)
+		}
+		return store, nil
+	}
+	store, err := cas.Open(s.TargetRoot)
+	if errors.Is(err, cas.ErrNotStore) {
+		if entries, _ := os.ReadDir(s.TargetRoot); len(entries) > 0 {
+			return nil, fmt.Errorf("failed to open store: %w", err)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %w", err)
+	}
+	return store, nil
+}
+
+// backupFile stores the content of one file, unless it is stored already,
+// and returns its snapshot entry, whether its content was added to the store
+// and the bytes that took (or would take, in a dry run: chunks already stored
+// aren't known without reading the file).
+func (s *Syncer) backupFile(store *cas.Store, fi *fileinfo.FileInfo, prev cas.Entry, hash func(string) (string, error)) (entry cas.Entry, added bool, written int64, err error) {
+	entry = cas.Entry{Path: filepath.ToSlash(fi.RelPath), Type: cas.TypeFile, Mode: fi.Mode, ModTime: fi.ModTime, Size: fi.Size}
+	if prev.Hash != "" && prev.Size == fi.Size && prev.ModTime.Equal(fi.ModTime) {
+		entry.Hash = prev.Hash // Unchanged since the last backup
+		return entry, false, 0, nil
+	}
+	sum, err := hash(fi.AbsPath)
+	if err != nil {
+		return entry, false, 0, err
+	}
+	entry.Hash = sum
+	if store != nil && store.HasContent(sum) {
+		return entry, false, 0, nil
+	}
+	if s.DryRun {
+		return entry, true, fi.Size, nil
+	}
+	// The content is hashed again while copying, so a file modified since
+	// it was hashed is stored under the hash of what was actually copied
+	put, err := store.Put(fi.AbsPath)
+	if err != nil {
+		return entry, false, 0, err
+	}
+	entry.Hash, entry.Size = put.Hash, put.Size
+	return entry, put.Added, put.Written, nil
+}
+
+// printBackupResult prints what backup did.
+func (s *Syncer) printBackupResult(result *BackupResult, dryRun bool) {
+	if dryRun {
+		fmt.Fprintln(s.stdout(), "\n"+i18n.T("backup.dry_run", result.Files, result.Stored, summary.FormatBytes(result.Bytes), result.Reused))
+		return
+	}
+	fmt.Fprintln(s.stdout(), "\n"+i18n.T("backup.result", result.Snapshot, result.Files, result.Stored, summary.FormatBytes(result.Bytes), result.Reused))
+}
@@ -0,0 +1,41 @@
+// pkg/syncer/buffers.go
+package syncer
+
+import "sync"
+
+// DefaultBufferSize is the copy buffer size used when none is configured.
+const DefaultBufferSize = 1024 * 1024 // 1 MiB
+
+// MaxBufferSize is the largest copy buffer accepted. Every parallel copy
+// holds one, and larger buffers don't copy faster.
+const MaxBufferSize = 256 * 1024 * 1024 // 256 MiB
+
+// bufferPool hands out reusable copy buffers of a fixed size, so concurrent
+// copies don't each allocate (and later garbage collect) a fresh buffer.
+type bufferPool struct {
+	size int
+	pool sync.Pool
+}
+
+// newBufferPool creates a pool of size-byte buffers (DefaultBufferSize if size <= 0).
+func newBufferPool(size int) *bufferPool {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+	p := &bufferPool{size: size}
+	p.pool.New = func() any {
+		buf := make([]byte, size)
+		return &buf // Pointer avoids an allocation when putting it back
+	}
+	return p
+}
+
+// Get returns a buffer from the pool.
+func (p *bufferPool) Get() *[]byte {
+	return p.pool.Get().(*[]byte)
+}
+
+// Put returns a buffer obtained from Get to the pool.
+func (p *bufferPool) Put(buf *[]byte) {
+	p.pool.Put(buf)
+}
@@ -0,0 +1,132 @@
+// pkg/syncer/casefold.go
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// caseRenameSuffix is appended to an item's name for the intermediate step of a
+// case-only rename. Renaming "foo" straight to "Foo" is a no-op (or an error) on
+// some case-insensitive filesystems, so we always go through a temporary name.
+const caseRenameSuffix = ".sync-dir-case-tmp"
+
+// isCaseInsensitive reports whether the filesystem holding path treats names
+// case-insensitively. It stats a case-swapped variant of the nearest existing
+// ancestor, so it never writes anything to the target.
+func isCaseInsensitive(path string) bool {
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		base := filepath.Base(p)
+		swapped := swapCase(base)
+		if swapped != base {
+			if orig, err := os.Stat(p); err == nil {
+				alt, err := os.Stat(filepath.Join(filepath.Dir(p), swapped))
+				return err == nil && os.SameFile(orig, alt)
+			}
+		}
+		if filepath.Dir(p) == p {
+			return false // Reached the root without finding a usable name
+		}
+	}
+}
+
+// swapCase inverts the case of every letter in s.
+func swapCase(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+}
+
+// planCaseRenames finds target items whose path only differs in case from a
+// source item. It returns Rename actions (parents before children) and a copy of
+// targetFiles re-keyed under the new names, so the rest of the planner sees the
+// renamed items as matching their source counterparts.
+func planCaseRenames(sourceFiles, targetFiles map[string]*fileinfo.FileInfo) ([]SyncAction, map[string]*fileinfo.FileInfo) {
+	// Index source paths by their folded form. Paths that collide when folded
+	// (e.g. "a.txt" and "A.txt" on a case-sensitive source) are ambiguous, so we
+	// never rename towards them.
+	folded := make(map[string]string, len(sourceFiles))
+	for relPath := range sourceFiles {
+		key := strings.ToLower(relPath)
+		if _, dup := folded[key]; dup {
+			folded[key] = ""
+			continue
+		}
+		folded[key] = relPath
+	}
+
+	// Visit target paths shallowest first so that directory renames are known
+	// before their children are looked at.
+	targetPaths := make([]string, 0, len(targetFiles))
+	for relPath := range targetFiles {
+		targetPaths = append(targetPaths, relPath)
+	}
+	sort.Slice(targetPaths, func(i, j int) bool {
+		depthI := strings.Count(targetPaths[i], string(os.PathSeparator))
+		depthJ := strings.Count(targetPaths[j], string(os.PathSeparator))
+		if depthI != depthJ {
+			return depthI < depthJ
+		}
+		return targetPaths[i] < targetPaths[j]
+	})
+
+	var renames []SyncAction
+	renamedDirs := make(map[string]string) // Old target dir path -> new path
+	adjusted := make(map[string]*fileinfo.FileInfo, len(targetFiles))
+
+	for _, relPath := range targetPaths {
+		targetFi := targetFiles[relPath]
+
+		// Apply any rename of the parent directory first
+		current := relPath
+		if parent := filepath.Dir(relPath); parent != "." {
+			if newParent, ok := renamedDirs[parent]; ok {
+				current = filepath.Join(newParent, filepath.Base(relPath))
+				renamedDirs[relPath] = current // Children follow this item too
+			}
+		}
+
+		sourcePath, ok := folded[strings.ToLower(current)]
+		_, exactInSource := sourceFiles[current]
+		_, exactInTarget := targetFiles[sourcePath]
+		if exactInSource || !ok || sourcePath == "" || sourcePath == current || exactInTarget {
+			adjusted[current] = withRelPath(targetFi, current)
+			continue
+		}
+
+		// Only the last path component can differ here, since parents were
+		// already re-keyed above.
+		renames = append(renames, SyncAction{
+			Type:       Rename,
+			SourceInfo: sourceFiles[sourcePath],
+			TargetInfo: targetFi,
+			RelPath:    sourcePath,
+			OldRelPath: current,
+		})
+		if targetFi.IsDir {
+			renamedDirs[relPath] = sourcePath
+		}
+		adjusted[sourcePath] = withRelPath(targetFi, sourcePath)
+	}
+
+	return renames, adjusted
+}
+
+// withRelPath returns fi, or a copy of it carrying relPath if the key changed.
+// AbsPath is left untouched: it still points at the item as it exists today.
+func withRelPath(fi *fileinfo.FileInfo, relPath string) *fileinfo.FileInfo {
+	if fi.RelPath == relPath {
+		return fi
+	}
+	moved := *fi
+	moved.RelPath = relPath
+	return &moved
+}
@@ -0,0 +1,129 @@
+// pkg/syncer/casefold_test.go
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// fileMap builds the files of a scan from slash-separated paths; those ending
+// in a slash are directories.
+func fileMap(paths ...string) map[string]*fileinfo.FileInfo {
+	files := make(map[string]*fileinfo.FileInfo, len(paths))
+	for _, p := range paths {
+		isDir := p[len(p)-1] == '/'
+		relPath := filepath.FromSlash(p)
+		if isDir {
+			relPath = relPath[:len(relPath)-1]
+		}
+		files[relPath] = &fileinfo.FileInfo{RelPath: relPath, IsDir: isDir}
+	}
+	return files
+}
+
+func TestPlanCaseRenames(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  []string
+		target  []string
+		renames []string // "old -> new"
+		keys    []string // Target paths after the renames
+	}{
+		{
+			name:    "file",
+			source:  []string{"Readme.md"},
+			target:  []string{"readme.md"},
+			renames: []string{"readme.md -> Readme.md"},
+			keys:    []string{"Readme.md"},
+		},
+		{
+			name:    "directory, then a child under its new name",
+			source:  []string{"Docs/", "Docs/Guide.txt", "Docs/b.txt", "Docs/Sub/", "Docs/Sub/c.txt"},
+			target:  []string{"docs/", "docs/guide.txt", "docs/b.txt", "docs/sub/", "docs/sub/c.txt"},
+			renames: []string{"docs -> Docs", "Docs/guide.txt -> Docs/Guide.txt", "Docs/sub -> Docs/Sub"},
+			keys:    []string{"Docs", "Docs/Guide.txt", "Docs/Sub", "Docs/Sub/c.txt", "Docs/b.txt"},
+		},
+		{
+			name:   "same case",
+			source: []string{"a.txt", "dir/", "dir/b.txt"},
+			target: []string{"a.txt", "dir/", "dir/b.txt"},
+			keys:   []string{"a.txt", "dir", "dir/b.txt"},
+		},
+		{
+			name:   "source names colliding when folded",
+			source: []string{"a.txt", "A.txt"},
+			target: []string{"a.TXT"},
+			keys:   []string{"a.TXT"},
+		},
+		{
+			name:   "both names on the target",
+			source: []string{"Note"},
+			target: []string{"Note", "note"},
+			keys:   []string{"Note", "note"},
+		},
+		{
+			name:   "only on the target",
+			source: []string{"kept"},
+			target: []string{"gone", "Other"},
+			keys:   []string{"Other", "gone"},
+		},
+	}
+	for _, tt := range tests {
+		renames, adjusted := planCaseRenames(fileMap(tt.source...), fileMap(tt.target...))
+		var got []string
+		for _, r := range renames {
+			if r.Type != Rename || r.SourceInfo == nil || r.TargetInfo == nil {
+				t.Errorf("%s: incomplete action %+v", tt.name, r)
+			}
+			got = append(got, filepath.ToSlash(r.OldRelPath)+" -> "+filepath.ToSlash(r.RelPath))
+		}
+		if !slices.Equal(got, tt.renames) {
+			t.Errorf("%s: renames %q, want %q", tt.name, got, tt.renames)
+		}
+		var keys []string
+		for key, fi := range adjusted {
+			keys = append(keys, filepath.ToSlash(key))
+			if fi.RelPath != key {
+				t.Errorf("%s: %s carries RelPath %s", tt.name, key, fi.RelPath)
+			}
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, tt.keys) {
+			t.Errorf("%s: target paths %q, want %q", tt.name, keys, tt.keys)
+		}
+	}
+}
+
+func TestSwapCase(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"abc", "ABC"},
+		{"MiXeD.Txt", "mIxEd.tXT"},
+		{"123_-.", "123_-."},
+		{"Straße", "sTRAßE"}, // No single upper case ß
+	}
+	for _, tt := range tests {
+		if got := swapCase(tt.in); got != tt.want {
+			t.Errorf("swapCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// The probe agrees with the filesystem, also for a path not created yet.
+func TestIsCaseInsensitive(t *testing.T) {
+	dir := t.TempDir()
+	probe := filepath.Join(dir, "Probe")
+	if err := os.Mkdir(probe, 0755); err != nil {
+		t.Fatal(err)
+	}
+	_, err := os.Stat(filepath.Join(dir, "pROBE"))
+	want := err == nil
+	for _, path := range []string{probe, filepath.Join(probe, "missing", "deeper")} {
+		if got := isCaseInsensitive(path); got != want {
+			t.Errorf("isCaseInsensitive(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// pkg/syncer/copy_linux.go
+//go:build linux
+
+package syncer
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// zeroCopy copies size bytes from src to dst inside the kernel, trying
+// copy_file_range first and falling back to sendfile. Data is moved in chunks of
+// chunkSize so progress can be reported as it goes. It returns ok=false (and no
+// error) if neither syscall is usable for this pair of files before any data
+// was copied, in which case the caller should fall back to a userspace copy.
+func zeroCopy(dst, src *os.File, size int64, chunkSize int, progress func(int64)) (ok bool, err error) {
+	var copied int64
+	useSendfile := false
+
+	for copied < size {
+		chunk := int(min(int64(chunkSize), size-copied))
+
+		var n int
+		if !useSendfile {
+			n, err = unix.CopyFileRange(int(src.Fd()), nil, int(dst.Fd()), nil, chunk, 0)
+			if err != nil && copied == 0 && unsupportedZeroCopy(err) {
+				useSendfile = true // e.g. cross-filesystem on older kernels
+				continue
+			}
+		} else {
+			n, err = unix.Sendfile(int(dst.Fd()), int(src.Fd()), nil, chunk)
+			if err != nil && copied == 0 && unsupportedZeroCopy(err) {
+				return false, nil
+			}
+		}
+		if err == unix.EINTR || err == unix.EAGAIN {
+			continue
+		}
+		if err != nil {
+			return true, err
+		}
+		if n == 0 {
+			break // Source shrank while copying; stop at EOF
+		}
+
+		copied += int64(n)
+		progress(int64(n))
+	}
+	return true, nil
+}
+
+// unsupportedZeroCopy reports whether err means the syscall can't be used for
+// these files at all (as opposed to a real I/O error).
+func unsupportedZeroCopy(err error) bool {
+	return errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EXDEV) ||
+		errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) ||
+		errors.Is(err, unix.EBADF) || errors.Is(err, unix.EPERM)
+}
@@ -0,0 +1,12 @@
+// pkg/syncer/copy_other.go
+//go:build !linux
+
+package syncer
+
+import "os"
+
+// zeroCopy is only implemented on Linux; elsewhere the caller always falls
+// back to a buffered copy.
+func zeroCopy(dst, src *os.File, size int64, chunkSize int, progress func(int64)) (ok bool, err error) {
+	return false, nil
+}
@@ -0,0 +1,120 @@
+// pkg/syncer/crosscheck.go
+package syncer
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// CrossCheckVerdict is what a cross-check concludes about two replicas.
+type CrossCheckVerdict int
+
+const (
+	CrossCheckInSync   CrossCheckVerdict = iota // The replicas hold the same items
+	CrossCheckStaleA                            // Replica A is behind replica B
+	CrossCheckStaleB                            // Replica B is behind replica A
+	CrossCheckDiverged                          // Each replica holds changes the other lacks
+)
+
+// CrossCheckReport describes how two replicas of the same source differ. A
+// is SourceRoot, B TargetRoot.
+type CrossCheckReport struct {
+	OnlyA     []string  // Items only in A (directories end in a separator)
+	OnlyB     []string  // Items only in B
+	NewerA    []string  // Files in both that differ, A's copy being newer
+	NewerB    []string  // Files in both that differ, B's copy being newer
+	Identical int       // Files in both with the same content
+	LatestA   time.Time // Newest mtime of a file in A
+	LatestB   time.Time // Newest mtime of a file in B
+}
+
+// Differences returns how many items differ between the replicas.
+func (r *CrossCheckReport) Differences() int {
+	return len(r.OnlyA) + len(r.OnlyB) + len(r.NewerA) + len(r.NewerB)
+}
+
+// Verdict tells which replica is stale. Files present in both decide: the
+// replica holding only the older copies is behind. When no such files
+// differ, the items present on one side only are judged by the newest file
+// of each replica, as a replica missing the latest runs holds older files.
+func (r *CrossCheckReport) Verdict() CrossCheckVerdict {
+	switch {
+	case r.Differences() == 0:
+		return CrossCheckInSync
+	case len(r.NewerA) > 0 && len(r.NewerB) > 0:
+		return CrossCheckDiverged
+	case len(r.NewerA) > 0:
+		return CrossCheckStaleB
+	case len(r.NewerB) > 0:
+		return CrossCheckStaleA
+	case r.LatestA.After(r.LatestB):
+		return CrossCheckStaleB
+	case r.LatestB.After(r.LatestA):
+		return CrossCheckStaleA
+	default:
+		return CrossCheckDiverged
+	}
+}
+
+// CrossCheck compares two replicas of the same source against each other,
+// SourceRoot being replica A and TargetRoot replica B, without changing
+// anything. B is planned against A as if A were the source, so the same
+// ignore rules and comparisons decide what differs.
+func (s *Syncer) CrossCheck() (*CrossCheckReport, error) {
+	if err := s.scanRoots(); err != nil {
+		return nil, err
+	}
+	if err := s.buildPlan(); err != nil {
+		return nil, err
+	}
+
+	report := &CrossCheckReport{
+		Identical: s.plan.Unchanged.Total(),
+		LatestA:   latestModTime(s.sourceFiles),
+		LatestB:   latestModTime(s.targetFiles),
+	}
+	for _, act := range s.plan.Actions {
+		switch act.Type {
+		case Add:
+			report.OnlyA = append(report.OnlyA, displayPath(act.RelPath, act.SourceInfo.IsDir))
+		case Delete:
+			report.OnlyB = append(report.OnlyB, displayPath(act.RelPath, act.TargetInfo.IsDir))
+		case Rename:
+			// The same content under another name on each side
+			report.OnlyA = append(report.OnlyA, displayPath(act.RelPath, act.TargetInfo.IsDir))
+			report.OnlyB = append(report.OnlyB, displayPath(act.OldRelPath, act.TargetInfo.IsDir))
+		case Update:
+			if act.TargetInfo.ModTime.After(act.SourceInfo.ModTime) {
+				report.NewerB = append(report.NewerB, act.RelPath)
+			} else {
+				report.NewerA = append(report.NewerA, act.RelPath)
+			}
+		}
+	}
+	for _, paths := range [][]string{report.OnlyA, report.OnlyB, report.NewerA, report.NewerB} {
+		sort.Strings(paths)
+	}
+	return report, nil
+}
+
+// displayPath marks directories with a trailing separator.
+func displayPath(relPath string, isDir bool) string {
+	if isDir {
+		return relPath + string(filepath.Separator)
+	}
+	return relPath
+}
+
+// latestModTime returns the newest mtime of the files in files.
+func latestModTime(files map[string]*fileinfo.FileInfo) time.Time {
+	var latest time.Time
+	for _, fi := range files {
+		if !fi.IsDir && fi.ModTime.After(latest) {
+			latest = fi.ModTime
+		}
+	}
+	return latest
+}
@@ -0,0 +1,136 @@
+// pkg/syncer/dedupe.go
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// DedupeResult reports what DedupeTarget did.
+type DedupeResult struct {
+	Groups    int      // Sets of identical files found
+	Linked    int      // Files replaced by a hard link
+	Reclaimed int64    // Bytes freed: files whose last link was replaced
+	Errors    []string // Files that could not be hashed or linked
+}
+
+// dedupeTarget hard links identical files within the target to each other.
+// Candidates are regular files of equal size and permissions; equal ones (by
+// SHA256) are all linked to the first by path. Updating a linked file later is
+// safe: copies replace the target file by renaming a temp file over it (see
+// copyFile), which never writes through the shared inode.
+func (s *Syncer) dedupeTarget() (*DedupeResult, error) {
+	files, err := scanDirectory(s.TargetRoot, s.TargetRoot, s.scanOptions(s.TargetRoot, s.ignoreMatcher, "target"))
+	s.observer().OnScanProgress(ScanProgress{Description: "target", Root: s.TargetRoot, Found: int64(len(files)), Done: true})
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		size int64
+		perm os.FileMode
+	}
+	candidates := make(map[key][]*fileinfo.FileInfo)
+	var total int64
+	for _, fi := range files {
+		if fi.IsDir || fi.IsSymlink() || fi.Size == 0 {
+			continue
+		}
+		k := key{fi.Size, fi.Mode.Perm()}
+		candidates[k] = append(candidates[k], fi)
+	}
+	for k, group := range candidates {
+		if len(group) < 2 {
+			delete(candidates, k)
+			continue
+		}
+		total += int64(len(group))
+	}
+
+	result := &DedupeResult{}
+	s.hashes = newHashPool(s.HashWorkers, s.HashCPU)
+	defer s.hashes.Close()
+	hash := s.cachedChecksumFunc()
+	bar := s.newBar(i18n.T("progress.deduplicating"), total)
+	defer bar.Finish()
+
+	for _, group := range candidates {
+		bySum := make(map[string][]*fileinfo.FileInfo)
+		for _, fi := range group {
+			bar.Add(1)
+			sum, err := hash(fi.AbsPath)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", fi.RelPath, err))
+				continue
+			}
+			bySum[sum] = append(bySum[sum], fi)
+		}
+		for _, same := range bySum {
+			if len(same) < 2 {
+				continue
+			}
+			sort.Slice(same, func(i, j int) bool { return same[i].RelPath < same[j].RelPath })
+			linked, reclaimed, errs := linkIdentical(same[0], same[1:])
+			if linked > 0 {
+				result.Groups++
+			}
+			result.Linked += linked
+			result.Reclaimed += reclaimed
+			result.Errors = append(result.Errors, errs...)
+		}
+	}
+	sort.Strings(result.Errors)
+	return result, nil
+}
+
+// linkIdentical replaces each duplicate by a hard link to canonical.
+func linkIdentical(canonical *fileinfo.FileInfo, duplicates []*fileinfo.FileInfo) (linked int, reclaimed int64, errs []string) {
+	canonicalInfo, err := os.Stat(canonical.AbsPath)
+	if err != nil {
+		return 0, 0, []string{fmt.Sprintf("%s: %v", canonical.RelPath, err)}
+	}
+	for _, dup := range duplicates {
+		info, err := os.Stat(dup.AbsPath)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", dup.RelPath, err))
+			continue
+		}
+		if os.SameFile(canonicalInfo, info) {
+			continue // Linked already
+		}
+		// Link under a temp name first and rename it over the duplicate, so
+		// the path never goes missing
+		tempPath, err := tempName(filepath.Dir(dup.AbsPath))
+		if err == nil {
+			err = os.Link(canonical.AbsPath, tempPath)
+		}
+		if err == nil {
+			if err = os.Rename(tempPath, dup.AbsPath); err != nil {
+				_ = os.Remove(tempPath)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", dup.RelPath, err))
+			continue
+		}
+		linked++
+		if linkCount(info) <= 1 {
+			reclaimed += info.Size() // Its data had no other link
+		}
+	}
+	return linked, reclaimed, errs
+}
+
+// printDedupeResult prints what dedupeTarget did.
+func (s *Syncer) printDedupeResult(result *DedupeResult) {
+	fmt.Fprintln(s.stdout(), "\n"+i18n.T("dedupe.result", result.Linked, result.Groups, summary.FormatBytes(result.Reclaimed)))
+	for _, e := range result.Errors {
+		fmt.Fprintf(s.stderr(), "Warning: Dedupe: %s\n", e)
+	}
+}
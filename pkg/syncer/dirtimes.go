@@ -0,0 +1,54 @@
+// pkg/syncer/dirtimes.go
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/compare"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// restoreDirTimes gives the directories of the target at root (TargetRoot, or
+// its staging clone with StageAndSwap) the mtimes of their source
+// directories. Writing into a directory changes its mtime, so this runs once
+// nothing else is written. Directories whose mtime already matches are left
+// alone, so a target in sync isn't touched at all.
+func (s *Syncer) restoreDirTimes(root string) {
+	times := make(map[string]time.Time)
+	for relPath, fi := range s.sourceFiles {
+		if fi.IsDir {
+			times[relPath] = fi.ModTime
+		}
+	}
+	if s.remote == nil && len(s.MergeSources) == 0 {
+		if info, err := os.Stat(s.SourceRoot); err == nil {
+			times["."] = info.ModTime()
+		}
+	}
+
+	restored, failed := 0, 0
+	var firstErr error
+	for relPath, modTime := range times {
+		targetPath := filepath.Join(root, relPath)
+		info, err := os.Stat(targetPath)
+		if err != nil || !info.IsDir() || compare.SameModTime(info.ModTime(), modTime, s.Quirks.MtimeTolerance) {
+			continue // Not synced (e.g. declined or failed), or already right
+		}
+		if err := os.Chtimes(targetPath, modTime, modTime); err != nil {
+			if failed++; firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", relPath, err)
+			}
+			continue
+		}
+		restored++
+	}
+	if restored > 0 {
+		fmt.Fprintln(s.stdout(), i18n.N("dirtimes.restored", restored, restored))
+	}
+	if failed > 0 && !s.Quirks.IgnoreChtimesErrors {
+		fmt.Fprintf(s.stderr(), "Warning: Could not set the modification time of %d directories (first failure: %v)\n", failed, firstErr)
+	}
+}
@@ -0,0 +1,51 @@
+// pkg/syncer/disk.go
+package syncer
+
+import "github.com/jeepinbird/sync-dir/pkg/fileinfo"
+
+// sameDiskWorkers is the copy concurrency used when source and target share a
+// device whose type we can't tell. Rotational disks get a single worker.
+const sameDiskWorkers = 2
+
+// diskInfo describes how the source and target roots relate on disk.
+type diskInfo struct {
+	SameDevice      bool // Source and target live on the same block device
+	Rotational      bool // The shared device is a spinning disk (if RotationalKnown)
+	RotationalKnown bool
+}
+
+// detectSharedDisk checks whether sourceRoot and targetRoot resolve to the same
+// device. A target that doesn't exist yet is judged by its nearest existing ancestor.
+func detectSharedDisk(sourceRoot, targetRoot string) diskInfo {
+	sourceDev, ok := deviceID(sourceRoot)
+	if !ok {
+		return diskInfo{}
+	}
+	targetDev, ok := deviceID(fileinfo.ExistingAncestor(targetRoot))
+	if !ok || sourceDev != targetDev {
+		return diskInfo{}
+	}
+
+	info := diskInfo{SameDevice: true}
+	info.Rotational, info.RotationalKnown = isRotational(sourceDev)
+	return info
+}
+
+// copyWorkers returns the number of parallel file operations to use. Shared
+// devices are throttled to avoid seek thrash unless SameDiskWorkers overrides it.
+// Workers overrides both.
+func (s *Syncer) copyWorkers(disk diskInfo) int {
+	if s.Workers > 0 {
+		return s.Workers
+	}
+	if !disk.SameDevice {
+		return maxConcurrentOps
+	}
+	if s.SameDiskWorkers > 0 {
+		return s.SameDiskWorkers
+	}
+	if disk.RotationalKnown && disk.Rotational {
+		return 1
+	}
+	return sameDiskWorkers
+}
@@ -0,0 +1,25 @@
+// pkg/syncer/disk_linux.go
+//go:build linux
+
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// isRotational reads the kernel's rotational flag for a block device. For a
+// partition the flag lives on the parent disk, one directory up.
+func isRotational(dev uint64) (rotational bool, known bool) {
+	base := fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(dev), unix.Minor(dev))
+	for _, path := range []string{base + "/queue/rotational", base + "/../queue/rotational"} {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			return strings.TrimSpace(string(data)) == "1", true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,9 @@
+// pkg/syncer/disk_nonlinux.go
+//go:build !linux
+
+package syncer
+
+// isRotational is only implemented on Linux.
+func isRotational(dev uint64) (rotational bool, known bool) {
+	return false, false
+}
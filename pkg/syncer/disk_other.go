@@ -0,0 +1,29 @@
+// pkg/syncer/disk_other.go
+//go:build !unix
+
+package syncer
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// deviceID is not available on this platform, so shared disks are never detected.
+func deviceID(path string) (uint64, bool) {
+	return 0, false
+}
+
+// linkCount can't be told on this platform; every file counts as the only link.
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
+
+// fileID has no inode to go by on this platform, so it uses the path with
+// all symlinks resolved.
+func fileID(path string, info os.FileInfo) (fileKey, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileKey{}, false
+	}
+	return fileKey{path: resolved}, true
+}
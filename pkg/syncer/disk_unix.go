@@ -0,0 +1,34 @@
+// pkg/syncer/disk_unix.go
+//go:build unix
+
+package syncer
+
+import (
+	"os"
+	"syscall"
+)
+
+// deviceID returns the ID of the device holding path.
+func deviceID(path string) (uint64, bool) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return 0, false
+	}
+	return uint64(st.Dev), true // Dev is int32 on some platforms
+}
+
+// linkCount returns the number of hard links to the file described by info.
+func linkCount(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink) // Nlink is uint16 on some platforms
+	}
+	return 1
+}
+
+// fileID returns the device and inode of the file described by info.
+func fileID(path string, info os.FileInfo) (fileKey, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+	}
+	return fileKey{}, false
+}
@@ -0,0 +1,99 @@
+// pkg/syncer/drift.go
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/state"
+)
+
+// Drift lists the items of one tree that changed compared to the snapshot.
+type Drift struct {
+	Added    []string
+	Modified []string
+	Removed  []string
+}
+
+// Empty reports whether nothing changed.
+func (d Drift) Empty() bool {
+	return len(d.Added) == 0 && len(d.Modified) == 0 && len(d.Removed) == 0
+}
+
+// StatusReport describes how a pair changed since its last successful sync.
+type StatusReport struct {
+	Synced       bool             // A snapshot from a previous successful run exists
+	SnapshotTime time.Time        // When that snapshot was taken
+	LastRun      *state.RunRecord // Most recent run (successful or not), if any
+	Interrupted  bool             // The last run started applying changes but never finished
+	Source       Drift            // Changes in the source since the snapshot
+	Target       Drift            // Out-of-band changes in the target since the snapshot
+}
+
+// Status scans source and target and compares both against the snapshot of
+// the last successful sync, without changing anything.
+func (s *Syncer) Status() (*StatusReport, error) {
+	report := &StatusReport{}
+
+	pair, err := state.Lookup(s.StateDir, s.stateKey(), s.TargetRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	if pair == nil {
+		return report, nil // Never synced
+	}
+
+	history, err := pair.History()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync history: %w", err)
+	}
+	if len(history) > 0 {
+		report.LastRun = &history[len(history)-1]
+	}
+	report.Interrupted = pair.Interrupted()
+
+	snapshot, err := pair.LoadSnapshot()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync snapshot: %w", err)
+	}
+	if snapshot == nil {
+		return report, nil
+	}
+	report.Synced = true
+	report.SnapshotTime = snapshot.Time
+
+	if err := s.scanRoots(); err != nil {
+		return nil, err
+	}
+	report.Source = diffSnapshot(snapshot.Files, s.sourceFiles)
+	report.Target = diffSnapshot(snapshot.Files, s.targetFiles)
+	return report, nil
+}
+
+// diffSnapshot compares a current scan against the snapshot by type, size and
+// mtime (the same quick check the planner starts with).
+func diffSnapshot(snapshot, current map[string]*fileinfo.FileInfo) Drift {
+	var drift Drift
+	for relPath, fi := range current {
+		old, ok := snapshot[relPath]
+		switch {
+		case !ok:
+			drift.Added = append(drift.Added, relPath)
+		case old.IsDir != fi.IsDir:
+			drift.Modified = append(drift.Modified, relPath)
+		case !fi.IsDir && (old.Size != fi.Size || !old.ModTime.Truncate(time.Second).Equal(fi.ModTime.Truncate(time.Second))):
+			drift.Modified = append(drift.Modified, relPath)
+		}
+	}
+	for relPath := range snapshot {
+		if _, ok := current[relPath]; !ok {
+			drift.Removed = append(drift.Removed, relPath)
+		}
+	}
+	sort.Strings(drift.Added)
+	sort.Strings(drift.Modified)
+	sort.Strings(drift.Removed)
+	return drift
+}
@@ -3,7 +3,10 @@ package syncer
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"os"
 	"path/filepath"
@@ -11,227 +14,599 @@ import (
 	"sync"
 	"time"
 
-	"github.com/schollz/progressbar/v3"
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/state"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+	"github.com/jeepinbird/sync-dir/pkg/transform"
+	"github.com/jeepinbird/sync-dir/pkg/trash"
 )
 
 const maxConcurrentOps = 10 // Max number of parallel file operations
 
+// stdinReader is shared by all confirmation prompts, so that several syncs in
+// one process don't lose buffered input to each other.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// executor carries the state shared by all actions of one plan execution.
+type executor struct {
+	sourceRoot string
+	targetRoot string
+	buffers    *bufferPool
+	transforms *transform.Pipeline
+	quirks     FSQuirks
+	journal    *state.Journal // Temp files are registered here (nil without state)
+	tempDir    string         // Temp files are written here instead of next to their destination ("" = next to it)
+	stageCopy  bool           // tempDir is on another filesystem, so staged files are copied into place
+	partialDir string         // Copies are written to a directory of this name next to their destination and resumed from there ("" = temp files)
+	appendMode bool           // Target files holding the start of their source are completed in place (see appendFile)
+	quota      *quotaMeter    // Keeps the target within TargetQuota (nil = no quota)
+	seeds      *seedIndex     // Files taken from SeedDir instead of the source (nil = no seed)
+	remote     *remoteSource  // Where source files are downloaded from (nil = local source)
+	live       *liveStatus
+	throttle   *throttle
+	toTrash    bool // Deletions go to the OS trash
+	markers    bool // Copies are tagged with xattr markers (see writeMarkers)
+	fakeSuper  bool // Special files are written as placeholders (see FakeSuper)
+	fromFake   bool // Placeholders of special files are turned back into them (see FromFakeSuper)
+	markerWarn sync.Once
+	oplog      *state.OpLog         // Completed actions are appended here (nil = not logged)
+	checksums  *state.ChecksumCache // Checksums known from earlier runs, for the log (nil = none)
+	oplogWarn  sync.Once
+	observer   Observer
+	stderr     io.Writer // Receives warnings about single files
+	ops        *opTimer
+	copiedMu   sync.Mutex // Protects copied from concurrent copies
+	copied     int64      // Bytes written so far
+}
+
 // executePlan performs the actions defined in the SyncPlan.
-func executePlan(plan *SyncPlan, sourceRoot, targetRoot string, dryRun bool) error {
+func (s *Syncer) executePlan(plan *SyncPlan) error {
+	if len(s.Explain) > 0 {
+		plan.Explanations = s.explain(plan)
+	}
 	if len(plan.Actions) == 0 {
-		fmt.Println("No actions needed. Source and target are already in sync.")
+		fmt.Fprintln(s.stdout(), i18n.T("plan.none"))
+		s.printExplanations(plan)
 		return nil
 	}
 
 	// --- Display Plan and Ask for Confirmation ---
-	fmt.Println("\n--- Sync Plan ---")
-	fmt.Printf("Adds: %d, Updates: %d, Deletes: %d\n", plan.Adds, plan.Updates, plan.Deletes)
-	fmt.Println("-----------------")
-
-	// Show sample actions (up to 20)
-	limit := 20
-	if len(plan.Actions) < limit {
-		limit = len(plan.Actions)
-	}
-	if limit > 0 {
-		fmt.Println("Sample actions:")
-		for i := 0; i < limit; i++ {
-			action := plan.Actions[i]
-			actionType := ""
-			switch action.Type {
-			case Add:
-				actionType = "[ADD   ]"
-			case Update:
-				actionType = "[UPDATE]"
-			case Delete:
-				actionType = "[DELETE]"
-			}
-			fmt.Printf("  %s %s\n", actionType, action.RelPath)
-		}
-		if len(plan.Actions) > limit {
-			fmt.Printf("  ... and %d more actions\n", len(plan.Actions)-limit)
+	fmt.Fprintln(s.stdout(), "\n"+theme.Paint(theme.Header, i18n.T("plan.header")))
+	fmt.Fprintln(s.stdout(), i18n.T("plan.counts", plan.Adds, plan.Updates, plan.Deletes, plan.Renames))
+	fmt.Fprintln(s.stdout(), "-----------------")
+	usage := s.usageOf(plan)
+	s.printTargetUsage(usage)
+
+	s.printPlanActions(plan, s.PlanView)
+	s.printExplanations(plan)
+	plan.Annotations = s.annotate(plan)
+	s.printAnnotations(plan)
+
+	// Throttle concurrency when source and target share a disk
+	disk := detectSharedDisk(s.SourceRoot, s.TargetRoot)
+	workers := s.copyWorkers(disk)
+	if disk.SameDevice {
+		kind := i18n.T("disk.unknown")
+		if disk.RotationalKnown && disk.Rotational {
+			kind = i18n.T("disk.rotational")
+		} else if disk.RotationalKnown {
+			kind = i18n.T("disk.non_rotational")
 		}
-		fmt.Println("-----------------")
+		fmt.Fprintln(s.stderr(), i18n.N("disk.shared", workers, kind, workers))
 	}
 
-	if dryRun {
-		fmt.Println("Dry run: No changes will be made.")
-		return nil // Stop here for dry run
+	if s.DeleteToTrash && plan.Deletes > 0 {
+		fmt.Fprintln(s.stderr(), i18n.T("plan.to_trash"))
 	}
 
-	// Confirmation prompt
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Proceed with synchronization? [Y/n]: ")
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return fmt.Errorf("failed to read confirmation: %w", err)
+	deletesFirst := usage.NearLimit() && plan.Deletes > 0
+	if deletesFirst {
+		fmt.Fprintln(s.stderr(), i18n.T("quota.deletes_first"))
 	}
-	response = strings.TrimSpace(strings.ToLower(response))
 
-	if response != "" && response != "y" && response != "yes" {
-		fmt.Println("Synchronization aborted by user.")
-		return nil // User cancelled
+	if s.DryRun {
+		fmt.Fprintln(s.stderr(), i18n.T("plan.dry_run"))
+		return nil // Stop here for dry run
 	}
 
-	fmt.Println("Starting synchronization...")
+	if err := s.checkTargetUsage(usage); err != nil {
+		return err
+	}
 
-	// --- Execute Actions Concurrently ---
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, maxConcurrentOps)   // Semaphore to limit concurrency
-	errChan := make(chan error, len(plan.Actions)) // Channel to collect errors
-
-	// Calculate total size for progress bar (approximated for adds/updates)
-	var totalSize int64
-	for _, action := range plan.Actions {
-		if (action.Type == Add || action.Type == Update) && action.SourceInfo != nil && !action.SourceInfo.IsDir {
-			totalSize += action.SourceInfo.Size
-		}
-	}
-
-	bar := progressbar.NewOptions64(totalSize,
-		progressbar.OptionSetDescription("Syncing files..."),
-		progressbar.OptionSetWriter(os.Stderr),
-		progressbar.OptionSetWidth(15),
-		progressbar.OptionShowBytes(true),
-		progressbar.OptionClearOnFinish(),
-		progressbar.OptionThrottle(100*time.Millisecond), // Refresh rate
-	)
-	defer func() {
-		if err := bar.Clear(); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to clear progress bar: %v\n", err)
-		}
-	}()
+	// Fail once, before asking, rather than once per action
+	if err := s.checkTargetWritable(s.TargetRoot); err != nil {
+		return err
+	}
 
-	var copyMu sync.Mutex // Mutex for progress bar updates during copy
+	// Confirmation prompt
+	s.live.setPhase(PhaseConfirming)
+	if s.needsConfirmation(plan) {
+		fmt.Fprint(s.stderr(), i18n.T("prompt.proceed"))
+		response, err := stdinReader.ReadString('\n')
+		if err != nil {
+			return i18n.Errorf("prompt.read_failed", err)
+		}
 
-	for _, action := range plan.Actions {
-		wg.Add(1)
-		sem <- struct{}{} // Acquire semaphore slot
+		if !i18n.Confirmed(response) {
+			fmt.Fprintln(s.stderr(), i18n.T("prompt.aborted"))
+			return nil // User cancelled
+		}
+	}
 
-		go func(act SyncAction) {
-			defer wg.Done()
-			defer func() { <-sem }() // Release semaphore slot
+	fmt.Fprintln(s.stderr(), i18n.T("sync.starting"))
+	s.executed = true
+	s.live.setPhase(PhaseExecuting)
 
-			var execErr error
-			targetPath := filepath.Join(targetRoot, act.RelPath)
+	var err error
+	if s.state != nil {
+		if s.state.Interrupted() {
+			fmt.Fprintln(s.stderr(), i18n.T("journal.resuming"))
+		}
+		if s.journal, err = s.state.BeginJournal(); err != nil {
+			fmt.Fprintln(s.stderr(), i18n.T("journal.start_failed", err))
+		}
+	}
 
-			switch act.Type {
-			case Add:
-				// Ensure parent directory exists in target
-				parentDir := filepath.Dir(targetPath)
-				if err := os.MkdirAll(parentDir, 0755); err != nil { // Use appropriate permissions
-					execErr = fmt.Errorf("failed to create parent directory %s for adding %s: %w", parentDir, act.RelPath, err)
-					break
-				}
-				// Add directory or file
-				if act.SourceInfo.IsDir {
-					if err := os.Mkdir(targetPath, act.SourceInfo.Mode.Perm()); err != nil { // Use source permissions
-						// Ignore error if dir already exists (might happen with concurrent adds)
-						if !os.IsExist(err) {
-							execErr = fmt.Errorf("failed to create directory %s: %w", act.RelPath, err)
-						}
-					}
-				} else {
-					// Add file (copy from source)
-					execErr = copyFile(act.SourceInfo.AbsPath, targetPath, act.SourceInfo.Mode.Perm(), act.SourceInfo.ModTime, bar, &copyMu)
-					if execErr != nil {
-						execErr = fmt.Errorf("failed to copy file for add %s: %w", act.RelPath, execErr)
-					}
-				}
+	stageCopy, err := s.prepareTempDir()
+	if err != nil {
+		return err
+	}
+	seeds, err := s.newSeedIndex(plan)
+	if err != nil {
+		return err
+	}
+	if s.remote != nil {
+		stateDir := ""
+		if s.state != nil {
+			stateDir = s.state.Dir
+		}
+		if err := s.remote.prepare(stateDir); err != nil {
+			return fmt.Errorf("could not create download directory: %w", err)
+		}
+		if stateDir == "" {
+			defer os.RemoveAll(s.remote.downloads)
+		}
+	}
 
-			case Update:
-				// Update file (copy from source, overwriting target)
-				// Parent directory should already exist if target file exists
-				if act.SourceInfo.IsDir {
-					// This case should ideally be handled by delete+add if type changes
-					// If types match (both dirs), no action needed here.
-					fmt.Fprintf(os.Stderr, "\nWarning: Unexpected 'Update' action for directory: %s\n", act.RelPath)
-				} else {
-					execErr = copyFile(act.SourceInfo.AbsPath, targetPath, act.SourceInfo.Mode.Perm(), act.SourceInfo.ModTime, bar, &copyMu)
-					if execErr != nil {
-						execErr = fmt.Errorf("failed to copy file for update %s: %w", act.RelPath, execErr)
-					}
-				}
+	// With StageAndSwap the actions go to a clone of the target, which
+	// replaces it once all of them succeeded
+	targetRoot := s.TargetRoot
+	if s.StageAndSwap {
+		if targetRoot, err = stageTarget(s.TargetRoot); err != nil {
+			return fmt.Errorf("could not stage the target: %w", err)
+		}
+	}
 
-			case Delete:
-				// Delete file or directory recursively
-				// Check if it still exists before attempting deletion
-				if _, statErr := os.Lstat(targetPath); statErr == nil {
-					if act.TargetInfo != nil && act.TargetInfo.IsDir {
-						// Use RemoveAll for directories
-						if err := os.RemoveAll(targetPath); err != nil {
-							execErr = fmt.Errorf("failed to delete directory %s: %w", act.RelPath, err)
-						}
-					} else {
-						// Use Remove for files or symlinks
-						if err := os.Remove(targetPath); err != nil {
-							execErr = fmt.Errorf("failed to delete file %s: %w", act.RelPath, err)
-						}
-					}
-				} else if !os.IsNotExist(statErr) {
-					// Error stating the file other than not existing
-					execErr = fmt.Errorf("failed to stat item for deletion %s: %w", act.RelPath, statErr)
-				}
-				// If os.IsNotExist(statErr), item is already gone, no error.
+	oplog := s.openOpLog()
 
-			} // end switch
+	// --- Execute Actions Concurrently ---
+	s.live.startPlan(s.TargetRoot, plan, plan.CopyBytes())
+
+	exec := &executor{
+		sourceRoot: s.SourceRoot,
+		targetRoot: targetRoot,
+		buffers:    newBufferPool(s.BufferSize),
+		transforms: s.Transforms,
+		quirks:     s.Quirks,
+		journal:    s.journal,
+		live:       s.live,
+		throttle:   s.throttle,
+		toTrash:    s.DeleteToTrash,
+		markers:    s.XattrMarkers,
+		fakeSuper:  s.FakeSuper,
+		fromFake:   s.FromFakeSuper,
+		partialDir: s.PartialDir,
+		appendMode: s.AppendResume && !s.StageAndSwap, // Appending would write through the clone's links
+		tempDir:    s.TempDir,
+		stageCopy:  stageCopy,
+		quota:      newQuotaMeter(usage),
+		seeds:      seeds,
+		remote:     s.remote,
+		oplog:      oplog,
+		checksums:  s.checksums,
+		observer:   s.observer(),
+		stderr:     s.stderr(),
+		ops:        s.ops,
+	}
 
-			if execErr != nil {
-				errChan <- execErr // Send error to the channel
+	// Record pauses in the journal and flush it, so the state on disk is
+	// current for as long as the run is held
+	if s.journal != nil {
+		journal := s.journal
+		s.pause.setHook(func(paused bool) {
+			kind := "resume"
+			if paused {
+				kind = "pause"
+			}
+			err := journal.Record(kind, time.Now().Format(time.RFC3339))
+			if err == nil {
+				err = journal.Sync()
 			}
+			if err != nil {
+				fmt.Fprintf(s.stderr(), "\nWarning: Could not record %s in sync journal: %v\n", kind, err)
+			}
+		})
+	}
+
+	// Run actions as soon as their dependencies are done (see actionGraph).
+	// The graph gets enough goroutines for any worker count; the throttle
+	// decides how many of them work at once, so SetWorkers takes effect
+	// without restarting.
+	s.throttle.start(workers, s.BandwidthLimit)
+	var copiedMu sync.Mutex
+	s.copiedFiles = nil
+	execErrs := buildActionGraph(plan.Actions, s.SerializeDirs, deletesFirst).run(MaxWorkers, func(act SyncAction) error {
+		s.pause.wait() // Paused runs hold here, between files
+		s.throttle.acquire()
+		defer s.throttle.release()
+		defer exec.live.actionDone()
+		exec.observer.OnActionStart(act)
+		err := exec.quota.admit(act)
+		if err == nil {
+			err = exec.applyLogged(act)
+			exec.quota.settle(act, err)
+		}
+		exec.observer.OnActionDone(act, err)
+		if err != nil {
+			exec.observer.OnError(err)
+		} else if s.SpotCheck > 0 && (act.Type == Add || act.Type == Update) && act.SourceInfo.Mode.IsRegular() {
+			copiedMu.Lock()
+			s.copiedFiles = append(s.copiedFiles, act)
+			copiedMu.Unlock()
+		}
+		return err
+	})
+	s.pause.setHook(nil)
+	if oplog != nil {
+		if err := oplog.Close(); err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Could not close operations log: %v\n", err)
+		}
+	}
+	s.bytesCopied = exec.copied
+	s.printSeedResult(seeds)
 
-		}(action) // Pass action by value to the goroutine
+	if s.journal != nil {
+		closeJournal := s.journal.Finish
+		if len(execErrs) > 0 {
+			closeJournal = s.journal.Abort // Leave it marked as unfinished
+		}
+		if err := closeJournal(); err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Could not close sync journal: %v\n", err)
+		}
 	}
 
-	// Wait for all operations to complete
-	wg.Wait()
-	close(errChan) // Close error channel
+	if s.StageAndSwap {
+		if len(execErrs) > 0 {
+			if err := os.RemoveAll(targetRoot); err != nil {
+				fmt.Fprintln(s.stderr(), i18n.T("stage.remove_failed", targetRoot, err))
+			}
+			fmt.Fprintln(s.stderr(), i18n.T("stage.discarded"))
+		} else {
+			// The clone's directories are its own, not links into the
+			// live target, so their mtimes can be set before the swap
+			if s.TimesDirs {
+				s.restoreDirTimes(targetRoot)
+			}
+			if err := s.swapStaged(targetRoot, s.TargetRoot); err != nil {
+				return fmt.Errorf("could not swap the staged target into place (it is kept in %s): %w", targetRoot, err)
+			}
+		}
+	}
 
 	// Check for errors
 	var errors []string
-	for err := range errChan {
+	for _, err := range execErrs {
 		errors = append(errors, err.Error())
 	}
+	s.actionErrors = errors
 
 	if len(errors) > 0 {
 		// Optionally rollback or provide more detailed error report
-		return fmt.Errorf("synchronization finished with %d error(s):\n- %s", len(errors), strings.Join(errors, "\n- "))
+		return i18n.Errorf("sync.finished_errors", len(errors), strings.Join(errors, "\n- "))
+	}
+
+	fmt.Fprintln(s.stderr(), "\n"+theme.PaintErr(theme.Success, i18n.T("sync.finished")))
+	return nil
+}
+
+// needsConfirmation decides whether the plan must be confirmed interactively.
+// Thresholds let routine plans through but stop unusually large ones, even
+// with AssumeYes.
+func (s *Syncer) needsConfirmation(plan *SyncPlan) bool {
+	changes := len(plan.Actions)
+	if s.ConfirmDeletes >= 0 && plan.Deletes > s.ConfirmDeletes {
+		fmt.Fprintln(s.stderr(), i18n.N("confirm.deletes", plan.Deletes, plan.Deletes, s.ConfirmDeletes))
+		return true
+	}
+	if s.ConfirmChanges >= 0 && changes > s.ConfirmChanges {
+		fmt.Fprintln(s.stderr(), i18n.N("confirm.changes", changes, changes, s.ConfirmChanges))
+		return true
 	}
+	if (s.ConfirmDeletes >= 0 || s.ConfirmChanges >= 0) && s.confirmUnusual(plan) {
+		return true
+	}
+	if s.AssumeYes || s.ConfirmDeletes >= 0 || s.ConfirmChanges >= 0 {
+		fmt.Fprintln(s.stderr(), i18n.T("confirm.skipped"))
+		return false
+	}
+	return true
+}
+
+// applyAction performs a single plan action against the target.
+func (e *executor) applyAction(act SyncAction) error {
+	var execErr error
+	targetPath := filepath.Join(e.targetRoot, act.RelPath)
+
+	switch act.Type {
+	case Add:
+		// Ensure parent directory exists in target
+		parentDir := filepath.Dir(targetPath)
+		if err := os.MkdirAll(parentDir, 0755); err != nil { // Use appropriate permissions
+			execErr = fmt.Errorf("failed to create parent directory %s for adding %s: %w", parentDir, act.RelPath, err)
+			break
+		}
+		// Add directory or file
+		if act.SourceInfo.IsDir {
+			mode := e.quirks.fileMode(act.SourceInfo.Mode.Perm(), true) // Source permissions unless avoided
+			if err := e.quirks.retryBusy(func() error { return os.Mkdir(targetPath, mode) }); err != nil {
+				// Ignore error if dir already exists (might happen with concurrent adds)
+				if !os.IsExist(err) {
+					execErr = fmt.Errorf("failed to create directory %s: %w", act.RelPath, err)
+				}
+			}
+		} else {
+			// Add file (copy from source)
+			execErr = e.copySourceFile(act, targetPath)
+			if execErr != nil {
+				execErr = fmt.Errorf("failed to copy file for add %s: %w", act.RelPath, execErr)
+			}
+		}
+
+	case Update:
+		// Update file (copy from source, overwriting target)
+		// Parent directory should already exist if target file exists
+		if act.SourceInfo.IsDir {
+			// This case should ideally be handled by delete+add if type changes
+			// If types match (both dirs), no action needed here.
+			fmt.Fprintf(e.stderr, "\nWarning: Unexpected 'Update' action for directory: %s\n", act.RelPath)
+		} else {
+			execErr = e.copySourceFile(act, targetPath)
+			if execErr != nil {
+				execErr = fmt.Errorf("failed to copy file for update %s: %w", act.RelPath, execErr)
+			}
+		}
+
+	case Delete:
+		// Delete file or directory recursively
+		// Check if it still exists before attempting deletion
+		start := time.Now()
+		_, statErr := os.Lstat(targetPath)
+		e.ops.record(summary.OpStat, start, 0)
+		if statErr == nil {
+			start = time.Now()
+			if e.toTrash {
+				// Directories go to the trash whole, like files
+				if err := e.quirks.retryBusy(func() error { return trash.Move(targetPath) }); err != nil {
+					execErr = fmt.Errorf("failed to move %s to the trash: %w", act.RelPath, err)
+				}
+			} else if act.TargetInfo != nil && act.TargetInfo.IsDir {
+				// Use RemoveAll for directories
+				if err := e.quirks.retryBusy(func() error { return os.RemoveAll(targetPath) }); err != nil {
+					execErr = fmt.Errorf("failed to delete directory %s: %w", act.RelPath, err)
+				}
+			} else {
+				// Use Remove for files or symlinks
+				if err := e.quirks.retryBusy(func() error { return os.Remove(targetPath) }); err != nil {
+					execErr = fmt.Errorf("failed to delete file %s: %w", act.RelPath, err)
+				}
+			}
+			e.ops.record(summary.OpDelete, start, 0)
+		} else if !os.IsNotExist(statErr) {
+			// Error stating the file other than not existing
+			execErr = fmt.Errorf("failed to stat item for deletion %s: %w", act.RelPath, statErr)
+		}
+		// If os.IsNotExist(statErr), item is already gone, no error.
+
+	case Rename:
+		execErr = e.quirks.retryBusy(func() error { return renameCaseOnly(e.targetRoot, act.OldRelPath, act.RelPath) })
 
-	fmt.Println("\nSynchronization finished successfully.")
+	} // end switch
+
+	return execErr
+}
+
+// renameCaseOnly renames oldRel to newRel (which only differ in case) via a
+// temporary name, since a direct rename is a no-op on some case-insensitive
+// filesystems.
+func renameCaseOnly(targetRoot, oldRel, newRel string) error {
+	oldPath := filepath.Join(targetRoot, oldRel)
+	tempPath := oldPath + caseRenameSuffix
+	newPath := filepath.Join(targetRoot, newRel)
+
+	if err := os.Rename(oldPath, tempPath); err != nil {
+		return fmt.Errorf("failed to rename %s to temporary name: %w", oldRel, err)
+	}
+	if err := os.Rename(tempPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename %s to %s: %w", oldRel, newRel, err)
+	}
 	return nil
 }
 
+// copySourceFile copies the action's source file to targetPath, running it
+// through the transform pipeline first if a rule matches. Special files are
+// written as placeholders with FakeSuper, and made from them with
+// FromFakeSuper.
+func (e *executor) copySourceFile(act SyncAction, targetPath string) error {
+	if e.fakeSuper && isSpecial(act.SourceInfo.Mode) {
+		return writePlaceholder(targetPath, act.SourceInfo.ModTime)
+	}
+	if e.fromFake {
+		if restored, err := restoreSpecial(act, targetPath); restored || err != nil {
+			return err
+		}
+	}
+	if !e.transforms.Matches(act.RelPath) { // Transformed content isn't in the seed
+		if seed, ok := e.seeds.find(act); ok {
+			return e.copySeed(seed, act, targetPath)
+		}
+	}
+	src := act.SourceInfo.AbsPath
+	if e.remote != nil {
+		start := time.Now()
+		download, err := e.remote.fetch(src)
+		if err != nil {
+			return err
+		}
+		e.ops.record(summary.OpRead, start, act.SourceInfo.Size)
+		src = download
+	}
+	if e.transforms != nil {
+		var err error
+		if src, err = e.transforms.Apply(act.RelPath, src); err != nil {
+			return err
+		}
+	}
+	var err error
+	appended := false
+	if e.appendMode && act.Type == Update {
+		appended, err = e.appendFile(src, targetPath, act.SourceInfo.ModTime)
+	}
+	if !appended {
+		err = e.copyFile(src, targetPath, act.SourceInfo.Mode.Perm(), act.SourceInfo.ModTime)
+	}
+	if err == nil && e.remote != nil {
+		// Downloads are only kept to be resumed or retried by the next run
+		_ = os.Remove(e.remote.downloadPath(act.SourceInfo.AbsPath))
+	}
+	return err
+}
+
+// prepareTempDir creates TempDir, if set, and reports whether it is on another
+// filesystem than the target, where temp files can't be renamed into place.
+func (s *Syncer) prepareTempDir() (stageCopy bool, err error) {
+	if s.TempDir == "" {
+		return false, nil
+	}
+	if err := os.MkdirAll(s.TempDir, 0700); err != nil {
+		return false, fmt.Errorf("could not create temp directory %s: %w", s.TempDir, err)
+	}
+	tempDev, ok1 := deviceID(s.TempDir)
+	targetDev, ok2 := deviceID(fileinfo.ExistingAncestor(s.TargetRoot))
+	if ok1 && ok2 && tempDev == targetDev {
+		return false, nil
+	}
+	fmt.Fprintln(s.stderr(), i18n.T("tempdir.other_fs", s.TempDir))
+	return true, nil
+}
+
 // copyFile copies a file from src to dst, sets permissions and mod time, and updates progress bar.
-func copyFile(src, dst string, perm os.FileMode, modTime time.Time, bar *progressbar.ProgressBar, barMu *sync.Mutex) error {
+// The data is written to a temp file next to dst, or in TempDir (see
+// TempPrefix), which then replaces dst atomically, so readers never see a
+// partially written file. With a partial directory, the data is written to a
+// file there instead, which a failed copy leaves to be resumed (see
+// openPartial).
+func (e *executor) copyFile(src, dst string, perm os.FileMode, modTime time.Time) error {
 	sourceFile, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("could not open source %s: %w", src, err)
 	}
 	defer func() {
 		if err := sourceFile.Close(); err != nil {
-			fmt.Printf("Error closing %s: %v\n", src, err)
+			fmt.Fprintf(e.stderr, "Error: Could not close %s: %v\n", src, err)
 		}
 	}()
 
-	// Create or truncate destination file
-	destFile, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+	start := time.Now()
+	info, err := sourceFile.Stat()
 	if err != nil {
-		return fmt.Errorf("could not create/open destination %s: %w", dst, err)
+		return fmt.Errorf("could not stat source %s: %w", src, err)
 	}
+	e.ops.record(summary.OpStat, start, 0)
+
+	// Create the temp file the data is written to, or continue the partial
+	// file an interrupted copy left
+	var destFile *os.File
+	var offset int64      // Bytes already in the partial file
+	var resumed hash.Hash // Checksum of those bytes so far
+	if e.partialDir != "" {
+		destFile, offset, resumed, err = e.openPartial(sourceFile, info.Size(), dst, perm)
+		if err != nil {
+			return fmt.Errorf("could not open partial file for %s: %w", dst, err)
+		}
+	} else {
+		err = e.quirks.retryBusy(func() error {
+			var openErr error
+			destFile, openErr = createTemp(e.tempDirFor(dst), e.quirks.fileMode(perm, false))
+			return openErr
+		})
+		if err != nil {
+			return fmt.Errorf("could not create temp file for %s: %w", dst, err)
+		}
+	}
+	tempPath := destFile.Name()
+	if e.journal != nil && e.partialDir == "" {
+		if err := e.journal.Record("temp", tempPath); err != nil {
+			fmt.Fprintf(e.stderr, "\nWarning: Could not record temp file in journal: %v\n", err)
+		}
+	}
+	committed := false
 	defer func() {
-		if err := destFile.Close(); err != nil {
-			fmt.Printf("Error closing %s: %v\n", dst, err)
+		if !committed {
+			_ = destFile.Close()
+			if e.partialDir == "" {
+				_ = os.Remove(tempPath) // Partial files are kept to be resumed
+			}
 		}
 	}()
 
-	// Create a buffer for copying
-	buf := make([]byte, 1024*1024) // 1MB buffer
+	source, dest := timedReader{sourceFile, e.ops}, timedWriter{destFile, e.ops}
+	start = time.Now() // Kernel copies are timed whole
+	remaining := info.Size() - offset
+	e.live.addBytes(offset) // Kept from an interrupted copy
 
-	// Use io.CopyBuffer with progress tracking
-	_, err = io.CopyBuffer(destFile, io.TeeReader(sourceFile, &progressWriter{bar: bar, mu: barMu}), buf)
-	if err != nil {
-		return fmt.Errorf("could not copy data from %s to %s: %w", src, dst, err)
+	var hasher hash.Hash
+	if e.markers {
+		hasher = resumed
+		if hasher == nil {
+			hasher = sha256.New()
+		}
+	}
+
+	if hasher != nil {
+		// Markers need the checksum of the content, so the data has to pass
+		// through user space on its way
+		buf := e.buffers.Get()
+		_, err = io.CopyBuffer(dest, io.TeeReader(source, io.MultiWriter(hasher, &progressWriter{exec: e})), *buf)
+		e.buffers.Put(buf)
+		if err != nil {
+			return fmt.Errorf("could not copy data from %s to %s: %w", src, dst, err)
+		}
+	} else if remaining <= int64(e.buffers.size) {
+		// Small file: per-chunk progress is pointless, so let io.Copy use the
+		// kernel fast path (copy_file_range/sendfile via ReadFrom) and account
+		// for the whole file at once.
+		n, err := io.Copy(destFile, sourceFile)
+		if err != nil {
+			return fmt.Errorf("could not copy data from %s to %s: %w", src, dst, err)
+		}
+		e.ops.record(summary.OpCopy, start, n)
+		e.addProgress(n)
+	} else if ok, err := zeroCopy(destFile, sourceFile, remaining, e.buffers.size, e.addProgress); ok {
+		// Large file copied inside the kernel, one buffer-sized chunk at a time
+		if err != nil {
+			return fmt.Errorf("could not copy data from %s to %s: %w", src, dst, err)
+		}
+		e.ops.record(summary.OpCopy, start, remaining)
+	} else {
+		// Use io.CopyBuffer with a pooled buffer and progress tracking
+		buf := e.buffers.Get()
+		_, err = io.CopyBuffer(dest, io.TeeReader(source, &progressWriter{exec: e}), *buf)
+		e.buffers.Put(buf)
+		if err != nil {
+			return fmt.Errorf("could not copy data from %s to %s: %w", src, dst, err)
+		}
 	}
 
 	// Sync file contents to disk (this is safer by SUPER slow)
@@ -240,31 +615,106 @@ func copyFile(src, dst string, perm os.FileMode, modTime time.Time, bar *progres
 	// 	fmt.Fprintf(os.Stderr, "\nWarning: Failed to sync file %s: %v\n", dst, err)
 	// }
 
+	if err := destFile.Close(); err != nil {
+		return fmt.Errorf("could not write %s: %w", dst, err)
+	}
+	if e.stageCopy {
+		placed, err := e.unstage(tempPath, dst, perm)
+		_ = os.Remove(tempPath) // The staged file is no longer needed
+		if err != nil {
+			return fmt.Errorf("could not copy staged %s into place: %w", dst, err)
+		}
+		tempPath = placed
+	}
+
 	// Set modification time
-	if err := os.Chtimes(dst, modTime, modTime); err != nil {
+	if err := os.Chtimes(tempPath, modTime, modTime); err != nil && !e.quirks.IgnoreChtimesErrors {
 		// Log warning, as setting time might fail on some systems/filesystems
-		fmt.Fprintf(os.Stderr, "\nWarning: Failed to set modification time for %s: %v\n", dst, err)
+		fmt.Fprintf(e.stderr, "\nWarning: Failed to set modification time for %s: %v\n", dst, err)
+	}
+
+	if hasher != nil {
+		if err := writeMarkers(tempPath, hex.EncodeToString(hasher.Sum(nil)), modTime); err != nil {
+			e.markerWarn.Do(func() {
+				fmt.Fprintf(e.stderr, "\nWarning: Could not write xattr markers (first failure: %s): %v\n", dst, err)
+			})
+		}
 	}
 
 	// Note: Setting exact permissions after creation might be needed on some OS
 	// if os.Chmod(dst, perm) != nil { ... }
 
+	// Move the complete file into place
+	if err := e.quirks.retryBusy(func() error { return os.Rename(tempPath, dst) }); err != nil {
+		return fmt.Errorf("could not replace %s: %w", dst, err)
+	}
+	committed = true
+	if e.partialDir != "" {
+		_ = os.Remove(filepath.Dir(tempPath)) // Unless other partial files are left there
+	}
 	return nil
 }
 
+// tempDirFor returns the directory of the temp file written for dst.
+func (e *executor) tempDirFor(dst string) string {
+	if e.tempDir != "" {
+		return e.tempDir
+	}
+	return filepath.Dir(dst)
+}
+
+// unstage copies a complete file staged in a temp directory on another
+// filesystem to a new temp file next to dst, from where it can be renamed
+// into place, and returns the new temp file's path.
+func (e *executor) unstage(stagedPath, dst string, perm os.FileMode) (string, error) {
+	staged, err := os.Open(stagedPath)
+	if err != nil {
+		return "", err
+	}
+	defer staged.Close()
+
+	var placed *os.File
+	err = e.quirks.retryBusy(func() error {
+		var openErr error
+		placed, openErr = createTemp(filepath.Dir(dst), e.quirks.fileMode(perm, false))
+		return openErr
+	})
+	if err != nil {
+		return "", err
+	}
+	if e.journal != nil {
+		if err := e.journal.Record("temp", placed.Name()); err != nil {
+			fmt.Fprintf(e.stderr, "\nWarning: Could not record temp file in journal: %v\n", err)
+		}
+	}
+	if _, err := io.Copy(placed, staged); err != nil {
+		_ = placed.Close()
+		_ = os.Remove(placed.Name())
+		return "", err
+	}
+	if err := placed.Close(); err != nil {
+		_ = os.Remove(placed.Name())
+		return "", err
+	}
+	return placed.Name(), nil
+}
+
+// addProgress counts n bytes written into the target.
+func (e *executor) addProgress(n int64) {
+	e.copiedMu.Lock()
+	e.copied += n
+	e.copiedMu.Unlock()
+	e.live.addBytes(n)
+	e.observer.OnBytesCopied(n)
+	e.throttle.wait(n) // Copies report every chunk here, so this paces them
+}
+
 // progressWriter is a helper to update the progress bar during io.Copy
 type progressWriter struct {
-	bar *progressbar.ProgressBar
-	mu  *sync.Mutex // Mutex to protect concurrent bar updates
+	exec *executor
 }
 
 func (pw *progressWriter) Write(p []byte) (int, error) {
-	n := len(p)
-	pw.mu.Lock()
-	err := pw.bar.Add(n)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "\nexecutor: Error updating progress bar: %v\n", err)
-	}
-	pw.mu.Unlock()
-	return n, nil
+	pw.exec.addProgress(int64(len(p)))
+	return len(p), nil
 }
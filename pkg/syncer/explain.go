@@ -0,0 +1,191 @@
+// pkg/syncer/explain.go
+package syncer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/compare"
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/rules"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+)
+
+// Explanation says why the plan does what it does with one path (see
+// Syncer.Explain).
+type Explanation struct {
+	RelPath string
+	Lines   []ExplanationLine
+}
+
+// ExplanationLine is an action planned for the path and why, or why there is
+// none (Type None).
+type ExplanationLine struct {
+	Type   SyncActionType
+	Reason string
+}
+
+// explain explains the final plan for each of s.Explain, from what the scans
+// found.
+func (s *Syncer) explain(plan *SyncPlan) []Explanation {
+	var explanations []Explanation
+	for _, path := range s.Explain {
+		relPath := s.explainedPath(path)
+		explanation := Explanation{RelPath: relPath}
+		for _, action := range plan.Actions {
+			if action.RelPath == relPath || (action.Type == Rename && action.OldRelPath == relPath) {
+				explanation.Lines = append(explanation.Lines, ExplanationLine{Type: action.Type, Reason: s.explainAction(action)})
+			}
+		}
+		if len(explanation.Lines) == 0 {
+			explanation.Lines = []ExplanationLine{{Type: None, Reason: s.explainNoAction(plan, relPath)}}
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations
+}
+
+// explainedPath turns a path given to explain into one relative to the roots.
+// Absolute paths inside the source or target are accepted too.
+func (s *Syncer) explainedPath(path string) string {
+	path = filepath.Clean(path)
+	if filepath.IsAbs(path) {
+		for _, root := range []string{s.SourceRoot, s.TargetRoot} {
+			if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+				return rel
+			}
+		}
+	}
+	return path
+}
+
+// explainAction says why an action was planned.
+func (s *Syncer) explainAction(action SyncAction) string {
+	source, target := s.sourceFiles[action.RelPath], s.targetFiles[action.RelPath]
+	switch action.Type {
+	case Rename:
+		return i18n.T("explain.case_rename", action.OldRelPath, action.RelPath)
+	case Add:
+		if target != nil {
+			return i18n.T("explain.replaces", kindOf(target), kindOf(source))
+		}
+		return i18n.T("explain.not_in_target")
+	case Delete:
+		if source != nil {
+			return i18n.T("explain.replaced", kindOf(target), kindOf(source))
+		}
+		return i18n.T("explain.not_in_source")
+	case Update:
+		return s.explainUpdate(source, target)
+	}
+	return ""
+}
+
+// explainUpdate says how the target file of an update differs from the source.
+func (s *Syncer) explainUpdate(source, target *fileinfo.FileInfo) string {
+	apart := timeApart(source.ModTime, target.ModTime)
+	switch {
+	case s.Rules.Applies(rules.AlwaysCopy, source.RelPath):
+		return i18n.T("explain.rule_always_copy")
+	case s.Transforms.Matches(source.RelPath):
+		return i18n.T("explain.transformed_mtime", apart)
+	case source.Size != target.Size:
+		return i18n.T("explain.size", summary.FormatBytes(source.Size), summary.FormatBytes(target.Size))
+	case s.alwaysHashed(source.RelPath):
+		return i18n.T("explain.always_hash")
+	default:
+		return i18n.T("explain.checksums", apart)
+	}
+}
+
+// explainNoAction says why the plan leaves relPath alone.
+func (s *Syncer) explainNoAction(plan *SyncPlan, relPath string) string {
+	source, target := s.sourceFiles[relPath], s.targetFiles[relPath]
+	switch {
+	case source == nil && target == nil:
+		if s.ignoreMatcher.OutsideSpec(relPath) {
+			return i18n.T("explain.outside_spec", ignore.SpecFileName)
+		}
+		if s.ignoreMatcher.InIgnoredDir(relPath) || s.ignoreMatcher.MatchesFile(relPath) || s.ignoreMatcher.MatchesDir(relPath) {
+			return i18n.T("explain.excluded")
+		}
+		return i18n.T("explain.not_found")
+	case source == nil:
+		if s.Rules.Applies(rules.SkipDelete, relPath) {
+			return i18n.T("explain.rule_skip_delete")
+		}
+		for _, action := range plan.Actions {
+			if action.Type == Delete && strings.HasPrefix(relPath, action.RelPath+string(filepath.Separator)) {
+				return i18n.T("explain.deleted_with", action.RelPath)
+			}
+		}
+		return i18n.T("explain.left_out")
+	case target == nil:
+		return i18n.T("explain.left_out") // E.g. a database in use, see SkipHotDBs
+	case source.IsDir && target.IsDir:
+		return i18n.T("explain.directories")
+	}
+
+	apart := timeApart(source.ModTime, target.ModTime)
+	switch {
+	case s.Transforms.Matches(relPath):
+		return i18n.T("explain.transformed_same")
+	case s.alwaysHashed(relPath):
+		return i18n.T("explain.same_checksum", apart)
+	case compare.SameModTime(source.ModTime, target.ModTime, 0):
+		return i18n.T("explain.same_size_mtime")
+	case s.Quirks.MtimeTolerance > 0 && compare.SameModTime(source.ModTime, target.ModTime, s.Quirks.MtimeTolerance):
+		return i18n.T("explain.tolerance", apart, s.Quirks.MtimeTolerance)
+	default:
+		return i18n.T("explain.same_checksum", apart)
+	}
+}
+
+// alwaysHashed reports whether relPath matches AlwaysHash.
+func (s *Syncer) alwaysHashed(relPath string) bool {
+	return len(s.AlwaysHash) > 0 && ignore.Compile(s.AlwaysHash).MatchesFile(relPath)
+}
+
+// kindOf names what kind of item fi is.
+func kindOf(fi *fileinfo.FileInfo) string {
+	if fi.IsDir {
+		return i18n.T("explain.directory")
+	}
+	return i18n.T("explain.file")
+}
+
+// timeApart is how far apart two mtimes are, in whole seconds unless they
+// are closer than that.
+func timeApart(a, b time.Time) time.Duration {
+	apart := a.Sub(b)
+	if apart < 0 {
+		apart = -apart
+	}
+	if apart >= time.Second {
+		return apart.Round(time.Second)
+	}
+	return apart
+}
+
+// printExplanations prints the plan's explanations below the plan.
+func (s *Syncer) printExplanations(plan *SyncPlan) {
+	if len(plan.Explanations) == 0 {
+		return
+	}
+	fmt.Fprintln(s.stdout(), i18n.T("explain.header"))
+	labels := i18n.Pad("action.add", "action.update", "action.delete", "action.rename", "action.none")
+	roles := map[SyncActionType]theme.Role{Add: theme.Add, Update: theme.Update, Delete: theme.Delete, Rename: theme.Rename}
+	index := map[SyncActionType]int{Add: 0, Update: 1, Delete: 2, Rename: 3, None: 4}
+	for _, explanation := range plan.Explanations {
+		for _, line := range explanation.Lines {
+			label := "[" + theme.Paint(roles[line.Type], labels[index[line.Type]]) + "]"
+			fmt.Fprintf(s.stdout(), "  %s %s: %s\n", label, explanation.RelPath, line.Reason)
+		}
+	}
+	fmt.Fprintln(s.stdout(), "-----------------")
+}
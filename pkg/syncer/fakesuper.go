@@ -0,0 +1,187 @@
+// pkg/syncer/fakesuper.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// xattrStat holds, with FakeSuper, the metadata of a source item that an
+// unprivileged sync can't give the target item itself, in the format of
+// rsync's user.rsync.%stat: "<octal st_mode> <major>,<minor> <uid>:<gid>".
+const xattrStat = "user.syncdir.stat"
+
+// errFakeSuperUnsupported is returned where file ownership can't be read.
+var errFakeSuperUnsupported = errors.New("--fake-super is not supported on this platform")
+
+// File types of st_mode, the same on every Unix
+const (
+	sIFMT   = 0170000
+	sIFIFO  = 0010000
+	sIFCHR  = 0020000
+	sIFBLK  = 0060000
+	sIFSOCK = 0140000
+)
+
+// fakeStat is the metadata recorded in xattrStat.
+type fakeStat struct {
+	mode         uint32 // st_mode: file type and permissions
+	major, minor uint32 // Device number of device files
+	uid, gid     int
+}
+
+func (f fakeStat) String() string {
+	return fmt.Sprintf("%o %d,%d %d:%d", f.mode, f.major, f.minor, f.uid, f.gid)
+}
+
+// parseFakeStat parses the value of xattrStat.
+func parseFakeStat(value string) (fakeStat, error) {
+	var f fakeStat
+	if _, err := fmt.Sscanf(value, "%o %d,%d %d:%d", &f.mode, &f.major, &f.minor, &f.uid, &f.gid); err != nil {
+		return f, fmt.Errorf("invalid %s %q: %w", xattrStat, value, err)
+	}
+	return f, nil
+}
+
+// special reports whether f describes a device file, FIFO or socket.
+func (f fakeStat) special() bool {
+	switch f.mode & sIFMT {
+	case sIFIFO, sIFCHR, sIFBLK, sIFSOCK:
+		return true
+	}
+	return false
+}
+
+// isSpecial reports whether mode is that of a device file, FIFO or socket,
+// which FakeSuper stores as empty placeholder files.
+func isSpecial(mode fs.FileMode) bool {
+	return mode&(fs.ModeDevice|fs.ModeCharDevice|fs.ModeNamedPipe|fs.ModeSocket) != 0
+}
+
+// writePlaceholder stands in for a special source file at path: an empty
+// file whose real type is recorded by recordFakeSuper.
+func writePlaceholder(path string, modTime time.Time) error {
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		return fmt.Errorf("could not write placeholder %s: %w", path, err)
+	}
+	return os.Chtimes(path, modTime, modTime)
+}
+
+// restoreSpecial recreates the special file a placeholder written with
+// FakeSuper stands for at targetPath. It reports false, leaving the
+// placeholder to be copied like any file, if the source item isn't one.
+func restoreSpecial(act SyncAction, targetPath string) (bool, error) {
+	if !act.SourceInfo.Mode.IsRegular() || act.SourceInfo.Size != 0 {
+		return false, nil
+	}
+	value, err := getXattr(act.SourceInfo.AbsPath, xattrStat)
+	if err != nil {
+		return false, nil
+	}
+	stat, err := parseFakeStat(string(value))
+	if err != nil || !stat.special() {
+		return false, err
+	}
+	if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
+		return true, err
+	}
+	if err := mknod(targetPath, stat); err != nil {
+		return true, fmt.Errorf("could not create special file %s: %w", targetPath, err)
+	}
+	modTime := act.SourceInfo.ModTime
+	return true, os.Chtimes(targetPath, modTime, modTime)
+}
+
+// recordFakeSuper tags every synced target item except symlinks with the
+// owner, group, permissions and file type of its source item, for a
+// privileged sync with FromFakeSuper to restore them. Items already tagged
+// correctly aren't touched.
+func (s *Syncer) recordFakeSuper() {
+	if s.remote != nil {
+		return // A published tree has no owners
+	}
+	recorded, failed := 0, 0
+	var firstErr error
+	for relPath, src := range s.sourceFiles {
+		if src.IsSymlink() {
+			continue // User xattrs can't be set on symlinks
+		}
+		targetPath := filepath.Join(s.TargetRoot, relPath)
+		if _, err := os.Lstat(targetPath); err != nil {
+			continue // Not synced (e.g. declined or failed)
+		}
+		changed, err := recordStat(src.AbsPath, targetPath)
+		if err != nil {
+			if failed++; firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", relPath, err)
+			}
+		} else if changed {
+			recorded++
+		}
+	}
+	if recorded > 0 {
+		fmt.Fprintln(s.stdout(), i18n.N("fakesuper.recorded", recorded, recorded))
+	}
+	if failed > 0 {
+		fmt.Fprintf(s.stderr(), "Warning: Could not record the metadata of %d items (first failure: %v)\n", failed, firstErr)
+	}
+}
+
+// recordStat tags the target item at targetPath with the metadata of the
+// source item at sourcePath, reporting whether the tag changed.
+func recordStat(sourcePath, targetPath string) (bool, error) {
+	stat, err := statOf(sourcePath)
+	if err != nil {
+		return false, err
+	}
+	value := stat.String()
+	if current, err := getXattr(targetPath, xattrStat); err == nil && string(current) == value {
+		return false, nil
+	}
+	return true, setXattr(targetPath, xattrStat, []byte(value))
+}
+
+// applyFakeSuper gives every target item the owner, group and permissions
+// recorded on its source item by a sync with FakeSuper. Items of source
+// items without a record are left as copied.
+func (s *Syncer) applyFakeSuper() {
+	if s.remote != nil {
+		return // A published tree carries no records
+	}
+	applied, failed := 0, 0
+	var firstErr error
+	for relPath, src := range s.sourceFiles {
+		if src.IsSymlink() {
+			continue
+		}
+		value, err := getXattr(src.AbsPath, xattrStat)
+		if err != nil {
+			continue // Not recorded
+		}
+		targetPath := filepath.Join(s.TargetRoot, relPath)
+		stat, err := parseFakeStat(string(value))
+		if err == nil {
+			var changed bool
+			if changed, err = applyStat(targetPath, stat); changed && err == nil {
+				applied++
+			}
+		}
+		if err != nil && !os.IsNotExist(err) {
+			if failed++; firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", relPath, err)
+			}
+		}
+	}
+	if applied > 0 {
+		fmt.Fprintln(s.stdout(), i18n.N("fakesuper.applied", applied, applied))
+	}
+	if failed > 0 {
+		fmt.Fprintf(s.stderr(), "Warning: Could not restore the recorded metadata of %d items (first failure: %v)\n", failed, firstErr)
+	}
+}
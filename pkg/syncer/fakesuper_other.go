@@ -0,0 +1,16 @@
+// pkg/syncer/fakesuper_other.go
+//go:build !linux && !darwin
+
+package syncer
+
+func statOf(path string) (fakeStat, error) {
+	return fakeStat{}, errFakeSuperUnsupported
+}
+
+func mknod(path string, f fakeStat) error {
+	return errFakeSuperUnsupported
+}
+
+func applyStat(path string, f fakeStat) (bool, error) {
+	return false, errFakeSuperUnsupported
+}
@@ -0,0 +1,54 @@
+// pkg/syncer/fakesuper_unix.go
+//go:build linux || darwin
+
+package syncer
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// statOf returns the metadata of the item at path for xattrStat, without
+// following a symlink.
+func statOf(path string) (fakeStat, error) {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return fakeStat{}, err
+	}
+	f := fakeStat{mode: uint32(st.Mode), uid: int(st.Uid), gid: int(st.Gid)}
+	if f.mode&sIFMT == sIFCHR || f.mode&sIFMT == sIFBLK {
+		f.major, f.minor = unix.Major(uint64(st.Rdev)), unix.Minor(uint64(st.Rdev))
+	}
+	return f, nil
+}
+
+// mknod creates the special file described by f at path.
+func mknod(path string, f fakeStat) error {
+	return unix.Mknod(path, f.mode, int(unix.Mkdev(f.major, f.minor)))
+}
+
+// applyStat gives the item at path the owner, group and permissions of f,
+// reporting whether any of them changed.
+func applyStat(path string, f fakeStat) (bool, error) {
+	var st unix.Stat_t
+	if err := unix.Lstat(path, &st); err != nil {
+		return false, err
+	}
+	changed := false
+	if int(st.Uid) != f.uid || int(st.Gid) != f.gid {
+		if err := os.Lchown(path, f.uid, f.gid); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	// Changing the owner clears the setuid and setgid bits, so the
+	// permissions go second
+	if changed || uint32(st.Mode)&07777 != f.mode&07777 {
+		if err := unix.Chmod(path, f.mode&07777); err != nil {
+			return changed, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
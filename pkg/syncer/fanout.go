@@ -0,0 +1,84 @@
+// pkg/syncer/fanout.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// sharedSource is the source side of a run fanned out to several targets:
+// the source is scanned by the first target's run and its files hashed at
+// most once, whichever run needs them first.
+type sharedSource struct {
+	files map[string]*fileinfo.FileInfo // Scanned source (nil until the first run scanned it)
+	mu    sync.Mutex
+	sums  map[string]sharedSum // Checksums of source files by absolute path
+}
+
+type sharedSum struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// sourceFiles returns a copy of the scanned source, or nil if it hasn't been
+// scanned yet.
+func (sh *sharedSource) sourceFiles() map[string]*fileinfo.FileInfo {
+	if sh == nil || sh.files == nil {
+		return nil
+	}
+	return maps.Clone(sh.files)
+}
+
+// get returns the checksum of the file at path if it was computed for the
+// same size and mtime.
+func (sh *sharedSource) get(path string, size int64, modTime time.Time) (string, bool) {
+	if sh == nil {
+		return "", false
+	}
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	known, ok := sh.sums[path]
+	return known.sum, ok && known.size == size && known.modTime.Equal(modTime)
+}
+
+func (sh *sharedSource) put(path string, size int64, modTime time.Time, sum string) {
+	if sh == nil {
+		return
+	}
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.sums[path] = sharedSum{size: size, modTime: modTime, sum: sum}
+}
+
+// runFanOut syncs the source into TargetRoot and then into each of Targets,
+// one after another, sharing the source scan and checksums between them. A
+// failing target doesn't stop the others; the errors are returned together.
+func (s *Syncer) runFanOut() error {
+	shared := &sharedSource{sums: make(map[string]sharedSum)}
+	var errs []error
+	var drift error // With AssertInSync, every target is checked before the differences fail the run
+	for _, target := range append([]string{s.TargetRoot}, s.Targets...) {
+		fmt.Fprintf(s.stdout(), "\n=== %s -> %s ===\n", s.describeSource(), target)
+		child := s.derive(s.SourceRoot, target)
+		child.MergeSources = s.MergeSources
+		child.shared = shared
+		err := child.Run()
+		s.summary.Add(child.Summary())
+		if errors.Is(err, ErrNotInSync) {
+			drift, err = err, nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("target %s: %w", target, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return drift
+}
@@ -0,0 +1,106 @@
+// pkg/syncer/follow.go
+package syncer
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+)
+
+// fileKey identifies a directory independently of the path it was reached by.
+type fileKey struct {
+	dev, ino uint64
+	path     string // Resolved path, where device and inode aren't available
+}
+
+// followSet decides which symlinked directories a scan descends into (see
+// Syncer.Follow). It remembers the directories it entered, by device and
+// inode, so that a link back to one of them can't send the scan in circles.
+type followSet struct {
+	matcher *ignore.Matcher
+	mu      sync.Mutex
+	visited map[fileKey]bool
+}
+
+// newFollowSet returns the set for a scan of root, or nil without patterns.
+func newFollowSet(patterns []string, root string) *followSet {
+	if len(patterns) == 0 {
+		return nil
+	}
+	f := &followSet{matcher: ignore.Compile(patterns), visited: make(map[fileKey]bool)}
+	if info, err := os.Stat(root); err == nil {
+		if key, ok := fileID(root, info); ok {
+			f.visited[key] = true
+		}
+	}
+	return f
+}
+
+// follows reports whether the symlink at relPath is scanned as the directory
+// it points to, and returns that directory's info. Links that don't match,
+// don't point to a directory, point to one of their own parents or to one
+// already entered stay links.
+func (f *followSet) follows(relPath, absPath string, log *slog.Logger) (fs.FileInfo, bool) {
+	if f == nil || !f.matcher.MatchesFile(relPath) {
+		return nil, false
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		log.Warn("Could not follow", "path", absPath, "err", err)
+		return nil, false
+	}
+	if !info.IsDir() {
+		return nil, false
+	}
+	key, ok := fileID(absPath, info)
+	if !ok {
+		log.Warn("Could not identify the directory of", "path", absPath)
+		return nil, false
+	}
+	if encloses(key, filepath.Dir(absPath)) {
+		log.Warn("Not following symlink loop", "path", relPath)
+		return nil, false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.visited[key] {
+		log.Warn("Not following symlink to a directory already scanned", "path", relPath)
+		return nil, false
+	}
+	f.visited[key] = true
+	return info, true
+}
+
+// encloses reports whether the directory identified by key is dir or one of
+// its parents once symlinks are resolved, in which case a link in dir to it
+// leads back to itself.
+func encloses(key fileKey, dir string) bool {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false
+	}
+	for {
+		if info, err := os.Stat(dir); err == nil {
+			if id, ok := fileID(dir, info); ok && id == key {
+				return true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
+// key tells scans following different links apart in the scan cache.
+func (f *followSet) key() string {
+	if f == nil {
+		return ""
+	}
+	return "\x00follow " + f.matcher.Fingerprint()
+}
@@ -0,0 +1,33 @@
+// pkg/syncer/fstype_bsd.go
+//go:build darwin || freebsd
+
+package syncer
+
+import "syscall"
+
+// fsTypes names the filesystems, by type name, whose naming rules are
+// Windows'.
+var fsTypes = map[string]string{
+	"msdos":   "fat",
+	"msdosfs": "fat",
+	"exfat":   "exfat",
+	"ntfs":    "ntfs",
+	"smbfs":   "smb",
+}
+
+// filesystemType returns the name of the filesystem holding path if it is one
+// of fsTypes, "" otherwise.
+func filesystemType(path string) string {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return ""
+	}
+	var name []byte
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return fsTypes[string(name)]
+}
@@ -0,0 +1,28 @@
+// pkg/syncer/fstype_linux.go
+//go:build linux
+
+package syncer
+
+import "syscall"
+
+// fsTypes names the filesystems, by statfs magic number, whose naming rules
+// are Windows'.
+var fsTypes = map[int64]string{
+	0x4d44:     "fat",
+	0x2011bab0: "exfat",
+	0x5346544e: "ntfs",
+	0x7366746e: "ntfs", // ntfs3
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+}
+
+// filesystemType returns the name of the filesystem holding path if it is one
+// of fsTypes, "" otherwise.
+func filesystemType(path string) string {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return ""
+	}
+	return fsTypes[int64(st.Type)&0xffffffff] // Type is signed, and wider than the magic
+}
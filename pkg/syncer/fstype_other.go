@@ -0,0 +1,10 @@
+// pkg/syncer/fstype_other.go
+//go:build !(linux || darwin || freebsd)
+
+package syncer
+
+// filesystemType can't tell filesystems apart on this platform; Windows
+// targets get Windows naming rules regardless (see targetNaming).
+func filesystemType(path string) string {
+	return ""
+}
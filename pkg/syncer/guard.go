@@ -0,0 +1,95 @@
+// pkg/syncer/guard.go
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// checkTargetGuards refuses to run when the target isn't the volume it should
+// be (see RequireMounted and RequireFile). Without them, syncing to the mount
+// point of a backup drive that isn't mounted would copy everything onto the
+// filesystem underneath it.
+func (s *Syncer) checkTargetGuards() error {
+	if !s.RequireMounted && s.RequireFile == "" {
+		return nil
+	}
+	info, err := os.Stat(s.TargetRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return i18n.Errorf("guard.target_missing", s.TargetRoot)
+		}
+		return fmt.Errorf("could not check target %s: %w", s.TargetRoot, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("target %s is not a directory", s.TargetRoot)
+	}
+
+	if s.RequireMounted {
+		mounted, err := isMountPoint(s.TargetRoot)
+		if err != nil {
+			return err
+		}
+		if !mounted {
+			return i18n.Errorf("guard.not_mounted", s.TargetRoot)
+		}
+	}
+	if s.RequireFile != "" {
+		marker := filepath.Join(s.TargetRoot, s.RequireFile)
+		if _, err := os.Lstat(marker); err != nil {
+			if os.IsNotExist(err) {
+				return i18n.Errorf("guard.marker_missing", marker)
+			}
+			return fmt.Errorf("could not check marker file %s: %w", marker, err)
+		}
+	}
+	return nil
+}
+
+// isMountPoint reports whether dir is the top of a mounted filesystem: it is
+// on another device than its parent, or has no parent. Bind mounts of a
+// directory on the same filesystem aren't detected.
+func isMountPoint(dir string) (bool, error) {
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return false, fmt.Errorf("could not resolve target %s: %w", dir, err)
+	}
+	parent := filepath.Dir(dir)
+	if parent == dir {
+		return true, nil // Filesystem root
+	}
+	dev, ok := deviceID(dir)
+	parentDev, parentOK := deviceID(parent)
+	if !ok || !parentOK {
+		return false, fmt.Errorf("cannot tell whether %s is a mount point on this system; use --require-file instead", dir)
+	}
+	return dev != parentDev, nil
+}
+
+// checkSourceSanity refuses a plan that deletes from the target when the
+// source holds fewer files than MinSourceFiles (at least one), unless Force is
+// set: an empty source is far more often an unmounted drive or a mistyped
+// path than a wish to wipe the mirror. The files of a tier's run are counted
+// in the whole source, as a tier may rightly be left with none.
+func (s *Syncer) checkSourceSanity(plan *SyncPlan) error {
+	if s.Force || plan.Deletes == 0 {
+		return nil
+	}
+	files := 0
+	for _, fi := range s.sourceFiles {
+		if !fi.IsDir {
+			files++
+		}
+	}
+	if s.tier != nil {
+		files = s.tier.files
+	}
+	minimum := max(s.MinSourceFiles, 1)
+	if files >= minimum {
+		return nil
+	}
+	return i18n.Errorf("guard.source_empty", files, minimum, plan.Deletes)
+}
@@ -0,0 +1,64 @@
+// pkg/syncer/hashlimit.go
+package syncer
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// HashCPULimit caps the CPU that hashing files may take, so checksum-heavy
+// runs don't occupy every core of a shared machine. It applies to the
+// hashing done while planning, deduplicating, backing up, scanning and
+// scrubbing; copy workers are limited separately.
+type HashCPULimit struct {
+	Hashers int // Files hashed in parallel at most (0 = no cap)
+	Nice    int // Nice level of the hashing threads, 1 to 19 (0 = unchanged; Linux only)
+}
+
+// ParseHashCPULimit parses a limit as used on the command line: the number
+// of hashers, optionally followed by a nice level, e.g. "2" or "2,nice=10".
+func ParseHashCPULimit(value string) (HashCPULimit, error) {
+	hashers, rest, _ := strings.Cut(value, ",")
+	var limit HashCPULimit
+	var err error
+	if limit.Hashers, err = strconv.Atoi(strings.TrimSpace(hashers)); err != nil || limit.Hashers < 1 {
+		return HashCPULimit{}, fmt.Errorf("invalid hash CPU limit %q: expected the number of parallel hashers, e.g. 2 or 2,nice=10", value)
+	}
+	if rest == "" {
+		return limit, nil
+	}
+	level, ok := strings.CutPrefix(strings.TrimSpace(rest), "nice=")
+	if !ok {
+		return HashCPULimit{}, fmt.Errorf("invalid hash CPU limit %q: expected nice=LEVEL after the number of hashers", value)
+	}
+	if limit.Nice, err = strconv.Atoi(level); err != nil || limit.Nice < 1 || limit.Nice > 19 {
+		return HashCPULimit{}, fmt.Errorf("invalid nice level %q in hash CPU limit: expected 1 to 19", level)
+	}
+	return limit, nil
+}
+
+// workers returns how many files to hash in parallel: requested, or one per
+// CPU if that is zero or negative, capped at Hashers.
+func (l HashCPULimit) workers(requested int) int {
+	if requested <= 0 {
+		requested = runtime.NumCPU()
+	}
+	if l.Hashers > 0 && requested > l.Hashers {
+		return l.Hashers
+	}
+	return requested
+}
+
+// enter lowers the CPU priority of the calling goroutine to Nice for the
+// hashing it is about to do. The goroutine is locked to its OS thread for
+// good, so the reniced thread is discarded when the goroutine exits instead
+// of running other work: callers must be goroutines that only hash.
+func (l HashCPULimit) enter() {
+	if l.Nice == 0 {
+		return
+	}
+	runtime.LockOSThread()
+	_ = niceThread(l.Nice) // Best effort: hashing at normal priority still works
+}
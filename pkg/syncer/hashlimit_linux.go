@@ -0,0 +1,12 @@
+// pkg/syncer/hashlimit_linux.go
+//go:build linux
+
+package syncer
+
+import "golang.org/x/sys/unix"
+
+// niceThread sets the nice level of the calling OS thread; on Linux, unlike
+// elsewhere, each thread has its own.
+func niceThread(level int) error {
+	return unix.Setpriority(unix.PRIO_PROCESS, unix.Gettid(), level)
+}
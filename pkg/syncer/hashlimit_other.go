@@ -0,0 +1,12 @@
+// pkg/syncer/hashlimit_other.go
+//go:build !linux
+
+package syncer
+
+import "errors"
+
+// niceThread is only implemented on Linux, where threads have their own nice
+// level; elsewhere it would renice the whole process.
+func niceThread(level int) error {
+	return errors.New("not supported on this platform")
+}
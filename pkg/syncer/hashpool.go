@@ -0,0 +1,76 @@
+// pkg/syncer/hashpool.go
+package syncer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// hashPool hashes files on a fixed number of worker goroutines. Each Syncer
+// owns one: the workers are started by the first request and stopped by
+// Close once planning is done, so nothing outlives the run.
+type hashPool struct {
+	workers int
+	limit   HashCPULimit
+	jobs    chan hashJob
+	start   sync.Once
+	wg      sync.WaitGroup
+	ops     *opTimer // Times the reads of the files hashed (nil = nothing)
+}
+
+type hashJob struct {
+	path   string
+	result chan<- hashResult
+}
+
+type hashResult struct {
+	sum string
+	err error
+}
+
+// newHashPool returns a pool with the given number of workers (one per CPU if
+// workers is zero or negative), within limit. No goroutines are started yet.
+func newHashPool(workers int, limit HashCPULimit) *hashPool {
+	return &hashPool{workers: limit.workers(workers), limit: limit}
+}
+
+// Sum returns the SHA256 of the file at path, computed by one of the workers.
+// It must not be called after Close.
+func (p *hashPool) Sum(path string) (string, error) {
+	p.start.Do(func() {
+		p.jobs = make(chan hashJob)
+		p.wg.Add(p.workers)
+		for i := 0; i < p.workers; i++ {
+			go p.work()
+		}
+	})
+	result := make(chan hashResult, 1)
+	p.jobs <- hashJob{path: path, result: result}
+	r := <-result
+	return r.sum, r.err
+}
+
+func (p *hashPool) work() {
+	defer p.wg.Done()
+	p.limit.enter()
+	for job := range p.jobs {
+		start := time.Now()
+		sum, n, err := fileinfo.SHA256WithSize(job.path)
+		if err == nil {
+			p.ops.record(summary.OpRead, start, n)
+		}
+		job.result <- hashResult{sum: sum, err: err}
+	}
+}
+
+// Close stops the workers, if they were started, and waits for them to exit.
+func (p *hashPool) Close() {
+	p.start.Do(func() {}) // A pool never used stays unstarted
+	if p.jobs != nil {
+		close(p.jobs)
+		p.wg.Wait()
+	}
+}
@@ -0,0 +1,116 @@
+// pkg/syncer/hotdb.go
+package syncer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// hotDatabaseSample is how many hot databases a warning lists.
+const hotDatabaseSample = 10
+
+// sqliteCompanions are the files SQLite keeps next to a database while a
+// connection has it open (-shm, -wal) or a transaction is in flight (-journal).
+var sqliteCompanions = []string{"-wal", "-shm", "-journal"}
+
+// accessLockFiles maps Microsoft Access database extensions to the extension
+// of the lock file present while the database is open.
+var accessLockFiles = map[string]string{".mdb": ".ldb", ".accdb": ".laccdb"}
+
+// isInnoDBFile reports whether name is a MySQL/MariaDB InnoDB data or log
+// file, which is written continuously while the server runs.
+func isInnoDBFile(name string) bool {
+	return strings.HasSuffix(name, ".ibd") || name == "ibdata1" || strings.HasPrefix(name, "ib_logfile")
+}
+
+// hotDatabases returns the source files that look like databases in use, with
+// the reason for each. Copying such a file while it's written yields a
+// corrupt backup.
+func hotDatabases(files map[string]*fileinfo.FileInfo) map[string]string {
+	hot := make(map[string]string)
+	exists := func(relPath string) bool {
+		fi, ok := files[relPath]
+		return ok && !fi.IsDir
+	}
+	for relPath, fi := range files {
+		if fi.IsDir {
+			continue
+		}
+		name := filepath.Base(relPath)
+		for _, suffix := range sqliteCompanions {
+			if db, ok := strings.CutSuffix(relPath, suffix); ok && exists(db) {
+				reason := fmt.Sprintf("SQLite database with %s file", suffix)
+				hot[db] = reason
+				hot[relPath] = reason
+			}
+		}
+		ext := strings.ToLower(filepath.Ext(name))
+		if lockExt, ok := accessLockFiles[ext]; ok && exists(strings.TrimSuffix(relPath, filepath.Ext(name))+lockExt) {
+			hot[relPath] = "Access database with lock file"
+		}
+		if isInnoDBFile(name) {
+			hot[relPath] = "InnoDB data file"
+		}
+	}
+	return hot
+}
+
+// checkHotDatabases warns about plan actions copying databases that look in
+// use and, with SkipHotDatabases, removes those actions from the plan.
+func (s *Syncer) checkHotDatabases(plan *SyncPlan) {
+	if s.AllowHotDBs {
+		return
+	}
+	hot := hotDatabases(s.sourceFiles)
+	if len(hot) == 0 {
+		return
+	}
+	var copies []string
+	for _, act := range plan.Actions {
+		if (act.Type == Add || act.Type == Update) && hot[act.RelPath] != "" {
+			copies = append(copies, act.RelPath)
+		}
+	}
+	if len(copies) == 0 {
+		return
+	}
+	sort.Strings(copies)
+
+	fmt.Fprintln(s.stderr(), "\n"+i18n.N("hotdb.warning", len(copies), len(copies)))
+	for i, relPath := range copies {
+		if i == hotDatabaseSample {
+			fmt.Fprintln(s.stderr(), "  "+i18n.T("sample.more", len(copies)-hotDatabaseSample))
+			break
+		}
+		fmt.Fprintf(s.stderr(), "  %s (%s)\n", relPath, hot[relPath])
+	}
+	if !s.SkipHotDBs {
+		fmt.Fprintln(s.stderr(), i18n.T("hotdb.hint"))
+		return
+	}
+
+	dropCopies(plan, hot)
+	fmt.Fprintln(s.stderr(), i18n.T("hotdb.skipping"))
+}
+
+// dropCopies removes the actions copying the files in skip from the plan.
+func dropCopies(plan *SyncPlan, skip map[string]string) {
+	kept := plan.Actions[:0]
+	for _, act := range plan.Actions {
+		if (act.Type == Add || act.Type == Update) && skip[act.RelPath] != "" {
+			if act.Type == Add {
+				plan.Adds--
+			} else {
+				plan.Updates--
+			}
+			continue
+		}
+		kept = append(kept, act)
+	}
+	plan.Actions = kept
+}
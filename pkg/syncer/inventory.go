@@ -0,0 +1,68 @@
+// pkg/syncer/inventory.go
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// Inventory scans SourceRoot with the ignore rules a sync of it would use and
+// returns its entries ordered by path, without looking at any target. With
+// hash, regular files carry their SHA256 checksum; files that can't be read
+// are left without one and logged. With SELinux and Caps, entries carry
+// their security context and capabilities.
+func (s *Syncer) Inventory(hash bool) ([]*fileinfo.FileInfo, error) {
+	matcher, err := s.newMatcher(s.SourceRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore rules: %w", err)
+	}
+	s.scanStats = &scanStatsLog{}
+	files, err := s.scan(s.SourceRoot, matcher, "source")
+	if err != nil {
+		return nil, err
+	}
+	s.readSecurity(files)
+
+	entries := make([]*fileinfo.FileInfo, 0, len(files))
+	for _, fi := range files {
+		entries = append(entries, fi)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].RelPath < entries[j].RelPath })
+	if hash {
+		s.hashEntries(entries)
+	}
+	return entries, nil
+}
+
+// hashEntries fills in the checksums of the regular files among entries,
+// on HashWorkers goroutines (one per CPU by default) within HashCPU.
+func (s *Syncer) hashEntries(entries []*fileinfo.FileInfo) {
+	workers := s.HashCPU.workers(s.HashWorkers)
+	jobs := make(chan *fileinfo.FileInfo)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			s.HashCPU.enter()
+			for fi := range jobs {
+				sum, err := fileinfo.SHA256(fi.AbsPath)
+				if err != nil {
+					s.log().Warn("Could not hash", "path", fi.AbsPath, "err", err)
+					continue
+				}
+				fi.Checksum = sum
+			}
+		}()
+	}
+	for _, fi := range entries {
+		if fi.Mode.IsRegular() {
+			jobs <- fi
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
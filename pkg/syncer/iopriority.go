@@ -0,0 +1,37 @@
+// pkg/syncer/iopriority.go
+package syncer
+
+import "fmt"
+
+// IOPriority is how the disk I/O of this process competes with that of
+// others, so a big sync can stay out of the way of interactive work.
+type IOPriority int
+
+const (
+	IONormal IOPriority = iota // The system's default
+	IOLow                      // Lowest priority of the normal (best-effort) class
+	IOIdle                     // Only when no other process needs the disk
+)
+
+func (p IOPriority) String() string {
+	switch p {
+	case IONormal:
+		return "normal"
+	case IOLow:
+		return "low"
+	case IOIdle:
+		return "idle"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseIOPriority converts a priority name as used on the command line.
+func ParseIOPriority(name string) (IOPriority, error) {
+	for _, p := range []IOPriority{IONormal, IOLow, IOIdle} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown I/O priority %q (expected idle, low or normal)", name)
+}
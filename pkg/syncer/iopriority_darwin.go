@@ -0,0 +1,30 @@
+// pkg/syncer/iopriority_darwin.go
+//go:build darwin
+
+package syncer
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// setpriority(2) arguments that move a process into the background band.
+const (
+	prioDarwinProcess = 4
+	prioDarwinBG      = 0x1000
+)
+
+// SetIOPriority moves the process into the background band for IOLow and
+// IOIdle: macOS then throttles its disk I/O (and CPU) whenever other work
+// needs them. There are no finer levels, so both behave the same.
+func SetIOPriority(p IOPriority) error {
+	switch p {
+	case IONormal:
+		return unix.Setpriority(prioDarwinProcess, 0, 0)
+	case IOLow, IOIdle:
+		return unix.Setpriority(prioDarwinProcess, 0, prioDarwinBG)
+	default:
+		return fmt.Errorf("unknown I/O priority %d", p)
+	}
+}
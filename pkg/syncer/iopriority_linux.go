@@ -0,0 +1,53 @@
+// pkg/syncer/iopriority_linux.go
+//go:build linux
+
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// I/O scheduling classes and targets of ioprio_set(2).
+const (
+	ioprioClassShift  = 13
+	ioprioClassNone   = 0 // Derived from the CPU nice value (the default)
+	ioprioClassBE     = 2 // Best effort, levels 0 (highest) to 7
+	ioprioClassIdle   = 3
+	ioprioWhoProcess  = 1
+	ioprioLowestLevel = 7
+)
+
+// SetIOPriority gives the whole process the I/O priority p, as ionice(1)
+// does. Linux keeps the priority per thread, so it is set on every thread
+// that exists now; threads started later inherit it.
+func SetIOPriority(p IOPriority) error {
+	var prio int
+	switch p {
+	case IONormal:
+		prio = ioprioClassNone << ioprioClassShift
+	case IOLow:
+		prio = ioprioClassBE<<ioprioClassShift | ioprioLowestLevel
+	case IOIdle:
+		prio = ioprioClassIdle << ioprioClassShift
+	default:
+		return fmt.Errorf("unknown I/O priority %d", p)
+	}
+	tasks, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(prio)); errno != 0 && errno != unix.ESRCH {
+			return errno // ESRCH: the thread exited meanwhile
+		}
+	}
+	return nil
+}
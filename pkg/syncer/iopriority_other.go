@@ -0,0 +1,15 @@
+// pkg/syncer/iopriority_other.go
+//go:build !linux && !darwin
+
+package syncer
+
+import "errors"
+
+// SetIOPriority is only implemented on Linux and macOS; IONormal, the
+// default, needs nothing.
+func SetIOPriority(p IOPriority) error {
+	if p == IONormal {
+		return nil
+	}
+	return errors.New("not supported on this platform")
+}
@@ -0,0 +1,104 @@
+// pkg/syncer/live.go
+package syncer
+
+import (
+	"sync/atomic"
+)
+
+// Phases of a run, as reported by LiveStatus.
+const (
+	PhaseIdle       = "idle"
+	PhaseScanning   = "scanning"
+	PhasePlanning   = "planning"
+	PhaseConfirming = "confirming"
+	PhaseExecuting  = "executing"
+	PhaseDone       = "done"
+)
+
+// LiveStatus is a snapshot of a run in progress.
+type LiveStatus struct {
+	Phase        string `json:"phase"`
+	Target       string `json:"target"` // Target of the current (sub-)run
+	Paused       bool   `json:"paused"`
+	Workers      int    `json:"workers"`   // Parallel operations allowed (0 before execution)
+	Bandwidth    int64  `json:"bandwidth"` // Copy bandwidth limit in bytes per second (0 = unlimited)
+	ActionsTotal int64  `json:"actions_total"`
+	ActionsDone  int64  `json:"actions_done"`
+	BytesTotal   int64  `json:"bytes_total"`
+	BytesDone    int64  `json:"bytes_done"`
+}
+
+// liveStatus is updated as a run progresses and may be read at any time from
+// other goroutines (e.g. the control socket). Syncers derived for mapped
+// subtrees share their parent's.
+type liveStatus struct {
+	phase        atomic.Value // string
+	target       atomic.Value // string
+	actionsTotal atomic.Int64
+	actionsDone  atomic.Int64
+	bytesTotal   atomic.Int64
+	bytesDone    atomic.Int64
+}
+
+func newLiveStatus() *liveStatus {
+	l := &liveStatus{}
+	l.phase.Store(PhaseIdle)
+	l.target.Store("")
+	return l
+}
+
+// setPhase moves to a new phase. A nil *liveStatus ignores updates.
+func (l *liveStatus) setPhase(phase string) {
+	if l != nil {
+		l.phase.Store(phase)
+	}
+}
+
+// startPlan resets the counters for the execution of a plan.
+func (l *liveStatus) startPlan(target string, plan *SyncPlan, totalBytes int64) {
+	l.start(target, int64(len(plan.Actions)), totalBytes)
+}
+
+// start resets the counters for a run of actions on target.
+func (l *liveStatus) start(target string, actions, totalBytes int64) {
+	if l == nil {
+		return
+	}
+	l.target.Store(target)
+	l.actionsTotal.Store(actions)
+	l.actionsDone.Store(0)
+	l.bytesTotal.Store(totalBytes)
+	l.bytesDone.Store(0)
+}
+
+func (l *liveStatus) actionDone() {
+	if l != nil {
+		l.actionsDone.Add(1)
+	}
+}
+
+func (l *liveStatus) addBytes(n int64) {
+	if l != nil {
+		l.bytesDone.Add(n)
+	}
+}
+
+// LiveStatus returns the current progress of the run. It is safe to call
+// while Run is executing in another goroutine.
+func (s *Syncer) LiveStatus() LiveStatus {
+	if s.live == nil {
+		return LiveStatus{Phase: PhaseIdle}
+	}
+	workers, bandwidth := s.throttle.limits()
+	return LiveStatus{
+		Phase:        s.live.phase.Load().(string),
+		Target:       s.live.target.Load().(string),
+		Paused:       s.Paused(),
+		Workers:      workers,
+		Bandwidth:    bandwidth,
+		ActionsTotal: s.live.actionsTotal.Load(),
+		ActionsDone:  s.live.actionsDone.Load(),
+		BytesTotal:   s.live.bytesTotal.Load(),
+		BytesDone:    s.live.bytesDone.Load(),
+	}
+}
@@ -0,0 +1,58 @@
+// pkg/syncer/log.go
+package syncer
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/jeepinbird/sync-dir/pkg/progress"
+)
+
+// discardLogger stands in for a missing Logger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// log returns the logger scans and planning report to. Informational records
+// carry a complete (localized) sentence; problems a short English message
+// with "path" and "err" attributes.
+func (s *Syncer) log() *slog.Logger {
+	if s.Logger == nil {
+		return discardLogger
+	}
+	return s.Logger
+}
+
+// stdout returns where the plan and the results are printed.
+func (s *Syncer) stdout() io.Writer {
+	if s.Stdout == nil {
+		return os.Stdout
+	}
+	return s.Stdout
+}
+
+// stderr returns where notes, warnings and the confirmation prompt are
+// printed.
+func (s *Syncer) stderr() io.Writer {
+	if s.Stderr == nil {
+		return os.Stderr
+	}
+	return s.Stderr
+}
+
+// newBar starts a progress bar for total items. Bars are drawn on the
+// process's stderr, so there is none (nil, which reports nothing) when Stderr
+// sends the output elsewhere.
+func (s *Syncer) newBar(description string, total int64) *progress.Progress {
+	if s.Stderr != nil && s.Stderr != os.Stderr {
+		return nil
+	}
+	return progress.New(description, total)
+}
+
+// newBytesBar is newBar for total bytes.
+func (s *Syncer) newBytesBar(description string, total int64) *progress.Progress {
+	if s.Stderr != nil && s.Stderr != os.Stderr {
+		return nil
+	}
+	return progress.NewBytes(description, total)
+}
@@ -0,0 +1,106 @@
+// pkg/syncer/mapping.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SubtreeMapping redirects a subtree of the source to its own target location.
+type SubtreeMapping struct {
+	Subtree string // Directory relative to the source root, e.g. "docs"
+	Target  string // Absolute target directory the subtree is synced into
+}
+
+// ParseSubtreeMapping parses a "SUBTREE=TARGET" rule such as "docs/=/archive/docs".
+func ParseSubtreeMapping(rule string) (SubtreeMapping, error) {
+	subtree, target, ok := strings.Cut(rule, "=")
+	if !ok || strings.TrimSpace(subtree) == "" || strings.TrimSpace(target) == "" {
+		return SubtreeMapping{}, fmt.Errorf("invalid mapping %q (expected SUBTREE=TARGET)", rule)
+	}
+	subtree = filepath.Clean(filepath.FromSlash(subtree))
+	if filepath.IsAbs(subtree) || subtree == "." || subtree == ".." || strings.HasPrefix(subtree, ".."+string(os.PathSeparator)) {
+		return SubtreeMapping{}, fmt.Errorf("invalid mapping %q: the subtree must be a directory inside the source", rule)
+	}
+	targetPath, err := filepath.Abs(target)
+	if err != nil {
+		return SubtreeMapping{}, fmt.Errorf("invalid mapping target '%s': %w", target, err)
+	}
+	return SubtreeMapping{Subtree: subtree, Target: targetPath}, nil
+}
+
+// runMapped syncs the source minus the mapped subtrees into the main target,
+// then each mapped subtree into its own target, one after another.
+func (s *Syncer) runMapped() error {
+	main := s.derive(s.SourceRoot, s.TargetRoot)
+	for _, mapping := range s.Mappings {
+		// Anchored pattern: only the subtree at this exact location
+		main.CliExcludes = append(main.CliExcludes, "/"+filepath.ToSlash(mapping.Subtree))
+	}
+
+	// With AssertInSync, every pair is checked before the differences fail the run
+	var drift error
+	fmt.Fprintf(s.stdout(), "\n=== %s -> %s ===\n", s.SourceRoot, s.TargetRoot)
+	err := main.Run()
+	s.summary.Add(main.Summary())
+	if errors.Is(err, ErrNotInSync) {
+		drift, err = err, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, mapping := range s.Mappings {
+		sourceRoot := filepath.Join(s.SourceRoot, mapping.Subtree)
+		if info, err := os.Stat(sourceRoot); err != nil || !info.IsDir() {
+			return fmt.Errorf("mapped subtree %s is not a directory in the source", mapping.Subtree)
+		}
+		fmt.Fprintf(s.stdout(), "\n=== %s -> %s ===\n", sourceRoot, mapping.Target)
+		child := s.derive(sourceRoot, mapping.Target)
+		child.RequireMounted, child.RequireFile = false, "" // Guards apply to the main target
+		child.Explain = explainedInSubtree(s.Explain, mapping.Subtree)
+		err := child.Run()
+		s.summary.Add(child.Summary())
+		if errors.Is(err, ErrNotInSync) {
+			drift, err = err, nil
+		}
+		if err != nil {
+			return fmt.Errorf("mapping %s: %w", mapping.Subtree, err)
+		}
+	}
+	return drift
+}
+
+// derive returns a Syncer with the same options for a different pair of roots.
+// It must be called before s has run, so no run state is carried over.
+func (s *Syncer) derive(sourceRoot, targetRoot string) *Syncer {
+	child := *s
+	child.SourceRoot = sourceRoot
+	child.TargetRoot = targetRoot
+	child.CliExcludes = append([]string(nil), s.CliExcludes...)
+	child.MergeSources = nil
+	child.Mappings = nil
+	child.Tiers = nil
+	child.Targets = nil
+	child.nested = true
+	return &child
+}
+
+// explainedInSubtree returns the relative paths of explain that lie in
+// subtree, made relative to it. Absolute paths are kept for the child to
+// resolve against its own roots.
+func explainedInSubtree(explain []string, subtree string) []string {
+	var paths []string
+	for _, path := range explain {
+		path = filepath.Clean(path)
+		if filepath.IsAbs(path) {
+			paths = append(paths, path)
+		} else if rel, err := filepath.Rel(subtree, path); err == nil && !strings.HasPrefix(rel, "..") {
+			paths = append(paths, rel)
+		}
+	}
+	return paths
+}
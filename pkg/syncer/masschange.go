@@ -0,0 +1,119 @@
+// pkg/syncer/masschange.go
+package syncer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+const (
+	massChangeShare = 0.3 // Share of the target's files replaced under a new extension that is suspicious
+	massChangeMin   = 20  // Targets with fewer files are never suspicious
+)
+
+// MassChangeGuard decides what happens when the plan looks like the source
+// was encrypted by ransomware (see checkMassChange).
+type MassChangeGuard int
+
+const (
+	MassChangeAbort MassChangeGuard = iota // Refuse to sync
+	MassChangeWarn                         // Warn and sync anyway
+	MassChangeOff                          // Don't check
+)
+
+func (g MassChangeGuard) String() string {
+	switch g {
+	case MassChangeAbort:
+		return "abort"
+	case MassChangeWarn:
+		return "warn"
+	case MassChangeOff:
+		return "off"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseMassChangeGuard converts a guard name as used on the command line.
+func ParseMassChangeGuard(name string) (MassChangeGuard, error) {
+	for _, g := range []MassChangeGuard{MassChangeAbort, MassChangeWarn, MassChangeOff} {
+		if g.String() == name {
+			return g, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mass change guard %q (expected abort, warn or off)", name)
+}
+
+// checkMassChange guards the target against a source encrypted by ransomware,
+// which replaces each file by one with new content under a new extension
+// (report.docx becomes report.docx.locked or report.crypt). A sync would
+// delete the good copies from the target and add the garbage. When the plan
+// does that to more than massChangeShare of the target's files, it is
+// refused, or with MassChangeWarn only warned about.
+func (s *Syncer) checkMassChange(plan *SyncPlan) error {
+	if s.MassChange == MassChangeOff || plan.Deletes == 0 || plan.Adds == 0 {
+		return nil
+	}
+	targetFiles := 0
+	for _, fi := range s.targetFiles {
+		if !fi.IsDir {
+			targetFiles++
+		}
+	}
+	if targetFiles < massChangeMin {
+		return nil
+	}
+
+	// Deleted files by path and by path without extension
+	deleted := make(map[string]bool)
+	stems := make(map[string]string)
+	for _, act := range plan.Actions {
+		if act.Type == Delete && act.TargetInfo != nil && !act.TargetInfo.IsDir {
+			deleted[act.RelPath] = true
+			stems[strings.TrimSuffix(act.RelPath, filepath.Ext(act.RelPath))] = act.RelPath
+		}
+	}
+	replaced := make(map[string]bool)
+	extensions := make(map[string]int)
+	for _, act := range plan.Actions {
+		if act.Type != Add || act.SourceInfo.IsDir {
+			continue
+		}
+		ext := filepath.Ext(act.RelPath)
+		stem := strings.TrimSuffix(act.RelPath, ext)
+		old := ""
+		switch {
+		case deleted[stem]: // Extension appended
+			old = stem
+		case stems[stem] != "" && stems[stem] != act.RelPath: // Extension replaced
+			old = stems[stem]
+		default:
+			continue
+		}
+		if !replaced[old] {
+			replaced[old] = true
+			extensions[strings.ToLower(ext)]++
+		}
+	}
+	if float64(len(replaced)) < massChangeShare*float64(targetFiles) {
+		return nil
+	}
+
+	common, most := "", 0
+	for ext, n := range extensions {
+		if n > most || n == most && ext < common {
+			common, most = ext, n
+		}
+	}
+	if common == "" {
+		common = i18n.T("guard.no_extension")
+	}
+	if s.MassChange == MassChangeWarn {
+		fmt.Fprintln(s.stderr(), "Warning: "+i18n.T("guard.mass_change_warn", len(replaced), targetFiles, common))
+		return nil
+	}
+	return i18n.Errorf("guard.mass_change", len(replaced), targetFiles, common)
+}
@@ -0,0 +1,60 @@
+// pkg/syncer/memlimit.go
+package syncer
+
+import (
+	"fmt"
+	"runtime/debug"
+	"runtime/metrics"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+const (
+	memCheckInterval = time.Second // How often the heap is compared with MemLimit
+	memSpillShare    = 0.75        // Share of MemLimit the heap may reach before the checksum cache is spilled
+)
+
+// heapMetric is the size of the live and not yet collected objects.
+const heapMetric = "/memory/classes/heap/objects:bytes"
+
+// watchMemory spills the checksum cache to disk once the heap grows past
+// memSpillShare of MemLimit, leaving the remaining memory to the scanned
+// trees and the plan. The cache then stays on disk in later runs too. It
+// returns a function ending the watch.
+func (s *Syncer) watchMemory() func() {
+	if s.MemLimit <= 0 || s.checksums == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(memCheckInterval)
+		defer ticker.Stop()
+		sample := []metrics.Sample{{Name: heapMetric}}
+		for {
+			metrics.Read(sample)
+			if heap := sample[0].Value.Uint64(); float64(heap) >= memSpillShare*float64(s.MemLimit) {
+				if err := s.checksums.Spill(); err != nil {
+					fmt.Fprintln(s.stderr(), i18n.T("memlimit.spill_failed", err))
+					return
+				}
+				debug.FreeOSMemory()
+				fmt.Fprintln(s.stderr(), i18n.T("memlimit.spilled"))
+				return
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,142 @@
+// pkg/syncer/merge.go
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// CollisionPolicy decides what happens when merged sources provide the same path.
+type CollisionPolicy int
+
+const (
+	CollisionError    CollisionPolicy = iota // Abort the sync and list the collisions
+	CollisionNewest                          // The most recently modified item wins
+	CollisionPriority                        // The source listed first wins
+)
+
+func (p CollisionPolicy) String() string {
+	switch p {
+	case CollisionError:
+		return "error"
+	case CollisionNewest:
+		return "newest-wins"
+	case CollisionPriority:
+		return "priority"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCollisionPolicy converts a policy name as used on the command line.
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	for _, p := range []CollisionPolicy{CollisionError, CollisionNewest, CollisionPriority} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown collision policy %q (expected error, newest-wins or priority)", name)
+}
+
+// MergeSource is one of several source roots merged into a single target.
+type MergeSource struct {
+	Root   string // Absolute source directory
+	Subdir string // Target subdirectory the source is mapped into ("" = target root)
+}
+
+// scanMergedSources scans every merge source (each with its own .sync-ignore)
+// and combines them into one file map, resolving collisions per policy.
+func (s *Syncer) scanMergedSources() (map[string]*fileinfo.FileInfo, error) {
+	merged := make(map[string]*fileinfo.FileInfo)
+	origin := make(map[string]int) // Which source each merged path came from
+	var collisions []string
+
+	for i, src := range s.MergeSources {
+		matcher, err := s.newMatcher(src.Root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ignore rules for %s: %w", src.Root, err)
+		}
+		files, err := s.scan(src.Root, matcher, fmt.Sprintf("source %d", i+1))
+		if err != nil {
+			return nil, fmt.Errorf("error scanning source %s: %w", src.Root, err)
+		}
+		if src.Subdir != "" {
+			files, err = mapIntoSubdir(files, src)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for relPath, fi := range files {
+			existing, ok := merged[relPath]
+			if !ok {
+				merged[relPath] = fi
+				origin[relPath] = i
+				continue
+			}
+			if existing.IsDir && fi.IsDir {
+				continue // Directories simply merge
+			}
+			switch s.Collision {
+			case CollisionError:
+				collisions = append(collisions, fmt.Sprintf("%s (in %s and %s)", relPath, s.MergeSources[origin[relPath]].Root, src.Root))
+			case CollisionNewest:
+				if fi.ModTime.After(existing.ModTime) {
+					merged[relPath] = fi
+					origin[relPath] = i
+				}
+			case CollisionPriority:
+				// Sources are scanned in priority order, so the first one stays
+			}
+		}
+	}
+
+	if len(collisions) > 0 {
+		sort.Strings(collisions)
+		return nil, fmt.Errorf("%d path(s) exist in more than one source (use --collision to choose a winner):\n- %s", len(collisions), strings.Join(collisions, "\n- "))
+	}
+
+	// A file from one source may have replaced a directory from another; drop
+	// whatever was below the losing directory.
+	for relPath := range merged {
+		for _, parent := range ancestors(relPath, false) {
+			if fi, ok := merged[parent]; ok && !fi.IsDir {
+				delete(merged, relPath)
+				break
+			}
+		}
+	}
+	return merged, nil
+}
+
+// mapIntoSubdir re-keys a source's files under src.Subdir and adds entries for
+// the subdirectory itself and its parents, so the planner doesn't see them as
+// extraneous target directories.
+func mapIntoSubdir(files map[string]*fileinfo.FileInfo, src MergeSource) (map[string]*fileinfo.FileInfo, error) {
+	subdir := filepath.Clean(src.Subdir)
+	if filepath.IsAbs(subdir) || subdir == ".." || strings.HasPrefix(subdir, ".."+string(os.PathSeparator)) {
+		return nil, fmt.Errorf("subdirectory %q for source %s must be relative and inside the target", src.Subdir, src.Root)
+	}
+
+	rootInfo, err := os.Stat(src.Root)
+	if err != nil {
+		return nil, fmt.Errorf("could not stat source %s: %w", src.Root, err)
+	}
+
+	mapped := make(map[string]*fileinfo.FileInfo, len(files)+1)
+	for relPath, fi := range files {
+		newPath := filepath.Join(subdir, relPath)
+		mapped[newPath] = withRelPath(fi, newPath)
+	}
+	if subdir != "." {
+		for _, dir := range ancestors(subdir, true) {
+			mapped[dir] = fileinfo.New(dir, src.Root, rootInfo)
+		}
+	}
+	return mapped, nil
+}
@@ -0,0 +1,120 @@
+// pkg/syncer/namemap.go
+package syncer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// NamesFileName is the file at the top of a target recording the source paths
+// that SanitizeNames synced under other names. Later runs keep giving them
+// the same names, and a sync from the target back to a filesystem that holds
+// any name restores the originals. It is never synced itself.
+const NamesFileName = ".sync-names.json"
+
+// nameMapVersion is the version of the names file format.
+const nameMapVersion = 1
+
+// nameMap is the content of a names file.
+type nameMap struct {
+	Version int               `json:"version"`
+	Names   map[string]string `json:"names"` // Slash-separated target path -> source path
+}
+
+// loadNameMap reads the names file at the top of root and returns its
+// mapping of target paths to source paths. Without one, it is empty.
+func loadNameMap(root string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, NamesFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var m nameMap
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", NamesFileName, err)
+	}
+	if m.Version != nameMapVersion {
+		return nil, fmt.Errorf("%s has unsupported version %d", NamesFileName, m.Version)
+	}
+	names := make(map[string]string, len(m.Names))
+	for targetPath, sourcePath := range m.Names {
+		names[filepath.FromSlash(targetPath)] = filepath.FromSlash(sourcePath)
+	}
+	return names, nil
+}
+
+// saveNameMap records the source paths synced under other names (source path
+// -> target path) in the names file at the top of root, or removes the file
+// when there are none.
+func saveNameMap(root string, renamed map[string]string) error {
+	path := filepath.Join(root, NamesFileName)
+	if len(renamed) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	m := nameMap{Version: nameMapVersion, Names: make(map[string]string, len(renamed))}
+	for sourcePath, targetPath := range renamed {
+		m.Names[filepath.ToSlash(targetPath)] = filepath.ToSlash(sourcePath)
+	}
+	tmp, err := createTemp(root, 0644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	enc.SetEscapeHTML(false) // Keep names like a<b readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// restoreSourceNames re-keys the source files under the names recorded in the
+// source's names file, when the source is a target that --sanitize-names
+// synced into, so their original names come back.
+func (s *Syncer) restoreSourceNames() {
+	if s.remote != nil || len(s.MergeSources) > 0 {
+		return
+	}
+	names, err := loadNameMap(s.SourceRoot)
+	if err != nil {
+		fmt.Fprintf(s.stderr(), "Warning: Could not restore original names: %v\n", err)
+		return
+	}
+	if len(names) == 0 {
+		return
+	}
+	files := make(map[string]*fileinfo.FileInfo, len(s.sourceFiles))
+	restored := 0
+	for relPath, fi := range s.sourceFiles {
+		if original, ok := names[relPath]; ok {
+			if _, taken := s.sourceFiles[original]; !taken { // Unless the source has both
+				files[original] = withRelPath(fi, original)
+				restored++
+				continue
+			}
+		}
+		files[relPath] = fi
+	}
+	s.sourceFiles = files
+	fmt.Fprintln(s.stderr(), "Note: "+i18n.N("names.restored", restored, restored, NamesFileName))
+}
@@ -0,0 +1,280 @@
+// pkg/syncer/names.go
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// maxNameUnits is the longest name FAT, exFAT, NTFS and SMB shares accept, in
+// UTF-16 code units.
+const maxNameUnits = 255
+
+// windowsMaxPath is the longest full path Windows accepts without the long
+// path prefix (MAX_PATH less the terminating NUL).
+const windowsMaxPath = 259
+
+// invalidNameChars can't appear in names on Windows filesystems, and neither
+// can control characters.
+const invalidNameChars = `<>:"\|?*`
+
+// maxNameProblems is how many paths the target can't hold are listed.
+const maxNameProblems = 20
+
+// reservedNames are device names Windows won't create files under, with or
+// without an extension.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// nameProblem is a source path the target's filesystem can't hold as is.
+type nameProblem struct {
+	relPath   string
+	suggested string // Path with valid names ("" if renaming doesn't help)
+	reason    string
+}
+
+// targetNaming names the target's filesystem if it restricts names the way
+// Windows does (FAT, exFAT, NTFS, SMB shares), or returns "" if it holds any
+// name a source can.
+func (s *Syncer) targetNaming() string {
+	switch {
+	case runtime.GOOS == "windows":
+		return "windows"
+	case s.Quirks.Name == "smb":
+		return "smb"
+	}
+	return filesystemType(fileinfo.ExistingAncestor(s.TargetRoot))
+}
+
+// checkTargetNames finds the source paths the target's filesystem can't hold.
+// With SanitizeNames, the source items are re-keyed under valid names, so
+// the planner compares them with, and creates, the renamed copies; the rest
+// is reported with the names --sanitize-names would give them.
+func (s *Syncer) checkTargetNames() {
+	fs := s.targetNaming()
+	if fs == "" {
+		return
+	}
+	maxPath := 0
+	if runtime.GOOS == "windows" {
+		maxPath = windowsMaxPath - utf16Len(s.TargetRoot) - 1
+	}
+	paths := make([]string, 0, len(s.sourceFiles))
+	for relPath := range s.sourceFiles {
+		paths = append(paths, relPath)
+	}
+	previous := make(map[string]string) // Names given on earlier runs, by source path
+	if s.SanitizeNames {
+		names, err := loadNameMap(s.TargetRoot)
+		if err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Could not read the names given on earlier runs: %v\n", err)
+		}
+		for targetPath, sourcePath := range names {
+			previous[sourcePath] = targetPath
+		}
+	}
+	renamed, problems := planTargetNames(paths, previous, maxPath)
+
+	if s.SanitizeNames && len(renamed) > 0 {
+		files := make(map[string]*fileinfo.FileInfo, len(s.sourceFiles))
+		for relPath, fi := range s.sourceFiles {
+			if newPath, ok := renamed[relPath]; ok {
+				files[newPath] = withRelPath(fi, newPath)
+				continue
+			}
+			files[relPath] = fi
+		}
+		s.sourceFiles = files
+		s.sanitized = renamed
+		var fixed []nameProblem
+		for _, problem := range problems {
+			if problem.suggested != "" {
+				s.log().Info("Renaming for the target", "path", problem.relPath, "as", problem.suggested)
+				continue
+			}
+			fixed = append(fixed, problem)
+		}
+		n := len(problems) - len(fixed)
+		fmt.Fprintln(s.stderr(), "Note: "+i18n.N("names.sanitized", n, n, fs))
+		problems = fixed
+	}
+	s.printNameProblems(problems, fs, s.SanitizeNames)
+}
+
+// printNameProblems lists the first paths the target can't hold.
+func (s *Syncer) printNameProblems(problems []nameProblem, fs string, sanitizing bool) {
+	if len(problems) == 0 {
+		return
+	}
+	fmt.Fprintln(s.stderr(), "Warning: "+i18n.N("names.problems", len(problems), len(problems), fs))
+	suggestions := false
+	for i, problem := range problems {
+		if i == maxNameProblems {
+			more := len(problems) - i
+			fmt.Fprintln(s.stderr(), "  "+i18n.N("names.more", more, more))
+			break
+		}
+		if problem.suggested == "" {
+			fmt.Fprintf(s.stderr(), "  %s (%s)\n", problem.relPath, problem.reason)
+			continue
+		}
+		suggestions = true
+		fmt.Fprintf(s.stderr(), "  %s -> %s (%s)\n", problem.relPath, problem.suggested, problem.reason)
+	}
+	if suggestions && !sanitizing {
+		fmt.Fprintln(s.stderr(), i18n.T("names.hint"))
+	}
+}
+
+// planTargetNames gives each path with a name the target can't hold a valid
+// one, keeping the renames of directories for what they contain. The name a
+// path got before (previous, by source path) is kept while it is free, so
+// names don't shift as other paths come and go; otherwise valid names that
+// are already taken, ignoring case, get a ~2, ~3... suffix. It returns the
+// new path of each path that changes and a problem for each invalid name and
+// each path longer than maxPath (0 = no limit).
+func planTargetNames(paths []string, previous map[string]string, maxPath int) (map[string]string, []nameProblem) {
+	sort.Slice(paths, func(i, j int) bool {
+		depthI := strings.Count(paths[i], string(os.PathSeparator))
+		depthJ := strings.Count(paths[j], string(os.PathSeparator))
+		if depthI != depthJ {
+			return depthI < depthJ // Parents first
+		}
+		return paths[i] < paths[j]
+	})
+	taken := make(map[string]bool, len(paths))
+	for _, relPath := range paths {
+		taken[strings.ToLower(relPath)] = true
+	}
+
+	renamed := make(map[string]string)
+	var problems []nameProblem
+	for _, relPath := range paths {
+		parent, base := filepath.Dir(relPath), filepath.Base(relPath)
+		if newParent, ok := renamed[parent]; ok {
+			parent = newParent
+		}
+		reason := nameIssue(base)
+		if prev, ok := previous[relPath]; reason != "" && ok && filepath.Dir(prev) == parent &&
+			nameIssue(filepath.Base(prev)) == "" && !taken[strings.ToLower(prev)] {
+			base = filepath.Base(prev)
+		} else if reason != "" {
+			base = sanitizeName(base)
+			valid := base
+			for n := 2; taken[strings.ToLower(filepath.Join(parent, base))]; n++ {
+				base = withNameSuffix(valid, n)
+			}
+		}
+		newPath := filepath.Join(parent, base)
+		if newPath != relPath {
+			renamed[relPath] = newPath
+			taken[strings.ToLower(newPath)] = true
+		}
+		switch {
+		case reason != "":
+			problems = append(problems, nameProblem{relPath: relPath, suggested: newPath, reason: reason})
+		case maxPath > 0 && utf16Len(newPath) > maxPath:
+			problems = append(problems, nameProblem{relPath: relPath, reason: i18n.T("names.path_too_long", maxPath)})
+		}
+	}
+	return renamed, problems
+}
+
+// nameIssue says why the target can't hold a file or directory called name,
+// or returns "" if it can.
+func nameIssue(name string) string {
+	for _, r := range name {
+		if r < 0x20 || strings.ContainsRune(invalidNameChars, r) {
+			return i18n.T("names.invalid_char", string(r))
+		}
+	}
+	switch {
+	case reservedNames[strings.ToUpper(nameStem(name))]:
+		return i18n.T("names.reserved")
+	case strings.HasSuffix(name, ".") || strings.HasSuffix(name, " "):
+		return i18n.T("names.trailing")
+	case utf16Len(name) > maxNameUnits:
+		return i18n.T("names.name_too_long", maxNameUnits)
+	}
+	return ""
+}
+
+// sanitizeName turns name into one the target can hold: invalid characters
+// become underscores, as do trailing dots and spaces, reserved names get an
+// underscore after their stem, and long names are shortened, keeping their
+// extension.
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(invalidNameChars, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if trimmed := strings.TrimRight(name, ". "); trimmed != name {
+		name = trimmed + strings.Repeat("_", len(name)-len(trimmed))
+	}
+	if stem := nameStem(name); reservedNames[strings.ToUpper(stem)] {
+		name = stem + "_" + name[len(stem):]
+	}
+	return shortenName(name, maxNameUnits)
+}
+
+// withNameSuffix adds ~n to a name, before its extension.
+func withNameSuffix(name string, n int) string {
+	suffix := "~" + strconv.Itoa(n)
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	return shortenName(stem, maxNameUnits-utf16Len(suffix+ext)) + suffix + ext
+}
+
+// shortenName cuts name to at most max UTF-16 code units, keeping its
+// extension if it is short enough to.
+func shortenName(name string, max int) string {
+	if utf16Len(name) <= max {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if utf16Len(ext) >= max/2 {
+		ext = ""
+	}
+	stem, units := strings.TrimSuffix(name, ext), 0
+	limit := max - utf16Len(ext)
+	for i, r := range stem {
+		units += utf16.RuneLen(r)
+		if units > limit {
+			return stem[:i] + ext
+		}
+	}
+	return stem + ext
+}
+
+// nameStem is a name up to its first dot, which is what Windows compares
+// with reserved names.
+func nameStem(name string) string {
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// utf16Len is the length of s in UTF-16 code units, which is how Windows
+// filesystems measure names.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
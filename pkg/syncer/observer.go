@@ -0,0 +1,54 @@
+// pkg/syncer/observer.go
+package syncer
+
+import "github.com/jeepinbird/sync-dir/pkg/summary"
+
+// Observer is told how a run progresses, e.g. to draw progress bars or feed a
+// GUI. Methods may be called from several goroutines at once and must return
+// quickly: OnScanProgress and OnBytesCopied are called for every scanned
+// entry and every copied chunk. Embed NopObserver to implement only some.
+type Observer interface {
+	// OnScanProgress reports each entry found while scanning a root, and
+	// the end of its scan (Done set).
+	OnScanProgress(progress ScanProgress)
+	// OnPlanReady hands over the plan before it is shown and confirmed.
+	OnPlanReady(plan *SyncPlan)
+	// OnActionStart and OnActionDone bracket each action executed; err is
+	// the reason a failed action failed.
+	OnActionStart(action SyncAction)
+	OnActionDone(action SyncAction, err error)
+	// OnBytesCopied reports n more bytes written into the target.
+	OnBytesCopied(n int64)
+	// OnError reports a problem that doesn't stop the run, such as a failed
+	// action or an unreadable target.
+	OnError(err error)
+	// OnComplete is called once when Run returns, with its outcome and error.
+	OnComplete(result *summary.Summary, err error)
+}
+
+// ScanProgress describes how far the scan of one root got.
+type ScanProgress struct {
+	Description string // "source", "target", "source 2", ... (see RoleName)
+	Root        string
+	Found       int64 // Entries found so far
+	Done        bool  // The scan of this root is over
+}
+
+// NopObserver ignores all events.
+type NopObserver struct{}
+
+func (NopObserver) OnScanProgress(ScanProgress)        {}
+func (NopObserver) OnPlanReady(*SyncPlan)              {}
+func (NopObserver) OnActionStart(SyncAction)           {}
+func (NopObserver) OnActionDone(SyncAction, error)     {}
+func (NopObserver) OnBytesCopied(int64)                {}
+func (NopObserver) OnError(error)                      {}
+func (NopObserver) OnComplete(*summary.Summary, error) {}
+
+// observer returns the Observer of the run, or a NopObserver.
+func (s *Syncer) observer() Observer {
+	if s.Observer == nil {
+		return NopObserver{}
+	}
+	return s.Observer
+}
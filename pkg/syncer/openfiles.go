@@ -0,0 +1,58 @@
+// pkg/syncer/openfiles.go
+package syncer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// checkOpenFiles warns about plan actions copying source files that other
+// processes have open for writing, such as active logs and documents being
+// saved, whose copies may be torn. With SkipOpenFiles, those actions are
+// removed from the plan.
+func (s *Syncer) checkOpenFiles(plan *SyncPlan) {
+	if !s.CheckOpenFiles && !s.SkipOpenFiles || s.remote != nil {
+		return
+	}
+	paths := make(map[string]string) // Absolute source path -> relative path
+	for _, act := range plan.Actions {
+		if (act.Type == Add || act.Type == Update) && !act.SourceInfo.IsDir {
+			paths[act.SourceInfo.AbsPath] = act.RelPath
+		}
+	}
+	if len(paths) == 0 {
+		return
+	}
+	writers, err := openForWrite(paths)
+	if err != nil {
+		fmt.Fprintln(s.stderr(), i18n.T("openfiles.check_failed", err))
+		return
+	}
+	if len(writers) == 0 {
+		return
+	}
+	open := make(map[string]string, len(writers))
+	var copies []string
+	for absPath, writer := range writers {
+		open[paths[absPath]] = writer
+		copies = append(copies, paths[absPath])
+	}
+	sort.Strings(copies)
+
+	fmt.Fprintln(s.stderr(), "\n"+i18n.N("openfiles.warning", len(copies), len(copies)))
+	for i, relPath := range copies {
+		if i == hotDatabaseSample {
+			fmt.Fprintln(s.stderr(), "  "+i18n.T("sample.more", len(copies)-hotDatabaseSample))
+			break
+		}
+		fmt.Fprintf(s.stderr(), "  %s (%s)\n", relPath, open[relPath])
+	}
+	if !s.SkipOpenFiles {
+		fmt.Fprintln(s.stderr(), i18n.T("openfiles.hint"))
+		return
+	}
+	dropCopies(plan, open)
+	fmt.Fprintln(s.stderr(), i18n.T("openfiles.skipping"))
+}
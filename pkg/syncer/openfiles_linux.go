@@ -0,0 +1,75 @@
+// pkg/syncer/openfiles_linux.go
+//go:build linux
+
+package syncer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// openForWrite returns which of paths other processes have open for writing,
+// with the process holding each ("name[pid]"). It goes through the file
+// descriptors in /proc, so only processes whose descriptors can be read (the
+// user's own, or all as root) are seen.
+func openForWrite(paths map[string]string) (map[string]string, error) {
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+	self := os.Getpid()
+	writers := make(map[string]string)
+	for _, proc := range procs {
+		pid, err := strconv.Atoi(proc.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		fdDir := filepath.Join("/proc", proc.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // Gone, or another user's
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			if _, ok := paths[target]; !ok || !fdWritable(proc.Name(), fd.Name()) {
+				continue
+			}
+			writers[target] = fmt.Sprintf("%s[%d]", processName(proc.Name()), pid)
+		}
+	}
+	return writers, nil
+}
+
+// fdWritable reports whether a file descriptor was opened for writing,
+// from the access mode in its flags (octal) in /proc/<pid>/fdinfo.
+func fdWritable(pid, fd string) bool {
+	file, err := os.Open(filepath.Join("/proc", pid, "fdinfo", fd))
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "flags:"); ok {
+			flags, err := strconv.ParseUint(strings.TrimSpace(value), 8, 64)
+			return err == nil && flags&uint64(os.O_WRONLY|os.O_RDWR) != 0
+		}
+	}
+	return false
+}
+
+// processName returns the command name of a process.
+func processName(pid string) string {
+	comm, err := os.ReadFile(filepath.Join("/proc", pid, "comm"))
+	if err != nil {
+		return "?"
+	}
+	return strings.TrimSpace(string(comm))
+}
@@ -0,0 +1,11 @@
+// pkg/syncer/openfiles_other.go
+//go:build !linux
+
+package syncer
+
+import "errors"
+
+// openForWrite is only implemented on Linux.
+func openForWrite(paths map[string]string) (map[string]string, error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -0,0 +1,95 @@
+// pkg/syncer/oplog.go
+package syncer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/state"
+)
+
+// openOpLog opens the operations log of the target, or returns nil if it
+// can't be; the sync proceeds without it.
+func (s *Syncer) openOpLog() *state.OpLog {
+	target, err := state.OpenTarget(s.StateDir, s.TargetRoot)
+	if err == nil {
+		var oplog *state.OpLog
+		if oplog, err = target.OpenOpLog(s.summary.Start); err == nil {
+			return oplog
+		}
+	}
+	fmt.Fprintf(s.stderr(), "Warning: Could not open operations log: %v\n", err)
+	return nil
+}
+
+// applyLogged applies an action and, once it succeeded, appends it to the
+// operations log. The checksum of a file to be deleted is looked up first,
+// while the file and its markers still exist.
+func (e *executor) applyLogged(act SyncAction) error {
+	if e.oplog == nil {
+		return e.applyAction(act)
+	}
+	var deletedSum string
+	if act.Type == Delete && act.TargetInfo != nil && !act.TargetInfo.IsDir {
+		deletedSum = e.knownChecksum(act.TargetInfo, act.TargetInfo.AbsPath)
+	}
+	if err := e.applyAction(act); err != nil {
+		return err
+	}
+	e.logOp(act, deletedSum)
+	return nil
+}
+
+// logOp appends a completed action to the operations log, with the checksum
+// of the content written (adds and updates) or removed (deletes) when it is
+// known: from planning, from earlier runs or from xattr markers. Files aren't
+// read just for the log.
+func (e *executor) logOp(act SyncAction, deletedSum string) {
+	record := state.OpRecord{
+		Time: time.Now().UTC(),
+		Op:   strings.ToLower(act.Type.String()),
+		Path: filepath.ToSlash(act.RelPath),
+	}
+	switch act.Type {
+	case Add, Update:
+		record.Dir = act.SourceInfo.IsDir
+		if !record.Dir {
+			record.Size = act.SourceInfo.Size
+			record.SHA256 = e.knownChecksum(act.SourceInfo, filepath.Join(e.targetRoot, act.RelPath))
+		}
+	case Delete:
+		if act.TargetInfo != nil {
+			record.Dir, record.Size, record.SHA256 = act.TargetInfo.IsDir, act.TargetInfo.Size, deletedSum
+		}
+	case Rename:
+		record.From = filepath.ToSlash(act.OldRelPath)
+		record.Dir = act.TargetInfo != nil && act.TargetInfo.IsDir
+	}
+	if record.Dir {
+		record.Size = 0
+	}
+	if err := e.oplog.Append(record); err != nil {
+		e.oplogWarn.Do(func() {
+			fmt.Fprintf(e.stderr, "\nWarning: Could not write operations log (first failure: %s): %v\n", act.RelPath, err)
+		})
+	}
+}
+
+// knownChecksum returns the checksum of fi if planning computed it, an
+// earlier run cached it or the xattr markers of the file at path (holding
+// fi's content) record it, and "" otherwise.
+func (e *executor) knownChecksum(fi *fileinfo.FileInfo, path string) string {
+	if fi.Checksum != "" {
+		return fi.Checksum
+	}
+	if e.checksums != nil {
+		if sum, ok := e.checksums.Get(fi.AbsPath, fi.Size, fi.ModTime); ok {
+			return sum
+		}
+	}
+	sum, _ := markedSum(path, fi.ModTime)
+	return sum
+}
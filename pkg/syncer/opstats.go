@@ -0,0 +1,89 @@
+// pkg/syncer/opstats.go
+package syncer
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// opKinds are the operations an opTimer times, in the order they are reported.
+var opKinds = []string{summary.OpList, summary.OpStat, summary.OpRead, summary.OpWrite, summary.OpCopy, summary.OpDelete}
+
+// opTimer tallies the count, data and duration of the operations of a run on
+// source and target, to tell whether a slow sync is bound by scanning or by
+// copying. It is safe for concurrent use; a nil *opTimer times nothing.
+type opTimer struct {
+	counters map[string]*opCounter // By kind; fixed once created
+}
+
+type opCounter struct {
+	count atomic.Int64
+	bytes atomic.Int64
+	nanos atomic.Int64
+}
+
+func newOpTimer() *opTimer {
+	t := &opTimer{counters: make(map[string]*opCounter, len(opKinds))}
+	for _, op := range opKinds {
+		t.counters[op] = &opCounter{}
+	}
+	return t
+}
+
+// record counts one operation of kind op that started at start and moved
+// bytes of data.
+func (t *opTimer) record(op string, start time.Time, bytes int64) {
+	if t == nil {
+		return
+	}
+	c := t.counters[op]
+	c.count.Add(1)
+	c.bytes.Add(bytes)
+	c.nanos.Add(int64(time.Since(start)))
+}
+
+// stats returns the operations done so far, leaving out kinds never done.
+func (t *opTimer) stats() []summary.OpStats {
+	if t == nil {
+		return nil
+	}
+	var stats []summary.OpStats
+	for _, op := range opKinds {
+		c := t.counters[op]
+		if n := c.count.Load(); n > 0 {
+			stats = append(stats, summary.OpStats{Op: op, Count: n, Bytes: c.bytes.Load(), Time: time.Duration(c.nanos.Load())})
+		}
+	}
+	return stats
+}
+
+// timedReader times the reads from a file being copied.
+type timedReader struct {
+	r   io.Reader
+	ops *opTimer
+}
+
+func (r timedReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.r.Read(p)
+	if n > 0 || err != io.EOF { // Not the read finding the end
+		r.ops.record(summary.OpRead, start, int64(n))
+	}
+	return n, err
+}
+
+// timedWriter times the writes to a file being copied.
+type timedWriter struct {
+	w   io.Writer
+	ops *opTimer
+}
+
+func (w timedWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.w.Write(p)
+	w.ops.record(summary.OpWrite, start, int64(n))
+	return n, err
+}
@@ -0,0 +1,85 @@
+// pkg/syncer/partial.go
+package syncer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultPartialDir is the name of the directories holding partial files
+// when partial copies are kept without naming the directory.
+const DefaultPartialDir = ".sync-partial"
+
+// partialPath returns where the partial data of dst is kept: in the partial
+// directory next to dst, under its own name.
+func (e *executor) partialPath(dst string) string {
+	return filepath.Join(filepath.Dir(dst), e.partialDir, filepath.Base(dst))
+}
+
+// openPartial opens the partial file of dst to write the content of src
+// (size bytes) to. If an earlier, interrupted copy left one whose content is
+// a prefix of src, both files are positioned after that prefix, and its
+// length and the hasher fed with it are returned, so the copy continues by
+// appending. Anything else in the partial file is discarded and the copy
+// starts from zero.
+func (e *executor) openPartial(src *os.File, size int64, dst string, perm os.FileMode) (*os.File, int64, hash.Hash, error) {
+	path := e.partialPath(dst)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, 0, nil, err
+	}
+	var file *os.File
+	err := e.quirks.retryBusy(func() error {
+		var openErr error
+		file, openErr = os.OpenFile(path, os.O_RDWR|os.O_CREATE, e.quirks.fileMode(perm, false))
+		return openErr
+	})
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, nil, err
+	}
+
+	offset := info.Size()
+	if offset > 0 && offset <= size {
+		prefix := sha256.New()
+		if same, err := samePrefix(src, file, offset, prefix); err == nil && same {
+			if _, err := src.Seek(offset, io.SeekStart); err != nil {
+				file.Close()
+				return nil, 0, nil, err
+			}
+			if _, err := file.Seek(offset, io.SeekStart); err != nil {
+				file.Close()
+				return nil, 0, nil, err
+			}
+			return file, offset, prefix, nil
+		}
+	}
+	if offset > 0 {
+		if err := file.Truncate(0); err != nil {
+			file.Close()
+			return nil, 0, nil, err
+		}
+	}
+	return file, 0, nil, nil
+}
+
+// samePrefix reports whether the first n bytes of src and partial hash the
+// same. The bytes of partial are also written to prefix.
+func samePrefix(src, partial *os.File, n int64, prefix hash.Hash) (bool, error) {
+	srcSum := sha256.New()
+	if _, err := io.Copy(srcSum, io.NewSectionReader(src, 0, n)); err != nil {
+		return false, err
+	}
+	copied, err := io.Copy(prefix, io.NewSectionReader(partial, 0, n))
+	if err != nil || copied != n {
+		return false, err
+	}
+	return bytes.Equal(srcSum.Sum(nil), prefix.Sum(nil)), nil
+}
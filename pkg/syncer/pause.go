@@ -0,0 +1,104 @@
+// pkg/syncer/pause.go
+package syncer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// pauser lets workers be held between actions. Pausing never interrupts an
+// action in progress: workers finish their current file, then wait.
+type pauser struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	paused bool
+	hook   func(paused bool) // Called on every change while a plan executes
+}
+
+func newPauser() *pauser {
+	p := &pauser{}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// set pauses or resumes. It reports whether the state changed.
+func (p *pauser) set(paused bool) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.paused == paused {
+		return false
+	}
+	p.paused = paused
+	if p.hook != nil {
+		p.hook(paused)
+	}
+	if !paused {
+		p.cond.Broadcast()
+	}
+	return true
+}
+
+// wait blocks while paused.
+func (p *pauser) wait() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	for p.paused {
+		p.cond.Wait()
+	}
+	p.mu.Unlock()
+}
+
+func (p *pauser) isPaused() bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+func (p *pauser) setHook(hook func(paused bool)) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.hook = hook
+	p.mu.Unlock()
+}
+
+// Pause holds the workers of the run after their current file. It is safe to
+// call from any goroutine, e.g. a signal handler; a run that hasn't reached
+// execution yet starts out paused.
+func (s *Syncer) Pause() {
+	if s.pause.set(true) {
+		fmt.Fprintln(s.stderr(), "\n"+i18n.T("control.paused"))
+	}
+}
+
+// Resume lets a paused run continue.
+func (s *Syncer) Resume() {
+	if s.pause.set(false) {
+		fmt.Fprintln(s.stderr(), "\n"+i18n.T("control.resumed"))
+	}
+}
+
+// TogglePause pauses a running sync, or resumes a paused one.
+func (s *Syncer) TogglePause() {
+	if s.pause.isPaused() {
+		s.Resume()
+	} else {
+		s.Pause()
+	}
+}
+
+// Paused reports whether the run is paused.
+func (s *Syncer) Paused() bool {
+	return s.pause.isPaused()
+}
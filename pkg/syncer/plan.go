@@ -2,13 +2,18 @@
 package syncer
 
 import (
-	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/jeepinbird/sync-dir/pkg/compare"
 	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
 )
 
 // SyncActionType defines the type of action to be taken.
@@ -18,6 +23,7 @@ const (
 	Add    SyncActionType = iota // Add source file/dir to target
 	Update                       // Update target file from source
 	Delete                       // Delete target file/dir
+	Rename                       // Rename target item whose name only differs in case
 	None                         // No action needed (for internal tracking)
 )
 
@@ -29,6 +35,8 @@ func (t SyncActionType) String() string {
 		return "Update"
 	case Delete:
 		return "Delete"
+	case Rename:
+		return "Rename"
 	case None:
 		return "None"
 	default:
@@ -42,29 +50,127 @@ type SyncAction struct {
 	SourceInfo *fileinfo.FileInfo // Info from source (nil for Delete)
 	TargetInfo *fileinfo.FileInfo // Info from target (nil for Add)
 	RelPath    string             // Relative path of the item
+	OldRelPath string             // Current target path for Rename (RelPath is the new one)
 }
 
 // SyncPlan contains the list of actions to perform.
 type SyncPlan struct {
-	Actions []SyncAction
-	Adds    int
-	Updates int
-	Deletes int
+	Actions   []SyncAction
+	Adds      int
+	Updates   int
+	Deletes   int
+	Renames   int
+	Unchanged summary.Unchanged // Files on both sides left alone, and why
+	// TypeExcluded counts the source files left out by content type.
+	TypeExcluded int
+	// KeptByRule counts the target items missing from the source that
+	// skip-delete rules keep.
+	KeptByRule int
+	// Explanations say why the plan does what it does with the paths of
+	// Syncer.Explain; they are filled in once the plan is final.
+	Explanations []Explanation
+	// Annotations flag the unusual items of the plan; they are filled in
+	// once the plan is final.
+	Annotations []Annotation
+}
+
+// CopyBytes returns how many bytes executing the plan copies: the sizes of
+// the files added or updated.
+func (p *SyncPlan) CopyBytes() int64 {
+	var total int64
+	for _, action := range p.Actions {
+		if (action.Type == Add || action.Type == Update) && action.SourceInfo != nil && !action.SourceInfo.IsDir {
+			total += action.SourceInfo.Size
+		}
+	}
+	return total
+}
+
+// planOptions controls how createSyncPlan compares the two trees.
+type planOptions struct {
+	// caseInsensitive makes target items whose names only differ in case from a
+	// source item get renamed in place instead of deleted and re-added.
+	caseInsensitive bool
+	// checksum hashes a file when size and mtime alone are inconclusive.
+	checksum func(path string) (string, error)
+	// transformed reports whether a path's content is transformed on copy.
+	transformed func(relPath string) bool
+	// compareWorkers is how many file comparisons run at once; the hashing
+	// they may need is bounded separately by the checksum function.
+	compareWorkers int
+	// log receives case-only differences and comparison errors (nil = nothing).
+	log *slog.Logger
+	// advance counts source and target items as they are planned (may be nil).
+	advance func(n int)
+	// comparing is called when the comparison of files on both sides starts
+	// (may be nil).
+	comparing func()
+	// mtimeTolerance makes files of equal size whose mtimes are at most this
+	// far apart count as unchanged (coarse or skewed target timestamps).
+	mtimeTolerance time.Duration
+	// alwaysHash reports whether a path's mtime is unreliable, so files of
+	// equal size are compared by content even when their mtimes match.
+	alwaysHash func(relPath string) bool
+	// freshChecksum hashes without consulting cached sums, which are keyed
+	// by mtime and so can't be trusted for alwaysHash paths.
+	freshChecksum func(path string) (string, error)
+	// excludedType reports whether a source file is left out for its content
+	// type, like an excluded one (nil = none are). It is asked while planning,
+	// not while scanning, so the incremental scan cache holds whatever the
+	// filter; sniffing reads only the first bytes of files whose extension
+	// says nothing.
+	excludedType func(fi *fileinfo.FileInfo) bool
+	// skipDelete reports whether a target item missing from the source is
+	// kept (nil = none are). The directories above it are kept with it, but
+	// an item replaced by one of another type is still deleted.
+	skipDelete func(relPath string) bool
+	// alwaysCopy reports whether a file on both sides is updated without
+	// comparing them (nil = none are).
+	alwaysCopy func(relPath string) bool
 }
 
 // createSyncPlan compares source and target file maps and generates the plan.
-func createSyncPlan(sourceFiles, targetFiles map[string]*fileinfo.FileInfo) (*SyncPlan, error) {
+func createSyncPlan(sourceFiles, targetFiles map[string]*fileinfo.FileInfo, opts planOptions) (*SyncPlan, error) {
 	plan := &SyncPlan{
 		Actions: make([]SyncAction, 0),
 	}
 	processedTargetFiles := make(map[string]bool) // Keep track of targets we've handled
+	var comparisons []SyncAction                  // Files present on both sides, to compare
 
-	fmt.Println("Comparing source and target...")
+	if opts.log == nil {
+		opts.log = discardLogger
+	}
+	if opts.advance == nil {
+		opts.advance = func(int) {}
+	}
+	tracing := opts.tracing()
+
+	if opts.caseInsensitive {
+		var renames []SyncAction
+		renames, targetFiles = planCaseRenames(sourceFiles, targetFiles)
+		for _, rename := range renames {
+			opts.log.Info(i18n.T("plan.case_difference", rename.OldRelPath, rename.RelPath))
+		}
+		plan.Actions = append(plan.Actions, renames...)
+		plan.Renames = len(renames)
+	}
 
 	// --- Iterate through Source Files ---
 	for relPath, sourceFi := range sourceFiles {
+		if !sourceFi.IsDir && opts.excludedType != nil && opts.excludedType(sourceFi) {
+			opts.advance(1)
+			plan.TypeExcluded++
+			if tracing {
+				opts.traceDecision(relPath, decisionSkip, "excluded content type", sourceFi, nil, nil)
+			}
+			continue // As if it weren't in the source
+		}
 		targetFi, existsInTarget := targetFiles[relPath]
 		processedTargetFiles[relPath] = true // Mark as processed
+		// Files on both sides are counted once compared (see compareFiles)
+		if !existsInTarget || sourceFi.IsDir || targetFi.IsDir {
+			opts.advance(1)
+		}
 
 		action := SyncAction{RelPath: relPath, SourceInfo: sourceFi}
 
@@ -73,6 +179,9 @@ func createSyncPlan(sourceFiles, targetFiles map[string]*fileinfo.FileInfo) (*Sy
 			action.Type = Add
 			plan.Actions = append(plan.Actions, action)
 			plan.Adds++
+			if tracing {
+				opts.traceDecision(relPath, decisionAdd, "only in source", sourceFi, nil, nil)
+			}
 		} else {
 			// Item exists in both source and target -> Compare for Update
 			action.TargetInfo = targetFi
@@ -91,29 +200,15 @@ func createSyncPlan(sourceFiles, targetFiles map[string]*fileinfo.FileInfo) (*Sy
 				action.Type = Add
 				plan.Actions = append(plan.Actions, action)
 				plan.Adds++
+				if tracing {
+					opts.traceDecision(relPath, decisionReplace, "file on one side, directory on the other", sourceFi, targetFi, nil)
+				}
 				continue // Move to next source item
 			}
 
-			// Types match, compare content if it's a file
+			// Types match, compare content if it's a file (done below, in parallel)
 			if !sourceFi.IsDir {
-				needsUpdate, err := sourceFi.NeedsUpdate(targetFi, calculateSHA256)
-				if err != nil {
-					// Log error during comparison, maybe skip this file?
-					// For now, let's return the error to halt the process.
-					fmt.Fprintf(os.Stderr, "\nError comparing %s: %v\n", relPath, err)
-					// Optionally, treat as update needed on error? Or skip?
-					// Let's treat as update needed to be safe, but log it clearly.
-					fmt.Fprintf(os.Stderr, "Assuming update needed for %s due to comparison error.\n", relPath)
-					needsUpdate = true
-					// return nil, fmt.Errorf("comparison failed for %s: %w", relPath, err)
-				}
-
-				if needsUpdate {
-					action.Type = Update
-					plan.Actions = append(plan.Actions, action)
-					plan.Updates++
-				}
-				// If no update needed, do nothing for this item
+				comparisons = append(comparisons, action)
 			}
 			// Directories: No update action needed based on content/time/size
 			// Their existence and type matching is handled above.
@@ -121,28 +216,87 @@ func createSyncPlan(sourceFiles, targetFiles map[string]*fileinfo.FileInfo) (*Sy
 		}
 	}
 
+	// --- Compare Files Present on Both Sides ---
+	var updates []SyncAction
+	updates, plan.Unchanged = compareFiles(comparisons, opts)
+	for _, action := range updates {
+		action.Type = Update
+		plan.Actions = append(plan.Actions, action)
+		plan.Updates++
+	}
+
 	// --- Iterate through Target Files ---
 	// Identify target items that were NOT in the source (and thus need deletion)
+	var deletes []SyncAction
+	keptDirs := make(map[string]bool) // Directories holding items kept by a rule
 	for relPath, targetFi := range targetFiles {
+		opts.advance(1)
 		if _, processed := processedTargetFiles[relPath]; !processed {
+			if opts.skipDelete != nil && opts.skipDelete(relPath) {
+				opts.log.Info("Keeping by skip-delete rule", "path", relPath)
+				plan.KeptByRule++
+				if tracing {
+					opts.traceDecision(relPath, decisionKeep, "skip-delete rule", nil, targetFi, nil)
+				}
+				for _, parent := range ancestors(relPath, false) {
+					keptDirs[parent] = true
+				}
+				continue
+			}
 			// This target item was not found in the source -> Delete
-			plan.Actions = append(plan.Actions, SyncAction{
+			deletes = append(deletes, SyncAction{
 				Type:       Delete,
 				TargetInfo: targetFi, // Need target info for deletion
 				RelPath:    relPath,
 			})
+		}
+	}
+	for _, action := range deletes {
+		if !keptDirs[action.RelPath] { // Deleting it would take the kept items along
+			plan.Actions = append(plan.Actions, action)
+			if tracing {
+				opts.traceDecision(action.RelPath, decisionDelete, "only in target", nil, action.TargetInfo, nil)
+			}
+		} else if tracing {
+			opts.traceDecision(action.RelPath, decisionKeep, "holds items kept by a skip-delete rule", nil, action.TargetInfo, nil)
+		}
+	}
+
+	// --- Drop Redundant Deletes ---
+	// A directory delete removes everything below it, so separate deletes for
+	// its descendants would only be wasted work for the executor.
+	plan.Actions = dropCoveredDeletes(plan.Actions)
+	plan.Deletes = 0
+	for _, action := range plan.Actions {
+		if action.Type == Delete {
 			plan.Deletes++
 		}
 	}
 
 	// --- Sort Actions ---
-	// Sort deletes first, then updates, then adds.
+	// The executor schedules by dependencies (see actionGraph), so this order is
+	// only for display and determinism.
+	// Sort case renames first (shallowest first, so parents are renamed before
+	// their children), then deletes, then updates, then adds.
 	// Within deletes, sort by path depth (deepest first) to avoid deleting a parent dir before its contents.
 	// Within adds/updates, sort alphabetically by path.
 	sort.SliceStable(plan.Actions, func(i, j int) bool {
 		actionI := plan.Actions[i]
 		actionJ := plan.Actions[j]
 
+		// Renames go before everything else
+		if actionI.Type == Rename || actionJ.Type == Rename {
+			if actionI.Type != actionJ.Type {
+				return actionI.Type == Rename
+			}
+			depthI := strings.Count(actionI.RelPath, string(os.PathSeparator))
+			depthJ := strings.Count(actionJ.RelPath, string(os.PathSeparator))
+			if depthI != depthJ {
+				return depthI < depthJ
+			}
+			return actionI.RelPath < actionJ.RelPath
+		}
+
 		// Prioritize Deletes
 		if actionI.Type == Delete && actionJ.Type != Delete {
 			return true
@@ -172,7 +326,135 @@ func createSyncPlan(sourceFiles, targetFiles map[string]*fileinfo.FileInfo) (*Sy
 		// For Adds and Updates, sort alphabetically by path
 		return actionI.RelPath < actionJ.RelPath
 	})
-
-	fmt.Printf("Comparison complete. Plan: %d Adds, %d Updates, %d Deletes.\n", plan.Adds, plan.Updates, plan.Deletes)
 	return plan, nil
 }
+
+// compareFiles compares the source and target file of each action and returns
+// the actions whose target needs an update, in their original order, and
+// the counts of files left alone.
+func compareFiles(actions []SyncAction, opts planOptions) ([]SyncAction, summary.Unchanged) {
+	workers := opts.compareWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if len(actions) > 0 && opts.comparing != nil {
+		opts.comparing()
+	}
+	verdicts := make([]compare.Verdict, len(actions))
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, workers)
+	for i := range actions {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			verdicts[i] = compareFile(actions[i], opts)
+			opts.advance(1)
+		}(i)
+	}
+	wg.Wait()
+
+	var updates []SyncAction
+	unchanged := summary.Unchanged{Compared: len(actions)}
+	for i, action := range actions {
+		switch verdicts[i] {
+		case compare.SameSizeMtime:
+			unchanged.SizeMtime++
+		case compare.WithinTolerance:
+			unchanged.Tolerance++
+		case compare.SameChecksum:
+			unchanged.Checksum++
+		case compare.SameMtime:
+			unchanged.Transformed++
+		default:
+			updates = append(updates, action)
+		}
+	}
+	return updates, unchanged
+}
+
+// compareFile tells whether the action's target file differs from its source,
+// or why it is the same.
+func compareFile(action SyncAction, opts planOptions) compare.Verdict {
+	var sums traceSums
+	tracing := opts.tracing()
+	if tracing {
+		sums = make(traceSums)
+		opts.checksum, opts.freshChecksum = sums.record(opts.checksum), sums.record(opts.freshChecksum)
+	}
+	if opts.alwaysCopy != nil && opts.alwaysCopy(action.RelPath) {
+		if tracing {
+			opts.traceDecision(action.RelPath, decisionUpdate, "always-copy rule", action.SourceInfo, action.TargetInfo, nil)
+		}
+		return compare.Differs
+	}
+	verdict, err := opts.comparer(action.RelPath).Compare(action.SourceInfo, action.TargetInfo)
+	if err != nil {
+		// Treat as update needed to be safe, but log it clearly.
+		opts.log.Error("Could not compare, assuming it needs an update:", "path", action.RelPath, "err", err)
+		if tracing {
+			opts.traceDecision(action.RelPath, decisionUpdate, "comparison failed: "+err.Error(), action.SourceInfo, action.TargetInfo, sums)
+		}
+		return compare.Differs
+	}
+	if tracing {
+		decision := decisionKeep
+		if verdict == compare.Differs {
+			decision = decisionUpdate
+		}
+		opts.traceDecision(action.RelPath, decision, verdictReason(verdict, action, sums), action.SourceInfo, action.TargetInfo, sums)
+	}
+	return verdict
+}
+
+// comparer selects how the files at relPath are compared: transformed
+// content only by mtime, since it never matches the source byte for byte;
+// paths with unreliable mtimes by fresh checksums; everything else by size
+// and mtime, and by checksum where those are inconclusive.
+func (opts planOptions) comparer(relPath string) compare.Comparer {
+	switch {
+	case opts.transformed != nil && opts.transformed(relPath):
+		return compare.MetadataComparer{Tolerance: opts.mtimeTolerance}
+	case opts.alwaysHash != nil && opts.alwaysHash(relPath):
+		return compare.ChecksumComparer{Sum: opts.freshChecksum}
+	default:
+		return compare.Chain{
+			compare.QuickComparer{Tolerance: opts.mtimeTolerance},
+			compare.ChecksumComparer{Sum: opts.checksum},
+		}
+	}
+}
+
+// dropCoveredDeletes removes Delete actions whose path lies inside a directory
+// that is itself being deleted.
+func dropCoveredDeletes(actions []SyncAction) []SyncAction {
+	deletedDirs := make(map[string]bool)
+	for _, action := range actions {
+		if action.Type == Delete && action.TargetInfo != nil && action.TargetInfo.IsDir {
+			deletedDirs[action.RelPath] = true
+		}
+	}
+	if len(deletedDirs) == 0 {
+		return actions
+	}
+
+	kept := actions[:0]
+	for _, action := range actions {
+		if action.Type == Delete && hasDeletedAncestor(action.RelPath, deletedDirs) {
+			continue
+		}
+		kept = append(kept, action)
+	}
+	return kept
+}
+
+// hasDeletedAncestor reports whether any parent directory of relPath is in deletedDirs.
+func hasDeletedAncestor(relPath string, deletedDirs map[string]bool) bool {
+	for _, parent := range ancestors(relPath, false) {
+		if deletedDirs[parent] {
+			return true
+		}
+	}
+	return false
+}
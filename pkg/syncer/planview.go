@@ -0,0 +1,159 @@
+// pkg/syncer/planview.go
+package syncer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/theme"
+)
+
+// DefaultPlanLimit is how many actions are listed with the plan unless told
+// otherwise.
+const DefaultPlanLimit = 20
+
+// PlanView selects and orders the actions listed with the plan, so large
+// plans can be reviewed piece by piece. The zero value lists every action in
+// plan order.
+type PlanView struct {
+	Types []SyncActionType // List actions of these types only (none = all)
+	Sort  string           // action (plan order), path, size (largest first) or type (file extension)
+	Limit int              // List at most this many (0 = all)
+}
+
+// planSorts are the orders selectable with --plan-sort.
+var planSorts = []string{"action", "path", "size", "type"}
+
+// planFilters are the action types selectable with --plan-filter.
+var planFilters = map[string]SyncActionType{"add": Add, "update": Update, "delete": Delete, "rename": Rename}
+
+// ParsePlanView checks the action types, order and limit of a plan view.
+func ParsePlanView(filter []string, sortBy string, limit int) (PlanView, error) {
+	view := PlanView{Sort: sortBy, Limit: limit}
+	for _, name := range filter {
+		actionType, ok := planFilters[strings.ToLower(name)]
+		if !ok {
+			return PlanView{}, fmt.Errorf("unknown plan filter %q (valid: add, update, delete, rename)", name)
+		}
+		view.Types = append(view.Types, actionType)
+	}
+	if sortBy != "" && !contains(planSorts, sortBy) {
+		return PlanView{}, fmt.Errorf("unknown plan sort %q (valid: %s)", sortBy, strings.Join(planSorts, ", "))
+	}
+	if limit < 0 {
+		return PlanView{}, fmt.Errorf("plan limit cannot be negative")
+	}
+	return view, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the actions of the view's types in the view's order, and how
+// many actions of other types were left out.
+func (v PlanView) apply(actions []SyncAction) ([]SyncAction, int) {
+	var selected []SyncAction
+	for _, action := range actions {
+		if len(v.Types) == 0 || v.includes(action.Type) {
+			selected = append(selected, action)
+		}
+	}
+	switch v.Sort {
+	case "path":
+		sort.SliceStable(selected, func(i, j int) bool { return selected[i].RelPath < selected[j].RelPath })
+	case "size":
+		sort.SliceStable(selected, func(i, j int) bool { return actionSize(selected[i]) > actionSize(selected[j]) })
+	case "type":
+		sort.SliceStable(selected, func(i, j int) bool {
+			extI, extJ := actionExt(selected[i]), actionExt(selected[j])
+			if extI != extJ {
+				return extI < extJ
+			}
+			return selected[i].RelPath < selected[j].RelPath
+		})
+	}
+	return selected, len(actions) - len(selected)
+}
+
+func (v PlanView) includes(actionType SyncActionType) bool {
+	for _, t := range v.Types {
+		if t == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+// actionSize is the size of the file an action copies or deletes (0 for
+// directories and renames).
+func actionSize(action SyncAction) int64 {
+	fi := action.SourceInfo
+	if action.Type == Delete {
+		fi = action.TargetInfo
+	}
+	if action.Type == Rename || fi == nil || fi.IsDir {
+		return 0
+	}
+	return fi.Size
+}
+
+// actionExt is the lowercase extension of an action's file; directories
+// sort first, with an empty one.
+func actionExt(action SyncAction) string {
+	fi := action.SourceInfo
+	if fi == nil {
+		fi = action.TargetInfo
+	}
+	if fi != nil && fi.IsDir {
+		return ""
+	}
+	return strings.ToLower(filepath.Ext(action.RelPath))
+}
+
+// printPlanActions lists the plan's actions as selected by the view.
+func (s *Syncer) printPlanActions(plan *SyncPlan, view PlanView) {
+	actions, filtered := view.apply(plan.Actions)
+	limit := len(actions)
+	if view.Limit > 0 && view.Limit < limit {
+		limit = view.Limit
+	}
+	if limit == 0 && filtered == 0 {
+		return
+	}
+	fmt.Fprintln(s.stdout(), i18n.T("plan.samples"))
+	labels := i18n.Pad("action.add", "action.update", "action.delete", "action.rename")
+	for _, action := range actions[:limit] {
+		actionType := ""
+		switch action.Type {
+		case Add:
+			actionType = "[" + theme.Paint(theme.Add, labels[0]) + "]"
+		case Update:
+			actionType = "[" + theme.Paint(theme.Update, labels[1]) + "]"
+		case Delete:
+			actionType = "[" + theme.Paint(theme.Delete, labels[2]) + "]"
+		case Rename:
+			actionType = "[" + theme.Paint(theme.Rename, labels[3]) + "]"
+		}
+		if action.Type == Rename {
+			fmt.Fprintf(s.stdout(), "  %s %s\n", actionType, i18n.T("plan.case_only", action.OldRelPath, action.RelPath))
+			continue
+		}
+		fmt.Fprintf(s.stdout(), "  %s %s\n", actionType, action.RelPath)
+	}
+	if more := len(actions) - limit; more > 0 {
+		fmt.Fprintln(s.stdout(), "  "+i18n.N("plan.more", more, more))
+	}
+	if filtered > 0 {
+		fmt.Fprintln(s.stdout(), "  "+i18n.N("plan.filtered", filtered, filtered))
+	}
+	fmt.Fprintln(s.stdout(), "-----------------")
+}
@@ -0,0 +1,144 @@
+// pkg/syncer/preflight.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/manifest"
+)
+
+// ErrTargetNotWritable is returned (wrapped) when the target can't be written
+// at all, so that a run stops with one error instead of failing every action.
+var ErrTargetNotWritable = errors.New("target is not writable")
+
+// checkTargetWritable probes the target root (or, if it doesn't exist yet, the
+// directory it will be created in) by creating and removing a temp file.
+func (s *Syncer) checkTargetWritable(targetRoot string) error {
+	dir := fileinfo.ExistingAncestor(targetRoot)
+	probe, err := createTemp(dir, 0600)
+	if err == nil {
+		name := probe.Name()
+		_ = probe.Close()
+		if err := os.Remove(name); err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Could not remove write probe %s: %v\n", name, err)
+		}
+		return nil
+	}
+
+	switch {
+	case errors.Is(err, syscall.EROFS):
+		return fmt.Errorf("%w: %s is on a read-only filesystem (remount it read-write or choose another target)", ErrTargetNotWritable, dir)
+	case errors.Is(err, os.ErrPermission):
+		return fmt.Errorf("%w: no permission to write to %s", ErrTargetNotWritable, dir)
+	default:
+		return fmt.Errorf("%w: %v", ErrTargetNotWritable, err)
+	}
+}
+
+// PreflightProblem is one path a sync would fail on.
+type PreflightProblem struct {
+	Path   string // Source file (relative) or target directory (absolute)
+	Reason string
+}
+
+// PreflightReport is the result of Preflight.
+type PreflightReport struct {
+	Plan              *SyncPlan          // The plan that was checked
+	SourceChecked     int                // Source files opened for reading
+	TargetDirsChecked int                // Target directories checked for writing
+	ScanSkipped       int64              // Entries the scans could not read
+	Unreadable        []PreflightProblem // Source files the plan copies that can't be read
+	NotWritable       []PreflightProblem // Target directories the plan changes that can't be written
+}
+
+// OK reports whether no problems were found.
+func (r *PreflightReport) OK() bool {
+	return len(r.Unreadable) == 0 && len(r.NotWritable) == 0 && r.ScanSkipped == 0
+}
+
+// Preflight plans the sync like Run and then checks, without changing
+// anything, that every source file the plan copies can be opened and every
+// target directory it adds to, replaces in or deletes from can be written.
+func (s *Syncer) Preflight() (*PreflightReport, error) {
+	if err := s.scanRoots(); err != nil {
+		return nil, err
+	}
+	if err := s.buildPlan(); err != nil {
+		return nil, err
+	}
+	report := &PreflightReport{Plan: s.plan}
+	for _, stats := range s.ScanStats() {
+		report.ScanSkipped += stats.Skipped
+	}
+
+	dirs := make(map[string]bool)
+	for _, act := range s.plan.Actions {
+		targetPath := filepath.Join(s.TargetRoot, act.RelPath)
+		switch act.Type {
+		case Add, Update:
+			if act.SourceInfo.IsDir {
+				break
+			}
+			report.SourceChecked++
+			if s.remote != nil {
+				if err := manifest.Probe(act.SourceInfo.AbsPath); err != nil {
+					report.Unreadable = append(report.Unreadable, PreflightProblem{Path: act.RelPath, Reason: problemReason(err)})
+					continue
+				}
+				break
+			}
+			file, err := os.Open(act.SourceInfo.AbsPath)
+			if err != nil {
+				report.Unreadable = append(report.Unreadable, PreflightProblem{Path: act.RelPath, Reason: problemReason(err)})
+				continue
+			}
+			_ = file.Close()
+		case Delete:
+			if act.TargetInfo != nil && act.TargetInfo.IsDir {
+				dirs[targetPath] = true // Its contents are removed too
+			}
+		}
+		// Directories the plan creates don't exist yet: what matters is the
+		// nearest one that does
+		dirs[fileinfo.ExistingAncestor(filepath.Dir(targetPath))] = true
+	}
+
+	for _, dir := range sortedKeys(dirs) {
+		report.TargetDirsChecked++
+		if err := dirWritable(dir); err != nil {
+			report.NotWritable = append(report.NotWritable, PreflightProblem{Path: dir, Reason: problemReason(err)})
+		}
+	}
+	return report, nil
+}
+
+// problemReason describes err without repeating the path.
+func problemReason(err error) string {
+	switch {
+	case errors.Is(err, syscall.EROFS):
+		return "read-only filesystem"
+	case errors.Is(err, os.ErrPermission):
+		return "permission denied"
+	}
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
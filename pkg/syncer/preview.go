@@ -0,0 +1,113 @@
+// pkg/syncer/preview.go
+package syncer
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// PreviewOrigin says where an entry of a previewed target comes from.
+type PreviewOrigin int
+
+const (
+	PreviewKept    PreviewOrigin = iota // In the target already and identical to its source
+	PreviewExtra                        // Only in the target, and nothing deletes it
+	PreviewAdded                        // Copied from the source
+	PreviewUpdated                      // Replaced by a copy of the source
+	PreviewRenamed                      // Moved here from another target path
+)
+
+// PreviewEntry is one item of the target as it would be after the sync.
+type PreviewEntry struct {
+	RelPath string
+	IsDir   bool
+	Symlink bool
+	Size    int64
+	Origin  PreviewOrigin
+	From    string // Source item (absolute or URL), or the old target path for PreviewRenamed ("" = none)
+}
+
+// Preview plans the sync like Run and returns, without changing anything,
+// what the target would hold once the plan is applied, ordered by path.
+func (s *Syncer) Preview() ([]PreviewEntry, *SyncPlan, error) {
+	if err := s.scanRoots(); err != nil {
+		return nil, nil, err
+	}
+	if err := s.buildPlan(); err != nil {
+		return nil, nil, err
+	}
+
+	tree := make(map[string]PreviewEntry, len(s.targetFiles))
+	for relPath, fi := range s.targetFiles {
+		tree[relPath] = previewEntry(fi, PreviewKept, "")
+	}
+	for _, act := range s.plan.Actions {
+		switch act.Type {
+		case Add:
+			tree[act.RelPath] = previewEntry(act.SourceInfo, PreviewAdded, act.SourceInfo.AbsPath)
+		case Update:
+			tree[act.RelPath] = previewEntry(act.SourceInfo, PreviewUpdated, act.SourceInfo.AbsPath)
+		case Delete:
+			removeSubtree(tree, act.RelPath)
+		case Rename:
+			// Renames come first and take what is below a directory along
+			moveSubtree(tree, act.OldRelPath, act.RelPath)
+			entry := previewEntry(act.TargetInfo, PreviewRenamed, act.OldRelPath)
+			entry.RelPath = act.RelPath
+			tree[act.RelPath] = entry
+		}
+	}
+	for relPath, entry := range tree {
+		if entry.Origin != PreviewKept {
+			continue
+		}
+		if src, ok := s.sourceFiles[relPath]; ok {
+			entry.From = src.AbsPath
+		} else {
+			entry.Origin = PreviewExtra
+		}
+		tree[relPath] = entry
+	}
+
+	entries := make([]PreviewEntry, 0, len(tree))
+	for _, entry := range tree {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].RelPath < entries[j].RelPath })
+	return entries, s.plan, nil
+}
+
+func previewEntry(fi *fileinfo.FileInfo, origin PreviewOrigin, from string) PreviewEntry {
+	entry := PreviewEntry{RelPath: fi.RelPath, IsDir: fi.IsDir, Symlink: fi.IsSymlink(), Origin: origin, From: from}
+	if !fi.IsDir {
+		entry.Size = fi.Size
+	}
+	return entry
+}
+
+// removeSubtree removes relPath and everything below it from tree.
+func removeSubtree(tree map[string]PreviewEntry, relPath string) {
+	delete(tree, relPath)
+	prefix := relPath + string(filepath.Separator)
+	for path := range tree {
+		if strings.HasPrefix(path, prefix) {
+			delete(tree, path)
+		}
+	}
+}
+
+// moveSubtree moves what is below oldPath in tree to below newPath.
+func moveSubtree(tree map[string]PreviewEntry, oldPath, newPath string) {
+	delete(tree, oldPath)
+	prefix := oldPath + string(filepath.Separator)
+	for path, entry := range tree {
+		if strings.HasPrefix(path, prefix) {
+			delete(tree, path)
+			entry.RelPath = filepath.Join(newPath, strings.TrimPrefix(path, prefix))
+			tree[entry.RelPath] = entry
+		}
+	}
+}
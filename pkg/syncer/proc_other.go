@@ -0,0 +1,16 @@
+// pkg/syncer/proc_other.go
+//go:build !unix
+
+package syncer
+
+import "os"
+
+// processAlive reports whether a process with this pid exists on this host.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,15 @@
+// pkg/syncer/proc_unix.go
+//go:build unix
+
+package syncer
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with this pid exists on this host.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,73 @@
+// pkg/syncer/quirks.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// busyRetryDelay is the wait before the first retry of an EBUSY failure; each
+// further retry waits one delay longer.
+const busyRetryDelay = 200 * time.Millisecond
+
+// FSQuirks adjusts comparisons and writes for targets whose filesystem doesn't
+// behave like a local one. The zero value changes nothing.
+type FSQuirks struct {
+	Name                string
+	MtimeTolerance      time.Duration // Mtimes this close are considered equal
+	IgnoreChtimesErrors bool          // Don't warn when the mtime can't be set
+	SkipChmod           bool          // Create items with default permissions instead of the source's
+	BusyRetries         int           // Retries of operations failing with EBUSY
+}
+
+// fsQuirkProfiles are the profiles selectable with --fs-quirks.
+var fsQuirkProfiles = map[string]FSQuirks{
+	"none": {},
+	// SMB/CIFS shares (typically NAS targets): FAT-like 2s mtime granularity,
+	// servers rejecting SETATTR, permissions mapped from share ACLs, and files
+	// transiently locked by the server or other clients.
+	"smb": {
+		Name:                "smb",
+		MtimeTolerance:      2 * time.Second,
+		IgnoreChtimesErrors: true,
+		SkipChmod:           true,
+		BusyRetries:         5,
+	},
+}
+
+// ParseFSQuirks returns the quirks profile with the given name ("" is "none").
+func ParseFSQuirks(name string) (FSQuirks, error) {
+	if name == "" {
+		return FSQuirks{}, nil
+	}
+	quirks, ok := fsQuirkProfiles[name]
+	if !ok {
+		return FSQuirks{}, fmt.Errorf("unknown filesystem quirks profile %q (valid: none, smb)", name)
+	}
+	return quirks, nil
+}
+
+// retryBusy runs op, retrying with a growing delay while it fails with EBUSY.
+func (q FSQuirks) retryBusy(op func() error) error {
+	err := op()
+	for attempt := 1; attempt <= q.BusyRetries && errors.Is(err, syscall.EBUSY); attempt++ {
+		time.Sleep(time.Duration(attempt) * busyRetryDelay)
+		err = op()
+	}
+	return err
+}
+
+// fileMode returns the permissions to create an item with: the source's, or
+// the defaults (before umask) when chmod is avoided.
+func (q FSQuirks) fileMode(perm os.FileMode, isDir bool) os.FileMode {
+	if !q.SkipChmod {
+		return perm
+	}
+	if isDir {
+		return 0777
+	}
+	return 0666
+}
@@ -0,0 +1,174 @@
+// pkg/syncer/quota.go
+package syncer
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// targetUsage is how much space the target's files take now and once the
+// plan is applied, and how much they may take. Usage is the sum of apparent
+// file sizes, so sparse, compressed or deduplicated files count in full.
+type targetUsage struct {
+	Current   int64 // Bytes of the target's files now
+	Projected int64 // Bytes of the target's files once the plan is applied
+	Unordered int64 // Most bytes the target may take at once if deletes run last
+	Quota     int64 // TargetQuota (0 = none)
+	Free      int64 // Bytes available on the target's filesystem (-1 = unknown)
+	freed     map[string]int64
+}
+
+// usageOf computes the target's usage for plan from the target scan.
+func (s *Syncer) usageOf(plan *SyncPlan) targetUsage {
+	usage := targetUsage{Quota: s.TargetQuota, Free: -1, freed: make(map[string]int64)}
+	if free, ok := freeSpace(fileinfo.ExistingAncestor(s.TargetRoot)); ok {
+		usage.Free = free
+	}
+
+	deleted := make(map[string]bool)
+	for _, action := range plan.Actions {
+		if action.Type == Delete {
+			deleted[action.RelPath] = true
+		}
+	}
+	// A deleted directory frees everything below it, which the plan doesn't list
+	for relPath, fi := range s.targetFiles {
+		if fi.IsDir {
+			continue
+		}
+		usage.Current += fi.Size
+		for _, p := range ancestors(relPath, true) {
+			if deleted[p] {
+				usage.freed[p] += fi.Size
+				break
+			}
+		}
+	}
+
+	usage.Projected, usage.Unordered = usage.Current, usage.Current
+	for _, action := range plan.Actions {
+		grow := usage.growth(action)
+		usage.Projected += grow
+		if grow > 0 {
+			usage.Unordered += grow
+		}
+	}
+	return usage
+}
+
+// growth is how many bytes action adds to the target's usage (negative if it
+// frees space).
+func (u *targetUsage) growth(action SyncAction) int64 {
+	switch action.Type {
+	case Add:
+		if !action.SourceInfo.IsDir {
+			return action.SourceInfo.Size
+		}
+	case Update:
+		return action.SourceInfo.Size - action.TargetInfo.Size
+	case Delete:
+		return -u.freed[action.RelPath]
+	}
+	return 0
+}
+
+// Limit is the most bytes the target's files may take: TargetQuota, or less
+// if its filesystem doesn't have the space (0 = unknown).
+func (u *targetUsage) Limit() int64 {
+	limit := u.Quota
+	if u.Free >= 0 && (limit == 0 || u.Current+u.Free < limit) {
+		limit = u.Current + u.Free
+	}
+	return limit
+}
+
+// NearLimit reports whether running deletes alongside copies could take the
+// target within 10% of its limit, so deletes should run first.
+func (u *targetUsage) NearLimit() bool {
+	limit := u.Limit()
+	return limit > 0 && u.Unordered > limit-limit/10
+}
+
+// printTargetUsage shows the projected usage with the plan when a quota is
+// set or the target's filesystem is getting full.
+func (s *Syncer) printTargetUsage(usage targetUsage) {
+	switch {
+	case usage.Quota > 0:
+		fmt.Fprintln(s.stdout(), i18n.T("quota.usage_quota", summary.FormatBytes(usage.Current), summary.FormatBytes(usage.Projected),
+			100*float64(usage.Projected)/float64(usage.Quota), summary.FormatBytes(usage.Quota)))
+	case usage.NearLimit():
+		fmt.Fprintln(s.stdout(), i18n.T("quota.usage_free", summary.FormatBytes(usage.Current), summary.FormatBytes(usage.Projected),
+			summary.FormatBytes(usage.Free)))
+	default:
+		return
+	}
+	fmt.Fprintln(s.stdout(), "-----------------")
+}
+
+// checkTargetUsage refuses a plan that grows the target beyond TargetQuota,
+// and warns if its filesystem doesn't seem to have the space.
+func (s *Syncer) checkTargetUsage(usage targetUsage) error {
+	if usage.Quota > 0 && usage.Projected > usage.Quota && usage.Projected > usage.Current {
+		return i18n.Errorf("quota.exceeded", summary.FormatBytes(usage.Projected), summary.FormatBytes(usage.Quota))
+	}
+	if usage.Free >= 0 && usage.Projected-usage.Current > usage.Free {
+		fmt.Fprintln(s.stderr(), "Warning: "+i18n.T("quota.no_space", summary.FormatBytes(usage.Projected-usage.Current), summary.FormatBytes(usage.Free)))
+	}
+	return nil
+}
+
+// quotaMeter keeps the target's usage within TargetQuota while the plan runs:
+// a copy that would take it over the quota fails instead.
+type quotaMeter struct {
+	mu    sync.Mutex
+	usage targetUsage
+	used  int64
+}
+
+// newQuotaMeter returns a meter for usage, or nil without a quota.
+func newQuotaMeter(usage targetUsage) *quotaMeter {
+	if usage.Quota == 0 {
+		return nil
+	}
+	return &quotaMeter{usage: usage, used: usage.Current}
+}
+
+// admit reserves the space action needs, or refuses it if that would take
+// the target over its quota.
+func (q *quotaMeter) admit(action SyncAction) error {
+	if q == nil {
+		return nil
+	}
+	grow := q.usage.growth(action)
+	if grow <= 0 {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.used+grow > q.usage.Quota {
+		return i18n.Errorf("quota.action_refused", action.RelPath, summary.FormatBytes(q.used+grow), summary.FormatBytes(q.usage.Quota))
+	}
+	q.used += grow
+	return nil
+}
+
+// settle accounts for action once it is done: a failed action gives back the
+// space it reserved, a successful one releases the space it freed.
+func (q *quotaMeter) settle(action SyncAction, err error) {
+	if q == nil {
+		return
+	}
+	grow := q.usage.growth(action)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	switch {
+	case grow > 0 && err != nil:
+		q.used -= grow
+	case grow < 0 && err == nil:
+		q.used += grow
+	}
+}
@@ -0,0 +1,137 @@
+// pkg/syncer/remote.go
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/manifest"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// remoteSource is a source published over HTTP(S) with a manifest (see
+// package manifest). Its files' AbsPath is their URL; they are downloaded
+// before being copied into place.
+type remoteSource struct {
+	remote    *manifest.Remote
+	entries   map[string]manifest.Entry // Manifest entries by URL
+	downloads string                    // Where downloads are kept until copied into place
+}
+
+// scanRemote lists the source from its manifest, like scan lists a local one.
+func (s *Syncer) scanRemote() (map[string]*fileinfo.FileInfo, error) {
+	const description = "source"
+	if err := manifest.Configure(s.Transport); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	remote, err := manifest.Open(s.SourceRoot, s.ManifestKey)
+	if err != nil {
+		return nil, err
+	}
+	s.ops.record(summary.OpList, start, 0)
+	opts := s.scanOptions(s.SourceRoot, s.ignoreMatcher, description)
+	opts.counts = &scanCounts{}
+	s.remote = &remoteSource{remote: remote, entries: make(map[string]manifest.Entry)}
+
+	files := make(map[string]*fileinfo.FileInfo)
+	skipped := make(map[string]bool) // Directories left out, with everything below
+	for _, entry := range remote.Manifest.Entries {
+		relPath := filepath.FromSlash(entry.Path)
+		if skipped[filepath.Dir(relPath)] || skipScanEntry(relPath, entry.Dir, opts.matcher, opts.logger()) {
+			skipped[relPath] = entry.Dir
+			opts.counts.ignored.Add(1)
+			continue
+		}
+		if opts.tooShallow(relPath) || opts.tooDeep(relPath) {
+			continue
+		}
+		mode := entry.Mode.Perm()
+		if entry.Dir {
+			mode |= fs.ModeDir
+		}
+		fi := &fileinfo.FileInfo{
+			RelPath:  relPath,
+			AbsPath:  remote.URL(entry.Path),
+			Size:     entry.Size,
+			Mode:     mode,
+			ModTime:  entry.ModTime,
+			IsDir:    entry.Dir,
+			Checksum: entry.SHA256,
+		}
+		files[relPath] = fi
+		s.remote.entries[fi.AbsPath] = entry
+		opts.advance()
+	}
+
+	s.observer().OnScanProgress(ScanProgress{Description: description, Root: s.SourceRoot, Found: int64(len(files)), Done: true})
+	s.log().Info(i18n.N("scan.finished", len(files), RoleName(description), len(files)))
+	if s.scanStats != nil {
+		s.scanStats.add(newScanStats(description, s.SourceRoot, files, opts.counts))
+	}
+	return files, nil
+}
+
+// withRemoteSums answers checksum requests for the source's files from its
+// manifest, and passes the others on to checksum.
+func (r *remoteSource) withRemoteSums(checksum func(string) (string, error)) func(string) (string, error) {
+	if r == nil {
+		return checksum
+	}
+	return func(path string) (string, error) {
+		entry, ok := r.entries[path]
+		if !ok {
+			return checksum(path)
+		}
+		if entry.SHA256 == "" {
+			return "", fmt.Errorf("the manifest has no checksum for %s", entry.Path)
+		}
+		return entry.SHA256, nil
+	}
+}
+
+// prepare sets up the download directory: in the pair's state when there is
+// one, so that interrupted downloads resume in the next run, and a temp
+// directory otherwise.
+func (r *remoteSource) prepare(stateDir string) error {
+	if stateDir != "" {
+		r.downloads = filepath.Join(stateDir, "downloads")
+		return os.MkdirAll(r.downloads, 0700)
+	}
+	var err error
+	r.downloads, err = os.MkdirTemp("", "sync-dir-downloads-")
+	return err
+}
+
+// fetch downloads a source file and returns where it was saved, resuming an
+// earlier download of the same version of the file.
+func (r *remoteSource) fetch(fileURL string) (string, error) {
+	path := r.downloadPath(fileURL)
+	if err := manifest.Download(fileURL, path, r.entries[fileURL], nil); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// downloadPath is where a source file is downloaded to: a name unique to the
+// version of the file, so that only the same version is resumed.
+func (r *remoteSource) downloadPath(fileURL string) string {
+	entry := r.entries[fileURL]
+	key := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%s", fileURL, entry.Size, entry.ModTime.UnixNano(), entry.SHA256)))
+	return filepath.Join(r.downloads, hex.EncodeToString(key[:12])+".part")
+}
+
+// finished tells the server the target is now a mirror of the tree, for
+// servers that wait for their targets (see "sync-dir agent").
+func (r *remoteSource) finished() {
+	if r != nil {
+		r.remote.Finished()
+	}
+}
@@ -0,0 +1,276 @@
+// pkg/syncer/scancache.go
+package syncer
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// scanCache is the on-disk record of a previous scan of one root directory.
+type scanCache struct {
+	Root        string                        // Absolute root the scan was made of
+	Fingerprint string                        // Ignore rules the scan was made with
+	Entries     map[string]*fileinfo.FileInfo // Keyed by relative path; "." is the root itself
+}
+
+// scanCachePath returns where the scan cache for root is stored.
+func scanCachePath(root string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(cacheDir, "sync-dir", "scans", hex.EncodeToString(sum[:8])+".gob"), nil
+}
+
+// loadScanCache reads a cache file. It returns nil if the file is missing,
+// unreadable, or was made for a different root or different ignore rules.
+func loadScanCache(path, root, fingerprint string, log *slog.Logger) *scanCache {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Error("Could not close", "path", path, "err", err)
+		}
+	}()
+
+	var cache scanCache
+	if err := gob.NewDecoder(file).Decode(&cache); err != nil {
+		log.Warn("Ignoring unreadable scan cache", "path", path, "err", err)
+		return nil
+	}
+	if cache.Root != root || cache.Fingerprint != fingerprint || cache.Entries == nil {
+		return nil
+	}
+	return &cache
+}
+
+// saveScanCache writes the cache atomically (temp file + rename).
+func saveScanCache(path string, cache *scanCache) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".scan-*.tmp")
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(tmp).Encode(cache); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// invalidateScanCache removes the cached scan of root, if any.
+func invalidateScanCache(root string, log *slog.Logger) {
+	path, err := scanCachePath(root)
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Warn("Could not remove scan cache", "path", path, "err", err)
+	}
+}
+
+// incrementalWalker scans a tree, reusing cached entries of directories whose
+// mtime hasn't changed since the previous scan. A directory's mtime changes
+// whenever entries are added, removed or renamed in it, so in that case its
+// listing and the stats of its files can be taken from the cache. Its
+// subdirectories are still visited, since their own mtimes have to be checked.
+//
+// In-place edits that keep a file's name don't touch the directory mtime, so
+// they are only picked up once something else changes in the same directory.
+type incrementalWalker struct {
+	rootPath string
+	matcher  *ignore.Matcher
+	cache    *scanCache          // nil when there is no usable previous scan
+	children map[string][]string // Cached child paths of each cached directory
+	results  map[string]*fileinfo.FileInfo
+	shallow  map[string]*fileinfo.FileInfo // Entries above the minimum depth: cached, not results
+	opts     scanOptions
+	reused   int // Entries taken from the cache without a stat
+}
+
+// scanDirectoryIncremental scans rootPath like scanDirectory, but uses (and
+// refreshes) the on-disk scan cache.
+func scanDirectoryIncremental(rootPath string, opts scanOptions) (map[string]*fileinfo.FileInfo, error) {
+	ignoreMatcher, description, log := opts.matcher, opts.description, opts.logger()
+	rootInfo, err := os.Stat(rootPath)
+	if err != nil {
+		return nil, fmt.Errorf("error during directory walk for %s: %w", description, err)
+	}
+
+	cachePath, cacheErr := scanCachePath(rootPath)
+	w := &incrementalWalker{
+		rootPath: rootPath,
+		matcher:  ignoreMatcher,
+		results:  make(map[string]*fileinfo.FileInfo),
+		shallow:  make(map[string]*fileinfo.FileInfo),
+		opts:     opts,
+	}
+	if cacheErr == nil {
+		w.cache = loadScanCache(cachePath, rootPath, ignoreMatcher.Fingerprint()+opts.cacheKey(), log)
+	}
+	w.indexChildren()
+
+	w.walkDir(".", rootInfo)
+	log.Info(i18n.N("scan.reused", w.reused, w.reused, RoleName(description)))
+
+	// Refresh the cache for next time; failing to do so only costs speed
+	if cacheErr == nil {
+		entries := make(map[string]*fileinfo.FileInfo, len(w.results)+len(w.shallow)+1)
+		for relPath, fi := range w.results {
+			entries[relPath] = fi
+		}
+		for relPath, fi := range w.shallow {
+			entries[relPath] = fi
+		}
+		entries["."] = fileinfo.New(".", rootPath, rootInfo)
+		cache := &scanCache{Root: rootPath, Fingerprint: ignoreMatcher.Fingerprint() + opts.cacheKey(), Entries: entries}
+		if err := saveScanCache(cachePath, cache); err != nil {
+			log.Warn("Could not save scan cache for "+description, "err", err)
+		}
+	} else {
+		log.Warn("No cache directory available for "+description, "err", cacheErr)
+	}
+
+	return w.results, nil
+}
+
+// indexChildren builds the parent -> children index of the cached entries.
+func (w *incrementalWalker) indexChildren() {
+	w.children = make(map[string][]string)
+	if w.cache == nil {
+		return
+	}
+	for relPath := range w.cache.Entries {
+		if relPath != "." {
+			parent := filepath.Dir(relPath)
+			w.children[parent] = append(w.children[parent], relPath)
+		}
+	}
+}
+
+// walkDir records the contents of relDir (whose current info is dirInfo) and recurses.
+func (w *incrementalWalker) walkDir(relDir string, dirInfo fs.FileInfo) {
+	if w.cache != nil {
+		cached, ok := w.cache.Entries[relDir]
+		if ok && cached.IsDir && cached.ModTime.Equal(dirInfo.ModTime()) && w.walkCachedDir(relDir) {
+			return
+		}
+	}
+
+	absDir := filepath.Join(w.rootPath, relDir)
+	start := time.Now()
+	entries, err := os.ReadDir(absDir)
+	w.opts.ops.record(summary.OpList, start, 0)
+	if err != nil {
+		w.opts.logger().Warn("Could not access", "path", absDir, "err", err)
+		w.opts.counts.skip()
+		return
+	}
+	for _, entry := range entries {
+		relPath := filepath.Join(relDir, entry.Name())
+		if w.opts.skip(relPath, entry.IsDir()) {
+			w.opts.counts.ignore()
+			continue
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if info, ok := w.opts.follow.follows(relPath, filepath.Join(absDir, entry.Name()), w.opts.logger()); ok {
+				w.add(relPath, info)
+				if !w.opts.atMaxDepth(relPath) {
+					w.walkDir(relPath, info)
+				}
+				continue
+			}
+		}
+		start := time.Now()
+		info, err := entry.Info()
+		w.opts.ops.record(summary.OpStat, start, 0)
+		if err != nil {
+			w.opts.logger().Warn("Could not get info for", "path", filepath.Join(absDir, entry.Name()), "err", err)
+			w.opts.counts.skip()
+			continue
+		}
+		w.add(relPath, info)
+		if entry.IsDir() && !w.opts.atMaxDepth(relPath) {
+			w.walkDir(relPath, info)
+		}
+	}
+}
+
+// walkCachedDir fills in relDir's children from the cache, statting only
+// subdirectories. It returns false if the cache turned out to be stale, in
+// which case nothing was recorded and the caller must read the directory.
+func (w *incrementalWalker) walkCachedDir(relDir string) bool {
+	type subdir struct {
+		relPath string
+		info    fs.FileInfo
+	}
+	var subdirs []subdir
+	for _, child := range w.children[relDir] {
+		if !w.cache.Entries[child].IsDir {
+			continue
+		}
+		start := time.Now()
+		info, err := os.Lstat(filepath.Join(w.rootPath, child))
+		w.opts.ops.record(summary.OpStat, start, 0)
+		if err != nil || !info.IsDir() {
+			return false // Changed despite the unchanged parent mtime
+		}
+		subdirs = append(subdirs, subdir{child, info})
+	}
+
+	for _, child := range w.children[relDir] {
+		if fi := w.cache.Entries[child]; !fi.IsDir {
+			w.keep(child, fi)
+			w.reused++
+		}
+	}
+	for _, sub := range subdirs {
+		w.add(sub.relPath, sub.info)
+		if !w.opts.atMaxDepth(sub.relPath) {
+			w.walkDir(sub.relPath, sub.info)
+		}
+	}
+	return true
+}
+
+// add records a freshly statted entry.
+func (w *incrementalWalker) add(relPath string, info fs.FileInfo) {
+	w.keep(relPath, fileinfo.New(relPath, filepath.Join(w.rootPath, relPath), info))
+}
+
+// keep records an entry in the results, or only for the cache if it lies
+// above the minimum depth.
+func (w *incrementalWalker) keep(relPath string, fi *fileinfo.FileInfo) {
+	if w.opts.tooShallow(relPath) {
+		w.shallow[relPath] = fi
+		return
+	}
+	w.results[relPath] = fi
+	w.tick()
+}
+
+func (w *incrementalWalker) tick() {
+	w.opts.advance()
+}
@@ -4,47 +4,115 @@ package syncer
 import (
 	"fmt"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
 	"github.com/jeepinbird/sync-dir/pkg/ignore"
-	"github.com/schollz/progressbar/v3"
+	"github.com/jeepinbird/sync-dir/pkg/progress"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/trash"
 )
 
+// scanOptions controls a scan. Scans never print: they report through log
+// and found, so they work the same behind the CLI and embedded in other
+// programs.
+type scanOptions struct {
+	matcher     *ignore.Matcher // Exclude rules (nil = none)
+	description string          // Role of the root: "source", "target", "source 2", ...
+	counts      *scanCounts     // Tallies ignored and unreadable entries (may be nil)
+	log         *slog.Logger    // Receives unreadable entries and ignored paths (nil = nothing)
+	ops         *opTimer        // Times directory reads and stats (nil = nothing)
+	minDepth    int             // Entries above this depth are walked through but left out (0 = none)
+	maxDepth    int             // Entries below this depth are not scanned (0 = no limit)
+	follow      *followSet      // Symlinked directories scanned as directories (nil = none)
+	partialDir  string          // Name of the directories holding partial files, left out ("" = none)
+	trashDirs   bool            // Leave out the trash directories at the top (see --delete-to-trash)
+	found       func()          // Called for every entry found, e.g. to advance a spinner (may be nil)
+}
+
+// logger returns opts.log, or a logger discarding everything.
+func (opts scanOptions) logger() *slog.Logger {
+	if opts.log == nil {
+		return discardLogger
+	}
+	return opts.log
+}
+
+// tooShallow reports whether an entry lies above the minimum depth.
+func (opts scanOptions) tooShallow(relPath string) bool {
+	return opts.minDepth > 0 && depthOf(relPath) < opts.minDepth
+}
+
+// tooDeep reports whether an entry lies below the maximum depth.
+func (opts scanOptions) tooDeep(relPath string) bool {
+	return opts.maxDepth > 0 && depthOf(relPath) > opts.maxDepth
+}
+
+// atMaxDepth reports whether the contents of a directory lie below the
+// maximum depth, so they aren't scanned.
+func (opts scanOptions) atMaxDepth(relPath string) bool {
+	return opts.maxDepth > 0 && depthOf(relPath) >= opts.maxDepth
+}
+
+// cacheKey tells scans with different depth limits, followed links or
+// partial directories apart in the scan cache.
+func (opts scanOptions) cacheKey() string {
+	key := opts.follow.key()
+	if opts.minDepth != 0 || opts.maxDepth != 0 {
+		key += fmt.Sprintf("\x00depth %d-%d", opts.minDepth, opts.maxDepth)
+	}
+	if opts.partialDir != "" {
+		key += "\x00partial " + opts.partialDir
+	}
+	return key
+}
+
+// depthOf is the depth of an entry below the root: 1 for the root's entries.
+func depthOf(relPath string) int {
+	return strings.Count(relPath, string(filepath.Separator)) + 1
+}
+
+// advance reports a found entry.
+func (opts scanOptions) advance() {
+	if opts.found != nil {
+		opts.found()
+	}
+}
+
 // scanDirectory concurrently scans a directory and returns a map of relative paths to FileInfo.
-// It respects ignore patterns and shows a progress bar.
-func scanDirectory(dirPath string, rootPath string, ignoreMatcher *ignore.Matcher, description string) (map[string]*fileinfo.FileInfo, error) {
+// It respects ignore patterns; ignored and unreadable entries are tallied in opts.counts.
+func scanDirectory(dirPath string, rootPath string, opts scanOptions) (map[string]*fileinfo.FileInfo, error) {
 	results := make(map[string]*fileinfo.FileInfo)
 	var mu sync.Mutex // Mutex to protect access to the results map
 	var wg sync.WaitGroup
 	errChan := make(chan error, 1) // Buffered channel to report the first error
-
-	// --- Progress Bar Setup ---
-	// We don't know the total number of files beforehand easily without a full walk first.
-	// We can use a spinner-style progress bar.
-	bar := progressbar.NewOptions(-1, // Use -1 for an indeterminate progress bar (spinner)
-		progressbar.OptionSetDescription(fmt.Sprintf("Scanning %s...", description)),
-		progressbar.OptionSetWriter(os.Stderr), // Write progress to stderr
-		progressbar.OptionSpinnerType(14),      // Choose a spinner type
-		progressbar.OptionSetWidth(15),
-		progressbar.OptionClearOnFinish(),
-		progressbar.OptionShowCount(), // Show the count of items processed
-	)
-	// Ensure the bar is cleaned up and handle potential errors
-	defer func() {
-		if err := bar.Finish(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error finishing progress bar: %v\n", err)
+	log, counts := opts.logger(), opts.counts
+	// WalkDir reads a directory between the call for it and the next call,
+	// so that is when its listing is timed
+	var listing time.Time
+	listed := func() {
+		if !listing.IsZero() {
+			opts.ops.record(summary.OpList, listing, 0)
+			listing = time.Time{}
 		}
-	}()
+	}
 
 	// --- Walk the Directory ---
 	walkErr := filepath.WalkDir(dirPath, func(absPath string, d fs.DirEntry, err error) error {
+		listed()
 		// Handle potential errors during walk (e.g., permission denied)
 		if err != nil {
 			// Log the error but continue walking if possible
-			fmt.Fprintf(os.Stderr, "\nWarning: Error accessing %s: %v\n", absPath, err)
+			log.Warn("Could not access", "path", absPath, "err", err)
+			if !os.IsNotExist(err) {
+				counts.skip() // A missing root (new target) isn't unreadable
+			}
 			// If it's a directory we can't read, skip its contents
 			if d != nil && d.IsDir() {
 				return filepath.SkipDir
@@ -65,17 +133,13 @@ func scanDirectory(dirPath string, rootPath string, ignoreMatcher *ignore.Matche
 
 		// Skip the root directory itself (relPath == ".")
 		if relPath == "." {
+			listing = time.Now()
 			return nil
 		}
 
 		// --- Check Ignore Rules ---
-		// Always ignore the .sync-ignore file itself if scanning source
-		if dirPath == rootPath && filepath.Base(absPath) == ignore.IgnoreFileName {
-			return nil
-		}
-		// Check against compiled patterns
-		if ignoreMatcher != nil && ignoreMatcher.Matches(relPath) {
-			fmt.Fprintf(os.Stderr, "\nIgnoring: %s\n", relPath) // Log ignored paths
+		if opts.skip(relPath, d.IsDir()) {
+			counts.ignore()
 			// If it's a directory, skip its contents entirely
 			if d.IsDir() {
 				return filepath.SkipDir
@@ -83,19 +147,52 @@ func scanDirectory(dirPath string, rootPath string, ignoreMatcher *ignore.Matche
 			return nil // Skip this file
 		}
 
+		// --- Followed Symlinks ---
+		// The link is walked as a root of its own, with a trailing separator
+		// so that WalkDir resolves it; that walk records the link itself
+		if d.Type()&fs.ModeSymlink != 0 {
+			if _, ok := opts.follow.follows(relPath, absPath, log); ok {
+				followed, err := scanDirectory(absPath+string(filepath.Separator), rootPath, opts)
+				if err != nil {
+					select {
+					case errChan <- err:
+					default:
+					}
+					return nil
+				}
+				if fi := followed[relPath]; fi != nil {
+					fi.AbsPath = absPath
+				}
+				mu.Lock()
+				for p, fi := range followed {
+					results[p] = fi
+				}
+				mu.Unlock()
+				return nil
+			}
+		}
+
+		// --- Depth Limits ---
+		if opts.tooShallow(relPath) {
+			if d.IsDir() {
+				listing = time.Now()
+			}
+			return nil // Walked through, but left out
+		}
+
 		// --- Process File/Directory ---
 		wg.Add(1)
 		go func(currentAbsPath string, currentRelPath string, entry fs.DirEntry) {
 			defer wg.Done()
-			err := bar.Add(1)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "\nscanner: Error updating progress bar: %v\n", err)
-			}
+			opts.advance()
 
+			start := time.Now()
 			info, err := entry.Info()
+			opts.ops.record(summary.OpStat, start, 0)
 			if err != nil {
 				// Log error getting file info, but continue
-				fmt.Fprintf(os.Stderr, "\nWarning: Could not get info for %s: %v\n", currentAbsPath, err)
+				log.Warn("Could not get info for", "path", currentAbsPath, "err", err)
+				counts.skip()
 				return // Skip this item
 			}
 
@@ -109,8 +206,15 @@ func scanDirectory(dirPath string, rootPath string, ignoreMatcher *ignore.Matche
 
 		}(absPath, relPath, d) // Pass copies of loop variables
 
+		if d.IsDir() {
+			if opts.atMaxDepth(relPath) {
+				return filepath.SkipDir // Keep the directory, not its contents
+			}
+			listing = time.Now()
+		}
 		return nil // Continue walking
 	})
+	listed()
 
 	// Wait for all goroutines launched inside WalkDir to finish
 	wg.Wait()
@@ -118,12 +222,59 @@ func scanDirectory(dirPath string, rootPath string, ignoreMatcher *ignore.Matche
 
 	// Check for the first error reported during path calculation or walking
 	if walkErr != nil {
-		return nil, fmt.Errorf("error during directory walk for %s: %w", description, walkErr)
+		return nil, fmt.Errorf("error during directory walk for %s: %w", opts.description, walkErr)
 	}
 	if err := <-errChan; err != nil {
-		return nil, fmt.Errorf("error during file processing for %s: %w", description, err)
+		return nil, fmt.Errorf("error during file processing for %s: %w", opts.description, err)
 	}
-
-	fmt.Fprintf(os.Stderr, "\nFinished scanning %s. Found %d items.\n", description, len(results))
 	return results, nil
 }
+
+// newScanBar creates the spinner shown while scanning.
+// We don't know the total number of files beforehand easily without a full walk first.
+func newScanBar(description string) *progress.Progress {
+	return progress.NewSpinner(i18n.T("progress.scanning", RoleName(description)))
+}
+
+// lostAndFound is the directory fsck keeps at the top of ext* filesystems.
+const lostAndFound = "lost+found"
+
+// skip reports whether a scanned entry must be left out of the results: see
+// skipScanEntry, the directories holding partial files and the trash.
+func (opts scanOptions) skip(relPath string, isDir bool) bool {
+	if isDir && opts.partialDir != "" && filepath.Base(relPath) == opts.partialDir {
+		return true
+	}
+	// Trash directories at the top of a target mount (see --delete-to-trash)
+	// belong to the system, not to the tree being synced
+	if opts.trashDirs && isDir && relPath == filepath.Base(relPath) && trash.IsTrashDir(relPath) {
+		return true
+	}
+	return skipScanEntry(relPath, isDir, opts.matcher, opts.logger())
+}
+
+// skipScanEntry reports whether a scanned entry must be left out of the results.
+func skipScanEntry(relPath string, isDir bool, ignoreMatcher *ignore.Matcher, log *slog.Logger) bool {
+	// Always ignore the .sync-ignore file itself
+	if filepath.Base(relPath) == ignore.IgnoreFileName {
+		return true
+	}
+	// So are the record of sanitized names and the spec at the top of a target
+	if relPath == NamesFileName || relPath == ignore.SpecFileName {
+		return true
+	}
+	// So does lost+found at the top of ext* filesystems, e.g. of --image
+	if isDir && relPath == lostAndFound {
+		return true
+	}
+	// Check against compiled patterns. The contents of an ignored directory
+	// are skipped with it, without being matched
+	if ignoreMatcher == nil {
+		return false
+	}
+	if isDir && ignoreMatcher.MatchesDir(relPath) || !isDir && ignoreMatcher.MatchesFile(relPath) {
+		log.Info("Ignoring", "path", relPath)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+// pkg/syncer/scanstats.go
+package syncer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// largestFilesShown is how many of the biggest files ScanStats lists.
+const largestFilesShown = 5
+
+// ScanStats summarizes the scan of one root.
+type ScanStats struct {
+	Description string // "source", "target", "source 2", ...
+	Root        string
+	Files       int
+	Dirs        int
+	Symlinks    int
+	Bytes       int64
+	Largest     []LargestFile
+	Ignored     int64 // Entries excluded by ignore rules (directories count once)
+	Skipped     int64 // Entries that could not be read
+}
+
+// LargestFile is one entry of ScanStats.Largest.
+type LargestFile struct {
+	RelPath string
+	Size    int64
+}
+
+// scanCounts collects what a scan leaves out of its results. A nil
+// *scanCounts counts nothing.
+type scanCounts struct {
+	ignored atomic.Int64
+	skipped atomic.Int64
+}
+
+func (c *scanCounts) ignore() {
+	if c != nil {
+		c.ignored.Add(1)
+	}
+}
+
+func (c *scanCounts) skip() {
+	if c != nil {
+		c.skipped.Add(1)
+	}
+}
+
+// scanStatsLog gathers the stats of the scans of one run, which may happen
+// concurrently.
+type scanStatsLog struct {
+	mu    sync.Mutex
+	stats []ScanStats
+}
+
+func (l *scanStatsLog) add(stats ScanStats) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stats = append(l.stats, stats)
+}
+
+// sorted returns the stats ordered by description.
+func (l *scanStatsLog) sorted() []ScanStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := append([]ScanStats(nil), l.stats...)
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Description < stats[j].Description })
+	return stats
+}
+
+// newScanStats summarizes the results of a scan.
+func newScanStats(description, root string, files map[string]*fileinfo.FileInfo, counts *scanCounts) ScanStats {
+	stats := ScanStats{Description: description, Root: root}
+	if counts != nil {
+		stats.Ignored = counts.ignored.Load()
+		stats.Skipped = counts.skipped.Load()
+	}
+	for relPath, fi := range files {
+		switch {
+		case fi.IsDir:
+			stats.Dirs++
+		case fi.IsSymlink():
+			stats.Symlinks++
+		default:
+			stats.Files++
+			stats.Bytes += fi.Size
+			stats.Largest = append(stats.Largest, LargestFile{RelPath: relPath, Size: fi.Size})
+		}
+	}
+	sort.Slice(stats.Largest, func(i, j int) bool {
+		if stats.Largest[i].Size != stats.Largest[j].Size {
+			return stats.Largest[i].Size > stats.Largest[j].Size
+		}
+		return stats.Largest[i].RelPath < stats.Largest[j].RelPath
+	})
+	if len(stats.Largest) > largestFilesShown {
+		stats.Largest = stats.Largest[:largestFilesShown]
+	}
+	return stats
+}
+
+// RoleName returns the description of a scanned root ("source", "target",
+// "source 2", ...) in the current language.
+func RoleName(description string) string {
+	role, n, numbered := strings.Cut(description, " ")
+	if numbered {
+		return i18n.T("role."+role+"_n", n)
+	}
+	return i18n.T("role." + role)
+}
+
+// printScanStats prints the summary of each scanned root on stderr, with
+// how the run is going, so stdout stays free for the plan and the report.
+func (s *Syncer) printScanStats(stats []ScanStats) {
+	for _, st := range stats {
+		fmt.Fprintln(s.stderr(), "\n"+i18n.T("scan.stats", RoleName(st.Description), st.Root, st.Files, st.Dirs, st.Symlinks, summary.FormatBytes(st.Bytes)))
+		if st.Ignored > 0 || st.Skipped > 0 {
+			fmt.Fprintln(s.stderr(), "  "+i18n.T("scan.ignored", st.Ignored, st.Skipped))
+		}
+		for _, f := range st.Largest {
+			fmt.Fprintf(s.stderr(), "  %10s  %s\n", summary.FormatBytes(f.Size), f.RelPath)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// pkg/syncer/schedule.go
+package syncer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// actionGraph is a dependency graph over plan actions. An action only becomes
+// runnable once every action it depends on has finished, which lets the
+// executor run everything else in parallel instead of relying on the order of
+// the plan.
+//
+// Dependencies:
+//   - Anything at or below a renamed path waits for that rename.
+//   - An Add/Update waits for a Delete of the same path (type change) or of
+//     any directory above it, and for the Add of its parent directory.
+//   - A directory Delete waits for Deletes of anything below it.
+//   - With serializeDirs, actions in the same directory wait for each other
+//     in plan order.
+//   - With deletesFirst, every Add/Update waits for all Deletes, through a
+//     barrier node after the actions that stands between the two.
+//
+// Every dependency points forward in plan order (see createSyncPlan), where
+// Deletes come before Adds and Updates, so the graph has no cycles.
+type actionGraph struct {
+	actions    []SyncAction
+	dependents [][]int // dependents[i] lists actions waiting on action i
+	pending    []int   // pending[i] is the number of unfinished dependencies of i
+}
+
+// buildActionGraph computes the dependencies between actions. serializeDirs
+// runs the actions of each directory one at a time, for filesystems that
+// misbehave under concurrent changes to one directory. deletesFirst frees
+// space before anything is copied, for targets near their quota.
+func buildActionGraph(actions []SyncAction, serializeDirs, deletesFirst bool) *actionGraph {
+	nodes := len(actions)
+	if deletesFirst {
+		nodes++ // The barrier
+	}
+	g := &actionGraph{
+		actions:    actions,
+		dependents: make([][]int, nodes),
+		pending:    make([]int, nodes),
+	}
+
+	addIdx := make(map[string]int)
+	deleteIdx := make(map[string]int)
+	renameIdx := make(map[string]int)
+	for i, action := range actions {
+		switch action.Type {
+		case Add, Update:
+			addIdx[action.RelPath] = i
+		case Delete:
+			deleteIdx[action.RelPath] = i
+		case Rename:
+			renameIdx[action.RelPath] = i
+		}
+	}
+
+	for i, action := range actions {
+		// Renames of this path's ancestors (and of the path itself, unless this
+		// is the rename) must happen first.
+		for _, p := range ancestors(action.RelPath, action.Type != Rename) {
+			if j, ok := renameIdx[p]; ok {
+				g.addEdge(j, i)
+			}
+		}
+
+		switch action.Type {
+		case Add, Update:
+			// A Delete of the path or of a directory above it, e.g. replaced
+			// by a file, must be done before anything is written there
+			for _, p := range ancestors(action.RelPath, true) {
+				if j, ok := deleteIdx[p]; ok {
+					g.addEdge(j, i)
+				}
+			}
+			if j, ok := addIdx[filepath.Dir(action.RelPath)]; ok && actions[j].Type == Add {
+				g.addEdge(j, i)
+			}
+		case Delete:
+			for _, p := range ancestors(action.RelPath, false) {
+				if j, ok := deleteIdx[p]; ok {
+					g.addEdge(i, j) // The parent delete waits for this one
+				}
+			}
+		}
+	}
+
+	if serializeDirs {
+		last := make(map[string]int) // Latest action seen in each directory
+		for i, action := range actions {
+			dir := filepath.Dir(action.RelPath)
+			if j, ok := last[dir]; ok {
+				g.addEdge(j, i)
+			}
+			last[dir] = i
+		}
+	}
+
+	if deletesFirst {
+		barrier := len(actions)
+		for i, action := range actions {
+			switch action.Type {
+			case Delete:
+				g.addEdge(i, barrier)
+			case Add, Update:
+				g.addEdge(barrier, i)
+			}
+		}
+	}
+	return g
+}
+
+// addEdge records that action `to` depends on action `from`.
+func (g *actionGraph) addEdge(from, to int) {
+	g.dependents[from] = append(g.dependents[from], to)
+	g.pending[to]++
+}
+
+// run executes every action with up to `workers` of them in flight at once
+// (at least one). If an action fails, everything that (transitively) depends
+// on it is skipped and reported as an error too; only ordering passes through
+// the barrier.
+func (g *actionGraph) run(workers int, apply func(SyncAction) error) []error {
+	total := len(g.pending)
+	if total == 0 {
+		return nil
+	}
+	workers = max(workers, 1) // With none, nothing would run and nothing fail
+
+	ready := make(chan int, total) // Every action is queued exactly once
+	for i := range g.pending {
+		if g.pending[i] == 0 {
+			ready <- i
+		}
+	}
+
+	var (
+		mu        sync.Mutex
+		errs      []error
+		failed    = make([]bool, total)
+		remaining = total
+		wg        sync.WaitGroup
+	)
+
+	// finish marks action i as done and queues dependents that became runnable.
+	// Must be called with mu held.
+	finish := func(i int) {
+		remaining--
+		for _, dep := range g.dependents[i] {
+			if failed[i] && dep < len(g.actions) {
+				failed[dep] = true
+			}
+			g.pending[dep]--
+			if g.pending[dep] == 0 {
+				ready <- dep
+			}
+		}
+		if remaining == 0 {
+			close(ready)
+		}
+	}
+
+	for w := 0; w < min(workers, total); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range ready {
+				mu.Lock()
+				if i == len(g.actions) {
+					finish(i) // The barrier
+					mu.Unlock()
+					continue
+				}
+				skip := failed[i]
+				mu.Unlock()
+
+				var err error
+				if skip {
+					err = fmt.Errorf("skipped %s %s: a prerequisite action failed", strings.ToLower(g.actions[i].Type.String()), g.actions[i].RelPath)
+				} else {
+					err = apply(g.actions[i])
+				}
+
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, err)
+					failed[i] = true
+				}
+				finish(i)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	return errs
+}
+
+// ancestors returns the parent directories of relPath, nearest first,
+// optionally including relPath itself.
+func ancestors(relPath string, includeSelf bool) []string {
+	var result []string
+	if includeSelf {
+		result = append(result, relPath)
+	}
+	for parent := filepath.Dir(relPath); parent != "." && parent != string(os.PathSeparator); parent = filepath.Dir(parent) {
+		result = append(result, parent)
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+// pkg/syncer/schedule_test.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// edges lists the dependencies of g as "from->to", sorted.
+func edges(g *actionGraph) []string {
+	var result []string
+	for from, dependents := range g.dependents {
+		for _, to := range dependents {
+			result = append(result, fmt.Sprintf("%d->%d", from, to))
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+func TestBuildActionGraph(t *testing.T) {
+	tests := []struct {
+		name          string
+		actions       []SyncAction
+		serializeDirs bool
+		deletesFirst  bool
+		want          []string
+	}{
+		{
+			name: "independent actions",
+			actions: []SyncAction{
+				{Type: Add, RelPath: "a"},
+				{Type: Update, RelPath: "b"},
+				{Type: Delete, RelPath: "c"},
+			},
+		},
+		{
+			name: "rename before anything below it",
+			actions: []SyncAction{
+				{Type: Rename, RelPath: "new", OldRelPath: "old"},
+				{Type: Add, RelPath: "new/a"},
+				{Type: Update, RelPath: "new/sub/b"},
+				{Type: Rename, RelPath: "new/c", OldRelPath: "new/d"},
+				{Type: Add, RelPath: "newer"},
+			},
+			want: []string{"0->1", "0->2", "0->3"},
+		},
+		{
+			name: "file replaced by a directory",
+			actions: []SyncAction{
+				{Type: Delete, RelPath: "p"},
+				{Type: Add, RelPath: "p"},
+				{Type: Add, RelPath: "p/f"},
+			},
+			want: []string{"0->1", "0->2", "1->2"},
+		},
+		{
+			name: "parent added before its children",
+			actions: []SyncAction{
+				{Type: Add, RelPath: "d"},
+				{Type: Add, RelPath: "d/e"},
+				{Type: Add, RelPath: "d/e/f"},
+				{Type: Update, RelPath: "d/g"},
+			},
+			want: []string{"0->1", "0->3", "1->2"},
+		},
+		{
+			name: "an updated parent is not waited for",
+			actions: []SyncAction{
+				{Type: Update, RelPath: "d"},
+				{Type: Add, RelPath: "d/f"},
+			},
+		},
+		{
+			name: "children deleted before their parent",
+			actions: []SyncAction{
+				{Type: Delete, RelPath: "d/e/f"},
+				{Type: Delete, RelPath: "d/e"},
+				{Type: Delete, RelPath: "d"},
+				{Type: Delete, RelPath: "x/y"},
+			},
+			want: []string{"0->1", "0->2", "1->2"},
+		},
+		{
+			name: "actions of a directory serialized",
+			actions: []SyncAction{
+				{Type: Add, RelPath: "d/a"},
+				{Type: Add, RelPath: "e/b"},
+				{Type: Delete, RelPath: "d/c"},
+				{Type: Update, RelPath: "d/e"},
+			},
+			serializeDirs: true,
+			want:          []string{"0->2", "2->3"},
+		},
+		{
+			name: "deletes first through the barrier",
+			actions: []SyncAction{
+				{Type: Delete, RelPath: "old"},
+				{Type: Delete, RelPath: "older"},
+				{Type: Add, RelPath: "new"},
+				{Type: Update, RelPath: "changed"},
+				{Type: Rename, RelPath: "to", OldRelPath: "from"},
+			},
+			deletesFirst: true,
+			want:         []string{"0->5", "1->5", "5->2", "5->3"},
+		},
+	}
+	for _, tt := range tests {
+		g := buildActionGraph(tt.actions, tt.serializeDirs, tt.deletesFirst)
+		if got := edges(g); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: edges %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// actionKey tells apart the actions of schedulePlan, where a path can be
+// both deleted and added.
+func actionKey(action SyncAction) string {
+	return action.Type.String() + " " + action.RelPath
+}
+
+// A plan touching every kind of dependency at once.
+var schedulePlan = []SyncAction{
+	{Type: Delete, RelPath: "gone/sub/file"},
+	{Type: Delete, RelPath: "gone/sub"},
+	{Type: Delete, RelPath: "gone"},
+	{Type: Delete, RelPath: "swap"},
+	{Type: Rename, RelPath: "moved", OldRelPath: "orig"},
+	{Type: Add, RelPath: "swap"},
+	{Type: Add, RelPath: "swap/a"},
+	{Type: Add, RelPath: "swap/b"},
+	{Type: Update, RelPath: "moved/c"},
+	{Type: Add, RelPath: "fresh"},
+	{Type: Add, RelPath: "fresh/deep"},
+	{Type: Add, RelPath: "fresh/deep/d"},
+	{Type: Update, RelPath: "top"},
+}
+
+func TestActionGraphRunOrder(t *testing.T) {
+	for _, workers := range []int{0, 1, 4, 16} {
+		for _, flags := range [][2]bool{{false, false}, {true, false}, {false, true}, {true, true}} {
+			g := buildActionGraph(schedulePlan, flags[0], flags[1])
+			deps := edges(g)
+
+			var mu sync.Mutex
+			seq := 0
+			started := make(map[string]int)
+			finished := make(map[string]int)
+			errs := g.run(workers, func(action SyncAction) error {
+				mu.Lock()
+				seq++
+				started[actionKey(action)] = seq
+				mu.Unlock()
+				runtime.Gosched() // Give actions started too early a chance to show
+				mu.Lock()
+				seq++
+				finished[actionKey(action)] = seq
+				mu.Unlock()
+				return nil
+			})
+			if len(errs) > 0 {
+				t.Errorf("workers %d, flags %v: errors %v", workers, flags, errs)
+			}
+			if len(finished) != len(schedulePlan) {
+				t.Errorf("workers %d, flags %v: ran %d actions, want %d", workers, flags, len(finished), len(schedulePlan))
+				continue
+			}
+			key := func(i int) string { return actionKey(schedulePlan[i]) }
+			for _, edge := range deps {
+				var from, to int
+				fmt.Sscanf(edge, "%d->%d", &from, &to)
+				if from == len(schedulePlan) || to == len(schedulePlan) {
+					continue // The barrier; checked below
+				}
+				if finished[key(from)] > started[key(to)] {
+					t.Errorf("workers %d, flags %v: %s started before %s finished", workers, flags, key(to), key(from))
+				}
+			}
+			if flags[1] {
+				for i, a := range schedulePlan {
+					for j, b := range schedulePlan {
+						if a.Type == Delete && (b.Type == Add || b.Type == Update) && finished[key(i)] > started[key(j)] {
+							t.Errorf("workers %d: %s started before the delete of %s finished", workers, b.RelPath, a.RelPath)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// An action that fails takes everything depending on it along, but nothing
+// else; a failed delete only orders the writes behind the barrier.
+func TestActionGraphRunFailure(t *testing.T) {
+	actions := []SyncAction{
+		{Type: Delete, RelPath: "stale"},
+		{Type: Add, RelPath: "d"},
+		{Type: Add, RelPath: "d/e"},
+		{Type: Add, RelPath: "d/e/f"},
+		{Type: Add, RelPath: "other"},
+	}
+	fail := map[string]bool{"stale": true, "d": true}
+	var mu sync.Mutex
+	var applied []string
+	errs := buildActionGraph(actions, false, true).run(4, func(action SyncAction) error {
+		if fail[action.RelPath] {
+			return errors.New("failed " + action.RelPath)
+		}
+		mu.Lock()
+		applied = append(applied, action.RelPath)
+		mu.Unlock()
+		return nil
+	})
+
+	if !slices.Equal(applied, []string{"other"}) {
+		t.Errorf("applied %v, want [other]", applied)
+	}
+	var skipped []string
+	for _, err := range errs {
+		if strings.HasPrefix(err.Error(), "skipped ") {
+			skipped = append(skipped, err.Error())
+		}
+	}
+	slices.Sort(skipped)
+	want := []string{
+		"skipped add d/e/f: a prerequisite action failed",
+		"skipped add d/e: a prerequisite action failed",
+	}
+	if len(errs) != 4 || !slices.Equal(skipped, want) {
+		t.Errorf("errors %v, want the two failures and %v", errs, want)
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	tests := []struct {
+		path        string
+		includeSelf bool
+		want        []string
+	}{
+		{"a", false, nil},
+		{"a", true, []string{"a"}},
+		{"a/b/c", false, []string{"a/b", "a"}},
+		{"a/b/c", true, []string{"a/b/c", "a/b", "a"}},
+	}
+	for _, tt := range tests {
+		if got := ancestors(tt.path, tt.includeSelf); !slices.Equal(got, tt.want) {
+			t.Errorf("ancestors(%q, %v) = %v, want %v", tt.path, tt.includeSelf, got, tt.want)
+		}
+	}
+}
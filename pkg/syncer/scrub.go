@@ -0,0 +1,159 @@
+// pkg/syncer/scrub.go
+package syncer
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/progress"
+	"github.com/jeepinbird/sync-dir/pkg/state"
+)
+
+// DamagedFile is a target file whose content changed although its size and
+// mtime didn't, which is what silent corruption looks like.
+type DamagedFile struct {
+	RelPath  string
+	Size     int64
+	Expected string // Checksum recorded earlier
+	Actual   string // Checksum of the current content
+}
+
+// ScrubReport is the result of verifying a target against its known checksums.
+type ScrubReport struct {
+	Checked   int           // Files hashed
+	Verified  int           // Files whose checksum matched a known one
+	Baselined int           // Files without a known checksum, recorded for next time
+	Modified  []string      // Files whose size or mtime changed (legitimate edits)
+	Missing   []string      // Files in the manifest that no longer exist
+	Damaged   []DamagedFile // Files that fail verification
+	Errors    []string      // Files that could not be read
+}
+
+// Scrub hashes every file in targetRoot and compares it with the checksums
+// known for that file at the same size and mtime: those recorded by earlier
+// scrubs, those cached by syncs of any pair writing into targetRoot and those
+// stored in the files' own xattr markers. The source isn't needed. Files seen
+// for the first time are added to the target's manifest so later scrubs can
+// verify them. limit caps the hashing done.
+func Scrub(targetRoot string, limit HashCPULimit, logger *slog.Logger) (*ScrubReport, error) {
+	target, err := state.OpenTarget("", targetRoot)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := target.LoadManifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scrub manifest: %w", err)
+	}
+	pairs, err := state.PairsForTarget(targetRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	var caches []*state.ChecksumCache
+	for _, pair := range pairs {
+		caches = append(caches, pair.LoadChecksums())
+	}
+
+	scanBar := newScanBar("target")
+	files, err := scanDirectory(targetRoot, targetRoot, scanOptions{description: "target", log: logger, found: func() { scanBar.Add(1) }})
+	scanBar.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect what each file is expected to hash to before reading anything
+	type job struct {
+		fi       *fileinfo.FileInfo
+		expected string
+	}
+	var jobs []job
+	var totalSize int64
+	report := &ScrubReport{}
+	for relPath, fi := range files {
+		if fi.IsDir {
+			continue
+		}
+		j := job{fi: fi}
+		if entry, ok := manifest[relPath]; ok && entry.Size == fi.Size && entry.ModTime.Equal(fi.ModTime) {
+			j.expected = entry.Sum
+		} else if ok {
+			report.Modified = append(report.Modified, relPath)
+		}
+		if j.expected == "" {
+			j.expected, _ = markedSum(fi.AbsPath, fi.ModTime) // See XattrMarkers
+		}
+		for _, cache := range caches {
+			if j.expected != "" {
+				break
+			}
+			if sum, ok := cache.Get(fi.AbsPath, fi.Size, fi.ModTime); ok {
+				j.expected = sum
+			}
+		}
+		jobs = append(jobs, j)
+		totalSize += fi.Size
+	}
+	for relPath := range manifest {
+		if fi, ok := files[relPath]; !ok || fi.IsDir {
+			report.Missing = append(report.Missing, relPath)
+			delete(manifest, relPath)
+		}
+	}
+
+	bar := progress.NewBytes(i18n.T("progress.verifying"), totalSize)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, limit.workers(maxConcurrentOps))
+	for _, j := range jobs {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(j job) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			limit.enter()
+
+			bar.Current(j.fi.RelPath)
+			sum, err := fileinfo.SHA256(j.fi.AbsPath)
+			mu.Lock()
+			defer mu.Unlock()
+			bar.Add64(j.fi.Size)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", j.fi.RelPath, err))
+				return
+			}
+			report.Checked++
+			switch {
+			case j.expected == "":
+				report.Baselined++
+			case j.expected == sum:
+				report.Verified++
+			default:
+				report.Damaged = append(report.Damaged, DamagedFile{
+					RelPath:  j.fi.RelPath,
+					Size:     j.fi.Size,
+					Expected: j.expected,
+					Actual:   sum,
+				})
+				return // Keep the good checksum so the file is flagged until repaired
+			}
+			manifest[j.fi.RelPath] = state.ManifestEntry{Size: j.fi.Size, ModTime: j.fi.ModTime, Sum: sum, Verified: time.Now()}
+		}(j)
+	}
+	wg.Wait()
+	bar.Finish()
+
+	if err := target.SaveManifest(manifest); err != nil {
+		return nil, fmt.Errorf("failed to save scrub manifest: %w", err)
+	}
+
+	sort.Strings(report.Modified)
+	sort.Strings(report.Missing)
+	sort.Strings(report.Errors)
+	sort.Slice(report.Damaged, func(i, j int) bool { return report.Damaged[i].RelPath < report.Damaged[j].RelPath })
+	return report, nil
+}
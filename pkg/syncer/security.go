@@ -0,0 +1,96 @@
+// pkg/syncer/security.go
+package syncer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/seclabel"
+)
+
+// readSecurity fills in the SELinux contexts (with SELinux) and file
+// capabilities (with Caps) of scanned local items. Items whose attributes
+// can't be read are left without them and counted in a warning.
+func (s *Syncer) readSecurity(files map[string]*fileinfo.FileInfo) {
+	if !s.SELinux && !s.Caps {
+		return
+	}
+	failed := 0
+	var firstErr error
+	for relPath, fi := range files {
+		var err error
+		if s.SELinux {
+			fi.SELinux, err = seclabel.Label(fi.AbsPath)
+		}
+		if s.Caps && fi.Mode.IsRegular() && err == nil {
+			fi.Caps, err = seclabel.Caps(fi.AbsPath)
+		}
+		if err != nil {
+			if failed++; firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", relPath, err)
+			}
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(s.stderr(), "Warning: Could not read the security attributes of %d items (first failure: %v)\n", failed, firstErr)
+	}
+}
+
+// restoreSecurity gives the target's items the SELinux contexts and file
+// capabilities of their source items. Capabilities the source file doesn't
+// have are removed; a source item without a context leaves the target's
+// alone. Items whose attributes already match aren't touched.
+func (s *Syncer) restoreSecurity() {
+	if s.remote != nil {
+		return // A published tree carries no security attributes
+	}
+	labeled, capped, failed := 0, 0, 0
+	var firstErr error
+	fail := func(relPath string, err error) {
+		if failed++; firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", relPath, err)
+		}
+	}
+	for relPath, src := range s.sourceFiles {
+		targetPath := filepath.Join(s.TargetRoot, relPath)
+		info, err := os.Lstat(targetPath)
+		if err != nil || info.Mode().Type() != src.Mode.Type() {
+			continue // Not synced (e.g. declined or failed)
+		}
+		if s.SELinux && src.SELinux != "" {
+			if label, err := seclabel.Label(targetPath); err != nil {
+				fail(relPath, err)
+			} else if label != src.SELinux {
+				if err := seclabel.SetLabel(targetPath, src.SELinux); err != nil {
+					fail(relPath, err)
+				} else {
+					labeled++
+				}
+			}
+		}
+		if s.Caps && src.Mode.IsRegular() {
+			if caps, err := seclabel.Caps(targetPath); err != nil {
+				fail(relPath, err)
+			} else if !bytes.Equal(caps, src.Caps) {
+				if err := seclabel.SetCaps(targetPath, src.Caps); err != nil {
+					fail(relPath, err)
+				} else {
+					capped++
+				}
+			}
+		}
+	}
+	if labeled > 0 {
+		fmt.Fprintln(s.stdout(), i18n.N("security.labeled", labeled, labeled))
+	}
+	if capped > 0 {
+		fmt.Fprintln(s.stdout(), i18n.N("security.capped", capped, capped))
+	}
+	if failed > 0 {
+		fmt.Fprintf(s.stderr(), "Warning: Could not replicate the security attributes of %d items (first failure: %v)\n", failed, firstErr)
+	}
+}
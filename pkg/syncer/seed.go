@@ -0,0 +1,193 @@
+// pkg/syncer/seed.go
+package syncer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jeepinbird/sync-dir/pkg/compare"
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/state"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// seedIndex finds files in SeedDir with the content of the source files the
+// plan copies, so they can be taken from there instead of from a slow source.
+//
+// A seed file at the same relative path with the same size and mtime is used
+// as is, like the planner's quick check. Any seed file of the same size is
+// used if its checksum equals the source file's, but only when that is known
+// without reading the source (from the checksum cache of earlier runs).
+type seedIndex struct {
+	root      string
+	bySize    map[int64][]string   // Seed files by size, absolute paths
+	checksums *state.ChecksumCache // Source checksums, and seed ones once computed (nil without state)
+	link      bool                 // The seed is on the target's filesystem
+	mu        sync.Mutex
+	seeded    int   // Files taken from the seed
+	linked    int   // Of which hard linked
+	bytes     int64 // Bytes not read from the source
+}
+
+// seedMatch is a seed file with the content of a source file.
+type seedMatch struct {
+	path string
+	info os.FileInfo
+}
+
+// newSeedIndex indexes the files in s.SeedDir with the size of a file the
+// plan copies. It returns nil without a seed directory or anything to copy.
+func (s *Syncer) newSeedIndex(plan *SyncPlan) (*seedIndex, error) {
+	if s.SeedDir == "" {
+		return nil, nil
+	}
+	sizes := make(map[int64]bool)
+	for _, action := range plan.Actions {
+		if (action.Type == Add || action.Type == Update) && !action.SourceInfo.IsDir {
+			sizes[action.SourceInfo.Size] = true
+		}
+	}
+	if len(sizes) == 0 {
+		return nil, nil
+	}
+
+	index := &seedIndex{root: s.SeedDir, bySize: make(map[int64][]string), checksums: s.checksums}
+	err := filepath.WalkDir(s.SeedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Error accessing seed %s: %v\n", path, err)
+			if d != nil && d.IsDir() && path != s.SeedDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // Gone since it was listed
+		}
+		if sizes[info.Size()] {
+			index.bySize[info.Size()] = append(index.bySize[info.Size()], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan seed directory %s: %w", s.SeedDir, err)
+	}
+	seedDev, ok1 := deviceID(s.SeedDir)
+	targetDev, ok2 := deviceID(fileinfo.ExistingAncestor(s.TargetRoot))
+	index.link = ok1 && ok2 && seedDev == targetDev
+	return index, nil
+}
+
+// find returns a seed file with the content of the action's source file.
+func (x *seedIndex) find(action SyncAction) (seedMatch, bool) {
+	if x == nil {
+		return seedMatch{}, false
+	}
+	source := action.SourceInfo
+	samePath := filepath.Join(x.root, action.RelPath)
+	if info, err := os.Stat(samePath); err == nil && info.Mode().IsRegular() &&
+		info.Size() == source.Size && compare.SameModTime(info.ModTime(), source.ModTime, 0) {
+		return seedMatch{path: samePath, info: info}, true
+	}
+
+	if x.checksums == nil {
+		return seedMatch{}, false
+	}
+	sum, ok := x.checksums.Get(source.AbsPath, source.Size, source.ModTime)
+	if !ok {
+		return seedMatch{}, false
+	}
+	for _, path := range x.bySize[source.Size] {
+		info, err := os.Stat(path)
+		if err != nil || info.Size() != source.Size {
+			continue
+		}
+		seedSum, ok := x.checksums.Get(path, info.Size(), info.ModTime())
+		if !ok {
+			if seedSum, err = fileinfo.SHA256(path); err != nil {
+				continue
+			}
+			x.checksums.Put(path, info.Size(), info.ModTime(), seedSum)
+		}
+		if seedSum == sum {
+			return seedMatch{path: path, info: info}, true
+		}
+	}
+	return seedMatch{}, false
+}
+
+// taken counts a file taken from the seed.
+func (x *seedIndex) taken(size int64, linked bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.seeded++
+	x.bytes += size
+	if linked {
+		x.linked++
+	}
+}
+
+// copySeed puts a seed file in place of the action's source file, hard
+// linked if possible (see canLink) and copied otherwise.
+func (e *executor) copySeed(seed seedMatch, act SyncAction, targetPath string) error {
+	size := act.SourceInfo.Size
+	if e.canLink(seed, act) {
+		if err := e.linkSeed(seed.path, targetPath); err != nil {
+			return err
+		}
+		e.live.addBytes(size) // Done without copying anything
+		e.seeds.taken(size, true)
+		return nil
+	}
+	if err := e.copyFile(seed.path, targetPath, act.SourceInfo.Mode.Perm(), act.SourceInfo.ModTime); err != nil {
+		return err
+	}
+	e.seeds.taken(size, false)
+	return nil
+}
+
+// canLink reports whether the target file can be a hard link to seed: it
+// is on the target's filesystem and already has the source's mode and
+// mtime, so nothing needs to be changed on the shared inode.
+func (e *executor) canLink(seed seedMatch, act SyncAction) bool {
+	return e.seeds.link && !e.markers &&
+		seed.info.Mode().Perm() == e.quirks.fileMode(act.SourceInfo.Mode.Perm(), false) &&
+		compare.SameModTime(seed.info.ModTime(), act.SourceInfo.ModTime, 0)
+}
+
+// linkSeed hard links dst to a seed file, under a temp name first that is
+// then renamed over dst, so dst never goes missing.
+func (e *executor) linkSeed(seed, dst string) error {
+	tempPath, err := tempName(filepath.Dir(dst))
+	if err != nil {
+		return err
+	}
+	if e.journal != nil {
+		if err := e.journal.Record("temp", tempPath); err != nil {
+			fmt.Fprintf(e.stderr, "\nWarning: Could not record temp file in journal: %v\n", err)
+		}
+	}
+	if err := os.Link(seed, tempPath); err != nil {
+		return fmt.Errorf("could not link seed %s: %w", seed, err)
+	}
+	if err := e.quirks.retryBusy(func() error { return os.Rename(tempPath, dst) }); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("could not replace %s: %w", dst, err)
+	}
+	return nil
+}
+
+// printSeedResult prints how much the seed directory provided.
+func (s *Syncer) printSeedResult(x *seedIndex) {
+	if x == nil {
+		return
+	}
+	fmt.Fprintln(s.stdout(), "\n"+i18n.N("seed.result", x.seeded, x.seeded, summary.FormatBytes(x.bytes), x.linked))
+}
@@ -0,0 +1,401 @@
+// pkg/syncer/selftest.go
+package syncer
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// SelfTestOptions configures SelfTest.
+type SelfTestOptions struct {
+	Dir      string // Where the trees are generated, one subdirectory per round
+	Seed     int64  // Seed of the first round; round i uses Seed+i, so a failure can be replayed alone
+	Rounds   int    // Rounds to run
+	MaxItems int    // Upper bound on the entries of a generated source tree
+	Workers  int    // Parallel operations while applying a plan
+	Keep     bool   // Keep the trees of failed rounds for inspection
+}
+
+// SelfTestRound is the outcome of one round of SelfTest.
+type SelfTestRound struct {
+	Seed     int64
+	Dir      string   // Trees of the round (removed unless kept)
+	Actions  int      // Actions of the plan that was applied
+	Problems []string // Failed actions and differences left between the trees
+}
+
+// Passed reports whether the round found nothing wrong.
+func (r SelfTestRound) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+// selfTestNames are the names generated trees are built from. There are few
+// of them so that source and target often collide on a path, and they include
+// prefixes of each other, dots, spaces and non-ASCII letters.
+var selfTestNames = []string{"a", "b", "ab", "a.txt", "a b", "c-1", "é", "ß", "x.y.z", "deep"}
+
+// selfTestEpoch is the mtime generated files are spread around; whole seconds
+// keep them comparable on filesystems with coarse timestamps.
+var selfTestEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// SelfTest checks the planner and the executor against random trees. Each
+// round generates a source tree and a target derived from it by random edits
+// (changed contents and mtimes, files turned into directories and back,
+// removed entries, extra nested subtrees), plans and applies a sync, and
+// asserts that the target then mirrors the source and that planning again
+// finds nothing to do. report is called after every round; the returned error
+// is only about the harness itself, such as a tree it could not generate.
+func SelfTest(opts SelfTestOptions, report func(SelfTestRound)) error {
+	if opts.MaxItems < 1 {
+		opts.MaxItems = 1
+	}
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	for i := 0; i < opts.Rounds; i++ {
+		round, err := selfTestRound(opts, opts.Seed+int64(i))
+		if err != nil {
+			return err
+		}
+		if round.Passed() || !opts.Keep {
+			if err := os.RemoveAll(round.Dir); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", round.Dir, err)
+			}
+		}
+		report(round)
+	}
+	return nil
+}
+
+// selfTestRound generates, syncs and checks the trees of one seed.
+func selfTestRound(opts SelfTestOptions, seed int64) (SelfTestRound, error) {
+	round := SelfTestRound{Seed: seed, Dir: filepath.Join(opts.Dir, fmt.Sprintf("round-%d", seed))}
+	source, target := filepath.Join(round.Dir, "source"), filepath.Join(round.Dir, "target")
+	if err := os.RemoveAll(round.Dir); err != nil {
+		return round, fmt.Errorf("failed to clear %s: %w", round.Dir, err)
+	}
+	if err := os.MkdirAll(source, 0755); err != nil {
+		return round, fmt.Errorf("failed to create %s: %w", source, err)
+	}
+
+	gen := &treeGenerator{rng: rand.New(rand.NewPCG(uint64(seed), 0x5eed))}
+	if err := gen.fill(source, 1+gen.rng.IntN(opts.MaxItems)); err != nil {
+		return round, err
+	}
+	if err := copyTree(source, target); err != nil {
+		return round, err
+	}
+	if err := gen.edit(target, 1+gen.rng.IntN(opts.MaxItems)); err != nil {
+		return round, err
+	}
+
+	// Seeds vary whether each directory's actions are serialized and whether
+	// deletes run first, so every schedule is exercised
+	plan, errs, err := selfTestSync(source, target, opts.Workers, seed%2 != 0, seed%4 >= 2)
+	if err != nil {
+		return round, err
+	}
+	round.Actions = len(plan.Actions)
+	for _, err := range errs {
+		round.Problems = append(round.Problems, err.Error())
+	}
+	differences, err := compareTrees(source, target)
+	if err != nil {
+		return round, err
+	}
+	round.Problems = append(round.Problems, differences...)
+
+	if len(round.Problems) == 0 {
+		// A synced pair must plan nothing; anything left means the plan
+		// and the executor disagree about what was done
+		again, err := selfTestPlan(source, target, 1)
+		if err != nil {
+			return round, err
+		}
+		for _, action := range again.Actions {
+			round.Problems = append(round.Problems, fmt.Sprintf("planned again after the sync: %s %s", action.Type, action.RelPath))
+		}
+	}
+	return round, nil
+}
+
+// selfTestPlan scans both trees and plans their sync, the way a run does
+// without ignore rules, state or output.
+func selfTestPlan(source, target string, workers int) (*SyncPlan, error) {
+	sourceFiles, err := scanDirectory(source, source, scanOptions{description: "source"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", source, err)
+	}
+	targetFiles, err := scanDirectory(target, target, scanOptions{description: "target"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", target, err)
+	}
+	plan, err := createSyncPlan(sourceFiles, targetFiles, planOptions{
+		caseInsensitive: isCaseInsensitive(target),
+		checksum:        fileinfo.SHA256,
+		compareWorkers:  workers,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan: %w", err)
+	}
+	return plan, nil
+}
+
+// selfTestSync plans the sync of source into target and applies it,
+// returning the plan and the errors of failed actions.
+func selfTestSync(source, target string, workers int, serializeDirs, deletesFirst bool) (*SyncPlan, []error, error) {
+	plan, err := selfTestPlan(source, target, workers)
+	if err != nil {
+		return nil, nil, err
+	}
+	throttle := newThrottle()
+	throttle.start(workers, 0)
+	exec := &executor{
+		sourceRoot: source,
+		targetRoot: target,
+		buffers:    newBufferPool(0),
+		live:       newLiveStatus(),
+		throttle:   throttle,
+		observer:   NopObserver{},
+	}
+	return plan, buildActionGraph(plan.Actions, serializeDirs, deletesFirst).run(workers, exec.applyAction), nil
+}
+
+// treeGenerator creates and edits random trees.
+type treeGenerator struct {
+	rng *rand.Rand
+}
+
+// name returns a random entry name.
+func (g *treeGenerator) name() string {
+	return selfTestNames[g.rng.IntN(len(selfTestNames))]
+}
+
+// fill adds up to n random files and directories under root.
+func (g *treeGenerator) fill(root string, n int) error {
+	dirs := []string{root}
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dirs[g.rng.IntN(len(dirs))], g.name())
+		if _, err := os.Lstat(path); err == nil {
+			continue // Taken, possibly by a name differing only in case
+		}
+		if g.rng.IntN(4) == 0 {
+			if err := os.Mkdir(path, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", path, err)
+			}
+			dirs = append(dirs, path)
+			continue
+		}
+		if err := g.writeFile(path, g.size(), g.modTime()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// edit applies n random edits to the tree under root.
+func (g *treeGenerator) edit(root string, n int) error {
+	for i := 0; i < n; i++ {
+		entries, err := listTree(root)
+		if err != nil {
+			return err
+		}
+		path := root
+		if len(entries) > 0 && g.rng.IntN(8) > 0 {
+			path = filepath.Join(root, entries[g.rng.IntN(len(entries))])
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case info.IsDir():
+			switch op := g.rng.IntN(3); {
+			case op == 0 && path != root: // Directory becomes a file
+				if err := os.RemoveAll(path); err != nil {
+					return err
+				}
+				err = g.writeFile(path, g.size(), g.modTime())
+			case op == 1 && path != root: // Directory removed with its subtree
+				err = os.RemoveAll(path)
+			default: // Extra entries, possibly nested directories
+				err = g.fill(path, 1+g.rng.IntN(6))
+			}
+		default:
+			switch g.rng.IntN(5) {
+			case 0: // File becomes a directory, maybe with content
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				if err := os.Mkdir(path, 0755); err != nil {
+					return err
+				}
+				err = g.fill(path, g.rng.IntN(4))
+			case 1: // File removed
+				err = os.Remove(path)
+			case 2: // Same size, other content and mtime
+				err = g.writeFile(path, info.Size(), info.ModTime().Add(time.Duration(1+g.rng.IntN(1000))*time.Second))
+			case 3: // Same content, other mtime
+				err = os.Chtimes(path, info.ModTime(), info.ModTime().Add(-time.Duration(1+g.rng.IntN(1000))*time.Second))
+			default: // Other size
+				err = g.writeFile(path, info.Size()+int64(1+g.rng.IntN(100)), g.modTime())
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to edit %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// size returns a random file size: mostly small, sometimes empty or larger
+// than a copy buffer.
+func (g *treeGenerator) size() int64 {
+	switch g.rng.IntN(10) {
+	case 0:
+		return 0
+	case 1:
+		return int64(DefaultBufferSize + g.rng.IntN(DefaultBufferSize))
+	default:
+		return int64(g.rng.IntN(8192))
+	}
+}
+
+func (g *treeGenerator) modTime() time.Time {
+	return selfTestEpoch.Add(time.Duration(g.rng.IntN(100000)) * time.Second)
+}
+
+// writeFile writes size random bytes to path and sets its mtime.
+func (g *treeGenerator) writeFile(path string, size int64, modTime time.Time) error {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(g.rng.Uint32())
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		return fmt.Errorf("failed to set mtime of %s: %w", path, err)
+	}
+	return nil
+}
+
+// copyTree copies the files and directories under source to target,
+// keeping file mtimes.
+func copyTree(source, target string) error {
+	return filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(target, relPath)
+		if d.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(dest, data, 0644); err != nil {
+			return err
+		}
+		return os.Chtimes(dest, info.ModTime(), info.ModTime())
+	})
+}
+
+// listTree returns the relative paths of all entries under root, sorted.
+func listTree(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root {
+			relPath, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", root, err)
+	}
+	return paths, nil
+}
+
+// compareTrees describes how the tree under target differs from the one
+// under source: missing and extra entries, type and content differences.
+func compareTrees(source, target string) ([]string, error) {
+	sourcePaths, err := listTree(source)
+	if err != nil {
+		return nil, err
+	}
+	targetPaths, err := listTree(target)
+	if err != nil {
+		return nil, err
+	}
+	inSource := make(map[string]bool, len(sourcePaths))
+	for _, relPath := range sourcePaths {
+		inSource[relPath] = true
+	}
+
+	var differences []string
+	for _, relPath := range targetPaths {
+		if !inSource[relPath] {
+			differences = append(differences, "extra in target: "+relPath)
+		}
+	}
+	for _, relPath := range sourcePaths {
+		sourceInfo, err := os.Lstat(filepath.Join(source, relPath))
+		if err != nil {
+			return nil, err
+		}
+		targetInfo, err := os.Lstat(filepath.Join(target, relPath))
+		switch {
+		case err != nil:
+			differences = append(differences, "missing in target: "+relPath)
+		case sourceInfo.IsDir() != targetInfo.IsDir():
+			differences = append(differences, "type differs: "+relPath)
+		case !sourceInfo.IsDir():
+			same, err := sameContent(filepath.Join(source, relPath), filepath.Join(target, relPath))
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				differences = append(differences, "content differs: "+relPath)
+			}
+		}
+	}
+	sort.Strings(differences)
+	return differences, nil
+}
+
+func sameContent(a, b string) (bool, error) {
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(dataA, dataB), nil
+}
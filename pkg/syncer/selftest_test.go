@@ -0,0 +1,24 @@
+// pkg/syncer/selftest_test.go
+package syncer
+
+import "testing"
+
+// TestPlanApplyProperties runs the self test over a fixed set of seeds: each
+// round syncs a random tree into a randomly edited copy and checks that the
+// target then mirrors the source and that nothing is left to plan. The seeds
+// cover every combination of serialized directories and deletes first.
+func TestPlanApplyProperties(t *testing.T) {
+	rounds := 32
+	if testing.Short() {
+		rounds = 8
+	}
+	for seed := int64(1); seed <= int64(rounds); seed++ {
+		round, err := selfTestRound(SelfTestOptions{Dir: t.TempDir(), MaxItems: 40, Workers: 4}, seed)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		for _, problem := range round.Problems {
+			t.Errorf("seed %d (%d actions): %s", seed, round.Actions, problem)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+// pkg/syncer/space_other.go
+//go:build !(linux || darwin || freebsd)
+
+package syncer
+
+// freeSpace is not available on this platform, so only TargetQuota limits
+// the target's usage.
+func freeSpace(path string) (int64, bool) {
+	return 0, false
+}
@@ -0,0 +1,17 @@
+// pkg/syncer/space_statfs.go
+//go:build linux || darwin || freebsd
+
+package syncer
+
+import "syscall"
+
+// freeSpace returns the bytes available to this user on the filesystem
+// holding path. Filesystems that enforce quotas through statfs, such as NFS
+// or XFS project quotas, report what the quota leaves.
+func freeSpace(path string) (int64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, false
+	}
+	return int64(st.Bavail) * int64(st.Bsize), true // Field types differ between platforms
+}
@@ -0,0 +1,105 @@
+// pkg/syncer/spotcheck.go
+package syncer
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+)
+
+// spotCheckConfidence is the confidence of the bound reported when no sampled
+// copy differs.
+const spotCheckConfidence = 0.95
+
+// ParseSpotCheck parses a spot check fraction as used on the command line: a
+// percentage of the copied files such as "1%" or "0.5%".
+func ParseSpotCheck(value string) (float64, error) {
+	number, ok := strings.CutSuffix(strings.TrimSpace(value), "%")
+	percent, err := strconv.ParseFloat(number, 64)
+	if !ok || err != nil || percent <= 0 || percent > 100 {
+		return 0, fmt.Errorf("invalid spot check %q: expected a percentage of the copied files above 0 and up to 100, e.g. 1%%", value)
+	}
+	return percent / 100, nil
+}
+
+// spotCheck re-reads a random sample of SpotCheck of the files the run copied
+// and compares their checksums with the source's. Finding no bad copy among n
+// samples bounds the share of bad copies: with 95% confidence it is below
+// 1-0.05^(1/n), about 3/n. Bad copies are listed and their target mtime reset,
+// so the next sync compares them by content and copies them again.
+func (s *Syncer) spotCheck() error {
+	var files []SyncAction
+	for _, act := range s.copiedFiles {
+		if !s.Transforms.Matches(act.RelPath) { // Transformed copies differ by design
+			files = append(files, act)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	n := int(math.Ceil(float64(len(files)) * s.SpotCheck))
+	rand.Shuffle(len(files), func(i, j int) { files[i], files[j] = files[j], files[i] })
+	sample := files[:min(n, len(files))]
+
+	var total int64
+	for _, act := range sample {
+		total += act.SourceInfo.Size
+	}
+	bar := s.newBytesBar(i18n.T("progress.verifying"), total)
+	hashes := newHashPool(s.HashWorkers, s.HashCPU)
+	sourceSum := s.remote.withRemoteSums(hashes.Sum)
+	var mu sync.Mutex
+	var bad []string
+	var wg sync.WaitGroup
+	for _, act := range sample {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			targetPath := filepath.Join(s.TargetRoot, act.RelPath)
+			want, err := sourceSum(act.SourceInfo.AbsPath)
+			var got string
+			if err == nil {
+				got, err = hashes.Sum(targetPath)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			bar.Add64(act.SourceInfo.Size)
+			switch {
+			case err != nil:
+				bad = append(bad, fmt.Sprintf("%s: %v", act.RelPath, err))
+			case got != want:
+				bad = append(bad, act.RelPath)
+				epoch := time.Unix(0, 0)
+				_ = os.Chtimes(targetPath, epoch, epoch)
+			}
+		}()
+	}
+	wg.Wait()
+	hashes.Close()
+	bar.Finish()
+
+	if len(bad) > 0 {
+		sort.Strings(bad)
+		fmt.Fprintln(s.stderr(), "Error: "+i18n.N("spotcheck.bad", len(bad), len(bad), len(sample)))
+		for _, line := range bad {
+			fmt.Fprintf(s.stderr(), "  %s\n", line)
+		}
+		return fmt.Errorf("spot check: %d of %d sampled copies do not match the source", len(bad), len(sample))
+	}
+	if len(sample) == len(files) {
+		fmt.Fprintln(s.stdout(), i18n.T("spotcheck.all", len(sample)))
+		return nil
+	}
+	bound := 1 - math.Pow(1-spotCheckConfidence, 1/float64(len(sample)))
+	fmt.Fprintln(s.stdout(), i18n.N("spotcheck.sampled", len(sample), len(sample), len(files), fmt.Sprintf("%.2g%%", bound*100)))
+	return nil
+}
@@ -0,0 +1,102 @@
+// pkg/syncer/stage.go
+package syncer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// stagingPath returns the sibling directory a sync of targetRoot is staged
+// in with StageAndSwap.
+func stagingPath(targetRoot string) string {
+	return filepath.Join(filepath.Dir(targetRoot), "."+filepath.Base(targetRoot)+".sync-staging")
+}
+
+// stageTarget clones targetRoot into its staging directory: directories are
+// recreated and everything else is hard linked, so the clone costs no data.
+// Copies replace files by renaming new ones into place, never by writing
+// through the links, so the live target is not changed by syncing into the
+// clone. A staging directory left by an interrupted run is discarded first.
+func stageTarget(targetRoot string) (string, error) {
+	staging := stagingPath(targetRoot)
+	if err := os.RemoveAll(staging); err != nil {
+		return "", fmt.Errorf("could not remove the staging directory of an earlier run: %w", err)
+	}
+	info, err := os.Lstat(targetRoot)
+	if os.IsNotExist(err) {
+		return staging, os.MkdirAll(staging, 0755) // Nothing to clone yet
+	}
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory (a symlink would be replaced by one)", targetRoot)
+	}
+	if parentDev, ok := deviceID(filepath.Dir(targetRoot)); ok {
+		if dev, ok := deviceID(targetRoot); ok && dev != parentDev {
+			return "", fmt.Errorf("%s is a mount point; staging needs the target on the filesystem of its parent directory", targetRoot)
+		}
+	}
+	if err := os.Mkdir(staging, 0700); err != nil {
+		return "", err
+	}
+
+	// The clone's directories are created writable, and get their permissions
+	// and mtimes (which change as they are filled in) once it is complete,
+	// deepest first
+	type clonedDir struct {
+		path    string
+		mode    fs.FileMode
+		modTime time.Time
+	}
+	dirs := []clonedDir{{staging, info.Mode(), info.ModTime()}}
+	err = filepath.WalkDir(targetRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(targetRoot, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		clone := filepath.Join(staging, rel)
+		if !d.IsDir() {
+			return os.Link(path, clone)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		dirs = append(dirs, clonedDir{clone, info.Mode(), info.ModTime()})
+		return os.Mkdir(clone, 0700)
+	})
+	if err != nil {
+		_ = os.RemoveAll(staging)
+		return "", err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].path, dirs[i].mode.Perm()); err != nil {
+			_ = os.RemoveAll(staging)
+			return "", err
+		}
+		_ = os.Chtimes(dirs[i].path, dirs[i].modTime, dirs[i].modTime)
+	}
+	return staging, nil
+}
+
+// swapStaged puts the synced staging directory in place of targetRoot and
+// removes the previous tree.
+func (s *Syncer) swapStaged(staging, targetRoot string) error {
+	if _, err := os.Lstat(targetRoot); os.IsNotExist(err) {
+		return os.Rename(staging, targetRoot)
+	}
+	if err := exchangeDirs(staging, targetRoot); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(staging); err != nil { // Now the previous tree
+		fmt.Fprintf(s.stderr(), "Warning: Could not remove the previous target tree %s: %v\n", staging, err)
+	}
+	return nil
+}
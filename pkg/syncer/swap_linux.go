@@ -0,0 +1,12 @@
+// pkg/syncer/swap_linux.go
+//go:build linux
+
+package syncer
+
+import "golang.org/x/sys/unix"
+
+// exchangeDirs swaps two directories in one atomic rename, so every reader of
+// either path sees one tree or the other.
+func exchangeDirs(a, b string) error {
+	return unix.Renameat2(unix.AT_FDCWD, a, unix.AT_FDCWD, b, unix.RENAME_EXCHANGE)
+}
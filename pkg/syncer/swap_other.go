@@ -0,0 +1,25 @@
+// pkg/syncer/swap_other.go
+//go:build !linux
+
+package syncer
+
+import (
+	"fmt"
+	"os"
+)
+
+// exchangeDirs swaps two directories with three renames: each is atomic, but
+// b is missing for the moment between the second and the third.
+func exchangeDirs(a, b string) error {
+	parked := b + ".sync-swap"
+	if err := os.Rename(b, parked); err != nil {
+		return err
+	}
+	if err := os.Rename(a, b); err != nil {
+		if undoErr := os.Rename(parked, b); undoErr != nil {
+			return fmt.Errorf("%w (and the target could not be moved back from %s: %v)", err, parked, undoErr)
+		}
+		return err
+	}
+	return os.Rename(parked, a)
+}
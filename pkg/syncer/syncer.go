@@ -2,46 +2,321 @@
 package syncer
 
 import (
+	"crypto/ed25519"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
 	"github.com/jeepinbird/sync-dir/pkg/ignore"
+	"github.com/jeepinbird/sync-dir/pkg/manifest"
+	"github.com/jeepinbird/sync-dir/pkg/rules"
+	"github.com/jeepinbird/sync-dir/pkg/state"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+	"github.com/jeepinbird/sync-dir/pkg/transform"
 )
 
 // Syncer orchestrates the directory synchronization process.
 type Syncer struct {
-	SourceRoot    string
-	TargetRoot    string
-	CliExcludes   []string
-	DryRun        bool
-	ignoreMatcher *ignore.Matcher
-	sourceFiles   map[string]*fileinfo.FileInfo
-	targetFiles   map[string]*fileinfo.FileInfo
-	plan          *SyncPlan
+	SourceRoot      string
+	TargetRoot      string
+	CliExcludes     []string
+	DryRun          bool
+	Gitignore       bool                // Also exclude what the source's .gitignore files (root and nested) exclude
+	BufferSize      int                 // Copy buffer size in bytes (DefaultBufferSize if zero)
+	TempDir         string              // Temp files are written here instead of next to their destination ("" = next to it)
+	PartialDir      string              // Copies are written to a directory of this name next to their destination and resumed from there if interrupted ("" = temp files)
+	AppendResume    bool                // Complete target files that hold the start of their source by appending the rest in place
+	SpotCheck       float64             // Share of the copied files verified by checksum after the run, e.g. 0.01 (0 = none)
+	TimesDirs       bool                // After syncing, give the target's directories the mtimes of the source's
+	StageAndSwap    bool                // Apply the plan to a hard-linked clone of the target and swap it into place once complete
+	TargetQuota     int64               // Soft limit in bytes on the size of the target's files (0 = none)
+	SeedDir         string              // Local directory whose files with the content of source files are used instead ("" = none)
+	ManifestKey     ed25519.PublicKey   // Key a URL source's manifest must be signed with (nil = unsigned accepted)
+	Transport       manifest.Transport  // Proxy, CA certificates and client certificate for a URL source
+	SameDiskWorkers int                 // Parallel operations when source and target share a device (0 = automatic)
+	Workers         int                 // Parallel file operations, overriding the automatic choice (0 = automatic)
+	SerializeDirs   bool                // Run the actions in one directory one at a time
+	BandwidthLimit  int64               // Copy bandwidth in bytes per second (0 = unlimited)
+	Incremental     bool                // Reuse cached scan entries of directories whose mtime is unchanged
+	MinDepth        int                 // Leave out entries above this depth, 1 being the roots' entries (0 = none)
+	MaxDepth        int                 // Don't scan below this depth, 1 being the roots' entries (0 = no limit)
+	Follow          []string            // Patterns of symlinks in the source scanned as the directories they point to
+	MergeSources    []MergeSource       // When set, these sources are merged into the target instead of SourceRoot alone
+	Collision       CollisionPolicy     // How paths provided by several merge sources are resolved
+	Mappings        []SubtreeMapping    // Source subtrees synced to their own targets instead of TargetRoot
+	Tiers           []Tier              // Source files synced to their own targets by age or size instead of TargetRoot
+	Targets         []string            // Further targets synced from the same source scan after TargetRoot
+	Transforms      *transform.Pipeline // Content transforms applied while copying (nil = none)
+	Rules           *rules.Rules        // Per-pattern action overrides; compress rules belong in Transforms (nil = none)
+	Quirks          FSQuirks            // Workarounds for the target filesystem (see --fs-quirks)
+	SanitizeNames   bool                // Sync source paths the target's filesystem can't hold under valid names
+	HashWorkers     int                 // Files hashed in parallel while planning (0 = one per CPU)
+	HashCPU         HashCPULimit        // Cap on parallel hashing and the nice level it runs at (zero = none)
+	AssumeYes       bool                // Proceed without asking for confirmation
+	AssertInSync    bool                // List every difference and fail with ErrNotInSync instead of syncing (set DryRun too)
+	ConfirmDeletes  int                 // Ask for confirmation only if the plan deletes more items than this (negative = unset)
+	ConfirmChanges  int                 // Ask for confirmation only if the plan has more actions than this (negative = unset)
+	ChurnWarning    float64             // Flag plans changing more than this share of the target's files, e.g. 0.25 (0 = never)
+	DeleteToTrash   bool                // Move deleted target items to the OS trash instead of removing them
+	AlwaysHash      []string            // Patterns of files compared by checksum even when size and mtime match
+	TypeFilter      *ignore.TypeFilter  // Content types of the source files synced (nil = all)
+	SkipHotDBs      bool                // Leave databases that look in use out of the plan
+	AllowHotDBs     bool                // Copy databases that look in use without warning
+	CheckOpenFiles  bool                // Warn about source files other processes have open for writing (Linux)
+	SkipOpenFiles   bool                // Leave source files other processes have open for writing out of the plan (Linux)
+	XattrMarkers    bool                // Tag copied files with their checksum and source mtime in extended attributes
+	SELinux         bool                // Give target items the SELinux security contexts of their source items (Linux)
+	Caps            bool                // Give target files the file capabilities of their source files (Linux)
+	FakeSuper       bool                // Record owners, permissions and special files of source items in xattrs of the target items instead of applying them
+	FromFakeSuper   bool                // Restore the owners, permissions and special files recorded in the source items' xattrs by FakeSuper (needs root)
+	DedupeTarget    bool                // After syncing, hard link identical files within the target
+	CAS             bool                // Back up into a content-addressed store at TargetRoot instead of mirroring
+	RequireMounted  bool                // Refuse to run unless TargetRoot is a mount point
+	RequireFile     string              // Refuse to run unless this file exists in TargetRoot
+	MinSourceFiles  int                 // Refuse deletions if the source holds fewer files than this (at least 1)
+	Force           bool                // Delete from the target even if the source looks empty
+	MassChange      MassChangeGuard     // What happens when the plan looks like ransomware encrypted the source
+	Logger          *slog.Logger        // Receives what scanning and planning report (nil = nothing)
+	Observer        Observer            // Is told how the run progresses (nil = nobody)
+	Stdout          io.Writer           // Receives the plan and the results (nil = os.Stdout)
+	Stderr          io.Writer           // Receives notes, warnings and the confirmation prompt (nil = os.Stderr)
+	StateDir        string              // Holds the state of pairs and targets ("" = state.BaseDir)
+	PlanView        PlanView            // Which actions are listed with the plan, in what order
+	Explain         []string            // Paths (relative, or absolute in a root) whose planned actions are explained with the plan
+	MemLimit        int64               // Soft cap on memory; the checksum cache is spilled to disk as the heap nears it (0 = none)
+	ignoreMatcher   *ignore.Matcher
+	sourceFiles     map[string]*fileinfo.FileInfo
+	targetFiles     map[string]*fileinfo.FileInfo
+	plan            *SyncPlan
+	state           *state.Pair          // Persistent state of this pair (nil if unavailable)
+	checksums       *state.ChecksumCache // Checksums remembered across runs (nil without state)
+	hashes          *hashPool            // Hashing workers, alive only while planning
+	scanStats       *scanStatsLog        // Stats of this run's scans
+	summary         *summary.Summary     // Outcome of the last Run
+	actionErrors    []string             // Actions that failed during execution
+	bytesCopied     int64                // Bytes written into the target during execution
+	copiedFiles     []SyncAction         // Files copied during execution, collected for SpotCheck
+	ops             *opTimer             // Operations on source and target timed during the run
+	live            *liveStatus          // Progress readable while running (see LiveStatus)
+	pause           *pauser              // Holds workers between actions (see Pause)
+	throttle        *throttle            // Worker and bandwidth limits, adjustable while running
+	journal         *state.Journal       // Journal of the run being executed
+	remote          *remoteSource        // The source when it is published over HTTP(S) (nil = local)
+	sanitized       map[string]string    // Source paths synced under other names, see SanitizeNames
+	tier            *tierSelection       // Derived for a tier; only its files are synced (nil = all)
+	shared          *sharedSource        // Source scan and checksums shared by the targets of a fan-out (nil = none)
+	executed        bool                 // The plan was confirmed and applied
+	nested          bool                 // Derived for a mapped subtree; the parent completes the run
 }
 
 // NewSyncer creates a new Syncer instance.
 func NewSyncer(sourceRoot, targetRoot string, cliExcludes []string, dryRun bool) *Syncer {
 	return &Syncer{
-		SourceRoot:  sourceRoot,
-		TargetRoot:  targetRoot,
-		CliExcludes: cliExcludes,
-		DryRun:      dryRun,
+		SourceRoot:     sourceRoot,
+		TargetRoot:     targetRoot,
+		CliExcludes:    cliExcludes,
+		DryRun:         dryRun,
+		ConfirmDeletes: -1,
+		ConfirmChanges: -1,
+		ChurnWarning:   DefaultChurnWarning,
+		PlanView:       PlanView{Limit: DefaultPlanLimit},
+		live:           newLiveStatus(),
+		pause:          newPauser(),
+		throttle:       newThrottle(),
 	}
 }
 
 // Run executes the entire synchronization process: load ignores, scan, plan, execute.
-func (s *Syncer) Run() error {
+func (s *Syncer) Run() (err error) {
+	start := time.Now()
+	s.summary = &summary.Summary{Source: s.describeSource(), Target: s.TargetRoot, Start: start, DryRun: s.DryRun}
+	s.ops = newOpTimer()
+	if !s.nested {
+		defer func() {
+			s.observer().OnComplete(s.summary, err)
+		}()
+	}
+	if len(s.Mappings) > 0 {
+		defer s.live.setPhase(PhaseDone)
+		return s.runMapped()
+	}
+	if len(s.Tiers) > 0 {
+		defer s.live.setPhase(PhaseDone)
+		return s.runTiered()
+	}
+	if len(s.Targets) > 0 {
+		defer s.live.setPhase(PhaseDone)
+		return s.runFanOut()
+	}
+	defer s.finishSummary(&err)
+	if err = s.checkTargetGuards(); err != nil {
+		return err
+	}
+
+	// 0. Open Pair State (snapshot, journal, checksum cache, history)
+	s.state, err = state.Open(s.StateDir, s.stateKey(), s.TargetRoot)
+	if err != nil {
+		// Syncing still works without it; only status/drift reporting suffers
+		fmt.Fprintf(s.stderr(), "Warning: Sync state unavailable: %v\n", err)
+	} else {
+		s.checksums = s.state.LoadChecksums()
+		defer s.recordRun(start, &err)
+		defer s.watchMemory()()
+	}
+	if s.CAS {
+		return s.backup()
+	}
+
+	// 1. Load Ignore Rules and 2. Scan Source and Target
+	if err = s.scanRoots(); err != nil {
+		return err
+	}
+
+	// 3. Create Sync Plan
+	if err = s.buildPlan(); err != nil {
+		return err
+	}
+	if err = s.checkSourceSanity(s.plan); err != nil {
+		return err
+	}
+	if err = s.checkMassChange(s.plan); err != nil {
+		return err
+	}
+	s.checkHotDatabases(s.plan)
+	s.checkOpenFiles(s.plan)
+	if s.AssertInSync {
+		return s.assertInSync(s.plan)
+	}
+
+	// 4. Execute Plan (includes confirmation)
+	err = s.executePlan(s.plan)
+	if s.Incremental && !s.DryRun && len(s.plan.Actions) > 0 {
+		// In-place updates don't change directory mtimes, so the cached target
+		// scan can't be trusted after we modified the target.
+		invalidateScanCache(s.TargetRoot, s.log())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to execute sync plan: %w", err)
+	}
+	if !s.DryRun && (s.executed || len(s.plan.Actions) == 0) {
+		s.remote.finished()
+		if s.SanitizeNames {
+			if err := saveNameMap(s.TargetRoot, s.sanitized); err != nil {
+				fmt.Fprintf(s.stderr(), "Warning: Could not record the names given to sanitized paths: %v\n", err)
+			}
+		}
+	}
+
+	// 5. Verify a sample of the copies
+	if s.SpotCheck > 0 && s.executed {
+		if err := s.spotCheck(); err != nil {
+			return err
+		}
+	}
+
+	// 6. Deduplicate the target (unless the plan was declined)
+	if s.DedupeTarget && !s.DryRun && (s.executed || len(s.plan.Actions) == 0) {
+		result, err := s.dedupeTarget()
+		if err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Dedupe failed: %v\n", err)
+			s.observer().OnError(err)
+		} else {
+			s.printDedupeResult(result)
+		}
+	}
+
+	// 7. Replicate security contexts and capabilities
+	if (s.SELinux || s.Caps) && !s.DryRun && (s.executed || len(s.plan.Actions) == 0) {
+		s.restoreSecurity()
+	}
+
+	// 8. Record or restore ownership for unprivileged targets
+	if (s.FakeSuper || s.FromFakeSuper) && !s.DryRun && (s.executed || len(s.plan.Actions) == 0) {
+		if s.FakeSuper {
+			s.recordFakeSuper()
+		} else {
+			s.applyFakeSuper()
+		}
+	}
+
+	// 9. Restore directory mtimes, now that nothing else is written. A
+	// staged target got them before it was swapped into place
+	if s.TimesDirs && !s.DryRun && (s.executed || len(s.plan.Actions) == 0) && (!s.StageAndSwap || len(s.plan.Actions) == 0) {
+		s.restoreDirTimes(s.TargetRoot)
+	}
+
+	return nil // Success
+}
+
+// buildPlan compares the scanned roots and stores the resulting plan.
+func (s *Syncer) buildPlan() error {
+	caseInsensitive := isCaseInsensitive(s.TargetRoot)
+	s.live.setPhase(PhasePlanning)
+	s.hashes = newHashPool(s.HashWorkers, s.HashCPU)
+	s.hashes.ops = s.ops
+	s.log().Info(i18n.T("plan.comparing"))
+	planProgress := s.newBar(i18n.T("progress.planning"), int64(len(s.sourceFiles)+len(s.targetFiles)))
+	plan, err := createSyncPlan(s.sourceFiles, s.targetFiles, planOptions{
+		caseInsensitive: caseInsensitive,
+		checksum:        s.checksumFunc(),
+		transformed:     s.Transforms.Matches,
+		compareWorkers:  s.hashes.workers,
+		log:             s.log(),
+		advance:         planProgress.Add,
+		comparing: func() {
+			planProgress.Describe(i18n.T("progress.comparing"))
+		},
+		mtimeTolerance: s.Quirks.MtimeTolerance,
+		alwaysHash:     s.alwaysHashFunc(),
+		freshChecksum:  s.remote.withRemoteSums(s.hashes.Sum),
+		excludedType:   s.excludedTypeFunc(),
+		skipDelete:     s.ruleFunc(rules.SkipDelete),
+		alwaysCopy:     s.ruleFunc(rules.AlwaysCopy),
+	})
+	planProgress.Finish()
+	s.hashes.Close()
+	if err != nil {
+		return fmt.Errorf("failed to create sync plan: %w", err)
+	}
+	s.log().Info(i18n.T("plan.complete", plan.Adds, plan.Updates, plan.Deletes, plan.Renames))
+	if plan.Unchanged.Compared > 0 {
+		s.log().Info(i18n.T("plan.unchanged", plan.Unchanged.Describe()))
+	}
+	if plan.TypeExcluded > 0 {
+		fmt.Fprintln(s.stderr(), "Note: "+i18n.N("plan.type_excluded", plan.TypeExcluded, plan.TypeExcluded))
+	}
+	if plan.KeptByRule > 0 {
+		fmt.Fprintln(s.stderr(), "Note: "+i18n.N("plan.kept_by_rule", plan.KeptByRule, plan.KeptByRule))
+	}
+	s.plan = plan
+	s.observer().OnPlanReady(plan)
+	return nil
+}
+
+// scanRoots loads the ignore rules and scans source and target concurrently.
+func (s *Syncer) scanRoots() error {
 	var err error
 
 	// 1. Load Ignore Rules
-	s.ignoreMatcher, err = ignore.NewMatcher(s.SourceRoot, s.CliExcludes)
-	if err != nil {
+	if manifest.IsURL(s.SourceRoot) {
+		s.ignoreMatcher = ignore.Compile(s.CliExcludes) // A published tree has no .sync-ignore
+	} else if s.ignoreMatcher, err = s.newMatcher(s.SourceRoot); err != nil {
 		return fmt.Errorf("failed to load ignore rules: %w", err)
 	}
+	if err := s.loadSpec(); err != nil {
+		return err
+	}
 
 	// 2. Scan Source and Target Directories Concurrently
+	s.live.setPhase(PhaseScanning)
+	s.scanStats = &scanStatsLog{}
 	var wg sync.WaitGroup
 	var sourceErr, targetErr error // Separate error variables for concurrent scans
 
@@ -49,14 +324,28 @@ func (s *Syncer) Run() error {
 
 	go func() {
 		defer wg.Done()
+		if files := s.shared.sourceFiles(); files != nil {
+			s.sourceFiles = files // Scanned for an earlier target of the fan-out
+			return
+		}
+		if len(s.MergeSources) > 0 {
+			s.sourceFiles, sourceErr = s.scanMergedSources()
+			s.readSecurity(s.sourceFiles)
+			return
+		}
+		if manifest.IsURL(s.SourceRoot) {
+			s.sourceFiles, sourceErr = s.scanRemote()
+			return
+		}
 		// Pass the ignore matcher only when scanning the source
-		s.sourceFiles, sourceErr = scanDirectory(s.SourceRoot, s.SourceRoot, s.ignoreMatcher, "source")
+		s.sourceFiles, sourceErr = s.scan(s.SourceRoot, s.ignoreMatcher, "source")
+		s.readSecurity(s.sourceFiles)
 	}()
 
 	go func() {
 		defer wg.Done()
 		// Do not pass the ignore matcher when scanning the target
-		s.targetFiles, targetErr = scanDirectory(s.TargetRoot, s.TargetRoot, nil, "target")
+		s.targetFiles, targetErr = s.scan(s.TargetRoot, nil, "target")
 	}()
 
 	wg.Wait() // Wait for both scans to complete
@@ -67,24 +356,264 @@ func (s *Syncer) Run() error {
 	if targetErr != nil {
 		// Target scan errors are often less critical (e.g., target doesn't exist yet)
 		// But we should still report them. If targetFiles is nil, planning will handle it.
-		fmt.Printf("Note: Error scanning target directory: %v\n", targetErr)
+		s.log().Warn(i18n.T("scan.target_error"), "err", targetErr)
+		s.observer().OnError(targetErr)
 		// Ensure targetFiles is initialized even if scan failed partially or fully
 		if s.targetFiles == nil {
 			s.targetFiles = make(map[string]*fileinfo.FileInfo)
 		}
 	}
+	if s.shared != nil && s.shared.files == nil && s.remote == nil {
+		s.shared.files = s.sourceFiles
+	}
+	s.printScanStats(s.ScanStats())
+	if s.tier != nil {
+		s.sourceFiles = s.tier.filter(s.sourceFiles)
+	}
+	s.restoreSourceNames()
+	s.checkTargetNames()
+	return nil
+}
 
-	// 3. Create Sync Plan
-	s.plan, err = createSyncPlan(s.sourceFiles, s.targetFiles)
+// recordRun persists the outcome of a run: history entry, checksum cache and,
+// if the target now mirrors the source, the snapshot used for drift detection.
+func (s *Syncer) recordRun(start time.Time, runErr *error) {
+	record := state.RunRecord{Start: start, End: time.Now(), DryRun: s.DryRun}
+	if s.plan != nil {
+		record.Adds, record.Updates, record.Deletes, record.Renames = s.plan.Adds, s.plan.Updates, s.plan.Deletes, s.plan.Renames
+	}
+	if *runErr != nil {
+		record.Error = (*runErr).Error()
+	}
+	if err := s.state.AppendHistory(record); err != nil {
+		fmt.Fprintf(s.stderr(), "Warning: Could not record sync history: %v\n", err)
+	}
+
+	if err := s.checksums.Save(); err != nil {
+		fmt.Fprintf(s.stderr(), "Warning: Could not save checksum cache: %v\n", err)
+	}
+
+	inSync := s.plan != nil && (len(s.plan.Actions) == 0 || s.executed)
+	if *runErr == nil && !s.DryRun && inSync {
+		if err := s.state.SaveSnapshot(s.sourceFiles); err != nil {
+			fmt.Fprintf(s.stderr(), "Warning: Could not save sync snapshot: %v\n", err)
+		}
+	}
+}
+
+// newMatcher loads the ignore rules of a source root: its .sync-ignore, the
+// CLI patterns and, with Gitignore, its .gitignore files.
+func (s *Syncer) newMatcher(root string) (*ignore.Matcher, error) {
+	matcher, err := ignore.NewMatcher(root, s.CliExcludes, s.log())
 	if err != nil {
-		return fmt.Errorf("failed to create sync plan: %w", err)
+		return nil, err
 	}
+	if s.Gitignore {
+		if err := matcher.LoadGitignores(root, s.log()); err != nil {
+			return nil, err
+		}
+	}
+	return matcher, nil
+}
 
-	// 4. Execute Plan (includes confirmation)
-	err = executePlan(s.plan, s.SourceRoot, s.TargetRoot, s.DryRun)
+// loadSpec narrows the source to the paths listed in the target's .sync-spec,
+// if it has one. Target items outside them are deleted like excluded ones.
+func (s *Syncer) loadSpec() error {
+	spec, err := ignore.LoadSpec(s.TargetRoot)
 	if err != nil {
-		return fmt.Errorf("failed to execute sync plan: %w", err)
+		return fmt.Errorf("failed to load target spec: %w", err)
+	}
+	if spec == nil {
+		return nil
+	}
+	if len(s.MergeSources) > 0 {
+		fmt.Fprintf(s.stderr(), "Warning: %s\n", i18n.T("spec.merge_ignored", ignore.SpecFileName))
+		return nil
+	}
+	s.ignoreMatcher.SetSpec(spec)
+	fmt.Fprintln(s.stderr(), "Note: "+i18n.N("spec.loaded", spec.Len(), spec.Len(), ignore.SpecFileName))
+	return nil
+}
+
+// alwaysHashFunc returns the matcher for AlwaysHash, or nil without patterns.
+func (s *Syncer) alwaysHashFunc() func(string) bool {
+	if len(s.AlwaysHash) == 0 {
+		return nil
 	}
+	return ignore.Compile(s.AlwaysHash).MatchesFile
+}
 
-	return nil // Success
+// ruleFunc returns the function the planner asks whether a rule of the action
+// applies to a path, or nil without rules of that action.
+func (s *Syncer) ruleFunc(action string) func(string) bool {
+	if !s.Rules.Has(action) {
+		return nil
+	}
+	return func(relPath string) bool {
+		return s.Rules.Applies(action, relPath)
+	}
+}
+
+// excludedTypeFunc returns the function the planner leaves source files out
+// by content type with, or nil without TypeFilter. A published source's files
+// can't be sniffed, so their type comes from their extension only.
+func (s *Syncer) excludedTypeFunc() func(*fileinfo.FileInfo) bool {
+	if s.TypeFilter == nil {
+		return nil
+	}
+	return func(fi *fileinfo.FileInfo) bool {
+		var open func() (io.ReadCloser, error)
+		if s.remote == nil {
+			open = func() (io.ReadCloser, error) { return os.Open(fi.AbsPath) }
+		}
+		excluded, mimeType := s.TypeFilter.Excludes(fi.RelPath, open)
+		if excluded {
+			s.log().Info("Ignoring by content type", "path", fi.RelPath, "type", mimeType)
+		}
+		return excluded
+	}
+}
+
+// checksumFunc returns the function the planner hashes files with: the hash
+// pool, backed by the checksum cache when state is available and preceded by
+// the files' own markers with XattrMarkers. A published source's checksums
+// come from its manifest.
+func (s *Syncer) checksumFunc() func(string) (string, error) {
+	if s.XattrMarkers {
+		return s.remote.withRemoteSums(withMarkers(s.cachedChecksumFunc()))
+	}
+	return s.remote.withRemoteSums(s.cachedChecksumFunc())
+}
+
+// cachedChecksumFunc returns the hash pool, backed by the checksum cache when
+// state is available and by the checksums shared with the other targets of a
+// fan-out.
+func (s *Syncer) cachedChecksumFunc() func(string) (string, error) {
+	if s.checksums == nil && s.shared == nil {
+		return s.hashes.Sum
+	}
+	return func(path string) (string, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		if s.checksums != nil {
+			if sum, ok := s.checksums.Get(path, info.Size(), info.ModTime()); ok {
+				return sum, nil
+			}
+		}
+		if sum, ok := s.shared.get(path, info.Size(), info.ModTime()); ok {
+			return sum, nil
+		}
+		sum, err := s.hashes.Sum(path)
+		if err != nil {
+			return "", err
+		}
+		if s.checksums != nil {
+			s.checksums.Put(path, info.Size(), info.ModTime(), sum)
+		}
+		s.shared.put(path, info.Size(), info.ModTime(), sum)
+		return sum, nil
+	}
+}
+
+// describeSource names the source side of the run for reports.
+func (s *Syncer) describeSource() string {
+	if len(s.MergeSources) == 0 {
+		return s.SourceRoot
+	}
+	var roots []string
+	for _, src := range s.MergeSources {
+		roots = append(roots, src.Root)
+	}
+	return strings.Join(roots, ", ")
+}
+
+// finishSummary completes the summary once a run is over.
+func (s *Syncer) finishSummary(runErr *error) {
+	s.summary.End = time.Now()
+	s.live.setPhase(PhaseDone)
+	s.summary.Executed = s.executed
+	if s.plan != nil {
+		s.summary.Adds, s.summary.Updates, s.summary.Deletes, s.summary.Renames = s.plan.Adds, s.plan.Updates, s.plan.Deletes, s.plan.Renames
+		s.summary.Unchanged = s.plan.Unchanged
+	}
+	s.summary.Bytes = s.bytesCopied
+	s.summary.Ops = s.ops.stats()
+	s.summary.Errors = s.actionErrors
+	if *runErr != nil && len(s.actionErrors) == 0 {
+		s.summary.Errors = []string{(*runErr).Error()}
+	}
+}
+
+// Summary returns the outcome of the last Run (nil before the first one).
+func (s *Syncer) Summary() *summary.Summary {
+	return s.summary
+}
+
+// stateKey identifies the source side of this pair in the state directory.
+func (s *Syncer) stateKey() string {
+	if len(s.MergeSources) == 0 {
+		return s.SourceRoot
+	}
+	var parts []string
+	for _, src := range s.MergeSources {
+		parts = append(parts, src.Root+"="+src.Subdir)
+	}
+	return strings.Join(parts, string(os.PathListSeparator))
+}
+
+// scan scans one root, incrementally if enabled, and records its stats.
+func (s *Syncer) scan(rootPath string, ignoreMatcher *ignore.Matcher, description string) (map[string]*fileinfo.FileInfo, error) {
+	opts := s.scanOptions(rootPath, ignoreMatcher, description)
+	opts.counts = &scanCounts{}
+	var files map[string]*fileinfo.FileInfo
+	var err error
+	if s.Incremental {
+		files, err = scanDirectoryIncremental(rootPath, opts)
+	} else {
+		files, err = scanDirectory(rootPath, rootPath, opts)
+	}
+	s.observer().OnScanProgress(ScanProgress{Description: description, Root: rootPath, Found: int64(len(files)), Done: true})
+	if err != nil {
+		return nil, err
+	}
+	s.log().Info(i18n.N("scan.finished", len(files), RoleName(description), len(files)))
+	if s.scanStats != nil {
+		s.scanStats.add(newScanStats(description, rootPath, files, opts.counts))
+	}
+	return files, nil
+}
+
+// scanOptions returns the options of a scan of rootPath that reports to the
+// run's logger and observer.
+func (s *Syncer) scanOptions(rootPath string, matcher *ignore.Matcher, description string) scanOptions {
+	var found atomic.Int64
+	var follow *followSet
+	if strings.HasPrefix(description, "source") {
+		follow = newFollowSet(s.Follow, rootPath)
+	}
+	return scanOptions{
+		matcher:     matcher,
+		description: description,
+		log:         s.log(),
+		ops:         s.ops,
+		minDepth:    s.MinDepth,
+		maxDepth:    s.MaxDepth,
+		follow:      follow,
+		partialDir:  s.PartialDir,
+		trashDirs:   description == "target" && s.DeleteToTrash,
+		found: func() {
+			s.observer().OnScanProgress(ScanProgress{Description: description, Root: rootPath, Found: found.Add(1)})
+		},
+	}
+}
+
+// ScanStats returns the summaries of the roots scanned by the last Run or
+// Status, ordered by description.
+func (s *Syncer) ScanStats() []ScanStats {
+	if s.scanStats == nil {
+		return nil
+	}
+	return s.scanStats.sorted()
 }
@@ -0,0 +1,147 @@
+// pkg/syncer/tempfile.go
+package syncer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/state"
+)
+
+// TempPrefix starts the name of every temp file written into a target. Files
+// are copied to a temp file next to their destination and renamed over it, so
+// a crash never leaves a half-written file under the real name.
+//
+// The name is .~sync-dir.<pid>.<random>: recognizable so that orphans can be
+// cleaned up (see CleanTemps), and random enough to be unique across
+// processes and hosts. createTemp still opens it with O_EXCL and picks another
+// name if it exists; on older NFS versions, which don't honour O_EXCL, the
+// random part alone keeps names apart.
+const TempPrefix = ".~sync-dir."
+
+// createTemp creates a new temp file in dir.
+func createTemp(dir string, perm os.FileMode) (*os.File, error) {
+	for attempt := 0; ; attempt++ {
+		name, err := tempName(dir)
+		if err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if os.IsExist(err) && attempt < 10 {
+			continue
+		}
+		return file, err
+	}
+}
+
+// tempName returns a new temp file name in dir without creating the file.
+func tempName(dir string) (string, error) {
+	var random [6]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s%d.%s", TempPrefix, os.Getpid(), hex.EncodeToString(random[:]))), nil
+}
+
+// isTempName reports whether name is a sync-dir temp file name.
+func isTempName(name string) bool {
+	return strings.HasPrefix(name, TempPrefix)
+}
+
+// tempOwner returns the pid encoded in a temp file name.
+func tempOwner(name string) (int, bool) {
+	rest := strings.TrimPrefix(name, TempPrefix)
+	pidPart, _, found := strings.Cut(rest, ".")
+	if !found {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(pidPart)
+	return pid, err == nil
+}
+
+// CleanResult lists the temp files found by CleanTemps.
+type CleanResult struct {
+	Removed []string // Orphans that were removed (or would be, in a dry run)
+	Kept    []string // Temp files that may still be in use
+	Errors  []string
+}
+
+// CleanTemps finds temp files left in targetRoot by crashed runs and removes
+// them. Candidates are the temp files registered in the journals of pairs
+// syncing into targetRoot and any file named like one. A temp file is only
+// considered orphaned if the process that created it isn't running on this
+// host and it is older than minAge; the age check protects runs on other hosts
+// sharing the target (e.g. over NFS).
+func CleanTemps(targetRoot string, minAge time.Duration, dryRun bool) (*CleanResult, error) {
+	candidates := make(map[string]bool)
+
+	pairs, err := state.PairsForTarget(targetRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sync state: %w", err)
+	}
+	for _, pair := range pairs {
+		entries, err := pair.JournalEntries()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not read journal of %s: %v\n", pair.Source, err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry[0] == "temp" && len(entry) > 1 {
+				candidates[entry[1]] = true
+			}
+		}
+	}
+
+	walkErr := filepath.WalkDir(targetRoot, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Error accessing %s: %v\n", path, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && isTempName(d.Name()) {
+			candidates[path] = true
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", targetRoot, walkErr)
+	}
+
+	result := &CleanResult{}
+	now := time.Now()
+	for path := range candidates {
+		info, err := os.Lstat(path)
+		if err != nil {
+			continue // Already renamed into place or removed
+		}
+		if pid, ok := tempOwner(filepath.Base(path)); ok && processAlive(pid) {
+			result.Kept = append(result.Kept, path)
+			continue
+		}
+		if now.Sub(info.ModTime()) < minAge {
+			result.Kept = append(result.Kept, path)
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+		}
+		result.Removed = append(result.Removed, path)
+	}
+	sort.Strings(result.Removed)
+	sort.Strings(result.Kept)
+	sort.Strings(result.Errors)
+	return result, nil
+}
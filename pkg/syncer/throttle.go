@@ -0,0 +1,144 @@
+// pkg/syncer/throttle.go
+package syncer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/i18n"
+	"github.com/jeepinbird/sync-dir/pkg/summary"
+)
+
+// MaxWorkers caps the number of parallel file operations, including changes
+// made with SetWorkers while a plan executes.
+const MaxWorkers = 64
+
+// throttle limits the parallel operations and the copy bandwidth of a run.
+// Both limits can be changed from other goroutines while the plan executes,
+// e.g. by the control socket. Syncers derived for mapped subtrees share their
+// parent's.
+type throttle struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	workers  int   // Operations allowed at once (0 until a plan executes)
+	override int   // Worker count set at runtime, replacing the computed one (0 = none)
+	active   int   // Operations in progress
+	rate     int64 // Bytes per second (0 = unlimited)
+	rateSet  bool  // rate was set at runtime and replaces BandwidthLimit
+	window   time.Time
+	sent     int64 // Bytes copied since window
+}
+
+func newThrottle() *throttle {
+	t := &throttle{}
+	t.cond = sync.NewCond(&t.mu)
+	return t
+}
+
+// start sets the limits for a plan about to execute, except those already
+// changed at runtime.
+func (t *throttle) start(workers int, rate int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.workers = workers
+	if t.override > 0 {
+		t.workers = t.override
+	}
+	if !t.rateSet {
+		t.rate = rate
+	}
+	t.window, t.sent = time.Now(), 0
+}
+
+// setWorkers changes the number of parallel operations. Lowering it lets the
+// operations in progress finish; no new ones start until fewer are running.
+func (t *throttle) setWorkers(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.override = n
+	t.workers = n
+	t.cond.Broadcast()
+}
+
+// acquire blocks until another operation may start.
+func (t *throttle) acquire() {
+	t.mu.Lock()
+	for t.active >= t.workers {
+		t.cond.Wait()
+	}
+	t.active++
+	t.mu.Unlock()
+}
+
+func (t *throttle) release() {
+	t.mu.Lock()
+	t.active--
+	t.cond.Signal()
+	t.mu.Unlock()
+}
+
+// setRate changes the bandwidth limit, in bytes per second (0 = unlimited).
+func (t *throttle) setRate(bytesPerSecond int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rate = bytesPerSecond
+	t.rateSet = true
+	t.window, t.sent = time.Now(), 0
+}
+
+// wait accounts for n bytes just copied and sleeps as long as needed to keep
+// the copies of all workers together under the bandwidth limit.
+func (t *throttle) wait(n int64) {
+	t.mu.Lock()
+	if t.rate <= 0 {
+		t.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	due := t.window.Add(time.Duration(float64(t.sent) / float64(t.rate) * float64(time.Second)))
+	if now.Sub(due) > time.Second {
+		// Idle for a while (e.g. paused or between small files): don't let the
+		// unused allowance turn into a burst
+		t.window, t.sent = now, 0
+	}
+	t.sent += n
+	due = t.window.Add(time.Duration(float64(t.sent) / float64(t.rate) * float64(time.Second)))
+	t.mu.Unlock()
+	if delay := time.Until(due); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+func (t *throttle) limits() (workers int, rate int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.workers, t.rate
+}
+
+// SetWorkers changes the number of parallel file operations (1 to MaxWorkers)
+// of a running sync, or of the next plan to execute. It is safe to call from
+// any goroutine.
+func (s *Syncer) SetWorkers(n int) error {
+	if n < 1 || n > MaxWorkers {
+		return i18n.Errorf("control.workers_range", MaxWorkers)
+	}
+	s.throttle.setWorkers(n)
+	fmt.Fprintln(s.stderr(), "\n"+i18n.T("control.workers", n))
+	return nil
+}
+
+// SetBandwidthLimit changes the copy bandwidth limit, in bytes per second
+// (0 = unlimited). It is safe to call from any goroutine.
+func (s *Syncer) SetBandwidthLimit(bytesPerSecond int64) error {
+	if bytesPerSecond < 0 {
+		return i18n.Errorf("control.bwlimit_negative")
+	}
+	s.throttle.setRate(bytesPerSecond)
+	if bytesPerSecond == 0 {
+		fmt.Fprintln(s.stderr(), "\n"+i18n.T("control.bwlimit_removed"))
+	} else {
+		fmt.Fprintln(s.stderr(), "\n"+i18n.T("control.bwlimit", summary.FormatBytes(bytesPerSecond)))
+	}
+	return nil
+}
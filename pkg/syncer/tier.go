@@ -0,0 +1,145 @@
+// pkg/syncer/tier.go
+package syncer
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// Tier routes the source files matching it to a target of its own, so one
+// run fills several targets, e.g. recent files on an SSD and old ones in an
+// archive. A file belongs to the first tier it matches; files matching none
+// go to TargetRoot.
+type Tier struct {
+	Target     string        // Absolute target directory of the tier's files
+	OlderThan  time.Duration // Files last modified longer ago than this (0 = any age)
+	LargerThan int64         // Files larger than this many bytes (0 = any size)
+}
+
+// ageUnits are the units of tier ages beyond those of time.ParseDuration.
+var ageUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+// ParseTierAge parses the age of a tier: a number of days, weeks or years
+// such as "90d", "2w" or "1y", or a duration such as "36h".
+func ParseTierAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+	if len(value) > 1 {
+		if unit, ok := ageUnits[value[len(value)-1:]]; ok {
+			if n, err := strconv.ParseFloat(value[:len(value)-1], 64); err == nil && n > 0 {
+				return time.Duration(n * float64(unit)), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid age %q (expected e.g. 90d, 2w, 1y or 36h)", value)
+}
+
+// matches reports whether fi belongs to the tier, now being the time the
+// run started.
+func (t Tier) matches(fi *fileinfo.FileInfo, now time.Time) bool {
+	if fi.IsDir {
+		return false
+	}
+	if t.OlderThan > 0 && now.Sub(fi.ModTime) <= t.OlderThan {
+		return false
+	}
+	return t.LargerThan == 0 || fi.Size > t.LargerThan
+}
+
+// tierSelection narrows the source of a run derived for one tier down to
+// the tier's files.
+type tierSelection struct {
+	tiers []Tier
+	index int       // Tier whose files are kept (-1 = the files of no tier)
+	now   time.Time // Start of the run, ages are measured from it
+	files int       // Files of the whole source, counted by filter
+}
+
+// of returns the index of the tier fi belongs to, or -1.
+func (t *tierSelection) of(fi *fileinfo.FileInfo) int {
+	for i, tier := range t.tiers {
+		if tier.matches(fi, t.now) {
+			return i
+		}
+	}
+	return -1
+}
+
+// filter returns the files of the selected tier. The main target keeps every
+// directory of the source; a tier's target only those holding its files.
+func (t *tierSelection) filter(files map[string]*fileinfo.FileInfo) map[string]*fileinfo.FileInfo {
+	kept := make(map[string]*fileinfo.FileInfo)
+	t.files = 0
+	for relPath, fi := range files {
+		if fi.IsDir {
+			if t.index < 0 {
+				kept[relPath] = fi
+			}
+			continue
+		}
+		t.files++
+		if t.of(fi) != t.index {
+			continue
+		}
+		kept[relPath] = fi
+		for dir := filepath.Dir(relPath); dir != "."; dir = filepath.Dir(dir) {
+			if parent, ok := files[dir]; ok {
+				kept[dir] = parent
+			}
+		}
+	}
+	return kept
+}
+
+// runTiered syncs the files of each tier into the tier's target, then the
+// files of no tier into the main target. Tiers go first, so a file that
+// moved to another tier since the last run is copied to its new target
+// before it is deleted from its old one. A target that fails doesn't stop
+// the others; the errors are returned together.
+func (s *Syncer) runTiered() error {
+	now := time.Now()
+	var drift error // With AssertInSync, every pair is checked before the differences fail the run
+	var errs []error
+	for i, tier := range s.Tiers {
+		fmt.Fprintf(s.stdout(), "\n=== %s -> %s ===\n", s.SourceRoot, tier.Target)
+		child := s.derive(s.SourceRoot, tier.Target)
+		child.RequireMounted, child.RequireFile = false, "" // Guards apply to the main target
+		child.tier = &tierSelection{tiers: s.Tiers, index: i, now: now}
+		err := child.Run()
+		s.summary.Add(child.Summary())
+		if errors.Is(err, ErrNotInSync) {
+			drift, err = err, nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tier %s: %w", tier.Target, err))
+		}
+	}
+
+	fmt.Fprintf(s.stdout(), "\n=== %s -> %s ===\n", s.SourceRoot, s.TargetRoot)
+	main := s.derive(s.SourceRoot, s.TargetRoot)
+	main.tier = &tierSelection{tiers: s.Tiers, index: -1, now: now}
+	err := main.Run()
+	s.summary.Add(main.Summary())
+	if errors.Is(err, ErrNotInSync) {
+		drift, err = err, nil
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return drift
+}
@@ -0,0 +1,89 @@
+// pkg/syncer/trace.go
+package syncer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/compare"
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// Decisions traced for a path (see traceDecision).
+const (
+	decisionAdd     = "add"
+	decisionDelete  = "delete"
+	decisionReplace = "replace" // Deleted and added again as another type
+	decisionUpdate  = "update"
+	decisionKeep    = "keep"
+	decisionSkip    = "skip" // Left out of the sync
+)
+
+// tracing reports whether the planner traces its decisions: whether the
+// logger takes debug records.
+func (opts planOptions) tracing() bool {
+	return opts.log.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// traceDecision logs at debug level what the plan does with relPath, why,
+// and the inputs it was decided on: the size and mtime of each side and the
+// checksums computed while comparing them, by absolute path. source or
+// target is nil if the path is only on the other side.
+func (opts planOptions) traceDecision(relPath, decision, reason string, source, target *fileinfo.FileInfo, sums traceSums) {
+	attrs := []any{"path", relPath, "decision", decision, "reason", reason}
+	for _, side := range []struct {
+		name string
+		fi   *fileinfo.FileInfo
+	}{{"source", source}, {"target", target}} {
+		if side.fi == nil {
+			continue
+		}
+		attrs = append(attrs, side.name+"_size", side.fi.Size, side.name+"_mtime", side.fi.ModTime.UTC().Format(time.RFC3339Nano))
+		if sum := sums[side.fi.AbsPath]; sum != "" {
+			attrs = append(attrs, side.name+"_sha256", sum)
+		}
+	}
+	opts.log.Debug("Decided", attrs...)
+}
+
+// traceSums collects the checksums computed while comparing one pair of
+// files, by absolute path, for traceDecision.
+type traceSums map[string]string
+
+// record returns sum, keeping the checksums it computes in t.
+func (t traceSums) record(sum func(string) (string, error)) func(string) (string, error) {
+	if sum == nil {
+		return nil
+	}
+	return func(path string) (string, error) {
+		value, err := sum(path)
+		if err == nil {
+			t[path] = value
+		}
+		return value, err
+	}
+}
+
+// verdictReason describes the verdict of comparing the files of action for
+// the trace.
+func verdictReason(verdict compare.Verdict, action SyncAction, sums traceSums) string {
+	switch verdict {
+	case compare.SameSizeMtime:
+		return "same size and mtime"
+	case compare.WithinTolerance:
+		return "same size, mtimes within tolerance"
+	case compare.SameChecksum:
+		return "same size and checksum"
+	case compare.SameMtime:
+		return "same mtime (transformed on copy)"
+	}
+	switch {
+	case action.SourceInfo.Size != action.TargetInfo.Size:
+		return "sizes differ"
+	case len(sums) > 0:
+		return "checksums differ"
+	default:
+		return "mtimes differ"
+	}
+}
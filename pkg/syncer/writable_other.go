@@ -0,0 +1,18 @@
+// pkg/syncer/writable_other.go
+//go:build !unix
+
+package syncer
+
+import "os"
+
+// dirWritable reports whether entries can be created in dir. Without
+// access(2) the only reliable test is to create (and remove) a temp file.
+func dirWritable(dir string) error {
+	probe, err := createTemp(dir, 0600)
+	if err != nil {
+		return err
+	}
+	name := probe.Name()
+	_ = probe.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,12 @@
+// pkg/syncer/writable_unix.go
+//go:build unix
+
+package syncer
+
+import "golang.org/x/sys/unix"
+
+// dirWritable reports whether entries can be created in and removed from dir,
+// without touching it: access(2) accounts for permissions and read-only mounts.
+func dirWritable(dir string) error {
+	return unix.Access(dir, unix.W_OK|unix.X_OK)
+}
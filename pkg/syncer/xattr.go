@@ -0,0 +1,61 @@
+// pkg/syncer/xattr.go
+package syncer
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/compare"
+)
+
+// Extended attributes written on target files with XattrMarkers. They travel
+// with the file (renames, moves within the filesystem), so a file's checksum
+// can be trusted later without a cache: as long as its mtime still equals
+// the recorded source mtime, its content is the one that was hashed.
+const (
+	xattrHash     = "user.syncdir.hash"      // Hex SHA256 of the content written
+	xattrSrcMtime = "user.syncdir.src_mtime" // Source mtime, RFC 3339 with nanoseconds
+)
+
+// errXattrUnsupported is returned where extended attributes aren't available.
+var errXattrUnsupported = errors.New("extended attributes are not supported on this platform")
+
+// writeMarkers tags the file at path with its checksum and source mtime.
+func writeMarkers(path, sum string, srcMtime time.Time) error {
+	if err := setXattr(path, xattrHash, []byte(sum)); err != nil {
+		return err
+	}
+	return setXattr(path, xattrSrcMtime, []byte(srcMtime.UTC().Format(time.RFC3339Nano)))
+}
+
+// markedSum returns the checksum recorded on the file at path, if it has
+// markers and its mtime still matches the recorded one.
+func markedSum(path string, modTime time.Time) (string, bool) {
+	sum, err := getXattr(path, xattrHash)
+	if err != nil || len(sum) != 64 {
+		return "", false
+	}
+	value, err := getXattr(path, xattrSrcMtime)
+	if err != nil {
+		return "", false
+	}
+	srcMtime, err := time.Parse(time.RFC3339Nano, string(value))
+	if err != nil || !compare.SameModTime(srcMtime, modTime, 0) {
+		return "", false
+	}
+	return string(sum), true
+}
+
+// withMarkers returns a checksum function that uses the markers of a file
+// when they are current and falls back to hash otherwise.
+func withMarkers(hash func(string) (string, error)) func(string) (string, error) {
+	return func(path string) (string, error) {
+		if info, err := os.Stat(path); err == nil {
+			if sum, ok := markedSum(path, info.ModTime()); ok {
+				return sum, nil
+			}
+		}
+		return hash(path)
+	}
+}
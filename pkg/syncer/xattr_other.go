@@ -0,0 +1,12 @@
+// pkg/syncer/xattr_other.go
+//go:build !linux && !darwin
+
+package syncer
+
+func setXattr(path, name string, value []byte) error {
+	return errXattrUnsupported
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	return nil, errXattrUnsupported
+}
@@ -0,0 +1,19 @@
+// pkg/syncer/xattr_unix.go
+//go:build linux || darwin
+
+package syncer
+
+import "golang.org/x/sys/unix"
+
+func setXattr(path, name string, value []byte) error {
+	return unix.Setxattr(path, name, value, 0)
+}
+
+func getXattr(path, name string) ([]byte, error) {
+	buf := make([]byte, 128) // Enough for both markers
+	n, err := unix.Getxattr(path, name, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
@@ -0,0 +1,189 @@
+// pkg/theme/theme.go
+// Package theme colors terminal output. Every color sync-dir uses is a role
+// of the theme (add, delete, header, ...), so all ANSI codes live here and
+// can be changed in the config file. Colors are only used where the console
+// allows them (see console.Color).
+package theme
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/console"
+)
+
+// Role is what a piece of colored output stands for.
+type Role string
+
+// Roles of the theme.
+const (
+	Add      Role = "add"      // Plan actions adding an item
+	Update   Role = "update"   // Plan actions updating an item
+	Delete   Role = "delete"   // Plan actions deleting an item
+	Rename   Role = "rename"   // Plan actions renaming an item
+	Header   Role = "header"   // Section headers
+	Progress Role = "progress" // Progress bar and line descriptions
+	Success  Role = "success"  // A run that finished well
+	Failure  Role = "failure"  // Damage and failures that need attention
+)
+
+// BackgroundKey is the config key choosing the default colors: "dark",
+// "light" or "auto" (detected, see DetectBackground).
+const BackgroundKey = "background"
+
+// Default styles for dark and light terminal backgrounds. Yellow and cyan are
+// hard to read on white, so the light theme avoids them.
+var (
+	darkStyles = map[Role]string{
+		Add: "green", Update: "yellow", Delete: "red-bold", Rename: "cyan",
+		Header: "bold", Progress: "cyan", Success: "green-bold", Failure: "red-bold",
+	}
+	lightStyles = map[Role]string{
+		Add: "green", Update: "blue", Delete: "red-bold", Rename: "magenta",
+		Header: "bold", Progress: "blue", Success: "green-bold", Failure: "red-bold",
+	}
+)
+
+// ANSI SGR codes of the style words.
+var (
+	colorCodes = map[string]int{
+		"black": 30, "red": 31, "green": 32, "yellow": 33,
+		"blue": 34, "magenta": 35, "cyan": 36, "white": 37,
+	}
+	attributeCodes = map[string]int{
+		"bold": 1, "dim": 2, "italic": 3, "underline": 4,
+	}
+)
+
+const reset = "\033[0m"
+
+// theme is the active theme: the SGR parameters of each role, and whether
+// stdout and stderr may be colored. Nothing is colored until Configure runs.
+var theme struct {
+	codes          map[Role]string
+	stdout, stderr bool
+}
+
+// Configure sets up the theme from the "colors" settings of the config file:
+// BackgroundKey picks the default styles, and each role name maps to a style
+// such as "red-bold", "bright-blue-underline" or "none". Nil settings give
+// the default theme for the detected background.
+func Configure(settings map[string]string) error {
+	background := settings[BackgroundKey]
+	if background == "" || background == "auto" {
+		background = DetectBackground()
+	}
+	var styles map[Role]string
+	switch background {
+	case "dark":
+		styles = darkStyles
+	case "light":
+		styles = lightStyles
+	default:
+		return fmt.Errorf("colors: %s must be dark, light or auto, not %q", BackgroundKey, background)
+	}
+
+	codes := make(map[Role]string, len(styles))
+	for role, style := range styles {
+		codes[role], _ = parseStyle(style)
+	}
+	for key, style := range settings {
+		if key == BackgroundKey {
+			continue
+		}
+		role := Role(key)
+		if _, ok := styles[role]; !ok {
+			return fmt.Errorf("colors: unknown role %q (valid: %s, %s)", key, BackgroundKey, strings.Join(roleNames(), ", "))
+		}
+		code, err := parseStyle(style)
+		if err != nil {
+			return fmt.Errorf("colors: %s: %w", key, err)
+		}
+		codes[role] = code
+	}
+
+	theme.codes = codes
+	theme.stdout = console.Color(os.Stdout)
+	theme.stderr = console.Color(os.Stderr)
+	return nil
+}
+
+// DetectBackground guesses whether the terminal background is "dark" or
+// "light" from COLORFGBG ("foreground;background" palette indexes, set by
+// rxvt, Konsole and others). Without it, terminals are assumed to be dark.
+func DetectBackground() string {
+	value := os.Getenv("COLORFGBG")
+	fields := strings.Split(value, ";")
+	bg, err := strconv.Atoi(fields[len(fields)-1])
+	if value == "" || err != nil {
+		return "dark"
+	}
+	if bg == 7 || bg >= 9 { // White, light grey and the bright colors
+		return "light"
+	}
+	return "dark"
+}
+
+// Paint returns text colored for role when writing to stdout.
+func Paint(role Role, text string) string {
+	return paint(theme.stdout, role, text)
+}
+
+// PaintErr returns text colored for role when writing to stderr.
+func PaintErr(role Role, text string) string {
+	return paint(theme.stderr, role, text)
+}
+
+func paint(enabled bool, role Role, text string) string {
+	code := theme.codes[role]
+	if !enabled || code == "" || text == "" {
+		return text
+	}
+	return "\033[" + code + "m" + text + reset
+}
+
+// parseStyle converts a style such as "bright-red-bold" into SGR parameters
+// ("91;1"). "none" and "" give no style.
+func parseStyle(style string) (string, error) {
+	if style == "" || style == "none" {
+		return "", nil
+	}
+	var codes []string
+	bright := false
+	for _, word := range strings.FieldsFunc(strings.ToLower(style), func(r rune) bool { return r == '-' || r == '+' || r == ' ' }) {
+		if word == "bright" {
+			bright = true
+			continue
+		}
+		if code, ok := colorCodes[word]; ok {
+			if bright {
+				code += 60
+				bright = false
+			}
+			codes = append(codes, strconv.Itoa(code))
+			continue
+		}
+		if code, ok := attributeCodes[word]; ok {
+			codes = append(codes, strconv.Itoa(code))
+			continue
+		}
+		return "", fmt.Errorf("invalid style %q: unknown word %q", style, word)
+	}
+	if bright {
+		return "", fmt.Errorf("invalid style %q: bright must precede a color", style)
+	}
+	return strings.Join(codes, ";"), nil
+}
+
+// roleNames returns the names of all roles, sorted.
+func roleNames() []string {
+	var names []string
+	for role := range darkStyles {
+		names = append(names, string(role))
+	}
+	sort.Strings(names)
+	return names
+}
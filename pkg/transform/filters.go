@@ -0,0 +1,118 @@
+// pkg/transform/filters.go
+package transform
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// gzipFilter compresses the content with gzip.
+func gzipFilter(r io.Reader, w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if _, err := io.Copy(zw, r); err != nil {
+		_ = zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// crlfToLF converts Windows line endings to Unix ones.
+func crlfToLF(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	pendingCR := false
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if pendingCR && b != '\n' {
+			if err := bw.WriteByte('\r'); err != nil {
+				return err
+			}
+		}
+		pendingCR = b == '\r'
+		if !pendingCR {
+			if err := bw.WriteByte(b); err != nil {
+				return err
+			}
+		}
+	}
+	if pendingCR {
+		if err := bw.WriteByte('\r'); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// lfToCRLF converts Unix line endings to Windows ones (existing CRLFs are kept).
+func lfToCRLF(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	var prev byte
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if b == '\n' && prev != '\r' {
+			if err := bw.WriteByte('\r'); err != nil {
+				return err
+			}
+		}
+		if err := bw.WriteByte(b); err != nil {
+			return err
+		}
+		prev = b
+	}
+	return bw.Flush()
+}
+
+// exifHeader starts the payload of a JPEG APP1 segment holding EXIF data.
+var exifHeader = []byte("Exif\x00\x00")
+
+// stripExif removes EXIF (APP1) segments from JPEG data. Anything that isn't a
+// well-formed JPEG is passed through unchanged.
+func stripExif(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		_, err := w.Write(data)
+		return err
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2]) // SOI
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xDA { // Start of scan: the rest is image data
+			break
+		}
+		length := int(data[pos+2])<<8 | int(data[pos+3])
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break // Malformed; keep the rest as-is
+		}
+		isExif := marker == 0xE1 && bytes.HasPrefix(data[pos+4:end], exifHeader)
+		if !isExif {
+			out.Write(data[pos:end])
+		}
+		pos = end
+	}
+	out.Write(data[pos:])
+
+	_, err = w.Write(out.Bytes())
+	return err
+}
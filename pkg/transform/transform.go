@@ -0,0 +1,199 @@
+// pkg/transform/transform.go
+package transform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jeepinbird/sync-dir/pkg/config"
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+	gitignore "github.com/sabhiram/go-gitignore"
+)
+
+// Filter rewrites file content from r to w.
+type Filter func(r io.Reader, w io.Writer) error
+
+// builtinFilters are the filters usable by name in a transform rule.
+var builtinFilters = map[string]Filter{
+	"gzip":       gzipFilter,
+	"crlf-to-lf": crlfToLF,
+	"lf-to-crlf": lfToCRLF,
+	"strip-exif": stripExif,
+}
+
+// rule is a compiled config.TransformRule.
+type rule struct {
+	matcher *gitignore.GitIgnore
+	filters []Filter
+	command string
+	id      string // Hash of the rule definition, part of the cache key
+}
+
+// Pipeline applies the first matching transform rule to files being copied.
+// Transformed outputs are cached by (source content hash, rule), so unchanged
+// inputs aren't re-transformed on later runs.
+type Pipeline struct {
+	rules    []rule
+	cacheDir string
+}
+
+// NewPipeline compiles the rules. Outputs are cached under cacheDir.
+func NewPipeline(rules []config.TransformRule, cacheDir string) (*Pipeline, error) {
+	p := &Pipeline{cacheDir: cacheDir}
+	for _, r := range rules {
+		if r.Pattern == "" {
+			return nil, fmt.Errorf("transform rule without a pattern")
+		}
+		if len(r.Filters) == 0 && r.Command == "" {
+			return nil, fmt.Errorf("transform rule for %q has neither filters nor a command", r.Pattern)
+		}
+		compiled := rule{
+			matcher: gitignore.CompileIgnoreLines(r.Pattern),
+			command: r.Command,
+		}
+		for _, name := range r.Filters {
+			filter, ok := builtinFilters[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown transform filter %q for %q (available: gzip, crlf-to-lf, lf-to-crlf, strip-exif)", name, r.Pattern)
+			}
+			compiled.filters = append(compiled.filters, filter)
+		}
+		sum := sha256.Sum256([]byte(r.Pattern + "\x00" + strings.Join(r.Filters, ",") + "\x00" + r.Command))
+		compiled.id = hex.EncodeToString(sum[:8])
+		p.rules = append(p.rules, compiled)
+	}
+	return p, nil
+}
+
+// DefaultCacheDir returns the shared cache directory for transformed outputs.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync-dir", "transforms"), nil
+}
+
+// Matches reports whether relPath is transformed on copy.
+func (p *Pipeline) Matches(relPath string) bool {
+	return p.match(relPath) != nil
+}
+
+func (p *Pipeline) match(relPath string) *rule {
+	if p == nil {
+		return nil
+	}
+	unixPath := filepath.ToSlash(relPath)
+	for i := range p.rules {
+		if p.rules[i].matcher.MatchesPath(unixPath) {
+			return &p.rules[i]
+		}
+	}
+	return nil
+}
+
+// Apply transforms the file at srcPath and returns the path of the output in
+// the cache. If no rule matches relPath, srcPath is returned unchanged.
+func (p *Pipeline) Apply(relPath, srcPath string) (string, error) {
+	r := p.match(relPath)
+	if r == nil {
+		return srcPath, nil
+	}
+
+	contentHash, err := fileinfo.SHA256(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("could not hash %s for transform: %w", srcPath, err)
+	}
+	key := contentHash + "-" + r.id
+	outPath := filepath.Join(p.cacheDir, key[:2], key)
+	if _, err := os.Stat(outPath); err == nil {
+		return outPath, nil // Already transformed on an earlier run
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), ".partial-*")
+	if err != nil {
+		return "", err
+	}
+	if err := r.run(srcPath, tmp); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", fmt.Errorf("transform of %s failed: %w", relPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), outPath); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return outPath, nil
+}
+
+// run streams srcPath through the rule's filters and command into out.
+func (r *rule) run(srcPath string, out io.Writer) error {
+	input, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := input.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not close %s: %v\n", srcPath, err)
+		}
+	}()
+
+	// Chain the stages with pipes: each stage reads the previous one's output
+	var reader io.Reader = input
+	errs := make(chan error, len(r.filters))
+	for _, filter := range r.filters {
+		pr, pw := io.Pipe()
+		go func(f Filter, in io.Reader) {
+			err := f(in, pw)
+			_ = pw.CloseWithError(err)
+			errs <- err
+		}(filter, reader)
+		reader = pr
+	}
+
+	if r.command != "" {
+		err = runCommand(r.command, reader, out)
+	} else {
+		_, err = io.Copy(out, reader)
+	}
+	// Drain the remaining pipe so filter goroutines can finish, then collect errors
+	_, _ = io.Copy(io.Discard, reader)
+	for range r.filters {
+		if filterErr := <-errs; filterErr != nil && err == nil {
+			err = filterErr
+		}
+	}
+	return err
+}
+
+// runCommand runs a shell command with stdin/stdout connected to in/out.
+func runCommand(command string, in io.Reader, out io.Writer) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stdin = in
+	cmd.Stdout = out
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("command %q: %w: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+// pkg/trash/mount_unix.go
+//go:build unix
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// deviceOf returns the device holding path.
+func deviceOf(path string) (uint64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, ErrUnsupported
+	}
+	return uint64(st.Dev), nil // Dev is int32 on some platforms
+}
+
+// mountTop returns the top directory of the mount holding path: its highest
+// ancestor on the same device.
+func mountTop(path string) (string, error) {
+	dev, err := deviceOf(path)
+	if err != nil {
+		return "", err
+	}
+	top := path
+	for {
+		parent := filepath.Dir(top)
+		if parent == top {
+			return top, nil
+		}
+		if parentDev, err := deviceOf(parent); err != nil || parentDev != dev {
+			return top, nil
+		}
+		top = parent
+	}
+}
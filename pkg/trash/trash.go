@@ -0,0 +1,56 @@
+// pkg/trash/trash.go
+// Package trash moves files and directories to the operating system's trash
+// (the freedesktop.org Trash on Linux and BSD, the Trash on macOS, the
+// Recycle Bin on Windows) so that deletions can be undone.
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned by Move on platforms without a trash.
+var ErrUnsupported = errors.New("moving to the trash is not supported on this platform")
+
+// Move moves the file or directory at path to the trash.
+func Move(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		return err
+	}
+	return move(abs)
+}
+
+// IsTrashDir reports whether name is the name of a trash directory kept at
+// the top of a mounted filesystem (.Trash, .Trash-<uid> or .Trashes).
+func IsTrashDir(name string) bool {
+	if name == ".Trash" || name == ".Trashes" {
+		return true
+	}
+	uid, ok := strings.CutPrefix(name, ".Trash-")
+	if !ok || uid == "" {
+		return false
+	}
+	_, err := strconv.ParseUint(uid, 10, 32)
+	return err == nil
+}
+
+// numberedName returns name for n == 1 and otherwise name with ".n" inserted
+// before its extension, e.g. "report.2.pdf".
+func numberedName(name string, n int) string {
+	if n == 1 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	base := name[:len(name)-len(ext)]
+	if base == "" { // Dotfile such as ".profile"
+		base, ext = name, ""
+	}
+	return base + "." + strconv.Itoa(n) + ext
+}
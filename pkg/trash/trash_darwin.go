@@ -0,0 +1,48 @@
+// pkg/trash/trash_darwin.go
+//go:build darwin
+
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// move renames path into the user's Trash: ~/.Trash for the startup volume,
+// <volume>/.Trashes/<uid> for other volumes, as the Finder does.
+func move(path string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	trashDir := filepath.Join(home, ".Trash")
+
+	pathDev, err := deviceOf(path)
+	if err != nil {
+		return err
+	}
+	if homeDev, err := deviceOf(home); err != nil || homeDev != pathDev {
+		top, err := mountTop(path)
+		if err != nil {
+			return err
+		}
+		if top == path {
+			return fmt.Errorf("%s is the top of its volume and cannot be moved to its trash", path)
+		}
+		trashDir = filepath.Join(top, ".Trashes", strconv.Itoa(os.Getuid()))
+	}
+	if err := os.MkdirAll(trashDir, 0700); err != nil {
+		return fmt.Errorf("could not create trash %s: %w", trashDir, err)
+	}
+
+	base := filepath.Base(path)
+	for n := 1; ; n++ {
+		dest := filepath.Join(trashDir, numberedName(base, n))
+		if _, err := os.Lstat(dest); err == nil {
+			continue
+		}
+		return os.Rename(path, dest)
+	}
+}
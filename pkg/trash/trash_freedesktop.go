@@ -0,0 +1,108 @@
+// pkg/trash/trash_freedesktop.go
+//go:build unix && !darwin
+
+package trash
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/jeepinbird/sync-dir/pkg/fileinfo"
+)
+
+// move implements the freedesktop.org Trash specification: items on the
+// device of the home trash go there, items elsewhere to the trash at the top
+// of their mount, so that trashing never copies data across devices.
+func move(path string) error {
+	trashDir, topDir, err := trashFor(path)
+	if err != nil {
+		return err
+	}
+	filesDir := filepath.Join(trashDir, "files")
+	infoDir := filepath.Join(trashDir, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("could not create trash %s: %w", trashDir, err)
+		}
+	}
+
+	// Paths in a mount's own trash are relative to its top, so they stay
+	// valid if the filesystem is mounted elsewhere
+	infoPath := path
+	if topDir != "" {
+		if rel, err := filepath.Rel(topDir, path); err == nil {
+			infoPath = rel
+		}
+	}
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: infoPath}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+
+	// Creating the .trashinfo file exclusively reserves the name, as the
+	// specification requires
+	base := filepath.Base(path)
+	for n := 1; ; n++ {
+		name := numberedName(base, n)
+		infoFile := filepath.Join(infoDir, name+".trashinfo")
+		file, err := os.OpenFile(infoFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not write trash info: %w", err)
+		}
+		_, err = file.WriteString(info)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(path, filepath.Join(filesDir, name))
+		}
+		if err != nil {
+			_ = os.Remove(infoFile)
+			return err
+		}
+		return nil
+	}
+}
+
+// trashFor returns the trash directory for path and, for trashes at the top
+// of a mount, that top directory.
+func trashFor(path string) (trashDir, topDir string, err error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	homeTrash := filepath.Join(dataHome, "Trash")
+
+	pathDev, err := deviceOf(path)
+	if err != nil {
+		return "", "", err
+	}
+	if homeDev, err := deviceOf(fileinfo.ExistingAncestor(homeTrash)); err == nil && homeDev == pathDev {
+		return homeTrash, "", nil
+	}
+
+	top, err := mountTop(path)
+	if err != nil {
+		return "", "", err
+	}
+	if top == path {
+		return "", "", fmt.Errorf("%s is the top of its mount and cannot be moved to its trash", path)
+	}
+	uid := strconv.Itoa(os.Getuid())
+	// An administrator-created $top/.Trash (a sticky directory, not a
+	// symlink) holds one trash per user; otherwise each user has $top/.Trash-$uid
+	shared := filepath.Join(top, ".Trash")
+	if info, err := os.Lstat(shared); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		return filepath.Join(shared, uid), top, nil
+	}
+	return filepath.Join(top, ".Trash-"+uid), top, nil
+}
@@ -0,0 +1,8 @@
+// pkg/trash/trash_other.go
+//go:build !unix && !(windows && (amd64 || arm64))
+
+package trash
+
+func move(path string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,60 @@
+// pkg/trash/trash_windows.go
+//go:build windows && (amd64 || arm64)
+
+package trash
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// SHFileOperationW flags (shellapi.h)
+const (
+	foDelete          = 0x0003
+	fofSilent         = 0x0004
+	fofNoConfirmation = 0x0010
+	fofAllowUndo      = 0x0040
+	fofNoErrorUI      = 0x0400
+)
+
+// shFileOpStruct is SHFILEOPSTRUCTW. Its natural Go layout matches the
+// 64-bit Windows ABI only; 32-bit Windows packs it to one byte.
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+var procSHFileOperation = syscall.NewLazyDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// move sends path to the Recycle Bin with SHFileOperationW, which deletes
+// with undo enabled.
+func move(path string) error {
+	from, err := syscall.UTF16FromString(path)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0) // The list of paths ends with an extra NUL
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	if err := procSHFileOperation.Find(); err != nil {
+		return ErrUnsupported
+	}
+	ret, _, _ := procSHFileOperation.Call(uintptr(unsafe.Pointer(&op)))
+	if ret != 0 {
+		return fmt.Errorf("SHFileOperation failed with code 0x%x", ret)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return fmt.Errorf("moving to the Recycle Bin was aborted")
+	}
+	return nil
+}